@@ -21,3 +21,13 @@ func (symbols) ListTags(ctx context.Context, args search.SymbolsParameters) ([]p
 	}
 	return result.Symbols, err
 }
+
+// ListRefs returns the occurrences of the names of symbols in a repository.
+func (symbols) ListRefs(ctx context.Context, args search.SymbolsParameters) ([]protocol.SymbolRef, error) {
+	args.Refs = true
+	result, err := symbolsclient.DefaultClient.Search(ctx, args)
+	if result == nil {
+		return nil, err
+	}
+	return result.Refs, err
+}
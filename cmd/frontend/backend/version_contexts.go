@@ -0,0 +1,155 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/internal/db"
+	"github.com/sourcegraph/sourcegraph/internal/errcode"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/search"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+var ErrVersionContextNotFound = errors.New("version context not found")
+
+// ResolveVersionContext returns the version context with the given name. The
+// version contexts of the site configuration take precedence over those
+// stored in the database, whose names are only resolved within the namespaces
+// of the current user: the user and the organizations the user is a member
+// of.
+func ResolveVersionContext(ctx context.Context, name string) (*types.VersionContext, error) {
+	if ef := conf.Get().ExperimentalFeatures; ef != nil {
+		for _, vc := range ef.VersionContexts {
+			if vc.Name == name {
+				return VersionContextFromConfig(vc), nil
+			}
+		}
+	}
+
+	user, err := CurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrVersionContextNotFound
+	}
+	orgs, err := db.Orgs.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	opt := db.VersionContextsListOptions{UserID: user.ID}
+	for _, org := range orgs {
+		opt.OrgIDs = append(opt.OrgIDs, org.ID)
+	}
+
+	vc, err := db.VersionContexts.GetByName(ctx, name, opt)
+	if err != nil {
+		if errcode.IsNotFound(err) {
+			return nil, ErrVersionContextNotFound
+		}
+		return nil, err
+	}
+	return vc, nil
+}
+
+// CheckVersionContextAccess returns an error if the current user is NEITHER
+// (1) a site admin NOR (2) the owner of the version context NOR (3) a member
+// of the organization owning the version context. Version contexts of the
+// site configuration are accessible to all users.
+func CheckVersionContextAccess(ctx context.Context, vc *types.VersionContext) error {
+	switch {
+	case vc.UserID != nil:
+		return CheckSiteAdminOrSameUser(ctx, *vc.UserID)
+	case vc.OrgID != nil:
+		return CheckOrgAccess(ctx, *vc.OrgID)
+	case vc.ID == 0:
+		return nil
+	default:
+		return errors.New("version context has no owner")
+	}
+}
+
+// VersionContextFromConfig returns the version context of the site
+// configuration as a version context without an ID and owner.
+func VersionContextFromConfig(vc *schema.VersionContext) *types.VersionContext {
+	revs := make([]*types.VersionContextRevision, len(vc.Revisions))
+	for i, rev := range vc.Revisions {
+		revs[i] = &types.VersionContextRevision{
+			Repo: api.RepoName(rev.Repo),
+			Rev:  rev.Rev,
+		}
+	}
+	return &types.VersionContext{
+		Name:        vc.Name,
+		Description: vc.Description,
+		Revisions:   revs,
+	}
+}
+
+// ValidateVersionContextRevision returns an error if more than one of the
+// revision, ref glob and latest tag pattern of rev are set, or if its latest
+// tag pattern is malformed.
+func ValidateVersionContextRevision(rev *types.VersionContextRevision) error {
+	n := 0
+	for _, s := range []string{rev.Rev, rev.RefGlob, rev.LatestTag} {
+		if s != "" {
+			n++
+		}
+	}
+	if n > 1 {
+		return fmt.Errorf("version context revision of repository %s must have at most one of rev, refGlob and latestTag", rev.Repo)
+	}
+	if rev.LatestTag != "" {
+		if _, err := path.Match(rev.LatestTag, ""); err != nil {
+			return fmt.Errorf("invalid latestTag pattern %q of repository %s: %s", rev.LatestTag, rev.Repo, err)
+		}
+	}
+	return nil
+}
+
+// NoMatchingTagError occurs when no tag of a repository matches the latest
+// tag pattern of a version context revision.
+type NoMatchingTagError struct {
+	Repo    api.RepoName
+	Pattern string
+}
+
+func (e *NoMatchingTagError) Error() string {
+	return fmt.Sprintf("no tag of repository %s matches %q", e.Repo, e.Pattern)
+}
+
+func (e *NoMatchingTagError) NotFound() bool {
+	return true
+}
+
+// ResolveVersionContextRevision returns the revision specifier that rev pins
+// its repository to. The latest tag pattern is resolved to the most recently
+// created tag whose name matches it, which is a NoMatchingTagError if there is
+// none.
+func ResolveVersionContextRevision(ctx context.Context, repo gitserver.Repo, rev *types.VersionContextRevision) (search.RevisionSpecifier, error) {
+	switch {
+	case rev.RefGlob != "":
+		return search.RevisionSpecifier{RefGlob: rev.RefGlob}, nil
+	case rev.LatestTag != "":
+		// ListTags returns the most recently created tags first.
+		tags, err := git.ListTags(ctx, repo)
+		if err != nil {
+			return search.RevisionSpecifier{}, err
+		}
+		for _, tag := range tags {
+			if ok, _ := path.Match(rev.LatestTag, tag.Name); ok {
+				return search.RevisionSpecifier{RevSpec: tag.Name}, nil
+			}
+		}
+		return search.RevisionSpecifier{}, &NoMatchingTagError{Repo: repo.Name, Pattern: rev.LatestTag}
+	default:
+		return search.RevisionSpecifier{RevSpec: rev.Rev}, nil
+	}
+}
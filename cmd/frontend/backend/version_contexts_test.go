@@ -0,0 +1,128 @@
+package backend
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/internal/db"
+	"github.com/sourcegraph/sourcegraph/internal/errcode"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/search"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestResolveVersionContext(t *testing.T) {
+	ctx := testContext()
+	defer func() { db.Mocks = db.MockStores{} }()
+
+	conf.Mock(&conf.Unified{SiteConfiguration: schema.SiteConfiguration{
+		ExperimentalFeatures: &schema.ExperimentalFeatures{
+			VersionContexts: []*schema.VersionContext{{
+				Name:      "config",
+				Revisions: []*schema.VersionContextRevision{{Repo: "a", Rev: "v1"}},
+			}},
+		},
+	}})
+	defer conf.Mock(nil)
+
+	owner := int32(1)
+	db.Mocks.VersionContexts.GetByName = func(ctx context.Context, name string, opt db.VersionContextsListOptions) (*types.VersionContext, error) {
+		// Only the namespaces of the current user are searched.
+		if name != "owned" || opt.UserID != owner {
+			return nil, &db.VersionContextNotFoundError{Message: name}
+		}
+		return &types.VersionContext{ID: 1, Name: name, UserID: &owner}, nil
+	}
+	db.Mocks.Orgs.GetByUserID = func(ctx context.Context, userID int32) ([]*types.Org, error) {
+		return nil, nil
+	}
+	db.Mocks.Users.GetByCurrentAuthUser = func(ctx context.Context) (*types.User, error) {
+		return &types.User{ID: actor.FromContext(ctx).UID}, nil
+	}
+	db.Mocks.Users.GetByID = func(ctx context.Context, id int32) (*types.User, error) {
+		return &types.User{ID: id}, nil
+	}
+
+	vc, err := ResolveVersionContext(ctx, "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vc.Revisions) != 1 || vc.Revisions[0].Repo != "a" || vc.Revisions[0].Rev != "v1" {
+		t.Errorf("got revisions %+v, want the revisions of the site configuration", vc.Revisions)
+	}
+
+	if _, err := ResolveVersionContext(ctx, "owned"); err != nil {
+		t.Errorf("got error %v resolving a version context of the current user", err)
+	}
+
+	otherUserCtx := actor.WithActor(context.Background(), &actor.Actor{UID: 2})
+	if _, err := ResolveVersionContext(otherUserCtx, "owned"); err != ErrVersionContextNotFound {
+		t.Errorf("got error %v resolving a version context of another user, want %v", err, ErrVersionContextNotFound)
+	}
+
+	if _, err := ResolveVersionContext(context.Background(), "owned"); err != ErrVersionContextNotFound {
+		t.Errorf("got error %v resolving a version context without a user, want %v", err, ErrVersionContextNotFound)
+	}
+
+	if _, err := ResolveVersionContext(ctx, "missing"); err != ErrVersionContextNotFound {
+		t.Errorf("got error %v, want %v", err, ErrVersionContextNotFound)
+	}
+}
+
+func TestResolveVersionContextRevision(t *testing.T) {
+	ctx := testContext()
+	defer git.ResetMocks()
+
+	git.Mocks.ListTags = func(repo gitserver.Repo) ([]*git.Tag, error) {
+		// Most recently created first.
+		return []*git.Tag{{Name: "v2024.4.0"}, {Name: "v2024.3.2"}, {Name: "v2024.3.1"}}, nil
+	}
+	repo := gitserver.Repo{Name: api.RepoName("r")}
+
+	tests := []struct {
+		rev  types.VersionContextRevision
+		want search.RevisionSpecifier
+	}{
+		{types.VersionContextRevision{}, search.RevisionSpecifier{}},
+		{types.VersionContextRevision{Rev: "v1"}, search.RevisionSpecifier{RevSpec: "v1"}},
+		{types.VersionContextRevision{RefGlob: "refs/heads/release/*"}, search.RevisionSpecifier{RefGlob: "refs/heads/release/*"}},
+		{types.VersionContextRevision{LatestTag: "v2024.3.*"}, search.RevisionSpecifier{RevSpec: "v2024.3.2"}},
+	}
+	for _, test := range tests {
+		got, err := ResolveVersionContextRevision(ctx, repo, &test.rev)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != test.want {
+			t.Errorf("%+v: got %+v, want %+v", test.rev, got, test.want)
+		}
+	}
+
+	_, err := ResolveVersionContextRevision(ctx, repo, &types.VersionContextRevision{LatestTag: "v2025.*"})
+	if !errcode.IsNotFound(err) {
+		t.Errorf("got error %v, want a not found error for a pattern matching no tag", err)
+	}
+}
+
+func TestValidateVersionContextRevision(t *testing.T) {
+	for _, test := range []struct {
+		rev     types.VersionContextRevision
+		wantErr bool
+	}{
+		{types.VersionContextRevision{}, false},
+		{types.VersionContextRevision{Rev: "v1"}, false},
+		{types.VersionContextRevision{LatestTag: "v1.*"}, false},
+		{types.VersionContextRevision{Rev: "v1", RefGlob: "refs/tags/*"}, true},
+		{types.VersionContextRevision{LatestTag: "v1.["}, true},
+	} {
+		err := ValidateVersionContextRevision(&test.rev)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%+v: got error %v, want error %v", test.rev, err, test.wantErr)
+		}
+	}
+}
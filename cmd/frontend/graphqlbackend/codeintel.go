@@ -180,7 +180,8 @@ type LSIFQueryPositionArgs struct {
 type LSIFPagedQueryPositionArgs struct {
 	LSIFQueryPositionArgs
 	graphqlutil.ConnectionArgs
-	After *string
+	After          *string
+	VersionContext *string
 }
 
 type LSIFDiagnosticsArgs struct {
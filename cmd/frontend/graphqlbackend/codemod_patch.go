@@ -0,0 +1,242 @@
+package graphqlbackend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/go-diff/diff"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/campaigns"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+)
+
+// codemodPatchResolver is a resolver for the GraphQL type `CodemodPatch`. It
+// combines the codemod results of a single repository revision into a patch.
+type codemodPatchResolver struct {
+	commit  *GitCommitResolver
+	results []*codemodResultResolver
+}
+
+// codemodPatches groups the codemod results in results by repository revision,
+// in the order in which the revisions first occur.
+func codemodPatches(results []SearchResultResolver) []*codemodPatchResolver {
+	var patches []*codemodPatchResolver
+	byCommit := make(map[string]*codemodPatchResolver)
+	for _, result := range results {
+		r, ok := result.ToCodemodResult()
+		if !ok {
+			continue
+		}
+		key := string(r.commit.repoResolver.repo.Name) + "@" + string(r.commit.oid)
+		patch, ok := byCommit[key]
+		if !ok {
+			patch = &codemodPatchResolver{commit: r.commit}
+			byCommit[key] = patch
+			patches = append(patches, patch)
+		}
+		patch.results = append(patch.results, r)
+	}
+	return patches
+}
+
+func (r *codemodPatchResolver) Repository() *RepositoryResolver { return r.commit.repoResolver }
+
+func (r *codemodPatchResolver) Commit() *GitCommitResolver { return r.commit }
+
+// Diff returns the changes to all files as a single patch in the format of
+// git diff, which can be applied with git apply.
+func (r *codemodPatchResolver) Diff() (string, error) {
+	results := make([]*codemodResultResolver, len(r.results))
+	copy(results, r.results)
+	sort.Slice(results, func(i, j int) bool { return results[i].path < results[j].path })
+
+	fileDiffs := make([]*diff.FileDiff, 0, len(results))
+	for _, result := range results {
+		// The replacer returns the diff of a file without a/ and b/ prefixes
+		// and without a git header.
+		fileDiff, err := diff.ParseFileDiff([]byte(result.diff))
+		if err != nil {
+			return "", errors.Wrapf(err, "invalid diff for %s", result.path)
+		}
+		fileDiff.OrigName = "a/" + result.path
+		fileDiff.OrigTime = nil
+		fileDiff.NewName = "b/" + result.path
+		fileDiff.NewTime = nil
+		fileDiff.Extended = []string{fmt.Sprintf("diff --git a/%s b/%s", result.path, result.path)}
+		for _, hunk := range fileDiff.Hunks {
+			if len(hunk.Body) > 0 && hunk.Body[len(hunk.Body)-1] != '\n' {
+				hunk.Body = append(hunk.Body, '\n')
+			}
+		}
+		fileDiffs = append(fileDiffs, fileDiff)
+	}
+	patch, err := diff.PrintMultiFileDiff(fileDiffs)
+	if err != nil {
+		return "", err
+	}
+	return string(patch), nil
+}
+
+type codemodPatchChangesetSpecArgs struct {
+	Branch        string
+	Title         string
+	Body          *string
+	CommitMessage *string
+}
+
+// ChangesetSpec returns a changeset spec, in JSON, that proposes the patch as
+// a changeset. It can be used to create a campaign with the
+// createChangesetSpec and createCampaignSpec mutations.
+func (r *codemodPatchResolver) ChangesetSpec(ctx context.Context, args *codemodPatchChangesetSpecArgs) (string, error) {
+	if args.Branch == "" {
+		return "", errors.New("branch must not be empty")
+	}
+	if args.Title == "" {
+		return "", errors.New("title must not be empty")
+	}
+
+	baseRef, err := r.baseRef(ctx)
+	if err != nil {
+		return "", err
+	}
+	patch, err := r.Diff()
+	if err != nil {
+		return "", err
+	}
+
+	spec := campaigns.ChangesetSpecDescription{
+		BaseRepository: r.commit.repoResolver.ID(),
+		BaseRev:        string(r.commit.oid),
+		BaseRef:        baseRef,
+		HeadRepository: r.commit.repoResolver.ID(),
+		HeadRef:        qualifyBranchRef(args.Branch),
+		Title:          args.Title,
+		Commits: []campaigns.GitCommitDescription{{
+			Message: args.Title,
+			Diff:    patch,
+		}},
+	}
+	if args.Body != nil {
+		spec.Body = *args.Body
+	}
+	if args.CommitMessage != nil && *args.CommitMessage != "" {
+		spec.Commits[0].Message = *args.CommitMessage
+	}
+	b, err := json.Marshal(spec)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// baseRef returns the full name of the branch that was searched, which is the
+// default branch if no revision was specified in the query. A changeset can
+// only be based on a branch, so other revisions, such as tags and commit IDs,
+// are an error.
+func (r *codemodPatchResolver) baseRef(ctx context.Context) (string, error) {
+	if r.commit.inputRev == nil || *r.commit.inputRev == "" || *r.commit.inputRev == "HEAD" {
+		ref, err := r.commit.repoResolver.DefaultBranch(ctx)
+		if err != nil {
+			return "", err
+		}
+		if ref == nil {
+			return "", errors.Errorf("repository %s has no default branch", r.commit.repoResolver.Name())
+		}
+		return ref.Name(), nil
+	}
+
+	rev := *r.commit.inputRev
+	cachedRepo, err := backend.CachedGitRepo(ctx, r.commit.repoResolver.repo)
+	if err != nil {
+		return "", err
+	}
+	refs, err := git.ListRefs(ctx, *cachedRepo)
+	if err != nil {
+		return "", err
+	}
+	ref, ok := resolveRefName(refs, rev)
+	if !ok || !strings.HasPrefix(ref, "refs/heads/") {
+		return "", errors.Errorf("revision %q of repository %s is not a branch", rev, r.commit.repoResolver.Name())
+	}
+	return ref, nil
+}
+
+// resolveRefName returns the full name of the ref in refs that rev refers to,
+// following the order in which git disambiguates revisions (see
+// gitrevisions(7)). It returns false if rev is not the name of a ref, for
+// example because it is a commit ID.
+func resolveRefName(refs []git.Ref, rev string) (string, bool) {
+	names := make(map[string]struct{}, len(refs))
+	for _, ref := range refs {
+		names[ref.Name] = struct{}{}
+	}
+	for _, name := range []string{
+		rev,
+		"refs/" + rev,
+		"refs/tags/" + rev,
+		"refs/heads/" + rev,
+		"refs/remotes/" + rev,
+		"refs/remotes/" + rev + "/HEAD",
+	} {
+		if _, ok := names[name]; ok {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// qualifyBranchRef returns the full name of the ref for branch, for example
+// refs/heads/master for master.
+func qualifyBranchRef(branch string) string {
+	if strings.HasPrefix(branch, "refs/") {
+		return branch
+	}
+	return "refs/heads/" + branch
+}
+
+func (sr *SearchResultsResolver) CodemodPatches() []*codemodPatchResolver {
+	return codemodPatches(sr.SearchResults)
+}
+
+// CodemodPatch is the change a search with a replace: field makes to a
+// repository revision.
+type CodemodPatch struct {
+	Repository api.RepoName
+	Commit     api.CommitID
+	Diff       string // in the format of git diff
+}
+
+// SearchCodemodPatches runs the search described by args and returns the
+// changes made by its replace: field as one patch per repository revision.
+func SearchCodemodPatches(ctx context.Context, args *SearchArgs) ([]CodemodPatch, error) {
+	impl, err := NewSearchImplementer(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	results, err := impl.Results(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if results.alert != nil && len(results.SearchResults) == 0 {
+		return nil, errors.New(results.alert.title)
+	}
+
+	var patches []CodemodPatch
+	for _, r := range results.CodemodPatches() {
+		patch, err := r.Diff()
+		if err != nil {
+			return nil, err
+		}
+		patches = append(patches, CodemodPatch{
+			Repository: r.commit.repoResolver.repo.Name,
+			Commit:     api.CommitID(r.commit.oid),
+			Diff:       patch,
+		})
+	}
+	return patches, nil
+}
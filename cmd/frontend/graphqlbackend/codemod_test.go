@@ -1,10 +1,17 @@
 package graphqlbackend
 
 import (
+	"context"
+	"encoding/json"
 	"strings"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/campaigns"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
 	"github.com/sourcegraph/sourcegraph/internal/search/query"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
 )
 
 func TestCodemod_validateArgsNoRegex(t *testing.T) {
@@ -39,3 +46,122 @@ func TestCodemod_resolver(t *testing.T) {
 		t.Fatalf("Expected error %q", err)
 	}
 }
+
+func TestCodemodPatches(t *testing.T) {
+	repoA := &RepositoryResolver{repo: &types.Repo{ID: 1, Name: "a"}}
+	repoB := &RepositoryResolver{repo: &types.Repo{ID: 2, Name: "b"}}
+	master := "master"
+	result := func(repo *RepositoryResolver, path, diff string) *codemodResultResolver {
+		return &codemodResultResolver{
+			commit: &GitCommitResolver{repoResolver: repo, oid: "c0ffee", inputRev: &master},
+			path:   path,
+			diff:   diff,
+		}
+	}
+	results := []SearchResultResolver{
+		result(repoA, "main.go", "--- main.go\n+++ main.go\n@@ -1,1 +1,1 @@\n-foo()\n+bar()"),
+		result(repoB, "b.go", "--- b.go\n+++ b.go\n@@ -2,1 +2,1 @@\n-foo()\n+bar()\n"),
+		&FileMatchResolver{JPath: "ignored.go", Repo: repoA},
+		result(repoA, "lib/lib.go", "--- lib/lib.go\n+++ lib/lib.go\n@@ -3,1 +3,1 @@\n-foo()\n+bar()\n"),
+	}
+
+	patches := codemodPatches(results)
+	if len(patches) != 2 {
+		t.Fatalf("got %d patches, want 2", len(patches))
+	}
+	if got := patches[0].Repository().Name(); got != "a" {
+		t.Fatalf("got first patch for repository %q, want a", got)
+	}
+
+	got, err := patches[0].Diff()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `diff --git a/lib/lib.go b/lib/lib.go
+--- a/lib/lib.go
++++ b/lib/lib.go
+@@ -3,1 +3,1 @@
+-foo()
++bar()
+diff --git a/main.go b/main.go
+--- a/main.go
++++ b/main.go
+@@ -1,1 +1,1 @@
+-foo()
++bar()
+`
+	if got != want {
+		t.Errorf("unexpected diff:\n%s", cmp.Diff(want, got))
+	}
+
+	git.Mocks.ListRefs = func(repo gitserver.Repo) ([]git.Ref, error) {
+		return []git.Ref{
+			{Name: "refs/heads/master", CommitID: "c0ffee"},
+			{Name: "refs/tags/v1", CommitID: "c0ffee"},
+		}, nil
+	}
+	defer git.ResetMocks()
+
+	spec, err := patches[1].ChangesetSpec(context.Background(), &codemodPatchChangesetSpecArgs{
+		Branch: "replace-foo",
+		Title:  "Replace foo with bar",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var desc campaigns.ChangesetSpecDescription
+	if err := json.Unmarshal([]byte(spec), &desc); err != nil {
+		t.Fatal(err)
+	}
+	wantDesc := campaigns.ChangesetSpecDescription{
+		BaseRepository: repoB.ID(),
+		BaseRev:        "c0ffee",
+		BaseRef:        "refs/heads/master",
+		HeadRepository: repoB.ID(),
+		HeadRef:        "refs/heads/replace-foo",
+		Title:          "Replace foo with bar",
+		Commits: []campaigns.GitCommitDescription{{
+			Message: "Replace foo with bar",
+			Diff:    "diff --git a/b.go b/b.go\n--- a/b.go\n+++ b/b.go\n@@ -2,1 +2,1 @@\n-foo()\n+bar()\n",
+		}},
+	}
+	if diff := cmp.Diff(wantDesc, desc); diff != "" {
+		t.Errorf("unexpected changeset spec:\n%s", diff)
+	}
+
+	// A changeset can only be based on a branch.
+	tag := "v1"
+	patches[1].commit.inputRev = &tag
+	if _, err := patches[1].ChangesetSpec(context.Background(), &codemodPatchChangesetSpecArgs{
+		Branch: "replace-foo",
+		Title:  "Replace foo with bar",
+	}); err == nil {
+		t.Error("got no error for a changeset spec based on a tag")
+	}
+}
+
+func TestResolveRefName(t *testing.T) {
+	refs := []git.Ref{
+		{Name: "refs/heads/master"},
+		{Name: "refs/heads/v1"},
+		{Name: "refs/tags/v1"},
+		{Name: "refs/remotes/origin/HEAD"},
+	}
+	for _, test := range []struct {
+		rev  string
+		want string
+	}{
+		{rev: "master", want: "refs/heads/master"},
+		{rev: "heads/master", want: "refs/heads/master"},
+		{rev: "refs/heads/master", want: "refs/heads/master"},
+		{rev: "v1", want: "refs/tags/v1"},
+		{rev: "origin", want: "refs/remotes/origin/HEAD"},
+		{rev: "c0ffee"},
+		{rev: "master~1"},
+	} {
+		got, ok := resolveRefName(refs, test.rev)
+		if got != test.want || ok != (test.want != "") {
+			t.Errorf("%q: got %q, %v, want %q", test.rev, got, ok, test.want)
+		}
+	}
+}
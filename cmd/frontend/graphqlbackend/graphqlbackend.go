@@ -587,6 +587,8 @@ func (r *schemaResolver) nodeByID(ctx context.Context, id graphql.ID) (Node, err
 		return RegistryExtensionByID(ctx, id)
 	case "SavedSearch":
 		return savedSearchByID(ctx, id)
+	case "VersionContext":
+		return versionContextByID(ctx, id)
 	case "Site":
 		return siteByGQLID(ctx, id)
 	case "LSIFUpload":
@@ -2,35 +2,356 @@ package graphqlbackend
 
 import (
 	"context"
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
 
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
 	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/db"
+	"github.com/sourcegraph/sourcegraph/internal/errcode"
 )
 
+// repoGroup is a repository group of the search.repositoryGroups setting, or
+// a repository group stored in the database if group is non-nil.
 type repoGroup struct {
 	name         string
 	repositories []api.RepoName
+	group        *types.RepoGroup
 }
 
-func (g repoGroup) Name() string { return g.name }
+func marshalRepoGroupID(id int32) graphql.ID {
+	return relay.MarshalID("RepoGroup", id)
+}
+
+func unmarshalRepoGroupID(id graphql.ID) (repoGroupID int32, err error) {
+	err = relay.UnmarshalSpec(id, &repoGroupID)
+	return
+}
+
+func repoGroupByID(ctx context.Context, id graphql.ID) (*repoGroup, error) {
+	intID, err := unmarshalRepoGroupID(id)
+	if err != nil {
+		return nil, err
+	}
+	g, err := db.RepoGroups.GetByID(ctx, intID)
+	if err != nil {
+		return nil, err
+	}
+	// 🚨 SECURITY: Make sure the current user has permission to get the repository group.
+	if err := checkRepoGroupAccess(ctx, g); err != nil {
+		return nil, err
+	}
+	return &repoGroup{name: g.Name, group: g}, nil
+}
 
-func (g repoGroup) Repositories() []string { return repoNamesToStrings(g.repositories) }
+// checkRepoGroupAccess returns an error if the current user is NEITHER (1) a
+// site admin NOR (2) the owner of the repository group NOR (3) a member of the
+// organization owning the repository group.
+func checkRepoGroupAccess(ctx context.Context, g *types.RepoGroup) error {
+	switch {
+	case g.UserID != nil:
+		return backend.CheckSiteAdminOrSameUser(ctx, *g.UserID)
+	case g.OrgID != nil:
+		return backend.CheckOrgAccess(ctx, *g.OrgID)
+	default:
+		return errors.New("repository group has no owner")
+	}
+}
+
+func (g *repoGroup) ID() *graphql.ID {
+	if g.group == nil {
+		return nil
+	}
+	id := marshalRepoGroupID(g.group.ID)
+	return &id
+}
+
+func (g *repoGroup) Name() string { return g.name }
+
+func (g *repoGroup) Description() string {
+	if g.group == nil {
+		return ""
+	}
+	return g.group.Description
+}
+
+func (g *repoGroup) Namespace(ctx context.Context) (*NamespaceResolver, error) {
+	if g.group == nil {
+		return nil, nil
+	}
+	var (
+		n   Namespace
+		err error
+	)
+	if g.group.OrgID != nil {
+		n, err = NamespaceByID(ctx, MarshalOrgID(*g.group.OrgID))
+	} else {
+		n, err = NamespaceByID(ctx, MarshalUserID(*g.group.UserID))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &NamespaceResolver{n}, nil
+}
+
+func (g *repoGroup) Repositories(ctx context.Context) ([]string, error) {
+	if g.group == nil {
+		return repoNamesToStrings(g.repositories), nil
+	}
+	reposByGroup, err := db.RepoGroups.ListRepos(ctx, g.group.ID)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(reposByGroup[g.group.ID]))
+	for _, repo := range reposByGroup[g.group.ID] {
+		names = append(names, string(repo.Name))
+	}
+	return names, nil
+}
+
+func (g *repoGroup) ExplicitRepositories(ctx context.Context) (*[]*RepositoryResolver, error) {
+	if g.group == nil {
+		return nil, nil
+	}
+	repos := make([]*RepositoryResolver, 0, len(g.group.RepoIDs))
+	for _, id := range g.group.RepoIDs {
+		repo, err := RepositoryByIDInt32(ctx, id)
+		if err != nil {
+			// The current user may not have access to all repositories of
+			// the repository group.
+			if errcode.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		repos = append(repos, repo)
+	}
+	return &repos, nil
+}
+
+func (g *repoGroup) Pattern() *string {
+	if g.group == nil {
+		return nil
+	}
+	return nonEmptyStringPtr(g.group.Pattern)
+}
+
+func (g *repoGroup) ExternalServiceID() *graphql.ID {
+	if g.group == nil || g.group.ExternalServiceID == nil {
+		return nil
+	}
+	id := marshalExternalServiceID(*g.group.ExternalServiceID)
+	return &id
+}
+
+func (g *repoGroup) ViewerCanAdminister(ctx context.Context) bool {
+	if g.group == nil {
+		return false
+	}
+	return checkRepoGroupAccess(ctx, g.group) == nil
+}
 
 func (r *schemaResolver) RepoGroups(ctx context.Context) ([]*repoGroup, error) {
-	groupsByName, err := resolveRepoGroups(ctx)
+	groupsByName, err := viewerRepoGroups(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
 
 	groups := make([]*repoGroup, 0, len(groupsByName))
-	for name, repos := range groupsByName {
-		repoPaths := make([]api.RepoName, len(repos))
-		for i, repo := range repos {
-			repoPaths[i] = repo.Name
+	for _, g := range groupsByName {
+		groups = append(groups, g)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].name < groups[j].name })
+	return groups, nil
+}
+
+// viewerRepoGroups returns the repository groups of the current user, keyed by
+// name. These are the groups of the search.repositoryGroups setting and the
+// groups owned by the user or their organizations. Groups owned by the user
+// take precedence over groups of their organizations with the same name,
+// which take precedence over the groups of the setting.
+//
+// If names is non-empty, only the groups with these names are returned.
+func viewerRepoGroups(ctx context.Context, names []string) (map[string]*repoGroup, error) {
+	groups := map[string]*repoGroup{}
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = true
+	}
+
+	settings, err := decodedViewerFinalSettings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for name, repoPaths := range settings.SearchRepositoryGroups {
+		if len(names) > 0 && !wanted[name] {
+			continue
+		}
+		repos := make([]api.RepoName, len(repoPaths))
+		for i, repoPath := range repoPaths {
+			repos[i] = api.RepoName(repoPath)
 		}
-		groups = append(groups, &repoGroup{
-			name:         name,
-			repositories: repoPaths,
-		})
+		groups[name] = &repoGroup{name: name, repositories: repos}
+	}
+
+	user, err := backend.CurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return groups, nil
+	}
+	orgs, err := db.Orgs.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	opt := db.RepoGroupsListOptions{UserID: user.ID, Names: names}
+	for _, org := range orgs {
+		opt.OrgIDs = append(opt.OrgIDs, org.ID)
+	}
+	dbGroups, err := db.RepoGroups.List(ctx, opt)
+	if err != nil {
+		return nil, err
+	}
+	// Add the groups of organizations first, so that the groups of the user
+	// replace them.
+	sort.SliceStable(dbGroups, func(i, j int) bool { return dbGroups[i].OrgID != nil && dbGroups[j].UserID != nil })
+	for _, g := range dbGroups {
+		groups[g.Name] = &repoGroup{name: g.Name, group: g}
 	}
 	return groups, nil
 }
+
+type repoGroupArgs struct {
+	Name            string
+	Description     *string
+	Repositories    []graphql.ID
+	Pattern         *string
+	ExternalService *graphql.ID
+}
+
+// toRepoGroup validates the arguments of the repository group mutations and
+// returns the repository group they describe.
+func (args *repoGroupArgs) toRepoGroup(ctx context.Context) (*types.RepoGroup, error) {
+	name := strings.TrimSpace(args.Name)
+	if name == "" {
+		return nil, errors.New("repository group name must not be empty")
+	}
+
+	g := &types.RepoGroup{Name: name}
+	if args.Description != nil {
+		g.Description = *args.Description
+	}
+	for _, id := range args.Repositories {
+		repoID, err := UnmarshalRepositoryID(id)
+		if err != nil {
+			return nil, err
+		}
+		// 🚨 SECURITY: Make sure the current user has access to the repository.
+		repo, err := db.Repos.Get(ctx, repoID)
+		if err != nil {
+			return nil, err
+		}
+		g.RepoIDs = append(g.RepoIDs, repo.ID)
+	}
+	if args.Pattern != nil && *args.Pattern != "" {
+		// The pattern is also validated by Postgres, which matches it, when
+		// the group is saved.
+		if _, err := regexp.Compile(*args.Pattern); err != nil {
+			return nil, err
+		}
+		g.Pattern = *args.Pattern
+	}
+	if args.ExternalService != nil {
+		externalServiceID, err := unmarshalExternalServiceID(*args.ExternalService)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := db.ExternalServices.GetByID(ctx, externalServiceID); err != nil {
+			return nil, err
+		}
+		g.ExternalServiceID = &externalServiceID
+	}
+	return g, nil
+}
+
+func (r *schemaResolver) CreateRepoGroup(ctx context.Context, args *struct {
+	Namespace graphql.ID
+	repoGroupArgs
+}) (*repoGroup, error) {
+	g, err := args.toRepoGroup(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// 🚨 SECURITY: Make sure the current user has permission to create a repository group for the specified user or org.
+	switch relay.UnmarshalKind(args.Namespace) {
+	case "User":
+		userID, err := UnmarshalUserID(args.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		if err := backend.CheckSiteAdminOrSameUser(ctx, userID); err != nil {
+			return nil, err
+		}
+		g.UserID = &userID
+	case "Org":
+		orgID, err := UnmarshalOrgID(args.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		if err := backend.CheckOrgAccess(ctx, orgID); err != nil {
+			return nil, err
+		}
+		g.OrgID = &orgID
+	default:
+		return nil, errors.New("invalid ID for namespace")
+	}
+
+	g, err = db.RepoGroups.Create(ctx, g)
+	if err != nil {
+		return nil, err
+	}
+	return &repoGroup{name: g.Name, group: g}, nil
+}
+
+func (r *schemaResolver) UpdateRepoGroup(ctx context.Context, args *struct {
+	ID graphql.ID
+	repoGroupArgs
+}) (*repoGroup, error) {
+	// 🚨 SECURITY: repoGroupByID checks that the current user has permission to update the repository group.
+	old, err := repoGroupByID(ctx, args.ID)
+	if err != nil {
+		return nil, err
+	}
+	g, err := args.toRepoGroup(ctx)
+	if err != nil {
+		return nil, err
+	}
+	g.ID = old.group.ID
+
+	g, err = db.RepoGroups.Update(ctx, g)
+	if err != nil {
+		return nil, err
+	}
+	return &repoGroup{name: g.Name, group: g}, nil
+}
+
+func (r *schemaResolver) DeleteRepoGroup(ctx context.Context, args *struct {
+	ID graphql.ID
+}) (*EmptyResponse, error) {
+	// 🚨 SECURITY: repoGroupByID checks that the current user has permission to delete the repository group.
+	g, err := repoGroupByID(ctx, args.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.RepoGroups.Delete(ctx, g.group.ID); err != nil {
+		return nil, err
+	}
+	return &EmptyResponse{}, nil
+}
@@ -0,0 +1,181 @@
+package graphqlbackend
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/gqltesting"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/db"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestResolveRepoGroups(t *testing.T) {
+	resetMocks()
+	defer resetMocks()
+
+	mockDecodedViewerFinalSettings = &schema.Settings{
+		SearchRepositoryGroups: map[string][]string{
+			"settings": {"github.com/a/settings"},
+			"shadowed": {"github.com/a/settings"},
+		},
+	}
+	defer func() { mockDecodedViewerFinalSettings = nil }()
+
+	db.Mocks.Users.GetByCurrentAuthUser = func(ctx context.Context) (*types.User, error) {
+		return &types.User{ID: 1}, nil
+	}
+	db.Mocks.Orgs.GetByUserID = func(ctx context.Context, userID int32) ([]*types.Org, error) {
+		return []*types.Org{{ID: 2}}, nil
+	}
+	userID, orgID := int32(1), int32(2)
+	db.Mocks.RepoGroups.List = func(ctx context.Context, opt db.RepoGroupsListOptions) ([]*types.RepoGroup, error) {
+		if opt.UserID != 1 || !reflect.DeepEqual(opt.OrgIDs, []int32{2}) {
+			t.Errorf("got options %+v, want the user and their org", opt)
+		}
+		if want := []string{"settings", "shadowed", "org"}; !reflect.DeepEqual(opt.Names, want) {
+			t.Errorf("got names %v, want %v", opt.Names, want)
+		}
+		return []*types.RepoGroup{
+			{ID: 1, Name: "shadowed", UserID: &userID},
+			{ID: 2, Name: "shadowed", OrgID: &orgID},
+			{ID: 3, Name: "org", OrgID: &orgID},
+		}, nil
+	}
+	db.Mocks.RepoGroups.ListRepos = func(ctx context.Context, ids ...int32) (map[int32][]*types.Repo, error) {
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		if want := []int32{1, 3}; !reflect.DeepEqual(ids, want) {
+			t.Errorf("got IDs %v, want %v", ids, want)
+		}
+		return map[int32][]*types.Repo{
+			1: {{ID: 10, Name: "github.com/a/user"}},
+			3: {{ID: 11, Name: "github.com/a/org"}},
+		}, nil
+	}
+
+	groups, err := resolveRepoGroups(actor.WithActor(context.Background(), &actor.Actor{UID: 1}), []string{"settings", "shadowed", "org"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string][]api.RepoName{}
+	for name, repos := range groups {
+		for _, repo := range repos {
+			got[name] = append(got[name], repo.Name)
+		}
+	}
+	want := map[string][]api.RepoName{
+		"settings": {"github.com/a/settings"},
+		"shadowed": {"github.com/a/user"},
+		"org":      {"github.com/a/org"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestMutation_CreateRepoGroup(t *testing.T) {
+	resetMocks()
+	defer resetMocks()
+
+	db.Mocks.Repos.Get = func(ctx context.Context, id api.RepoID) (*types.Repo, error) {
+		return &types.Repo{ID: id, Name: "github.com/sourcegraph/sourcegraph"}, nil
+	}
+	var created *types.RepoGroup
+	db.Mocks.RepoGroups.Create = func(ctx context.Context, g *types.RepoGroup) (*types.RepoGroup, error) {
+		created = g
+		withID := *g
+		withID.ID = 1
+		return &withID, nil
+	}
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Context: actor.WithActor(context.Background(), &actor.Actor{UID: 1}),
+			Schema:  mustParseGraphQLSchema(t),
+			Query: `
+				mutation {
+					createRepoGroup(
+						namespace: "VXNlcjox",
+						name: "platform",
+						repositories: ["UmVwb3NpdG9yeTox"],
+						pattern: "^github\\.com/sourcegraph/"
+					) {
+						id
+						name
+						pattern
+						externalServiceID
+						viewerCanAdminister
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"createRepoGroup": {
+						"id": "UmVwb0dyb3VwOjE=",
+						"name": "platform",
+						"pattern": "^github\\.com/sourcegraph/",
+						"externalServiceID": null,
+						"viewerCanAdminister": true
+					}
+				}
+			`,
+		},
+	})
+
+	if created == nil || created.UserID == nil || *created.UserID != 1 || !reflect.DeepEqual(created.RepoIDs, []api.RepoID{1}) {
+		t.Errorf("got %+v, want a repository group owned by user 1 with repository 1", created)
+	}
+}
+
+// 🚨 SECURITY: This tests that users can't create repository groups for other users.
+func TestMutation_CreateRepoGroup_otherUser(t *testing.T) {
+	resetMocks()
+	defer resetMocks()
+
+	db.Mocks.Users.GetByCurrentAuthUser = func(ctx context.Context) (*types.User, error) {
+		return &types.User{ID: 2}, nil
+	}
+	db.Mocks.Users.GetByID = func(ctx context.Context, id int32) (*types.User, error) {
+		return &types.User{ID: id, Username: "other"}, nil
+	}
+	db.Mocks.RepoGroups.Create = func(ctx context.Context, g *types.RepoGroup) (*types.RepoGroup, error) {
+		t.Fatal("repository group created for another user")
+		return nil, nil
+	}
+
+	ctx := actor.WithActor(context.Background(), &actor.Actor{UID: 2})
+	args := &struct {
+		Namespace graphql.ID
+		repoGroupArgs
+	}{Namespace: MarshalUserID(1), repoGroupArgs: repoGroupArgs{Name: "platform"}}
+	_, err := (&schemaResolver{}).CreateRepoGroup(ctx, args)
+	if _, ok := err.(*backend.InsufficientAuthorizationError); !ok {
+		t.Errorf("got error %v, want %T", err, &backend.InsufficientAuthorizationError{})
+	}
+}
+
+func TestMutation_CreateRepoGroup_invalidPattern(t *testing.T) {
+	resetMocks()
+	defer resetMocks()
+
+	db.Mocks.RepoGroups.Create = func(ctx context.Context, g *types.RepoGroup) (*types.RepoGroup, error) {
+		t.Fatal("repository group created with an invalid pattern")
+		return nil, nil
+	}
+
+	ctx := actor.WithActor(context.Background(), &actor.Actor{UID: 1})
+	pattern := "("
+	args := &struct {
+		Namespace graphql.ID
+		repoGroupArgs
+	}{Namespace: MarshalUserID(1), repoGroupArgs: repoGroupArgs{Name: "platform", Pattern: &pattern}}
+	if _, err := (&schemaResolver{}).CreateRepoGroup(ctx, args); err == nil {
+		t.Error("got no error, want an error for the invalid pattern")
+	}
+}
@@ -0,0 +1,49 @@
+package graphqlbackend
+
+import (
+	"context"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/db"
+)
+
+type savedSearchWebhookDeliveryResolver struct {
+	d *types.SavedSearchWebhookDelivery
+}
+
+func toSavedSearchWebhookDeliveryResolvers(deliveries []*types.SavedSearchWebhookDelivery) []*savedSearchWebhookDeliveryResolver {
+	resolvers := make([]*savedSearchWebhookDeliveryResolver, 0, len(deliveries))
+	for _, d := range deliveries {
+		resolvers = append(resolvers, &savedSearchWebhookDeliveryResolver{d: d})
+	}
+	return resolvers
+}
+
+func (r *savedSearchWebhookDeliveryResolver) SavedSearch(ctx context.Context) (*savedSearchResolver, error) {
+	return savedSearchByID(ctx, marshalSavedSearchID(r.d.SavedSearchID))
+}
+
+func (r *savedSearchWebhookDeliveryResolver) URL() string { return r.d.URL }
+
+func (r *savedSearchWebhookDeliveryResolver) Attempts() int32 { return r.d.Attempts }
+
+func (r *savedSearchWebhookDeliveryResolver) StatusCode() *int32 { return r.d.StatusCode }
+
+func (r *savedSearchWebhookDeliveryResolver) Error() *string { return r.d.Error }
+
+func (r *savedSearchWebhookDeliveryResolver) CreatedAt() DateTime {
+	return DateTime{Time: r.d.CreatedAt}
+}
+
+func (r *schemaResolver) SavedSearchWebhookDeliveryFailures(ctx context.Context, args *struct{ First int32 }) ([]*savedSearchWebhookDeliveryResolver, error) {
+	// 🚨 SECURITY: Only site admins may see the failed deliveries of all saved searches.
+	if err := backend.CheckCurrentUserIsSiteAdmin(ctx); err != nil {
+		return nil, err
+	}
+	deliveries, err := db.SavedSearchWebhookDeliveries.ListFailed(ctx, int(args.First))
+	if err != nil {
+		return nil, err
+	}
+	return toSavedSearchWebhookDeliveryResolvers(deliveries), nil
+}
@@ -3,6 +3,8 @@ package graphqlbackend
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net/url"
 
 	"github.com/graph-gophers/graphql-go"
 	"github.com/graph-gophers/graphql-go/relay"
@@ -59,6 +61,9 @@ func savedSearchByID(ctx context.Context, id graphql.ID) (*savedSearchResolver,
 			UserID:          ss.Config.UserID,
 			OrgID:           ss.Config.OrgID,
 			SlackWebhookURL: ss.Config.SlackWebhookURL,
+			NotifyWebhook:   ss.Config.NotifyWebhook,
+			WebhookURL:      ss.Config.WebhookURL,
+			WebhookSecret:   ss.Config.WebhookSecret,
 		},
 	}
 	return savedSearch, nil
@@ -100,6 +105,22 @@ func (r savedSearchResolver) Namespace(ctx context.Context) (*NamespaceResolver,
 
 func (r savedSearchResolver) SlackWebhookURL() *string { return r.s.SlackWebhookURL }
 
+func (r savedSearchResolver) NotifyWebhook() bool { return r.s.NotifyWebhook }
+
+func (r savedSearchResolver) WebhookURL() *string { return r.s.WebhookURL }
+
+func (r savedSearchResolver) HasWebhookSecret() bool {
+	return r.s.WebhookSecret != nil && *r.s.WebhookSecret != ""
+}
+
+func (r savedSearchResolver) WebhookDeliveries(ctx context.Context, args *struct{ First int32 }) ([]*savedSearchWebhookDeliveryResolver, error) {
+	deliveries, err := db.SavedSearchWebhookDeliveries.ListBySavedSearchID(ctx, r.s.ID, int(args.First))
+	if err != nil {
+		return nil, err
+	}
+	return toSavedSearchWebhookDeliveryResolvers(deliveries), nil
+}
+
 func toSavedSearchResolver(entry types.SavedSearch) *savedSearchResolver {
 	return &savedSearchResolver{entry}
 }
@@ -151,6 +172,10 @@ func (r *schemaResolver) CreateSavedSearch(ctx context.Context, args *struct {
 	NotifySlack bool
 	OrgID       *graphql.ID
 	UserID      *graphql.ID
+
+	NotifyWebhook bool
+	WebhookURL    *string
+	WebhookSecret *string
 }) (*savedSearchResolver, error) {
 	var userID, orgID *int32
 	// 🚨 SECURITY: Make sure the current user has permission to create a saved search for the specified user or org.
@@ -179,14 +204,20 @@ func (r *schemaResolver) CreateSavedSearch(ctx context.Context, args *struct {
 	if !queryHasPatternType(args.Query) {
 		return nil, errMissingPatternType
 	}
+	if err := validateWebhookURL(args.NotifyWebhook, args.WebhookURL); err != nil {
+		return nil, err
+	}
 
 	ss, err := db.SavedSearches.Create(ctx, &types.SavedSearch{
-		Description: args.Description,
-		Query:       args.Query,
-		Notify:      args.NotifyOwner,
-		NotifySlack: args.NotifySlack,
-		UserID:      userID,
-		OrgID:       orgID,
+		Description:   args.Description,
+		Query:         args.Query,
+		Notify:        args.NotifyOwner,
+		NotifySlack:   args.NotifySlack,
+		UserID:        userID,
+		OrgID:         orgID,
+		NotifyWebhook: args.NotifyWebhook,
+		WebhookURL:    args.WebhookURL,
+		WebhookSecret: args.WebhookSecret,
 	})
 	if err != nil {
 		return nil, err
@@ -203,6 +234,10 @@ func (r *schemaResolver) UpdateSavedSearch(ctx context.Context, args *struct {
 	NotifySlack bool
 	OrgID       *graphql.ID
 	UserID      *graphql.ID
+
+	NotifyWebhook *bool
+	WebhookURL    *string
+	WebhookSecret *string
 }) (*savedSearchResolver, error) {
 	var userID, orgID *int32
 	// 🚨 SECURITY: Make sure the current user has permission to update a saved search for the specified user or org.
@@ -237,14 +272,32 @@ func (r *schemaResolver) UpdateSavedSearch(ctx context.Context, args *struct {
 		return nil, errMissingPatternType
 	}
 
+	notifyWebhook, webhookURL := false, args.WebhookURL
+	if args.NotifyWebhook != nil {
+		notifyWebhook = *args.NotifyWebhook
+	} else {
+		// Keep the webhook settings of clients that don't know about them.
+		old, err := db.SavedSearches.GetByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		notifyWebhook, webhookURL = old.Config.NotifyWebhook, old.Config.WebhookURL
+	}
+	if err := validateWebhookURL(notifyWebhook, webhookURL); err != nil {
+		return nil, err
+	}
+
 	ss, err := db.SavedSearches.Update(ctx, &types.SavedSearch{
-		ID:          id,
-		Description: args.Description,
-		Query:       args.Query,
-		Notify:      args.NotifyOwner,
-		NotifySlack: args.NotifySlack,
-		UserID:      userID,
-		OrgID:       orgID,
+		ID:            id,
+		Description:   args.Description,
+		Query:         args.Query,
+		Notify:        args.NotifyOwner,
+		NotifySlack:   args.NotifySlack,
+		UserID:        userID,
+		OrgID:         orgID,
+		NotifyWebhook: notifyWebhook,
+		WebhookURL:    webhookURL,
+		WebhookSecret: args.WebhookSecret,
 	})
 	if err != nil {
 		return nil, err
@@ -289,4 +342,20 @@ func queryHasPatternType(query string) bool {
 	return patternTypeRegexp.Match([]byte(query))
 }
 
+// validateWebhookURL returns an error if notifications should be sent to an
+// outgoing webhook without a valid HTTP(S) URL.
+func validateWebhookURL(notifyWebhook bool, webhookURL *string) error {
+	if webhookURL == nil || *webhookURL == "" {
+		if notifyWebhook {
+			return errors.New("a webhook URL is required to send webhook notifications")
+		}
+		return nil
+	}
+	u, err := url.Parse(*webhookURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid webhook URL %q: must be an absolute http or https URL", *webhookURL)
+	}
+	return nil
+}
+
 var errMissingPatternType = errors.New("a `patternType:` filter is required in the query for all saved searches. `patternType` can be \"literal\" or \"regexp\"")
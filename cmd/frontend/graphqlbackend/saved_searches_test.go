@@ -67,6 +67,10 @@ func TestCreateSavedSearch(t *testing.T) {
 		NotifySlack bool
 		OrgID       *graphql.ID
 		UserID      *graphql.ID
+
+		NotifyWebhook bool
+		WebhookURL    *string
+		WebhookSecret *string
 	}{Description: "test query", Query: "test type:diff patternType:regexp", NotifyOwner: true, NotifySlack: false, OrgID: nil, UserID: &userID})
 	if err != nil {
 		t.Fatal(err)
@@ -97,6 +101,10 @@ func TestCreateSavedSearch(t *testing.T) {
 		NotifySlack bool
 		OrgID       *graphql.ID
 		UserID      *graphql.ID
+
+		NotifyWebhook bool
+		WebhookURL    *string
+		WebhookSecret *string
 	}{Description: "test query", Query: "test type:diff", NotifyOwner: true, NotifySlack: false, OrgID: nil, UserID: &userID})
 	if err == nil {
 		t.Error("Expected error for createSavedSearch when query does not provide a patternType: field.")
@@ -113,9 +121,13 @@ func TestUpdateSavedSearch(t *testing.T) {
 	}
 	updateSavedSearchCalled := false
 
+	webhookURL := "https://example.com/hook"
+	db.Mocks.SavedSearches.GetByID = func(ctx context.Context, id int32) (*api.SavedQuerySpecAndConfig, error) {
+		return &api.SavedQuerySpecAndConfig{Config: api.ConfigSavedQuery{NotifyWebhook: true, WebhookURL: &webhookURL}}, nil
+	}
 	db.Mocks.SavedSearches.Update = func(ctx context.Context, savedSearch *types.SavedSearch) (*types.SavedSearch, error) {
 		updateSavedSearchCalled = true
-		return &types.SavedSearch{ID: key, Description: savedSearch.Description, Query: savedSearch.Query, Notify: savedSearch.Notify, NotifySlack: savedSearch.NotifySlack, UserID: savedSearch.UserID, OrgID: savedSearch.OrgID}, nil
+		return &types.SavedSearch{ID: key, Description: savedSearch.Description, Query: savedSearch.Query, Notify: savedSearch.Notify, NotifySlack: savedSearch.NotifySlack, UserID: savedSearch.UserID, OrgID: savedSearch.OrgID, NotifyWebhook: savedSearch.NotifyWebhook, WebhookURL: savedSearch.WebhookURL}, nil
 	}
 	userID := MarshalUserID(key)
 	savedSearches, err := (&schemaResolver{}).UpdateSavedSearch(ctx, &struct {
@@ -126,6 +138,10 @@ func TestUpdateSavedSearch(t *testing.T) {
 		NotifySlack bool
 		OrgID       *graphql.ID
 		UserID      *graphql.ID
+
+		NotifyWebhook *bool
+		WebhookURL    *string
+		WebhookSecret *string
 	}{ID: marshalSavedSearchID(key), Description: "updated query description", Query: "test type:diff patternType:regexp", NotifyOwner: true, NotifySlack: false, OrgID: nil, UserID: &userID})
 	if err != nil {
 		t.Fatal(err)
@@ -139,6 +155,10 @@ func TestUpdateSavedSearch(t *testing.T) {
 		NotifySlack: false,
 		OrgID:       nil,
 		UserID:      &key,
+
+		// Webhook settings are kept if not specified.
+		NotifyWebhook: true,
+		WebhookURL:    &webhookURL,
 	}}
 
 	if !updateSavedSearchCalled {
@@ -158,6 +178,10 @@ func TestUpdateSavedSearch(t *testing.T) {
 		NotifySlack bool
 		OrgID       *graphql.ID
 		UserID      *graphql.ID
+
+		NotifyWebhook *bool
+		WebhookURL    *string
+		WebhookSecret *string
 	}{ID: marshalSavedSearchID(key), Description: "updated query description", Query: "test type:diff", NotifyOwner: true, NotifySlack: false, OrgID: nil, UserID: &userID})
 	if err == nil {
 		t.Error("Expected error for updateSavedSearch when query does not provide a patternType: field.")
@@ -195,3 +219,26 @@ func TestDeleteSavedSearch(t *testing.T) {
 		t.Errorf("Database method db.SavedSearches.Delete not called")
 	}
 }
+
+func TestValidateWebhookURL(t *testing.T) {
+	str := func(s string) *string { return &s }
+	tests := []struct {
+		notifyWebhook bool
+		webhookURL    *string
+		wantErr       bool
+	}{
+		{notifyWebhook: false, webhookURL: nil},
+		{notifyWebhook: true, webhookURL: nil, wantErr: true},
+		{notifyWebhook: true, webhookURL: str(""), wantErr: true},
+		{notifyWebhook: true, webhookURL: str("https://example.com/hook")},
+		{notifyWebhook: false, webhookURL: str("http://localhost:8080/hook")},
+		{notifyWebhook: true, webhookURL: str("example.com/hook"), wantErr: true},
+		{notifyWebhook: true, webhookURL: str("ftp://example.com/hook"), wantErr: true},
+	}
+	for _, test := range tests {
+		err := validateWebhookURL(test.notifyWebhook, test.webhookURL)
+		if (err != nil) != test.wantErr {
+			t.Errorf("validateWebhookURL(%v, %v): got error %v, want error: %v", test.notifyWebhook, test.webhookURL, err, test.wantErr)
+		}
+	}
+}
@@ -316,6 +316,12 @@ type Mutation {
         notifySlack: Boolean!
         orgID: ID
         userID: ID
+        # Whether or not to POST new results to the outgoing webhook at webhookURL.
+        notifyWebhook: Boolean = false
+        # The URL of the outgoing webhook.
+        webhookURL: String
+        # The secret used to sign the payloads of the outgoing webhook with HMAC-SHA256, if any.
+        webhookSecret: String
     ): SavedSearch!
     # Updates a saved search
     updateSavedSearch(
@@ -326,9 +332,67 @@ type Mutation {
         notifySlack: Boolean!
         orgID: ID
         userID: ID
+        # Whether or not to POST new results to the outgoing webhook at webhookURL. If null, the
+        # webhook settings are not changed.
+        notifyWebhook: Boolean
+        # The URL of the outgoing webhook.
+        webhookURL: String
+        # The secret used to sign the payloads of the outgoing webhook with HMAC-SHA256. An empty
+        # string removes the secret. If null, the existing secret is kept.
+        webhookSecret: String
     ): SavedSearch!
     # Deletes a saved search
     deleteSavedSearch(id: ID!): EmptyResponse
+    # (experimental) Creates a version context owned by a user or organization. Its name must be
+    # unique among the version contexts of its owner and those of the site configuration. When
+    # searching, a name refers to a version context of the site configuration, of the viewer or
+    # of an organization of the viewer, in that order.
+    createVersionContext(
+        # The namespace (either a user or organization) that owns the version context.
+        namespace: ID!
+        name: String!
+        description: String
+        # The repositories of the version context and their revisions.
+        revisions: [VersionContextRevisionInput!]!
+    ): VersionContext!
+    # (experimental) Updates a version context. The revisions replace all of its revisions.
+    updateVersionContext(
+        id: ID!
+        name: String!
+        description: String
+        revisions: [VersionContextRevisionInput!]!
+    ): VersionContext!
+    # (experimental) Deletes a version context.
+    deleteVersionContext(id: ID!): EmptyResponse
+
+    # Creates a repository group owned by a user or organization. Its name must be unique among
+    # the repository groups of the owner. Its repositories are the union of the explicitly listed
+    # repositories, the repositories whose names match the pattern and the repositories of the
+    # external service.
+    createRepoGroup(
+        # The namespace (either a user or organization) that owns the repository group.
+        namespace: ID!
+        name: String!
+        description: String
+        # The repositories that are explicitly listed as members.
+        repositories: [ID!]!
+        # A regular expression that matches the names of member repositories.
+        pattern: String
+        # An external service whose repositories are all members.
+        externalService: ID
+    ): RepoGroup!
+    # Updates a repository group. The repositories replace all of its explicitly listed
+    # repositories.
+    updateRepoGroup(
+        id: ID!
+        name: String!
+        description: String
+        repositories: [ID!]!
+        pattern: String
+        externalService: ID
+    ): RepoGroup!
+    # Deletes a repository group.
+    deleteRepoGroup(id: ID!): EmptyResponse
 
     # (experimental) The LSIF API may change substantially in the near future as we
     # continue to adjust it for our use cases. Changes will not be documented in the
@@ -1356,9 +1420,17 @@ type Query {
     ): Search
     # All saved searches configured for the current user, merged from all configurations.
     savedSearches: [SavedSearch!]!
+    # The most recent failed deliveries to the outgoing webhooks of all saved searches, newest first.
+    #
+    # Only site admins may perform this query.
+    savedSearchWebhookDeliveryFailures(
+        # Returns the first n failed deliveries.
+        first: Int = 50
+    ): [SavedSearchWebhookDelivery!]!
     # All repository groups for the current user, merged from all configurations.
     repoGroups: [RepoGroup!]!
-    # (experimental) All version contexts.
+    # (experimental) All version contexts of the site configuration, and the version contexts
+    # owned by the current user and their organizations.
     versionContexts: [VersionContext!]!
     # (experimental) Return the parse tree of a search query.
     parseSearchQuery(
@@ -1500,6 +1572,94 @@ type Search {
     # cached and thus quicker to query. Useful for e.g. querying sparkline
     # data.
     stats: SearchResultsStats!
+    # EXPERIMENTAL: The number of matches of the search grouped by a dimension, such as repository
+    # or language. The aggregation is computed over the same results as the results field, so the
+    # count: and timeout: fields of the query apply. It is null if the query is invalid.
+    aggregations(
+        # The dimension to group matches by.
+        groupBy: SearchAggregationGroupBy!
+        # The number of groups with the most matches to return. The matches of the other groups are
+        # counted in SearchAggregation.otherCount.
+        first: Int = 50
+    ): SearchAggregation
+    # How the search would be run and its estimated cost, without running it. It is null if the
+    # query is invalid.
+    explain: SearchExplanation
+}
+
+# How a search is run: which repositories are searched by the indexed and unindexed search backends,
+# with which timeouts, and its estimated cost.
+type SearchExplanation {
+    # The parsed query, as an S-expression such as (and "repo:foo" "bar").
+    queryTree: String!
+    # The pattern that is searched for, after applying the pattern type of the search.
+    pattern: String!
+    # The types of results that are searched for, such as "file" or "commit".
+    resultTypes: [String!]!
+    # The number of repository revisions that the query resolved to.
+    repositoriesCount: Int!
+    # The number of repository revisions in the query that do not exist.
+    missingRepositoriesCount: Int!
+    # The number of repositories whose file contents, paths and symbols are searched by the index.
+    indexedRepositoriesCount: Int!
+    # The repositories whose file contents, paths and symbols are searched without the index.
+    unindexedRepositories: [Repository!]!
+    # The number of repositories that are not indexed and would not be searched, because the limit
+    # on the number of unindexed repositories a search may search was reached.
+    unindexedRepositoriesLimitedCount: Int!
+    # Whether the index is unavailable, in which case all repositories are searched without it.
+    indexUnavailable: Boolean!
+    # The timeout of the search, in milliseconds.
+    timeoutMilliseconds: Int!
+    # The timeout of fetching an unindexed repository, in milliseconds. Repositories that can't be
+    # fetched before it are reported as cloning or timed out.
+    unindexedFetchTimeoutMilliseconds: Int!
+    # The estimated cost of the search. The unit is roughly the work needed to search one indexed
+    # repository.
+    estimatedCost: Int!
+    # The maximum estimated cost of a search, set by the search.maxEstimatedCost site configuration
+    # property, or null if there is no limit.
+    maxEstimatedCost: Int
+    # The alert that the search would return instead of results, for example if its estimated cost
+    # exceeds maxEstimatedCost.
+    alert: SearchAlert
+}
+
+# A dimension to group the matches of a search by.
+enum SearchAggregationGroupBy {
+    # The repository of the match.
+    REPOSITORY
+    # The top-level directory of the file containing the match, such as "cmd/". Matches in files at
+    # the root of a repository are grouped as "/". Only file matches are counted.
+    TOP_LEVEL_DIRECTORY
+    # The language of the file containing the match, as detected from its name. Only file matches
+    # are counted.
+    LANGUAGE
+    # The author of the commit, as "name <email>". Only commit and diff matches are counted.
+    COMMIT_AUTHOR
+    # The value of the first capture group of the regexp pattern, such as the argument in
+    # foo\((\w+)\). Only line matches of regexp searches are counted.
+    CAPTURE_GROUP
+}
+
+# The matches of a search grouped by a dimension.
+type SearchAggregation {
+    # The groups with the most matches, in descending order of their match count.
+    groups: [SearchAggregationGroup!]!
+    # The number of matches in groups that were not returned because of the limit on the number of
+    # groups.
+    otherCount: Int!
+    # Whether the search hit its result limit or timed out, in which case the counts are lower
+    # bounds.
+    limitHit: Boolean!
+}
+
+# The matches of a search that have the same value of a dimension.
+type SearchAggregationGroup {
+    # The value of the dimension, such as a repository name.
+    value: String!
+    # The number of matches.
+    count: Int!
 }
 
 # Predefined suggestions for search filters when backfill.
@@ -1594,6 +1754,9 @@ type SearchResults {
     repositoriesSearched: [Repository!]!
     # Indexed repositories searched. This is a subset of repositoriesSearched.
     indexedRepositoriesSearched: [Repository!]!
+    # The repository revisions searched, and whether each was searched using the index or fell back
+    # to unindexed search because the revision is not indexed.
+    revisionsSearched: [SearchedRevision!]!
     # Repositories that are busy cloning onto gitserver.
     #
     # In paginated search requests, some repositories may be cloning. These are reported here
@@ -1626,6 +1789,9 @@ type SearchResults {
     #
     # This field is only applcable when the original request was a paginated one.
     pageInfo: PageInfo!
+    # EXPERIMENTAL: The changes made by a search with a replace: field, as one patch per repository
+    # revision. It is empty if the search has no replace: field.
+    codemodPatches: [CodemodPatch!]!
 }
 
 # Statistics about search results.
@@ -1691,6 +1857,44 @@ type SavedSearch implements Node {
     namespace: Namespace!
     # The Slack webhook URL associated with this saved search, if any.
     slackWebhookURL: String
+    # Whether or not to POST new results to the outgoing webhook.
+    notifyWebhook: Boolean!
+    # The URL of the outgoing webhook, if any.
+    webhookURL: String
+    # Whether a secret is configured to sign the payloads of the outgoing webhook.
+    hasWebhookSecret: Boolean!
+    # The most recent deliveries to the outgoing webhook, newest first.
+    webhookDeliveries(
+        # Returns the first n deliveries. At most 100 deliveries are kept.
+        first: Int = 20
+    ): [SavedSearchWebhookDelivery!]!
+}
+
+# A notification for a saved search that was POSTed to its outgoing webhook.
+type SavedSearchWebhookDelivery {
+    # The saved search.
+    savedSearch: SavedSearch!
+    # The URL that the notification was POSTed to.
+    url: String!
+    # The number of attempts that were made, including retries.
+    attempts: Int!
+    # The HTTP status code of the last attempt, or null if no response was received.
+    statusCode: Int
+    # The reason why the delivery failed, or null if it succeeded.
+    error: String
+    # When the last attempt finished.
+    createdAt: DateTime!
+}
+
+# A revision of a repository that was searched.
+type SearchedRevision {
+    # The repository.
+    repository: Repository!
+    # The revision, as specified in the search query. HEAD is the default branch.
+    rev: String!
+    # Whether the revision was searched using the index. If false, the revision is not indexed and
+    # was searched without the index.
+    indexed: Boolean!
 }
 
 # A search query description.
@@ -1701,12 +1905,29 @@ type SearchQueryDescription {
     query: String!
 }
 
-# A group of repositories.
+# A group of repositories. A repository group is either defined in the search.repositoryGroups
+# setting or owned by a user or organization.
 type RepoGroup {
+    # The unique ID of the repository group, or null if it is defined in settings.
+    id: ID
     # The name.
     name: String!
+    # The description.
+    description: String!
+    # The user or organization that owns the repository group, or null if it is defined in settings.
+    namespace: Namespace
     # The repositories.
     repositories: [String!]!
+    # The repositories that are explicitly listed as members of the repository group, or null if
+    # it is defined in settings.
+    explicitRepositories: [Repository!]
+    # The regular expression that matches the names of the member repositories, if any.
+    pattern: String
+    # The ID of the external service whose repositories are all members of the repository group,
+    # if any.
+    externalServiceID: ID
+    # Whether the viewer can update and delete the repository group.
+    viewerCanAdminister: Boolean!
 }
 
 # A diff between two diffable Git objects.
@@ -1759,6 +1980,33 @@ type CodemodResult implements GenericSearchResultInterface {
     rawDiff: String!
 }
 
+# EXPERIMENTAL: The changes that a search with a replace: field makes to a repository revision.
+type CodemodPatch {
+    # The repository that the patch changes.
+    repository: Repository!
+    # The commit that the patch applies to.
+    commit: GitCommit!
+    # The changes to all files of the repository, in the format of git diff. It can be applied
+    # with git apply.
+    diff: String!
+    # A changeset spec (in JSON) that proposes the patch as a changeset. It can be passed to the
+    # createChangesetSpec mutation, and the resulting changeset specs to the createCampaignSpec
+    # mutation, to create a campaign from the patches of a search. The changeset is based on the
+    # branch that was searched. It is an error if another revision, such as a tag or a commit, was
+    # searched.
+    changesetSpec(
+        # The branch to create with the changes, for example "replace-foo".
+        branch: String!
+        # The title of the changeset, which is also the commit message if commitMessage is not
+        # given.
+        title: String!
+        # The body of the changeset.
+        body: String
+        # The commit message.
+        commitMessage: String
+    ): String!
+}
+
 # A search result that is a diff between two diffable Git objects.
 type DiffSearchResult {
     # The diff that matched the search query.
@@ -2152,10 +2400,13 @@ type ExternalRepository {
 # (experimental) A version context. Used to change the set of default repository and
 # revisions searched.
 #
-# Note: We do not expose the list of repositories and revisions in the version
-# context. This is intentional. However, if a need arises we can add it in.
+# Version contexts are defined in the site configuration, or created by users and
+# organizations. Note: We do not expose the list of repositories and revisions of the
+# version contexts of the site configuration. This is intentional. However, if a need
+# arises we can add it in.
 type VersionContext implements Node {
-    # The version context ID is its name.
+    # The version context ID. The ID of a version context of the site configuration is
+    # its name.
     id: ID!
 
     # The name of the version context.
@@ -2163,6 +2414,52 @@ type VersionContext implements Node {
 
     # The description of the version context.
     description: String!
+
+    # The user or organization that owns the version context, or null for a version
+    # context of the site configuration.
+    namespace: Namespace
+
+    # The repositories of the version context and their revisions, or null for a version
+    # context of the site configuration.
+    revisions: [VersionContextRevision!]
+
+    # Whether the viewer can update and delete the version context.
+    viewerCanAdminister: Boolean!
+}
+
+# (experimental) The revision that a version context pins a repository to. At most one
+# of rev, refGlob and latestTag is set. If none is set, the repository's default branch
+# is used.
+type VersionContextRevision {
+    # The repository.
+    repository: Repository!
+
+    # A revision, such as a tag, branch or commit.
+    rev: String
+
+    # A glob of refs, such as "refs/heads/release/*". All matching refs are searched.
+    refGlob: String
+
+    # A glob of tag names, such as "v2024.3.*". The most recently created matching tag
+    # is used.
+    latestTag: String
+}
+
+# (experimental) The revision that a version context pins a repository to. At most one
+# of rev, refGlob and latestTag may be set. If none is set, the repository's default
+# branch is used.
+input VersionContextRevisionInput {
+    # The ID of the repository.
+    repository: ID!
+
+    # A revision, such as a tag, branch or commit.
+    rev: String
+
+    # A glob of refs, such as "refs/heads/release/*".
+    refGlob: String
+
+    # A glob of tag names, such as "v2024.3.*".
+    latestTag: String
 }
 
 # Information about a repository's text search index.
@@ -3117,6 +3414,11 @@ type GitBlobLSIFData implements TreeEntryLSIFData {
         # the first N results (relative to the cursor) should be returned. i.e.
         # how many results to return per page.
         first: Int
+
+        # (experimental) When specified, only the references in the repositories of the
+        # version context, at the revisions that it pins them to, are returned. Pages may
+        # then have fewer than the requested number of results.
+        versionContext: String
     ): LocationConnection!
 
     # (experimental) The LSIF API may change substantially in the near future as we
@@ -3165,8 +3467,15 @@ type FileMatch {
     symbols: [Symbol!]!
     # The line matches.
     lineMatches: [LineMatch!]!
+    # The matches that span more than one line, such as matches of a regular expression containing
+    # \n. Each line of such a match is also included in lineMatches.
+    multilineMatches: [MultilineMatch!]!
     # Whether or not the limit was hit.
     limitHit: Boolean!
+    # When a revision range was searched (such as with repo:foo@v1.0..v2.0), the commits in which
+    # this version of the file existed, oldest first. The first commit is the base of the range or
+    # the commit that introduced this version of the file. Otherwise, it is null.
+    revisionRangeCommits: [GitCommit!]
 }
 
 # A line match.
@@ -3180,6 +3489,30 @@ type LineMatch {
     offsetAndLengths: [[Int!]!]!
     # Whether or not the limit was hit.
     limitHit: Boolean!
+    # The values of the capture groups of a regexp pattern in the matches that start on this line,
+    # such as the version number in version = "(\d+\.\d+)". It is empty if the pattern has no
+    # capture groups.
+    captureGroups: [CaptureGroup!]!
+}
+
+# The value of a capture group of a regexp pattern in a match.
+type CaptureGroup {
+    # The number of the group in the pattern, starting at 1.
+    index: Int!
+    # The name of the group, such as "version" in (?P<version>\d+), or null if it is unnamed.
+    name: String
+    # The captured text. It may contain newlines if the match spans more than one line.
+    value: String!
+    # The [offset, length] of the captured text in the line, measured in characters (not bytes).
+    offsetAndLength: [Int!]!
+}
+
+# A match that spans more than one line.
+type MultilineMatch {
+    # The lines that the match spans.
+    preview: String!
+    # The range of the match. Character offsets are measured in characters (not bytes).
+    range: Range!
 }
 
 # A hunk.
@@ -18,6 +18,7 @@ import (
 	"github.com/neelance/parallel"
 	"github.com/pkg/errors"
 
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/envvar"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
 	"github.com/sourcegraph/sourcegraph/internal/api"
@@ -36,6 +37,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/vcs"
 	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
 	"github.com/sourcegraph/sourcegraph/schema"
+	"github.com/src-d/enry/v2"
 )
 
 // This file contains the root resolver for search. It currently has a lot of
@@ -68,6 +70,10 @@ type SearchImplementer interface {
 	Suggestions(context.Context, *searchSuggestionsArgs) ([]*searchSuggestionResolver, error)
 	//lint:ignore U1000 is used by graphql via reflection
 	Stats(context.Context) (*searchResultsStats, error)
+	//lint:ignore U1000 is used by graphql via reflection
+	Aggregations(context.Context, *searchAggregationsArgs) (*searchAggregationResolver, error)
+	//lint:ignore U1000 is used by graphql via reflection
+	Explain(context.Context) (*searchExplanationResolver, error)
 }
 
 // NewSearchImplementer returns a SearchImplementer that provides search results and suggestions.
@@ -97,11 +103,13 @@ func NewSearchImplementer(ctx context.Context, args *SearchArgs) (SearchImplemen
 	}
 
 	var queryInfo query.QueryInfo
-	if (conf.AndOrQueryEnabled() && query.ContainsAndOrKeyword(args.Query)) || useNewParser || searchType == query.SearchTypeStructural {
+	if (conf.AndOrQueryEnabled() && (query.ContainsAndOrKeyword(args.Query) || query.ContainsNegatedGroup(args.Query))) || query.ContainsDottedField(args.Query) || useNewParser || searchType == query.SearchTypeStructural {
 		// To process the input as an and/or query, the flag must be
-		// enabled (default is on) and must contain either an 'and' or
-		// 'or' expression or set in settings. Else, fallback to the
-		// older existing parser.
+		// enabled (default is on) and must contain either an 'and',
+		// 'or' or 'not (...)' expression or set in settings. Else,
+		// fallback to the older existing parser. Fields with dots in
+		// their names, like repo.topic:, are only supported by the
+		// and/or parser.
 		globbing := getBoolPtr(settings.SearchGlobbing, false)
 		queryInfo, err = query.ProcessAndOr(args.Query, query.ParserOptions{SearchType: searchType, Globbing: globbing})
 		if err != nil {
@@ -341,6 +349,25 @@ func (r *searchResolver) maxResults() int32 {
 	return defaultMaxSearchResults
 }
 
+// backendMaxResults returns the maximum number of results to ask each search
+// backend for. With rank:yes, backends return more results than
+// maxResults, so that ranking chooses which results to return instead of
+// reordering the results that come first by name.
+func (r *searchResolver) backendMaxResults() int32 {
+	max := r.maxResults()
+	if r.pagination != nil || !r.query.BoolValue(query.FieldRank) {
+		return max
+	}
+	candidates := int64(max) * rankingCandidatesFactor
+	if candidates > maxRankingCandidates {
+		candidates = maxRankingCandidates
+	}
+	if candidates < int64(max) {
+		return max
+	}
+	return int32(candidates)
+}
+
 var mockDecodedViewerFinalSettings *schema.Settings
 
 func decodedViewerFinalSettings(ctx context.Context) (*schema.Settings, error) {
@@ -360,38 +387,47 @@ func decodedViewerFinalSettings(ctx context.Context) (*schema.Settings, error) {
 
 var mockResolveRepoGroups func() (map[string][]*types.Repo, error)
 
-func resolveRepoGroups(ctx context.Context) (map[string][]*types.Repo, error) {
+// resolveRepoGroups returns the repositories of the viewer's repository groups
+// with the given names, keyed by name. Names that aren't the name of a group
+// are ignored.
+func resolveRepoGroups(ctx context.Context, names []string) (map[string][]*types.Repo, error) {
 	if mockResolveRepoGroups != nil {
 		return mockResolveRepoGroups()
 	}
 
-	groups := map[string][]*types.Repo{}
-
-	// Repo groups can be defined in the search.repoGroups settings field.
-	settings, err := decodedViewerFinalSettings(ctx)
+	groupsByName, err := viewerRepoGroups(ctx, names)
 	if err != nil {
 		return nil, err
 	}
-	for name, repoPaths := range settings.SearchRepositoryGroups {
-		repos := make([]*types.Repo, len(repoPaths))
-		for i, repoPath := range repoPaths {
-			repos[i] = &types.Repo{Name: api.RepoName(repoPath)}
+
+	// Repo groups can be defined in the search.repoGroups settings field, or
+	// be stored in the database, in which case their repositories are listed
+	// by ListRepos.
+	var ids []int32
+	for _, g := range groupsByName {
+		if g.group != nil {
+			ids = append(ids, g.group.ID)
 		}
-		groups[name] = repos
+	}
+	reposByGroup, err := db.RepoGroups.ListRepos(ctx, ids...)
+	if err != nil {
+		return nil, err
 	}
 
-	return groups, nil
-}
-
-// NOTE: This function is not called if the version context is not used
-func resolveVersionContext(versionContext string) (*schema.VersionContext, error) {
-	for _, vc := range conf.Get().ExperimentalFeatures.VersionContexts {
-		if vc.Name == versionContext {
-			return vc, nil
+	groups := make(map[string][]*types.Repo, len(groupsByName))
+	for name, g := range groupsByName {
+		if g.group != nil {
+			groups[name] = reposByGroup[g.group.ID]
+			continue
 		}
+		repos := make([]*types.Repo, len(g.repositories))
+		for i, repoName := range g.repositories {
+			repos[i] = &types.Repo{Name: repoName}
+		}
+		groups[name] = repos
 	}
 
-	return nil, errors.New("version context not found")
+	return groups, nil
 }
 
 // Cf. golang/go/src/regexp/syntax/parse.go.
@@ -525,6 +561,21 @@ func (r *searchResolver) resolveRepositories(ctx context.Context, effectiveRepoF
 
 	commitAfter, _ := r.query.StringValue(query.FieldRepoHasCommitAfter)
 
+	descriptionPatterns, minusDescriptionPatterns := r.query.RegexpPatterns(query.FieldRepoDescription)
+	topics, minusTopics := r.query.StringValues(query.FieldRepoTopic)
+	languages, minusLanguages := r.query.StringValues(query.FieldRepoLanguage)
+	if languages, err = repoLanguages(languages); err != nil {
+		return nil, nil, nil, false, err
+	}
+	if minusLanguages, err = repoLanguages(minusLanguages); err != nil {
+		return nil, nil, nil, false, err
+	}
+	sizes, _ := r.query.StringValues(query.FieldRepoSize)
+	minSize, maxSize, err := sizeBounds(sizes)
+	if err != nil {
+		return nil, nil, nil, false, err
+	}
+
 	var versionContextName string
 	if r.versionContext != nil {
 		versionContextName = *r.versionContext
@@ -544,6 +595,15 @@ func (r *searchResolver) resolveRepositories(ctx context.Context, effectiveRepoF
 		onlyPublic:         visibility == query.Public,
 		commitAfter:        commitAfter,
 		query:              r.query,
+
+		descriptionPatterns:      descriptionPatterns,
+		minusDescriptionPatterns: minusDescriptionPatterns,
+		topics:                   topics,
+		minusTopics:              minusTopics,
+		languages:                languages,
+		minusLanguages:           minusLanguages,
+		minSize:                  minSize,
+		maxSize:                  maxSize,
 	}
 	repoRevs, missingRepoRevs, overLimit, excludedRepos, err = resolveRepositories(ctx, options)
 	tr.LazyPrintf("resolveRepositories - done")
@@ -557,6 +617,40 @@ func (r *searchResolver) resolveRepositories(ctx context.Context, effectiveRepoF
 	return repoRevs, missingRepoRevs, excludedRepos, overLimit, err
 }
 
+// repoLanguages returns the names of the languages of the repo.language:
+// values, such as "Go" for "golang".
+func repoLanguages(values []string) ([]string, error) {
+	languages := make([]string, 0, len(values))
+	for _, value := range values {
+		lang, ok := enry.GetLanguageByAlias(value)
+		if !ok {
+			return nil, fmt.Errorf("unknown language: %q", value)
+		}
+		languages = append(languages, lang)
+	}
+	return languages, nil
+}
+
+// sizeBounds returns the inclusive bounds in bytes of the sizes matching
+// all of the values of repo.size: or file.size: filters, such as >10MB. A nil
+// bound means that the sizes are unbounded on that side.
+func sizeBounds(values []string) (min, max *int64, err error) {
+	for _, value := range values {
+		f, err := query.ParseSizeFilter(value)
+		if err != nil {
+			return nil, nil, err
+		}
+		fmin, fmax := f.Bounds()
+		if fmin >= 0 && (min == nil || fmin > *min) {
+			min = &fmin
+		}
+		if fmax >= 0 && (max == nil || fmax < *max) {
+			max = &fmax
+		}
+	}
+	return min, max, nil
+}
+
 // a patternRevspec maps an include pattern to a list of revisions
 // for repos matching that pattern. "map" in this case does not mean
 // an actual map, because we want regexp matches, not identity matches.
@@ -663,6 +757,16 @@ type resolveRepoOp struct {
 	onlyPrivate        bool
 	onlyPublic         bool
 	query              query.QueryInfo
+
+	// Filters of the metadata of repositories on their code host.
+	descriptionPatterns      []string
+	minusDescriptionPatterns []string
+	topics                   []string
+	minusTopics              []string
+	languages                []string
+	minusLanguages           []string
+	minSize                  *int64
+	maxSize                  *int64
 }
 
 func (op *resolveRepoOp) String() string {
@@ -709,6 +813,31 @@ func (op *resolveRepoOp) String() string {
 		b.WriteString(" onlyPublic")
 	}
 
+	if len(op.descriptionPatterns) > 0 {
+		_, _ = fmt.Fprintf(&b, " description=%v", op.descriptionPatterns)
+	}
+	if len(op.minusDescriptionPatterns) > 0 {
+		_, _ = fmt.Fprintf(&b, " -description=%v", op.minusDescriptionPatterns)
+	}
+	if len(op.topics) > 0 {
+		_, _ = fmt.Fprintf(&b, " topics=%v", op.topics)
+	}
+	if len(op.minusTopics) > 0 {
+		_, _ = fmt.Fprintf(&b, " -topics=%v", op.minusTopics)
+	}
+	if len(op.languages) > 0 {
+		_, _ = fmt.Fprintf(&b, " languages=%v", op.languages)
+	}
+	if len(op.minusLanguages) > 0 {
+		_, _ = fmt.Fprintf(&b, " -languages=%v", op.minusLanguages)
+	}
+	if op.minSize != nil {
+		_, _ = fmt.Fprintf(&b, " minSize=%d", *op.minSize)
+	}
+	if op.maxSize != nil {
+		_, _ = fmt.Fprintf(&b, " maxSize=%d", *op.maxSize)
+	}
+
 	return b.String()
 }
 
@@ -733,7 +862,7 @@ func resolveRepositories(ctx context.Context, op resolveRepoOp) (repoRevisions,
 	// groups and the set of repos specified with repo:. (If none are specified
 	// with repo:, then include all from the group.)
 	if groupNames := op.repoGroupFilters; len(groupNames) > 0 {
-		groups, err := resolveRepoGroups(ctx)
+		groups, err := resolveRepoGroups(ctx, groupNames)
 		if err != nil {
 			return nil, nil, false, nil, err
 		}
@@ -743,6 +872,12 @@ func resolveRepositories(ctx context.Context, op resolveRepoOp) (repoRevisions,
 				patterns = append(patterns, "^"+regexp.QuoteMeta(string(repo.Name))+"$")
 			}
 		}
+		// An empty include pattern would match all repositories, so a repo
+		// group without any (accessible) repositories matches none.
+		if len(patterns) == 0 {
+			tr.LazyPrintf("repogroups: no repos")
+			return nil, nil, false, nil, nil
+		}
 		tr.LazyPrintf("repogroups: adding %d repos to include pattern", len(patterns))
 		includePatterns = append(includePatterns, unionRegExps(patterns))
 
@@ -762,16 +897,16 @@ func resolveRepositories(ctx context.Context, op resolveRepoOp) (repoRevisions,
 	// If a version context is specified, gather the list of repository names
 	// to limit the results to these repositories.
 	var versionContextRepositories []string
-	var versionContext *schema.VersionContext
+	var versionContext *types.VersionContext
 	// If a ref is specified we skip using version contexts.
 	if len(includePatternRevs) == 0 && op.versionContextName != "" {
-		versionContext, err = resolveVersionContext(op.versionContextName)
+		versionContext, err = backend.ResolveVersionContext(ctx, op.versionContextName)
 		if err != nil {
 			return nil, nil, false, nil, err
 		}
 
 		for _, revision := range versionContext.Revisions {
-			versionContextRepositories = append(versionContextRepositories, revision.Repo)
+			versionContextRepositories = append(versionContextRepositories, string(revision.Repo))
 		}
 	}
 
@@ -811,6 +946,15 @@ func resolveRepositories(ctx context.Context, op resolveRepoOp) (repoRevisions,
 			OnlyArchived: op.onlyArchived,
 			NoPrivate:    op.onlyPublic,
 			OnlyPrivate:  op.onlyPrivate,
+
+			DescriptionPatterns:        op.descriptionPatterns,
+			ExcludeDescriptionPatterns: op.minusDescriptionPatterns,
+			Topics:                     op.topics,
+			ExcludeTopics:              op.minusTopics,
+			Languages:                  op.languages,
+			ExcludeLanguages:           op.minusLanguages,
+			MinSize:                    op.minSize,
+			MaxSize:                    op.maxSize,
 		}
 		excludedRepos = computeExcludedRepositories(ctx, op.query, options)
 		tr.LazyPrintf("excluded repos: %+v", excludedRepos)
@@ -830,11 +974,26 @@ func resolveRepositories(ctx context.Context, op resolveRepoOp) (repoRevisions,
 		var revs []search.RevisionSpecifier
 		// versionContext will be nil if the query contains revision specifiers
 		if versionContext != nil {
+			repoRev.Repo = repo
 			for _, vcRepoRev := range versionContext.Revisions {
-				if vcRepoRev.Repo == string(repo.Name) {
-					repoRev.Repo = repo
-					revs = append(revs, search.RevisionSpecifier{RevSpec: vcRepoRev.Rev})
+				if vcRepoRev.Repo != repo.Name {
+					continue
+				}
+				rev, err := backend.ResolveVersionContextRevision(ctx, repoRev.GitserverRepo(), vcRepoRev)
+				if err != nil {
+					// The latest tag pattern matches no tag, or the tags
+					// can't be listed, so report the pattern as missing.
+					tr.LazyPrintf("version context revision of %s: %s", repo.Name, err)
+					missingRepoRevisions = append(missingRepoRevisions, &search.RepositoryRevisions{
+						Repo: repo,
+						Revs: []search.RevisionSpecifier{{RevSpec: vcRepoRev.LatestTag}},
+					})
+					continue
 				}
+				revs = append(revs, rev)
+			}
+			if len(revs) == 0 {
+				continue
 			}
 		} else {
 			var clashingRevs []search.RevisionSpecifier
@@ -872,7 +1031,22 @@ func resolveRepositories(ctx context.Context, op resolveRepoOp) (repoRevisions,
 				// searches like "repo:@foobar" (where foobar is an invalid revspec on most repos)
 				// taking a long time because they all ask gitserver to try to fetch from the remote
 				// repo.
-				if _, err := git.ResolveRevision(ctx, repoRev.GitserverRepo(), nil, rev.RevSpec, git.ResolveRevisionOptions{NoEnsureRevision: true}); gitserver.IsRevisionNotFound(err) || err == context.DeadlineExceeded {
+				specs := []string{rev.RevSpec}
+				if base, head, ok := rev.RevisionRange(); ok {
+					// Both ends of a revision range must exist.
+					specs = []string{base, head}
+				} else if base, head, ok := rev.DiffRange(); ok {
+					// Both ends of a diff range must exist.
+					specs = []string{base, head}
+				}
+				missing := false
+				for _, spec := range specs {
+					if _, err := git.ResolveRevision(ctx, repoRev.GitserverRepo(), nil, spec, git.ResolveRevisionOptions{NoEnsureRevision: true}); gitserver.IsRevisionNotFound(err) || err == context.DeadlineExceeded {
+						missing = true
+						break
+					}
+				}
+				if missing {
 					// The revspec does not exist, so don't include it, and report that it's missing.
 					if rev.RevSpec == "" {
 						// Report as HEAD not "" (empty string) to avoid user confusion.
@@ -0,0 +1,187 @@
+package graphqlbackend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/inventory"
+	"github.com/sourcegraph/sourcegraph/internal/search/query"
+)
+
+// Values of the GraphQL enum SearchAggregationGroupBy.
+const (
+	searchAggregationGroupByRepository        = "REPOSITORY"
+	searchAggregationGroupByTopLevelDirectory = "TOP_LEVEL_DIRECTORY"
+	searchAggregationGroupByLanguage          = "LANGUAGE"
+	searchAggregationGroupByCommitAuthor      = "COMMIT_AUTHOR"
+	searchAggregationGroupByCaptureGroup      = "CAPTURE_GROUP"
+)
+
+// searchAggregationRootDirectory is the group of files that are not in a
+// directory when grouping by top-level directory.
+const searchAggregationRootDirectory = "/"
+
+type searchAggregationsArgs struct {
+	GroupBy string
+	First   int32
+}
+
+func (r *searchResolver) Aggregations(ctx context.Context, args *searchAggregationsArgs) (*searchAggregationResolver, error) {
+	if args.First < 0 {
+		return nil, errors.New("first must not be negative")
+	}
+
+	if args.GroupBy == searchAggregationGroupByCaptureGroup {
+		if err := r.checkCaptureGroupAggregation(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Aggregations are computed over the same results as a normal search, so
+	// the count: and timeout: fields apply.
+	srr, err := r.Results(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if srr.alert != nil && len(srr.Results()) == 0 {
+		return nil, fmt.Errorf("unable to aggregate search results: %s", srr.alert.title)
+	}
+
+	counts, err := aggregateSearchResults(ctx, srr.Results(), args.GroupBy)
+	if err != nil {
+		return nil, err
+	}
+	limitHit := srr.LimitHit() || len(srr.Timedout()) > 0
+	return newSearchAggregationResolver(counts, int(args.First), limitHit), nil
+}
+
+// checkCaptureGroupAggregation returns an error if the pattern of the search
+// has no capture group to aggregate.
+func (r *searchResolver) checkCaptureGroupAggregation() error {
+	if r.patternType != query.SearchTypeRegex {
+		return errors.New("aggregating by capture group requires a regexp search (patternType:regexp)")
+	}
+	p, err := r.getPatternInfo(nil)
+	if err != nil {
+		return err
+	}
+	re, err := compileCaptureGroupRegexp(p)
+	if err != nil {
+		return err
+	}
+	if re == nil {
+		return errors.New("aggregating by capture group requires a regexp pattern with a capture group, such as foo\\((\\w+)\\)")
+	}
+	return nil
+}
+
+// aggregateSearchResults counts the matches of results for each value of the
+// dimension groupBy. Results that don't have a value for the dimension, such
+// as repository results when grouping by language, are not counted.
+func aggregateSearchResults(ctx context.Context, results []SearchResultResolver, groupBy string) (map[string]int32, error) {
+	counts := map[string]int32{}
+	for _, result := range results {
+		switch groupBy {
+		case searchAggregationGroupByRepository:
+			var repo string
+			if fm, ok := result.ToFileMatch(); ok {
+				repo = string(fm.Repo.repo.Name)
+			} else if r, ok := result.ToRepository(); ok {
+				repo = string(r.repo.Name)
+			} else if c, ok := result.ToCommitSearchResult(); ok {
+				repo = string(c.commit.repoResolver.repo.Name)
+			} else {
+				continue
+			}
+			counts[repo] += result.resultCount()
+
+		case searchAggregationGroupByTopLevelDirectory:
+			if fm, ok := result.ToFileMatch(); ok {
+				dir := searchAggregationRootDirectory
+				if i := strings.Index(fm.JPath, "/"); i >= 0 {
+					dir = fm.JPath[:i+1]
+				}
+				counts[dir] += fm.resultCount()
+			}
+
+		case searchAggregationGroupByLanguage:
+			if fm, ok := result.ToFileMatch(); ok {
+				if lang, _ := inventory.GetLanguageByFilename(fm.JPath); lang != "" {
+					counts[lang] += fm.resultCount()
+				}
+			}
+
+		case searchAggregationGroupByCommitAuthor:
+			if c, ok := result.ToCommitSearchResult(); ok {
+				author, err := c.commit.Author(ctx)
+				if err != nil {
+					return nil, err
+				}
+				counts[fmt.Sprintf("%s <%s>", author.person.name, author.person.email)]++
+			}
+
+		case searchAggregationGroupByCaptureGroup:
+			if fm, ok := result.ToFileMatch(); ok {
+				for _, lm := range fm.JLineMatches {
+					for _, g := range lm.JCaptureGroups {
+						if g.JIndex == 1 && g.JValue != "" {
+							counts[g.JValue]++
+						}
+					}
+				}
+			}
+
+		default:
+			return nil, fmt.Errorf("unsupported aggregation %q", groupBy)
+		}
+	}
+	return counts, nil
+}
+
+// searchAggregationResolver is a resolver for the GraphQL type
+// `SearchAggregation`.
+type searchAggregationResolver struct {
+	groups     []*searchAggregationGroupResolver
+	otherCount int32
+	limitHit   bool
+}
+
+// newSearchAggregationResolver returns the first groups with the highest
+// counts. The counts of the remaining groups are added up in otherCount.
+func newSearchAggregationResolver(counts map[string]int32, first int, limitHit bool) *searchAggregationResolver {
+	groups := make([]*searchAggregationGroupResolver, 0, len(counts))
+	for value, count := range counts {
+		groups = append(groups, &searchAggregationGroupResolver{value: value, count: count})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].count != groups[j].count {
+			return groups[i].count > groups[j].count
+		}
+		return groups[i].value < groups[j].value
+	})
+
+	r := &searchAggregationResolver{limitHit: limitHit}
+	if len(groups) > first {
+		for _, g := range groups[first:] {
+			r.otherCount += g.count
+		}
+		groups = groups[:first]
+	}
+	r.groups = groups
+	return r
+}
+
+func (r *searchAggregationResolver) Groups() []*searchAggregationGroupResolver { return r.groups }
+func (r *searchAggregationResolver) OtherCount() int32                         { return r.otherCount }
+func (r *searchAggregationResolver) LimitHit() bool                            { return r.limitHit }
+
+type searchAggregationGroupResolver struct {
+	value string
+	count int32
+}
+
+func (r *searchAggregationGroupResolver) Value() string { return r.value }
+func (r *searchAggregationGroupResolver) Count() int32  { return r.count }
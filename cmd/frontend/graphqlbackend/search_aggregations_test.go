@@ -0,0 +1,105 @@
+package graphqlbackend
+
+import (
+	"context"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+)
+
+func TestAggregateSearchResults(t *testing.T) {
+	repoA := &RepositoryResolver{repo: &types.Repo{Name: "a"}}
+	repoB := &RepositoryResolver{repo: &types.Repo{Name: "b"}}
+	re := regexp.MustCompile(`foo\((\w)\)`)
+	fileMatch := func(repo *RepositoryResolver, path string, previews ...string) *FileMatchResolver {
+		fm := &FileMatchResolver{Repo: repo, JPath: path, MatchCount: len(previews)}
+		for _, p := range previews {
+			lm := &lineMatch{JPreview: p}
+			for _, loc := range re.FindAllStringIndex(p, -1) {
+				lm.JOffsetAndLengths = append(lm.JOffsetAndLengths, [2]int32{int32(loc[0]), int32(loc[1] - loc[0])})
+			}
+			addCaptureGroups(re, lm)
+			fm.JLineMatches = append(fm.JLineMatches, lm)
+		}
+		return fm
+	}
+	commit := func(repo *RepositoryResolver, name string) *commitSearchResultResolver {
+		return &commitSearchResultResolver{
+			commit: toGitCommitResolver(repo, &git.Commit{
+				ID:     "c0ffee",
+				Author: git.Signature{Name: name, Email: name + "@example.com"},
+			}),
+		}
+	}
+	results := []SearchResultResolver{
+		fileMatch(repoA, "cmd/main.go", "foo(x)", "foo(y) + foo(x)"),
+		fileMatch(repoA, "README.md", "foo(x)"),
+		fileMatch(repoB, "cmd/b/b.go", "foo(z)"),
+		repoB,
+		commit(repoA, "alice"),
+		commit(repoB, "alice"),
+		commit(repoB, "bob"),
+	}
+
+	tests := []struct {
+		groupBy string
+		want    map[string]int32
+	}{
+		{
+			groupBy: searchAggregationGroupByRepository,
+			want:    map[string]int32{"a": 4, "b": 4},
+		},
+		{
+			groupBy: searchAggregationGroupByTopLevelDirectory,
+			want:    map[string]int32{"cmd/": 3, "/": 1},
+		},
+		{
+			groupBy: searchAggregationGroupByLanguage,
+			want:    map[string]int32{"Go": 3, "Markdown": 1},
+		},
+		{
+			groupBy: searchAggregationGroupByCommitAuthor,
+			want:    map[string]int32{"alice <alice@example.com>": 2, "bob <bob@example.com>": 1},
+		},
+		{
+			groupBy: searchAggregationGroupByCaptureGroup,
+			want:    map[string]int32{"x": 3, "y": 1, "z": 1},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.groupBy, func(t *testing.T) {
+			got, err := aggregateSearchResults(context.Background(), results, test.groupBy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+
+	if _, err := aggregateSearchResults(context.Background(), results, "BOGUS"); err == nil {
+		t.Error("expected an error for an unsupported aggregation")
+	}
+}
+
+func TestNewSearchAggregationResolver(t *testing.T) {
+	r := newSearchAggregationResolver(map[string]int32{"a": 1, "b": 5, "c": 2, "d": 2}, 2, true)
+
+	var got []string
+	for _, g := range r.Groups() {
+		got = append(got, g.Value())
+	}
+	if want := []string{"b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got groups %v, want %v", got, want)
+	}
+	if r.OtherCount() != 3 {
+		t.Errorf("got otherCount %d, want 3", r.OtherCount())
+	}
+	if !r.LimitHit() {
+		t.Error("got limitHit false, want true")
+	}
+}
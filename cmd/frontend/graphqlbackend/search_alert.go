@@ -13,6 +13,7 @@ import (
 	"github.com/hashicorp/go-multierror"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/envvar"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
 	"github.com/sourcegraph/sourcegraph/internal/search"
 	"github.com/sourcegraph/sourcegraph/internal/search/query"
 	"github.com/sourcegraph/sourcegraph/internal/search/query/syntax"
@@ -144,6 +145,16 @@ func (r *searchResolver) alertForNoResolvedRepos(ctx context.Context) *searchAle
 	archived, _ := r.query.StringValue(query.FieldArchived)
 	archivedNotSet := len(archived) == 0
 
+	for _, field := range []string{query.FieldRepoDescription, query.FieldRepoTopic, query.FieldRepoLanguage, query.FieldRepoSize} {
+		if len(r.query.Values(field)) > 0 {
+			return &searchAlert{
+				prometheusType: "no_resolved_repos__repo_metadata",
+				title:          "No repositories satisfied your repository metadata filters",
+				description:    "Expand your repo.description:, repo.topic:, repo.language: and repo.size: filters to see results. Only some code hosts report the topics, languages and sizes of repositories.",
+			}
+		}
+	}
+
 	// Handle repogroup-only scenarios.
 	if len(repoFilters) == 0 && len(repoGroupFilters) == 0 {
 		return &searchAlert{
@@ -546,6 +557,21 @@ func alertForMissingRepoRevs(patternType query.SearchType, missingRepoRevs []*se
 	}
 }
 
+// alertForDiffRange is returned if the diff range rev of repo, such as
+// repo:foo@main...feature-x, is searched for other results than diffs.
+func alertForDiffRange(p syntax.ParseTree, patternType query.SearchType, repo *types.Repo, rev string) *searchAlert {
+	return &searchAlert{
+		prometheusType: "diff_range_without_type_diff",
+		title:          "Diff ranges can only be searched with type:diff",
+		description:    fmt.Sprintf("The revision %q of the repository %s is a diff range, which contains the changes made on a branch rather than files. Only its diff can be searched, with type:diff.", rev, repo.Name),
+		proposedQueries: []*searchQueryDescription{{
+			description: "Search the diff range",
+			query:       strings.TrimSpace(omitQueryField(p, query.FieldType) + " type:diff"),
+			patternType: patternType,
+		}},
+	}
+}
+
 func omitQueryField(p syntax.ParseTree, field string) string {
 	omitField := func(e syntax.Expr) *syntax.Expr {
 		if e.Field == field {
@@ -641,3 +667,7 @@ func (searchAlert) Suggestions(context.Context, *searchSuggestionsArgs) ([]*sear
 	return nil, nil
 }
 func (searchAlert) Stats(context.Context) (*searchResultsStats, error) { return nil, nil }
+func (searchAlert) Aggregations(context.Context, *searchAggregationsArgs) (*searchAggregationResolver, error) {
+	return nil, nil
+}
+func (searchAlert) Explain(context.Context) (*searchExplanationResolver, error) { return nil, nil }
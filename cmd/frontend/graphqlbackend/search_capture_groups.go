@@ -0,0 +1,87 @@
+package graphqlbackend
+
+import (
+	"regexp"
+	"unicode/utf8"
+
+	"github.com/sourcegraph/sourcegraph/internal/search"
+)
+
+// captureGroup is the value of a capture group of a regexp pattern in a
+// match. It is unmarshaled from the searcher's protocol.CaptureGroup.
+type captureGroup struct {
+	JIndex  int32  `json:"Index"`
+	JName   string `json:"Name"`
+	JValue  string `json:"Value"`
+	JOffset int32  `json:"Offset"`
+	JLength int32  `json:"Length"`
+}
+
+func (g *captureGroup) Index() int32 { return g.JIndex }
+
+func (g *captureGroup) Name() *string {
+	if g.JName == "" {
+		return nil
+	}
+	return &g.JName
+}
+
+func (g *captureGroup) Value() string { return g.JValue }
+
+func (g *captureGroup) OffsetAndLength() []int32 { return []int32{g.JOffset, g.JLength} }
+
+// compileCaptureGroupRegexp returns the regexp of a search pattern if it has
+// capture groups, and nil otherwise. It matches the same text as searcher and
+// zoekt do for the pattern.
+func compileCaptureGroupRegexp(p *search.TextPatternInfo) (*regexp.Regexp, error) {
+	if !p.IsRegExp || p.IsStructuralPat || p.Pattern == "" {
+		return nil, nil
+	}
+	expr := p.Pattern
+	if p.IsWordMatch {
+		expr = `\b` + expr + `\b`
+	}
+	if !p.IsCaseSensitive {
+		expr = "(?i:" + expr + ")"
+	}
+	re, err := regexp.Compile("(?m:" + expr + ")")
+	if err != nil {
+		return nil, err
+	}
+	if re.NumSubexp() == 0 {
+		return nil, nil
+	}
+	return re, nil
+}
+
+// addCaptureGroups sets the capture groups of the matches in lm, for search
+// backends which only report the offsets of matches. A match of re in the
+// preview of lm is considered to be one of the matches of lm if it starts at
+// the same offset.
+func addCaptureGroups(re *regexp.Regexp, lm *lineMatch) {
+	starts := make(map[int]struct{}, len(lm.JOffsetAndLengths))
+	for _, ol := range lm.JOffsetAndLengths {
+		starts[int(ol[0])] = struct{}{}
+	}
+
+	names := re.SubexpNames()
+	for _, m := range re.FindAllStringSubmatchIndex(lm.JPreview, -1) {
+		if _, ok := starts[utf8.RuneCountInString(lm.JPreview[:m[0]])]; !ok {
+			continue
+		}
+		for i := 1; 2*i+1 < len(m); i++ {
+			start, end := m[2*i], m[2*i+1]
+			if start < 0 {
+				// The group did not participate in the match.
+				continue
+			}
+			lm.JCaptureGroups = append(lm.JCaptureGroups, &captureGroup{
+				JIndex:  int32(i),
+				JName:   names[i],
+				JValue:  lm.JPreview[start:end],
+				JOffset: int32(utf8.RuneCountInString(lm.JPreview[:start])),
+				JLength: int32(utf8.RuneCountInString(lm.JPreview[start:end])),
+			})
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package graphqlbackend
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/internal/search"
+)
+
+func TestCompileCaptureGroupRegexp(t *testing.T) {
+	for _, test := range []struct {
+		info *search.TextPatternInfo
+		want bool
+	}{
+		{info: &search.TextPatternInfo{Pattern: `v(\d+)`, IsRegExp: true}, want: true},
+		{info: &search.TextPatternInfo{Pattern: `v\d+`, IsRegExp: true}, want: false},
+		{info: &search.TextPatternInfo{Pattern: `v(\d+)`}, want: false},
+		{info: &search.TextPatternInfo{Pattern: `v(:[x])`, IsRegExp: true, IsStructuralPat: true}, want: false},
+	} {
+		re, err := compileCaptureGroupRegexp(test.info)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := re != nil; got != test.want {
+			t.Errorf("%q: got regexp %v, want %v", test.info.Pattern, got, test.want)
+		}
+	}
+
+	if _, err := compileCaptureGroupRegexp(&search.TextPatternInfo{Pattern: `(`, IsRegExp: true}); err == nil {
+		t.Error("expected an error for an invalid regexp")
+	}
+}
+
+func TestAddCaptureGroups(t *testing.T) {
+	re, err := compileCaptureGroupRegexp(&search.TextPatternInfo{Pattern: `(?P<key>\w+)=(\d+)?`, IsRegExp: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lm := &lineMatch{
+		JPreview: "ä a=1 b= C=3",
+		// Only the first and last match are matches of lm, for example
+		// because a search backend limited the number of matches.
+		JOffsetAndLengths: [][2]int32{{2, 3}, {9, 3}},
+	}
+	addCaptureGroups(re, lm)
+
+	want := []*captureGroup{
+		{JIndex: 1, JName: "key", JValue: "a", JOffset: 2, JLength: 1},
+		{JIndex: 2, JValue: "1", JOffset: 4, JLength: 1},
+		{JIndex: 1, JName: "key", JValue: "C", JOffset: 9, JLength: 1},
+		{JIndex: 2, JValue: "3", JOffset: 11, JLength: 1},
+	}
+	if !reflect.DeepEqual(lm.JCaptureGroups, want) {
+		t.Errorf("got %+v, want %+v", lm.JCaptureGroups, want)
+	}
+}
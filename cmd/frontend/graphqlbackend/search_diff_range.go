@@ -0,0 +1,134 @@
+package graphqlbackend
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/search"
+	"github.com/sourcegraph/sourcegraph/internal/search/query"
+	"github.com/sourcegraph/sourcegraph/internal/trace"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+)
+
+// hasDiffRange reports whether any of the revisions of repoRevs is a diff
+// range, such as repo:foo@main...feature-x.
+func hasDiffRange(repoRevs *search.RepositoryRevisions) bool {
+	for _, rev := range repoRevs.Revs {
+		if _, _, ok := rev.DiffRange(); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// diffRangeForOtherResults returns the first diff range of repos and its
+// repository, if any of resultTypes is not "diff". Only diffs can be searched
+// in a diff range, which is not a revision that has files.
+func diffRangeForOtherResults(resultTypes []string, repos []*search.RepositoryRevisions) (repo *types.Repo, rev string, ok bool) {
+	onlyDiffs := true
+	for _, resultType := range resultTypes {
+		if resultType != "diff" {
+			onlyDiffs = false
+		}
+	}
+	if onlyDiffs {
+		return nil, "", false
+	}
+	for _, repoRevs := range repos {
+		for _, r := range repoRevs.Revs {
+			if _, _, ok := r.DiffRange(); ok {
+				return repoRevs.Repo, r.RevSpec, true
+			}
+		}
+	}
+	return nil, "", false
+}
+
+// searchDiffRangeInRepo searches the diff range (such as "main...feature-x")
+// of a repository: the combined diff of the changes made on the head
+// revision since it diverged from the base revision, like `git diff
+// main...feature-x`. The diff is computed once, and each file diff whose added
+// or removed lines match the pattern is a result.
+//
+// It has the same signature as searchCommitsInRepo so that it can be used
+// instead of it for type:diff searches.
+func searchDiffRangeInRepo(ctx context.Context, op search.CommitParameters) (results []*commitSearchResultResolver, limitHit, timedOut bool, err error) {
+	tr, ctx := trace.New(ctx, "searchDiffRangeInRepo", fmt.Sprintf("repoRevs: %v, pattern %+v", op.RepoRevs, op.PatternInfo))
+	defer func() {
+		tr.LazyPrintf("%d results, limitHit=%v", len(results), limitHit)
+		tr.SetError(err)
+		tr.Finish()
+	}()
+
+	if len(op.RepoRevs.Revs) != 1 {
+		return nil, false, false, errors.New("a diff range such as repo:foo@main...feature-x cannot be combined with other revisions")
+	}
+	baseSpec, headSpec, ok := op.RepoRevs.Revs[0].DiffRange()
+	if !ok {
+		return nil, false, false, fmt.Errorf("invalid diff range %q", op.RepoRevs.Revs[0].RevSpec)
+	}
+	for _, field := range []string{query.FieldAuthor, query.FieldCommitter, query.FieldMessage, query.FieldBefore, query.FieldAfter} {
+		if len(op.Query.Values(field)) > 0 {
+			return nil, false, false, fmt.Errorf("the %s: filter cannot be used when searching a diff range, which is not a single commit", field)
+		}
+	}
+
+	gitserverRepo := op.RepoRevs.GitserverRepo()
+	base, err := git.ResolveRevision(ctx, gitserverRepo, nil, baseSpec, git.ResolveRevisionOptions{NoEnsureRevision: true})
+	if err != nil {
+		return nil, false, false, err
+	}
+	head, err := git.ResolveRevision(ctx, gitserverRepo, nil, headSpec, git.ResolveRevisionOptions{NoEnsureRevision: true})
+	if err != nil {
+		return nil, false, false, err
+	}
+	mergeBase, err := git.MergeBase(ctx, gitserverRepo, base, head)
+	if err != nil {
+		return nil, false, false, err
+	}
+
+	rawResults, limitHit, err := git.RangeDiffSearch(ctx, gitserverRepo, mergeBase, head, git.RangeDiffSearchOptions{
+		Query: git.TextSearchOptions{
+			Pattern:         op.PatternInfo.Pattern,
+			IsRegExp:        op.PatternInfo.IsRegExp,
+			IsCaseSensitive: op.PatternInfo.IsCaseSensitive,
+		},
+		Paths: git.PathOptions{
+			IncludePatterns: op.PatternInfo.IncludePatterns,
+			ExcludePattern:  op.PatternInfo.ExcludePattern,
+			IsCaseSensitive: op.PatternInfo.PathPatternsAreCaseSensitive,
+			IsRegExp:        op.PatternInfo.PathPatternsAreRegExps,
+		},
+		MaxFiles: int(op.PatternInfo.FileMatchLimit),
+	})
+	if err != nil {
+		return nil, false, false, err
+	}
+
+	repoResolver := &RepositoryResolver{repo: op.RepoRevs.Repo}
+	commitResolver := &GitCommitResolver{repoResolver: repoResolver, oid: GitObjectID(head)}
+	rangeSpec := baseSpec + "..." + headSpec
+	compareURL := repoResolver.URL() + "/-/compare/" + escapeRevspecForURL(rangeSpec)
+	label := fmt.Sprintf("[%s](%s) › [%s](%s)", displayRepoName(string(op.RepoRevs.Repo.Name)), repoResolver.URL(), rangeSpec, compareURL)
+
+	results = make([]*commitSearchResultResolver, len(rawResults))
+	for i, rawResult := range rawResults {
+		highlights := fromVCSHighlights(rawResult.DiffHighlights)
+		matchBody, matchHighlights := cleanDiffPreview(highlights, rawResult.Diff.Raw)
+		results[i] = &commitSearchResultResolver{
+			commit: commitResolver,
+			diffPreview: &highlightedString{
+				value:      rawResult.Diff.Raw,
+				highlights: highlights,
+			},
+			icon:    commitIcon,
+			label:   label,
+			url:     compareURL,
+			detail:  fmt.Sprintf("`%s`", rawResult.Path),
+			matches: []*searchResultMatchResolver{{body: matchBody, highlights: matchHighlights, url: compareURL}},
+		}
+	}
+	return results, limitHit, false, nil
+}
@@ -0,0 +1,136 @@
+package graphqlbackend
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/search"
+	"github.com/sourcegraph/sourcegraph/internal/search/query"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+)
+
+func TestSearchDiffRangeInRepo(t *testing.T) {
+	ctx := context.Background()
+	defer git.ResetMocks()
+
+	git.Mocks.ResolveRevision = func(spec string, opt git.ResolveRevisionOptions) (api.CommitID, error) {
+		return api.CommitID("commit-" + spec), nil
+	}
+	git.Mocks.MergeBase = func(repo gitserver.Repo, a, b api.CommitID) (api.CommitID, error) {
+		if a != "commit-main" || b != "commit-feature-x" {
+			t.Errorf("got merge base of %q and %q, want commit-main and commit-feature-x", a, b)
+		}
+		return "commit-mergebase", nil
+	}
+	git.Mocks.RangeDiffSearch = func(base, head api.CommitID, opt git.RangeDiffSearchOptions) ([]*git.FileDiffSearchResult, bool, error) {
+		if base != "commit-mergebase" || head != "commit-feature-x" {
+			t.Errorf("got range %s..%s, want commit-mergebase..commit-feature-x", base, head)
+		}
+		if opt.Query.Pattern != "p" || opt.MaxFiles != 30 {
+			t.Errorf("got options %+v", opt)
+		}
+		return []*git.FileDiffSearchResult{
+			{Path: "a.go", Diff: &git.RawDiff{Raw: "x"}},
+		}, true, nil
+	}
+
+	q, err := query.ParseAndCheck("type:diff p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	repoRevs := &search.RepositoryRevisions{
+		Repo: &types.Repo{ID: 1, Name: "repo"},
+		Revs: []search.RevisionSpecifier{{RevSpec: "main...feature-x"}},
+	}
+	if !hasDiffRange(repoRevs) {
+		t.Fatal("hasDiffRange: got false, want true")
+	}
+	results, limitHit, timedOut, err := searchDiffRangeInRepo(ctx, search.CommitParameters{
+		RepoRevs:    repoRevs,
+		PatternInfo: &search.CommitPatternInfo{Pattern: "p", FileMatchLimit: 30},
+		Query:       q,
+		Diff:        true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !limitHit || timedOut {
+		t.Errorf("got limitHit %v and timedOut %v, want true and false", limitHit, timedOut)
+	}
+	if len(results) != 1 {
+		t.Fatalf("got %d results, want 1", len(results))
+	}
+	r := results[0]
+	if want := "/repo/-/compare/main...feature-x"; r.url != want {
+		t.Errorf("got URL %q, want %q", r.url, want)
+	}
+	if want := "[repo](/repo) › [main...feature-x](/repo/-/compare/main...feature-x)"; r.label != want {
+		t.Errorf("got label %q, want %q", r.label, want)
+	}
+	if want := "`a.go`"; r.detail != want {
+		t.Errorf("got detail %q, want %q", r.detail, want)
+	}
+	if r.commit.OID() != "commit-feature-x" || r.diffPreview.value != "x" {
+		t.Errorf("got commit %q and diff %q", r.commit.OID(), r.diffPreview.value)
+	}
+
+	t.Run("commit filters", func(t *testing.T) {
+		q, err := query.ParseAndCheck("type:diff author:alice p")
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _, _, err = searchDiffRangeInRepo(ctx, search.CommitParameters{
+			RepoRevs:    repoRevs,
+			PatternInfo: &search.CommitPatternInfo{Pattern: "p", FileMatchLimit: 30},
+			Query:       q,
+			Diff:        true,
+		})
+		if err == nil {
+			t.Error("got no error, want an error for author:")
+		}
+	})
+
+	t.Run("other revisions", func(t *testing.T) {
+		repoRevs := &search.RepositoryRevisions{
+			Repo: &types.Repo{ID: 1, Name: "repo"},
+			Revs: []search.RevisionSpecifier{{RevSpec: "main...feature-x"}, {RevSpec: "v1"}},
+		}
+		_, _, _, err = searchDiffRangeInRepo(ctx, search.CommitParameters{
+			RepoRevs:    repoRevs,
+			PatternInfo: &search.CommitPatternInfo{Pattern: "p", FileMatchLimit: 30},
+			Query:       q,
+			Diff:        true,
+		})
+		if err == nil {
+			t.Error("got no error, want an error for several revisions")
+		}
+	})
+}
+
+func TestDiffRangeForOtherResults(t *testing.T) {
+	repos := makeRepositoryRevisions("foo", "bar@main...feature-x")
+
+	if _, _, ok := diffRangeForOtherResults([]string{"diff"}, repos); ok {
+		t.Error("got a diff range for type:diff, want none")
+	}
+	if _, _, ok := diffRangeForOtherResults([]string{"file"}, makeRepositoryRevisions("foo@v1.0..v2.0")); ok {
+		t.Error("got a diff range for a revision range, want none")
+	}
+
+	repo, rev, ok := diffRangeForOtherResults([]string{"diff", "commit"}, repos)
+	if !ok || repo.Name != "bar" || rev != "main...feature-x" {
+		t.Fatalf("got %v, %q, %v, want bar, main...feature-x, true", repo, rev, ok)
+	}
+
+	q, err := query.ParseAndCheck("repo:bar@main...feature-x type:commit NewClient")
+	if err != nil {
+		t.Fatal(err)
+	}
+	alert := alertForDiffRange(q.ParseTree(), query.SearchTypeLiteral, repo, rev)
+	if want := "repo:bar@main...feature-x NewClient type:diff"; len(alert.proposedQueries) != 1 || alert.proposedQueries[0].query != want {
+		t.Errorf("got proposed queries %+v, want %q", alert.proposedQueries, want)
+	}
+}
@@ -0,0 +1,313 @@
+package graphqlbackend
+
+import (
+	"context"
+	"fmt"
+	"regexp/syntax"
+	"strings"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/internal/search"
+	"github.com/sourcegraph/sourcegraph/internal/search/query"
+	"github.com/sourcegraph/sourcegraph/internal/trace"
+)
+
+// Weights of the estimated cost of a search. The unit is roughly the work
+// needed to search a single indexed repository.
+const (
+	searchCostIndexedRepo      = 1
+	searchCostUnindexedRepoRev = 20
+	searchCostRevisionRange    = 200
+	searchCostCommitRepoRev    = 50
+	searchCostSymbolRefRepoRev = 20
+
+	// searchCostNoLiteralFactor multiplies the cost of searching file
+	// contents with a regexp that has no literal substring, because neither
+	// zoekt nor searcher can use it to skip files.
+	searchCostNoLiteralFactor = 4
+)
+
+// searchPlan describes how a search is run: which backends search which
+// repositories, with which timeouts, and its estimated cost.
+type searchPlan struct {
+	resultTypes []string
+	repos       int
+	missing     int
+
+	indexed          int                           // repositories searched by zoekt
+	indexedRequest   *indexedSearchRequest         // reused by the search of file contents and paths
+	unindexed        []*search.RepositoryRevisions // searched by searcher
+	unindexedLimited []*types.Repo                 // not searched because there are too many
+	indexUnavailable bool
+
+	timeout               time.Duration
+	unindexedFetchTimeout time.Duration
+
+	cost int
+}
+
+// planSearch returns the plan for a search over the repositories in args,
+// for the given result types. It does not run the search.
+func (r *searchResolver) planSearch(ctx context.Context, args *search.TextParameters, resultTypes []string, missing int) (*searchPlan, error) {
+	timeout, err := r.searchTimeout()
+	if err != nil {
+		return nil, err
+	}
+	plan := &searchPlan{
+		resultTypes: resultTypes,
+		repos:       len(args.Repos),
+		missing:     missing,
+		timeout:     timeout,
+	}
+
+	var searchesFiles, searchesCommits, searchesSymbolRefs bool
+	for _, t := range resultTypes {
+		switch t {
+		case "file", "path", "symbol", "codemod":
+			searchesFiles = true
+		case "commit", "diff":
+			searchesCommits = true
+		case "symbol-ref":
+			searchesSymbolRefs = true
+		}
+	}
+
+	if searchesFiles {
+		typ := textRequest
+		if args.PatternInfo.IsStructuralPat {
+			typ = fileRequest
+		}
+		indexed, err := newIndexedSearchRequest(ctx, args, typ)
+		if err != nil {
+			return nil, err
+		}
+		plan.indexedRequest = indexed
+		plan.indexed = len(indexed.Repos())
+		plan.indexUnavailable = indexed.IndexUnavailable
+		if !indexed.DisableUnindexedSearch {
+			plan.unindexed, plan.unindexedLimited = limitSearcherRepos(indexed.Unindexed, maxUnindexedRepoRevSearchesPerQuery)
+		}
+
+		// This mirrors how callSearcherOverRepos chooses the timeout.
+		plan.unindexedFetchTimeout = unindexedFetchTimeoutManyRepos
+		if len(plan.unindexed) == 1 || args.UseFullDeadline {
+			plan.unindexedFetchTimeout = timeout
+		}
+
+		fileCost := plan.indexed * searchCostIndexedRepo
+		for _, repoRev := range plan.unindexed {
+			for _, rev := range repoRev.Revs {
+				if _, _, ok := rev.RevisionRange(); ok {
+					fileCost += searchCostRevisionRange
+				} else {
+					fileCost += searchCostUnindexedRepoRev
+				}
+			}
+		}
+		if args.PatternInfo.Pattern != "" && args.PatternInfo.PatternMatchesContent && !hasLiteralSubstring(args.PatternInfo) {
+			fileCost *= searchCostNoLiteralFactor
+		}
+		plan.cost += fileCost
+	}
+
+	if searchesCommits {
+		for _, repoRev := range args.Repos {
+			plan.cost += len(repoRev.Revs) * searchCostCommitRepoRev
+		}
+	}
+
+	if searchesSymbolRefs {
+		// Every repository is searched by the symbols service, as in
+		// searchSymbolRefs.
+		searched, _ := limitSearcherRepos(args.Repos, maxUnindexedRepoRevSearchesPerQuery)
+		for _, repoRev := range searched {
+			plan.cost += len(repoRev.Revs) * searchCostSymbolRefRepoRev
+		}
+	}
+
+	return plan, nil
+}
+
+// hasLiteralSubstring reports whether every match of the pattern contains a
+// literal string of at least 3 characters, which search backends use to skip
+// files that can't match.
+func hasLiteralSubstring(p *search.TextPatternInfo) bool {
+	if !p.IsRegExp {
+		return len(p.Pattern) >= 3
+	}
+	re, err := syntax.Parse(p.Pattern, syntax.Perl)
+	if err != nil {
+		return false
+	}
+	var walk func(re *syntax.Regexp) bool
+	walk = func(re *syntax.Regexp) bool {
+		switch re.Op {
+		case syntax.OpLiteral:
+			return len(re.Rune) >= 3
+		case syntax.OpCapture, syntax.OpPlus:
+			return walk(re.Sub[0])
+		case syntax.OpConcat:
+			for _, sub := range re.Sub {
+				if walk(sub) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	return walk(re.Simplify())
+}
+
+// maxEstimatedSearchCost returns the maximum estimated cost of a search, or 0
+// if it is unlimited.
+func maxEstimatedSearchCost() int {
+	if max := conf.Get().SearchMaxEstimatedCost; max > 0 {
+		return max
+	}
+	return 0
+}
+
+func alertForSearchCost(plan *searchPlan, max int) *searchAlert {
+	return &searchAlert{
+		prometheusType: "exceeded_estimated_cost",
+		title:          "Search query is too expensive",
+		description:    fmt.Sprintf("The estimated cost of this search (%d) exceeds the limit of %d set by the site admin. Try using the \"repo:\" filter to narrow down which repositories to search, or searching the default branch instead of other revisions.", plan.cost, max),
+	}
+}
+
+func (r *searchResolver) Explain(ctx context.Context) (_ *searchExplanationResolver, err error) {
+	tr, ctx := trace.New(ctx, "graphql.SearchExplain", r.rawQuery())
+	defer func() {
+		tr.SetError(err)
+		tr.Finish()
+	}()
+
+	explanation := &searchExplanationResolver{
+		queryTree:        queryTree(r.query),
+		maxEstimatedCost: maxEstimatedSearchCost(),
+	}
+
+	p, err := r.getPatternInfo(r.patternInfoOptions())
+	if err != nil {
+		return nil, err
+	}
+	explanation.pattern = p.Pattern
+	forceOnlyResultType := ""
+	if r.patternType == query.SearchTypeStructural {
+		if p.Pattern == "" {
+			// Like doResults, fall back to literal search.
+			p.IsStructuralPat = false
+		} else {
+			forceOnlyResultType = "file"
+		}
+	}
+
+	repos, missingRepoRevs, _, alertResult, err := r.determineRepos(ctx, tr, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if alertResult != nil {
+		// The search would not run.
+		explanation.alert = alertResult.alert
+		explanation.plan = &searchPlan{}
+		return explanation, nil
+	}
+
+	args := search.TextParameters{
+		PatternInfo:     p,
+		Repos:           repos,
+		Query:           r.query,
+		UseFullDeadline: r.searchTimeoutFieldSet(),
+		Zoekt:           r.zoekt,
+		SearcherURLs:    r.searcherURLs,
+	}
+	resultTypes := r.determineResultTypes(args, forceOnlyResultType)
+	resultTypes, explanation.alert = alertOnSearchLimit(resultTypes, &args)
+
+	explanation.plan, err = r.planSearch(ctx, &args, resultTypes, len(missingRepoRevs))
+	if err != nil {
+		return nil, err
+	}
+	if max := explanation.maxEstimatedCost; max > 0 && explanation.plan.cost > max {
+		explanation.alert = alertForSearchCost(explanation.plan, max)
+	}
+	return explanation, nil
+}
+
+// queryTree returns the parsed query q as an S-expression, such as
+// (and "repo:foo" "bar").
+func queryTree(q query.QueryInfo) string {
+	switch q := q.(type) {
+	case *query.AndOrQuery:
+		nodes := make([]string, len(q.Query))
+		for i, node := range q.Query {
+			nodes[i] = node.String()
+		}
+		return strings.Join(nodes, " ")
+	case *query.OrdinaryQuery:
+		return q.ParseTree().String()
+	}
+	return ""
+}
+
+// searchExplanationResolver is a resolver for the GraphQL type
+// `SearchExplanation`.
+type searchExplanationResolver struct {
+	queryTree        string
+	pattern          string
+	plan             *searchPlan
+	maxEstimatedCost int
+	alert            *searchAlert
+}
+
+func (r *searchExplanationResolver) QueryTree() string { return r.queryTree }
+func (r *searchExplanationResolver) Pattern() string   { return r.pattern }
+
+func (r *searchExplanationResolver) ResultTypes() []string {
+	if r.plan.resultTypes == nil {
+		return []string{}
+	}
+	return r.plan.resultTypes
+}
+
+func (r *searchExplanationResolver) RepositoriesCount() int32 { return int32(r.plan.repos) }
+func (r *searchExplanationResolver) MissingRepositoriesCount() int32 {
+	return int32(r.plan.missing)
+}
+func (r *searchExplanationResolver) IndexedRepositoriesCount() int32 { return int32(r.plan.indexed) }
+
+func (r *searchExplanationResolver) UnindexedRepositories() []*RepositoryResolver {
+	repos := make([]*RepositoryResolver, len(r.plan.unindexed))
+	for i, repoRev := range r.plan.unindexed {
+		repos[i] = &RepositoryResolver{repo: repoRev.Repo}
+	}
+	return repos
+}
+
+func (r *searchExplanationResolver) UnindexedRepositoriesLimitedCount() int32 {
+	return int32(len(r.plan.unindexedLimited))
+}
+
+func (r *searchExplanationResolver) IndexUnavailable() bool { return r.plan.indexUnavailable }
+
+func (r *searchExplanationResolver) TimeoutMilliseconds() int32 {
+	return int32(r.plan.timeout / time.Millisecond)
+}
+
+func (r *searchExplanationResolver) UnindexedFetchTimeoutMilliseconds() int32 {
+	return int32(r.plan.unindexedFetchTimeout / time.Millisecond)
+}
+
+func (r *searchExplanationResolver) EstimatedCost() int32 { return int32(r.plan.cost) }
+
+func (r *searchExplanationResolver) MaxEstimatedCost() *int32 {
+	if r.maxEstimatedCost <= 0 {
+		return nil
+	}
+	max := int32(r.maxEstimatedCost)
+	return &max
+}
+
+func (r *searchExplanationResolver) Alert() *searchAlert { return r.alert }
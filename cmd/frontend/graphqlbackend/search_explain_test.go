@@ -0,0 +1,147 @@
+package graphqlbackend
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/zoekt"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/search"
+	searchbackend "github.com/sourcegraph/sourcegraph/internal/search/backend"
+	"github.com/sourcegraph/sourcegraph/internal/search/query"
+)
+
+func TestPlanSearch(t *testing.T) {
+	z := &searchbackend.Zoekt{
+		Client: &fakeSearcher{
+			repos: []*zoekt.RepoListEntry{{
+				Repository: zoekt.Repository{
+					Name:     "indexed",
+					Branches: []zoekt.RepositoryBranch{{Name: "HEAD", Version: "deadbeef"}},
+				},
+			}},
+		},
+		DisableCache: true,
+	}
+	repoRevs := []*search.RepositoryRevisions{
+		{Repo: &types.Repo{Name: "indexed"}, Revs: []search.RevisionSpecifier{{RevSpec: ""}}},
+		{Repo: &types.Repo{Name: "unindexed"}, Revs: []search.RevisionSpecifier{{RevSpec: ""}}},
+		{Repo: &types.Repo{Name: "range"}, Revs: []search.RevisionSpecifier{{RevSpec: "v1..v2"}}},
+	}
+
+	tests := []struct {
+		query       string
+		resultTypes []string
+		wantCost    int
+		wantTimeout time.Duration
+	}{
+		{
+			query:       "foobar",
+			resultTypes: []string{"file"},
+			wantCost:    1 + 20 + 200,
+			wantTimeout: defaultTimeout,
+		},
+		{
+			query:       "foo.*bar timeout:3s",
+			resultTypes: []string{"file"},
+			wantCost:    1 + 20 + 200,
+			wantTimeout: 3 * time.Second,
+		},
+		{
+			query:       "[a-z]+",
+			resultTypes: []string{"file"},
+			wantCost:    4 * (1 + 20 + 200),
+			wantTimeout: defaultTimeout,
+		},
+		{
+			query:       "foobar",
+			resultTypes: []string{"commit", "diff"},
+			wantCost:    3 * 50,
+			wantTimeout: defaultTimeout,
+		},
+		{
+			query:       "foobar",
+			resultTypes: []string{"symbol-ref"},
+			wantCost:    3 * 20,
+			wantTimeout: defaultTimeout,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			q, err := query.ParseAndCheck(test.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := &searchResolver{query: q, zoekt: z, patternType: query.SearchTypeRegex}
+			p, err := r.getPatternInfo(r.patternInfoOptions())
+			if err != nil {
+				t.Fatal(err)
+			}
+			p.PatternMatchesContent = true
+			args := &search.TextParameters{
+				PatternInfo: p,
+				Repos:       repoRevs,
+				Query:       q,
+				Zoekt:       z,
+			}
+			plan, err := r.planSearch(context.Background(), args, test.resultTypes, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if plan.cost != test.wantCost {
+				t.Errorf("got cost %d, want %d", plan.cost, test.wantCost)
+			}
+			if plan.timeout != test.wantTimeout {
+				t.Errorf("got timeout %s, want %s", plan.timeout, test.wantTimeout)
+			}
+			if plan.repos != 3 {
+				t.Errorf("got %d repos, want 3", plan.repos)
+			}
+		})
+	}
+
+	// Only the repository which zoekt has indexed is searched by it.
+	q, _ := query.ParseAndCheck("foobar")
+	r := &searchResolver{query: q, zoekt: z}
+	p, _ := r.getPatternInfo(nil)
+	plan, err := r.planSearch(context.Background(), &search.TextParameters{PatternInfo: p, Repos: repoRevs, Query: q, Zoekt: z}, []string{"file"}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.indexed != 1 || len(plan.unindexed) != 2 {
+		t.Errorf("got %d indexed and %d unindexed repos, want 1 and 2", plan.indexed, len(plan.unindexed))
+	}
+	if plan.unindexedFetchTimeout != unindexedFetchTimeoutManyRepos {
+		t.Errorf("got unindexed fetch timeout %s, want %s", plan.unindexedFetchTimeout, unindexedFetchTimeoutManyRepos)
+	}
+}
+
+func TestHasLiteralSubstring(t *testing.T) {
+	for _, test := range []struct {
+		info *search.TextPatternInfo
+		want bool
+	}{
+		{info: &search.TextPatternInfo{Pattern: "foo"}, want: true},
+		{info: &search.TextPatternInfo{Pattern: "fo"}, want: false},
+		{info: &search.TextPatternInfo{Pattern: "foo.*bar", IsRegExp: true}, want: true},
+		{info: &search.TextPatternInfo{Pattern: "(foo)+", IsRegExp: true}, want: true},
+		{info: &search.TextPatternInfo{Pattern: "foo|bar", IsRegExp: true}, want: false},
+		{info: &search.TextPatternInfo{Pattern: `\w+\d`, IsRegExp: true}, want: false},
+		{info: &search.TextPatternInfo{Pattern: "(", IsRegExp: true}, want: false},
+	} {
+		if got := hasLiteralSubstring(test.info); got != test.want {
+			t.Errorf("%q: got %v, want %v", test.info.Pattern, got, test.want)
+		}
+	}
+}
+
+func TestQueryTree(t *testing.T) {
+	q, err := query.ProcessAndOr(`repo:foo (bar or baz)`, query.ParserOptions{SearchType: query.SearchTypeRegex})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := queryTree(q), `(and "repo:foo" (or "bar" "baz"))`; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
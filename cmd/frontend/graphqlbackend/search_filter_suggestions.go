@@ -15,7 +15,9 @@ func (r *schemaResolver) SearchFilterSuggestions(ctx context.Context) (*searchFi
 		return nil, err
 	}
 
-	groupsByName, err := resolveRepoGroups(ctx)
+	// Only the names of the repository groups are suggested, so their
+	// repositories are not listed.
+	groupsByName, err := viewerRepoGroups(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -12,14 +12,6 @@ import (
 )
 
 func TestSearchFilterSuggestions(t *testing.T) {
-	mockResolveRepoGroups = func() (map[string][]*types.Repo, error) {
-		return map[string][]*types.Repo{
-			"repogroup1": {},
-			"repogroup2": {},
-		}, nil
-	}
-	defer func() { mockResolveRepoGroups = nil }()
-
 	db.Mocks.Repos.List = func(_ context.Context, _ db.ReposListOptions) ([]*types.Repo, error) {
 		return []*types.Repo{
 			{Name: "github.com/foo/repo"},
@@ -44,7 +36,12 @@ func TestSearchFilterSuggestions(t *testing.T) {
 		},
 	}
 
-	mockDecodedViewerFinalSettings = &schema.Settings{}
+	mockDecodedViewerFinalSettings = &schema.Settings{
+		SearchRepositoryGroups: map[string][]string{
+			"repogroup1": {},
+			"repogroup2": {},
+		},
+	}
 	defer func() { mockDecodedViewerFinalSettings = nil }()
 
 	for _, tt := range tests {
@@ -0,0 +1,238 @@
+package graphqlbackend
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/inconshreveable/log15"
+	"github.com/neelance/parallel"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/internal/db"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/goroutine"
+	"github.com/sourcegraph/sourcegraph/internal/linguist"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func init() {
+	conf.ContributeValidator(func(c conf.Unified) (problems conf.Problems) {
+		for _, p := range c.SearchRankingRepoPriorities {
+			if _, err := regexp.Compile(p.RepoPattern); err != nil {
+				problems = append(problems, conf.NewSiteProblem(fmt.Sprintf("search.ranking.repoPriorities: invalid repoPattern %q: %s", p.RepoPattern, err)))
+			}
+		}
+		return problems
+	})
+}
+
+const (
+	// With rank:yes, search backends return rankingCandidatesFactor times
+	// as many results as requested, up to maxRankingCandidates, and the
+	// results that rank highest are returned.
+	rankingCandidatesFactor = 4
+	maxRankingCandidates    = 2000
+
+	// maxRankingRecencyLookups is the maximum number of file matches for
+	// which we look up the last commit that changed the file. The remaining
+	// file matches are ranked without recency.
+	maxRankingRecencyLookups = 100
+
+	// rankingRecencyWeight is the score of a result changed right now. The
+	// score halves every rankingRecencyHalfLife.
+	rankingRecencyWeight   = 2
+	rankingRecencyHalfLife = 30 * 24 * time.Hour
+
+	// The penalties of files in vendored directories, generated files and
+	// test files.
+	rankingVendoredPenalty  = 3
+	rankingGeneratedPenalty = 3
+	rankingTestPenalty      = 1
+)
+
+// rankingSignals are the signals used to score a search result.
+type rankingSignals struct {
+	date     time.Time // the date of the last change, if known
+	priority float64   // the priority of the repository in search.ranking.repoPriorities
+	stars    int       // the number of stars of the repository on its code host
+	path     string    // the path of the file, if any
+}
+
+// score returns the ranking score of a result with the signals s. Higher
+// scores rank first.
+func (s rankingSignals) score(now time.Time) float64 {
+	score := s.priority + math.Log10(1+float64(s.stars))
+	if !s.date.IsZero() {
+		age := now.Sub(s.date)
+		if age < 0 {
+			age = 0
+		}
+		score += rankingRecencyWeight * math.Exp2(-float64(age)/float64(rankingRecencyHalfLife))
+	}
+	return score - rankingPathPenalty(s.path)
+}
+
+var (
+	rankingVendoredDirs = map[string]bool{"vendor": true, "node_modules": true, "third_party": true, "bower_components": true}
+	rankingTestDirs     = map[string]bool{"test": true, "tests": true, "__tests__": true, "testdata": true, "spec": true}
+
+	rankingTestSuffixes = []string{"_test.go", "_test.py", "_spec.rb", "Test.java"}
+)
+
+// rankingPathPenalty returns the penalty of a file based on its path: files in
+// vendored directories, generated files and test files rank lower.
+func rankingPathPenalty(p string) float64 {
+	if p == "" {
+		return 0
+	}
+	var penalty float64
+
+	dirs := strings.Split(path.Dir(p), "/")
+	for _, dir := range dirs {
+		if rankingVendoredDirs[dir] {
+			penalty += rankingVendoredPenalty
+			break
+		}
+	}
+
+	if linguist.IsGeneratedPath(p) {
+		penalty += rankingGeneratedPenalty
+	}
+
+	name := path.Base(p)
+
+	isTest := strings.Contains(name, ".test.") || strings.Contains(name, ".spec.") || strings.HasPrefix(name, "test_")
+	for _, suffix := range rankingTestSuffixes {
+		isTest = isTest || strings.HasSuffix(name, suffix)
+	}
+	for _, dir := range dirs {
+		isTest = isTest || rankingTestDirs[dir]
+	}
+	if isTest {
+		penalty += rankingTestPenalty
+	}
+	return penalty
+}
+
+// rankingRepoPriority returns the priority of the first rule of
+// search.ranking.repoPriorities that matches repo, or 0.
+func rankingRepoPriority(priorities []*schema.SearchRepoPriority, repo api.RepoName) float64 {
+	for _, p := range priorities {
+		re, err := regexp.Compile(p.RepoPattern)
+		if err != nil {
+			// The site configuration validator reports invalid patterns.
+			continue
+		}
+		if re.MatchString(string(repo)) {
+			return p.Priority
+		}
+	}
+	return 0
+}
+
+// rankResults sorts results by descending ranking score. The score of a
+// result combines the recency of the last change to the file or commit, the
+// priority and stars of its repository, and penalties for vendored, generated
+// and test files. Results with the same score keep their order.
+//
+// Signals that can't be looked up are left out of the score, so ranking never
+// fails a search.
+func rankResults(ctx context.Context, results []SearchResultResolver) {
+	signals := make([]rankingSignals, len(results))
+	repoIDs := make([]api.RepoID, len(results))
+
+	var (
+		run          = parallel.NewRun(8) // number of concurrent commit lookups
+		recencyTodo  = maxRankingRecencyLookups
+		priorities   = conf.Get().SearchRankingRepoPriorities
+		repoPriority = map[api.RepoName]float64{}
+	)
+	for i, result := range results {
+		var repoName api.RepoName
+		switch m := result.(type) {
+		case *RepositoryResolver:
+			repoIDs[i], repoName = m.repo.ID, m.repo.Name
+		case *FileMatchResolver:
+			repoIDs[i], repoName = m.Repo.repo.ID, m.Repo.repo.Name
+			signals[i].path = m.JPath
+			if recencyTodo > 0 {
+				recencyTodo--
+				i, m := i, m
+				run.Acquire()
+				goroutine.Go(func() {
+					defer run.Release()
+
+					commits, err := git.Commits(ctx, gitserver.Repo{Name: m.Repo.repo.Name}, git.CommitsOptions{
+						Range:            string(m.CommitID),
+						Path:             m.JPath,
+						N:                1,
+						NoEnsureRevision: true,
+					})
+					if err != nil {
+						log15.Warn("failed to look up the last commit of a file for search ranking", "repo", m.Repo.repo.Name, "path", m.JPath, "error", err)
+						return
+					}
+					if len(commits) > 0 {
+						signals[i].date = commits[0].Author.Date
+					}
+				})
+			}
+		case *commitSearchResultResolver:
+			repoIDs[i], repoName = m.commit.repoResolver.repo.ID, m.commit.repoResolver.repo.Name
+			signals[i].date = m.commit.author.date
+		default:
+			continue
+		}
+
+		priority, ok := repoPriority[repoName]
+		if !ok {
+			priority = rankingRepoPriority(priorities, repoName)
+			repoPriority[repoName] = priority
+		}
+		signals[i].priority = priority
+	}
+
+	// 🚨 SECURITY: The IDs are those of repositories in the results, which the
+	// current user has access to.
+	stars, err := db.Repos.ListStars(ctx, uniqueRepoIDs(repoIDs)...)
+	if err != nil {
+		log15.Warn("failed to list repository stars for search ranking", "error", err)
+	}
+
+	_ = run.Wait()
+
+	type scoredResult struct {
+		result SearchResultResolver
+		score  float64
+	}
+	now := time.Now()
+	scored := make([]scoredResult, len(results))
+	for i, result := range results {
+		signals[i].stars = stars[repoIDs[i]]
+		scored[i] = scoredResult{result: result, score: signals[i].score(now)}
+	}
+	sort.SliceStable(scored, func(i, j int) bool { return scored[i].score > scored[j].score })
+	for i := range scored {
+		results[i] = scored[i].result
+	}
+}
+
+// uniqueRepoIDs returns the non-zero IDs of ids without duplicates.
+func uniqueRepoIDs(ids []api.RepoID) []api.RepoID {
+	seen := make(map[api.RepoID]bool, len(ids))
+	unique := make([]api.RepoID, 0, len(ids))
+	for _, id := range ids {
+		if id != 0 && !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,132 @@
+package graphqlbackend
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/internal/db"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/search/query"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestRankingPathPenalty(t *testing.T) {
+	tests := map[string]float64{
+		"":                                 0,
+		"cmd/main.go":                      0,
+		"vendor/github.com/a/b/b.go":       rankingVendoredPenalty,
+		"web/node_modules/react/index.js":  rankingVendoredPenalty,
+		"schema/schema.pb.go":              rankingGeneratedPenalty,
+		"dist/app.min.js":                  rankingGeneratedPenalty,
+		"api/client.generated.ts":          rankingGeneratedPenalty,
+		"cmd/main_test.go":                 rankingTestPenalty,
+		"src/app.test.ts":                  rankingTestPenalty,
+		"tests/integration.py":             rankingTestPenalty,
+		"vendor/github.com/a/b/b_test.go":  rankingVendoredPenalty + rankingTestPenalty,
+		"vendor/github.com/a/b/b.pb.go":    rankingVendoredPenalty + rankingGeneratedPenalty,
+		"internal/testutil/vendor_list.go": 0,
+	}
+	for path, want := range tests {
+		if got := rankingPathPenalty(path); got != want {
+			t.Errorf("rankingPathPenalty(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestRankingSignals_score(t *testing.T) {
+	now := time.Now()
+	recent := rankingSignals{date: now}.score(now)
+	old := rankingSignals{date: now.Add(-rankingRecencyHalfLife)}.score(now)
+	if recent != rankingRecencyWeight || old != rankingRecencyWeight/2 {
+		t.Errorf("got recency scores %v and %v, want %v and %v", recent, old, rankingRecencyWeight, rankingRecencyWeight/2)
+	}
+	if got := (rankingSignals{stars: 999}).score(now); got != 3 {
+		t.Errorf("got score %v for 999 stars, want 3", got)
+	}
+	if got := (rankingSignals{priority: 10, path: "vendor/a.go"}).score(now); got != 10-rankingVendoredPenalty {
+		t.Errorf("got score %v, want %v", got, 10-rankingVendoredPenalty)
+	}
+}
+
+func TestRankResults(t *testing.T) {
+	resetMocks()
+	defer resetMocks()
+
+	conf.Mock(&conf.Unified{SiteConfiguration: schema.SiteConfiguration{
+		SearchRankingRepoPriorities: []*schema.SearchRepoPriority{
+			{RepoPattern: "^github\\.com/a/important$", Priority: 10},
+		},
+	}})
+	defer conf.Mock(nil)
+
+	db.Mocks.Repos.ListStars = func(ctx context.Context, ids ...api.RepoID) (map[api.RepoID]int, error) {
+		return map[api.RepoID]int{2: 999}, nil
+	}
+	now := time.Now()
+	git.Mocks.Commits = func(repo gitserver.Repo, opt git.CommitsOptions) ([]*git.Commit, error) {
+		if opt.Path == "recent.go" {
+			return []*git.Commit{{Author: git.Signature{Date: now}}}, nil
+		}
+		return []*git.Commit{{Author: git.Signature{Date: now.Add(-365 * 24 * time.Hour)}}}, nil
+	}
+	defer func() { git.Mocks.Commits = nil }()
+
+	fileMatch := func(id api.RepoID, name api.RepoName, path string) *FileMatchResolver {
+		return &FileMatchResolver{
+			JPath:    path,
+			Repo:     &RepositoryResolver{repo: &types.Repo{ID: id, Name: name}},
+			CommitID: "deadbeef",
+		}
+	}
+	results := []SearchResultResolver{
+		fileMatch(1, "github.com/a/a", "old.go"),
+		fileMatch(1, "github.com/a/a", "recent.go"),
+		fileMatch(1, "github.com/a/a", "vendor/old.go"),
+		fileMatch(2, "github.com/a/popular", "old.go"),
+		fileMatch(3, "github.com/a/important", "vendor/old.go"),
+	}
+	rankResults(context.Background(), results)
+
+	var got []string
+	for _, r := range results {
+		repo, path := r.searchResultURIs()
+		got = append(got, repo+"/"+path)
+	}
+	want := []string{
+		"github.com/a/important/vendor/old.go", // priority 10, vendored
+		"github.com/a/popular/old.go",          // 999 stars
+		"github.com/a/a/recent.go",             // changed now
+		"github.com/a/a/old.go",
+		"github.com/a/a/vendor/old.go", // vendored
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got order %v, want %v", got, want)
+	}
+}
+
+func TestSearchResolver_backendMaxResults(t *testing.T) {
+	for _, test := range []struct {
+		query string
+		want  int32
+	}{
+		{query: "foo", want: defaultMaxSearchResults},
+		{query: "foo rank:yes", want: defaultMaxSearchResults * rankingCandidatesFactor},
+		{query: "foo rank:yes count:1000", want: maxRankingCandidates},
+		{query: "foo rank:yes count:5000", want: 5000},
+	} {
+		q, err := query.ParseAndCheck(test.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := &searchResolver{query: q}
+		if got := r.backendMaxResults(); got != test.want {
+			t.Errorf("%q: got %d, want %d", test.query, got, test.want)
+		}
+	}
+}
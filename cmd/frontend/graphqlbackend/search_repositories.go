@@ -39,6 +39,10 @@ func searchRepositories(ctx context.Context, args *search.TextParameters, limit
 		query.FieldCase:               {},
 		query.FieldRepoHasFile:        {},
 		query.FieldRepoHasCommitAfter: {},
+		query.FieldRepoDescription:    {},
+		query.FieldRepoTopic:          {},
+		query.FieldRepoLanguage:       {},
+		query.FieldRepoSize:           {},
 	}
 	// Don't return repo results if the search contains fields that aren't on the allowlist.
 	// Matching repositories based whether they contain files at a certain path (etc.) is not yet implemented.
@@ -65,8 +65,31 @@ type searchResultsCommon struct {
 	timedout []*types.Repo
 
 	indexUnavailable bool // True if indexed search is enabled but was not available during this search.
+
+	revisions []searchedRevision // repository revisions that were searched, and whether the index was used
+}
+
+// searchedRevision is a revision of a repository that was searched.
+type searchedRevision struct {
+	repo    *types.Repo
+	rev     string
+	indexed bool // whether the revision was searched using the index, rather than searcher
+}
+
+func newSearchedRevision(repo *types.Repo, rev search.RevisionSpecifier, indexed bool) searchedRevision {
+	revSpec := rev.RevSpec
+	if revSpec == "" {
+		revSpec = "HEAD"
+	}
+	return searchedRevision{repo: repo, rev: revSpec, indexed: indexed}
 }
 
+func (r *searchedRevision) Repository() *RepositoryResolver { return NewRepositoryResolver(r.repo) }
+
+func (r *searchedRevision) Rev() string { return r.rev }
+
+func (r *searchedRevision) Indexed() bool { return r.indexed }
+
 func (c *searchResultsCommon) LimitHit() bool {
 	return c.limitHit || c.resultCount > c.maxResultsCount
 }
@@ -87,6 +110,25 @@ func (c *searchResultsCommon) IndexedRepositoriesSearched() []*RepositoryResolve
 	return RepositoryResolvers(c.indexed)
 }
 
+func (c *searchResultsCommon) RevisionsSearched() []*searchedRevision {
+	revs := make([]*searchedRevision, 0, len(c.revisions))
+	seen := make(map[searchedRevision]struct{}, len(c.revisions))
+	for i := range c.revisions {
+		if _, ok := seen[c.revisions[i]]; ok {
+			continue
+		}
+		seen[c.revisions[i]] = struct{}{}
+		revs = append(revs, &c.revisions[i])
+	}
+	sort.Slice(revs, func(i, j int) bool {
+		if revs[i].repo.Name != revs[j].repo.Name {
+			return revs[i].repo.Name < revs[j].repo.Name
+		}
+		return revs[i].rev < revs[j].rev
+	})
+	return revs
+}
+
 func (c *searchResultsCommon) Cloning() []*RepositoryResolver {
 	return RepositoryResolvers(c.cloning)
 }
@@ -126,6 +168,7 @@ func (c *searchResultsCommon) update(other searchResultsCommon) {
 	c.excluded.forks = c.excluded.forks + other.excluded.forks
 	c.excluded.archived = c.excluded.archived + other.excluded.archived
 	c.timedout = append(c.timedout, other.timedout...)
+	c.revisions = append(c.revisions, other.revisions...)
 	c.resultCount += other.resultCount
 
 	if c.partial == nil {
@@ -682,52 +725,69 @@ func (r *searchResolver) evaluateLeaf(ctx context.Context) (*SearchResultsResolv
 	return rr, err
 }
 
-// unionMerge performs a merge of file match results, merging line matches when
-// they occur in the same file, and taking care to update match counts.
-func unionMerge(left, right *SearchResultsResolver) *SearchResultsResolver {
-	var count int // count non-overlapping files when we merge.
-	var merged []SearchResultResolver
-	rightFileMatches := make(map[string]*FileMatchResolver)
+// searchResultKey returns a key that identifies a search result across result
+// sets, so that results of subexpressions in and/or queries can be merged.
+func searchResultKey(result SearchResultResolver) string {
+	switch v := result.(type) {
+	case *FileMatchResolver:
+		return "file:" + v.uri
+	case *RepositoryResolver:
+		return "repo:" + v.Name()
+	case *commitSearchResultResolver:
+		if v.diffPreview != nil {
+			return "diff:" + v.url
+		}
+		return "commit:" + v.url
+	case *codemodResultResolver:
+		return "codemod:" + v.fileURL
+	}
+	repo, file := result.searchResultURIs()
+	return fmt.Sprintf("%T:%s/%s", result, repo, file)
+}
 
-	// accumulate file matches for the right subexpression in a lookup.
-	for _, r := range right.SearchResults {
-		if fileMatch, ok := r.ToFileMatch(); ok {
-			rightFileMatches[fileMatch.uri] = fileMatch
+// mergeFileMatch merges the line, multiline and symbol matches of src into dst.
+func mergeFileMatch(dst, src *FileMatchResolver) {
+	dst.JLineMatches = append(dst.JLineMatches, src.JLineMatches...)
+	dst.JMultilineMatches = append(dst.JMultilineMatches, src.JMultilineMatches...)
+	dst.symbols = append(dst.symbols, src.symbols...)
+	dst.MatchCount += src.MatchCount
+	dst.JLimitHit = dst.JLimitHit || src.JLimitHit
+}
+
+// unionMerge performs a merge of results, merging line and symbol matches when
+// they occur in the same file, and taking care to update match counts. Other
+// results (repositories, commits, diffs) that occur in both sets are kept once.
+func unionMerge(left, right *SearchResultsResolver) *SearchResultsResolver {
+	merged := make([]SearchResultResolver, 0, len(left.SearchResults)+len(right.SearchResults))
+	seen := make(map[string]SearchResultResolver, len(left.SearchResults))
+	for _, leftResult := range left.SearchResults {
+		key := searchResultKey(leftResult)
+		if _, ok := seen[key]; ok {
 			continue
 		}
-		merged = append(merged, r)
+		seen[key] = leftResult
+		merged = append(merged, leftResult)
 	}
 
-	for _, leftMatch := range left.SearchResults {
-		leftFileMatch, ok := leftMatch.ToFileMatch()
+	for _, rightResult := range right.SearchResults {
+		leftResult, ok := seen[searchResultKey(rightResult)]
 		if !ok {
-			merged = append(merged, leftMatch)
+			// no overlap with existing results.
+			merged = append(merged, rightResult)
 			continue
 		}
 
-		rightFileMatch := rightFileMatches[leftFileMatch.uri]
-		if rightFileMatch == nil {
-			// no overlap with existing matches.
-			merged = append(merged, leftMatch)
-			count++
-			continue
+		// merge matches with a file match that already exists.
+		if leftFileMatch, ok := leftResult.ToFileMatch(); ok {
+			rightFileMatch, _ := rightResult.ToFileMatch()
+			mergeFileMatch(leftFileMatch, rightFileMatch)
 		}
-
-		// merge line matches with a file match that already exists.
-		rightFileMatch.JLineMatches = append(rightFileMatch.JLineMatches, leftFileMatch.JLineMatches...)
-		rightFileMatch.MatchCount += leftFileMatch.MatchCount
-		rightFileMatch.JLimitHit = rightFileMatch.JLimitHit || leftFileMatch.JLimitHit
-		rightFileMatches[leftFileMatch.uri] = rightFileMatch
-	}
-
-	for _, v := range rightFileMatches {
-		merged = append(merged, v)
 	}
 
 	left.SearchResults = merged
 	left.searchResultsCommon.update(right.searchResultsCommon)
 	// set the count that tracks non-overlapping result count.
-	left.searchResultsCommon.resultCount = int32(count)
+	left.searchResultsCommon.resultCount = int32(len(merged))
 	return left
 }
 
@@ -748,32 +808,30 @@ func union(left, right *SearchResultsResolver) *SearchResultsResolver {
 	return left
 }
 
-// intersectMerge performs a merge of file match results, merging line matches
-// for files contained in both result sets, and updating counts.
+// intersectMerge performs a merge of results, keeping only results contained
+// in both result sets. Line and symbol matches are merged for files contained
+// in both result sets, and counts are updated.
 func intersectMerge(left, right *SearchResultsResolver) *SearchResultsResolver {
-	rightFileMatches := make(map[string]*FileMatchResolver)
+	rightResults := make(map[string]SearchResultResolver, len(right.SearchResults))
 	for _, r := range right.SearchResults {
-		if fileMatch, ok := r.ToFileMatch(); ok {
-			rightFileMatches[fileMatch.uri] = fileMatch
-		}
+		rightResults[searchResultKey(r)] = r
 	}
 
 	var merged []SearchResultResolver
-	for _, leftMatch := range left.SearchResults {
-		leftFileMatch, ok := leftMatch.ToFileMatch()
+	for _, leftResult := range left.SearchResults {
+		key := searchResultKey(leftResult)
+		rightResult, ok := rightResults[key]
 		if !ok {
 			continue
 		}
+		// Only keep the first occurrence of a result that is in both sets.
+		delete(rightResults, key)
 
-		rightFileMatch := rightFileMatches[leftFileMatch.uri]
-		if rightFileMatch == nil {
-			continue
+		if leftFileMatch, ok := leftResult.ToFileMatch(); ok {
+			rightFileMatch, _ := rightResult.ToFileMatch()
+			mergeFileMatch(leftFileMatch, rightFileMatch)
 		}
-
-		leftFileMatch.JLineMatches = append(leftFileMatch.JLineMatches, rightFileMatch.JLineMatches...)
-		leftFileMatch.MatchCount += rightFileMatch.MatchCount
-		leftFileMatch.JLimitHit = leftFileMatch.JLimitHit || rightFileMatch.JLimitHit
-		merged = append(merged, leftMatch)
+		merged = append(merged, leftResult)
 	}
 	left.SearchResults = merged
 	left.searchResultsCommon.update(right.searchResultsCommon)
@@ -782,8 +840,9 @@ func intersectMerge(left, right *SearchResultsResolver) *SearchResultsResolver {
 	return left
 }
 
-// intersect returns the intersection of two sets of search result content
-// matches, based on whether a single file path contains content matches in both sets.
+// intersect returns the intersection of two sets of search results, based on
+// whether a result (e.g., a file path, repository, or commit) is contained in
+// both sets.
 func intersect(left, right *SearchResultsResolver) *SearchResultsResolver {
 	if left == nil || right == nil {
 		return nil
@@ -967,20 +1026,72 @@ func (r *searchResolver) evaluatePatternExpression(ctx context.Context, scopePar
 	return nil, fmt.Errorf("unrecognized type %s in evaluatePatternExpression", reflect.TypeOf(node).String())
 }
 
+// evaluateScoped evaluates a search pattern expression scoped by parameters
+// that do not contain nested expressions.
+func (r *searchResolver) evaluateScoped(ctx context.Context, scopeParameters []query.Node, pattern query.Node) (*SearchResultsResolver, error) {
+	if pattern == nil {
+		r.query.(*query.AndOrQuery).Query = scopeParameters
+		return r.evaluateLeaf(ctx)
+	}
+	return r.evaluatePatternExpression(ctx, scopeParameters, pattern)
+}
+
+// evaluateDisjuncts evaluates a query where scope parameters contain and/or
+// expressions, like (repo:foo or repo:bar) -file:test. Each disjunct of the
+// query's disjunctive normal form has flat scope parameters and is evaluated
+// separately, after which results are unioned. If the maximum number of results
+// are reached after evaluating a disjunct, we shortcircuit and return results
+// immediately.
+func (r *searchResolver) evaluateDisjuncts(ctx context.Context, q []query.Node) (*SearchResultsResolver, error) {
+	wantCount := defaultMaxSearchResults
+	query.VisitField(q, "count", func(value string, _ bool, _ query.Annotation) {
+		wantCount, _ = strconv.Atoi(value) // Invariant: count is validated.
+	})
+
+	var result *SearchResultsResolver
+	for _, disjunct := range query.Disjuncts(q) {
+		scopeParameters, pattern, err := query.PartitionSearchPattern(disjunct)
+		if err != nil {
+			return alertForQuery("", err).wrap(), nil
+		}
+		new, err := r.evaluateScoped(ctx, scopeParameters, pattern)
+		if err != nil {
+			return nil, err
+		}
+		result = union(result, new)
+		// Do not rely on result.searchResultsCommon.resultCount because it may
+		// count non-content matches and there's no easy way to know.
+		if result != nil && len(result.SearchResults) > wantCount {
+			result.SearchResults = result.SearchResults[:wantCount]
+			result.searchResultsCommon.resultCount = int32(wantCount)
+			result.searchResultsCommon.limitHit = true
+			return result, nil
+		}
+	}
+	return result, nil
+}
+
 // evaluate evaluates all expressions of a search query.
 func (r *searchResolver) evaluate(ctx context.Context, q []query.Node) (*SearchResultsResolver, error) {
+	var result *SearchResultsResolver
 	scopeParameters, pattern, err := query.PartitionSearchPattern(q)
-	if err != nil {
-		return alertForQuery("", err).wrap(), nil
+	if operator, ok := pattern.(query.Operator); err != nil || (ok && operator.Kind != query.Concat) {
+		// Results of subexpressions are intersected or merged before
+		// they are final, so they must not be streamed.
+		ctx = withSearchStream(ctx, nil)
 	}
-	if pattern == nil {
-		r.query.(*query.AndOrQuery).Query = scopeParameters
-		return r.evaluateLeaf(ctx)
+	if err != nil {
+		// Scope parameters contain nested expressions.
+		result, err = r.evaluateDisjuncts(ctx, q)
+	} else {
+		result, err = r.evaluateScoped(ctx, scopeParameters, pattern)
 	}
-	result, err := r.evaluatePatternExpression(ctx, scopeParameters, pattern)
 	if err != nil {
 		return nil, err
 	}
+	if result == nil {
+		return &SearchResultsResolver{}, nil
+	}
 	r.sortResults(ctx, result.SearchResults)
 	return result, nil
 }
@@ -1177,6 +1288,18 @@ type getPatternInfoOptions struct {
 	fileMatchLimit int32
 }
 
+// patternInfoOptions returns the options for getPatternInfo that match the
+// pattern type of the search.
+func (r *searchResolver) patternInfoOptions() *getPatternInfoOptions {
+	switch r.patternType {
+	case query.SearchTypeStructural:
+		return &getPatternInfoOptions{performStructuralSearch: true}
+	case query.SearchTypeLiteral:
+		return &getPatternInfoOptions{performLiteralSearch: true}
+	}
+	return &getPatternInfoOptions{}
+}
+
 // getPatternInfo gets the search pattern info for the query in the resolver.
 func (r *searchResolver) getPatternInfo(opts *getPatternInfoOptions) (*search.TextPatternInfo, error) {
 	if opts == nil {
@@ -1184,7 +1307,7 @@ func (r *searchResolver) getPatternInfo(opts *getPatternInfoOptions) (*search.Te
 	}
 
 	if opts.fileMatchLimit == 0 {
-		opts.fileMatchLimit = r.maxResults()
+		opts.fileMatchLimit = r.backendMaxResults()
 	}
 
 	return getPatternInfo(r.query, opts)
@@ -1272,6 +1395,9 @@ func processSearchPattern(q query.QueryInfo, opts *getPatternInfoOptions) (strin
 	return pattern, isRegExp, isStructuralPat, isNegated
 }
 
+// generatedFilePattern is the value of -file: that excludes generated files.
+const generatedFilePattern = "generated"
+
 // getPatternInfo gets the search pattern info for q
 func getPatternInfo(q query.QueryInfo, opts *getPatternInfoOptions) (*search.TextPatternInfo, error) {
 	pattern, isRegExp, isStructuralPat, isNegated := processSearchPattern(q, opts)
@@ -1301,6 +1427,21 @@ func getPatternInfo(q query.QueryInfo, opts *getPatternInfoOptions) (*search.Tex
 
 	languages, _ := q.StringValues(query.FieldLang)
 
+	fileSizes, _ := q.StringValues(query.FieldFileSize)
+	minFileSize, maxFileSize, err := sizeBounds(fileSizes)
+	if err != nil {
+		return nil, err
+	}
+
+	// -file:generated also excludes the files detected as generated, in
+	// addition to the paths matching "generated".
+	var excludeGenerated bool
+	for _, p := range excludePatterns {
+		if p == generatedFilePattern {
+			excludeGenerated = true
+		}
+	}
+
 	patternInfo := &search.TextPatternInfo{
 		IsRegExp:                     isRegExp,
 		IsStructuralPat:              isStructuralPat,
@@ -1314,6 +1455,9 @@ func getPatternInfo(q query.QueryInfo, opts *getPatternInfoOptions) (*search.Tex
 		Languages:                    languages,
 		PathPatternsAreCaseSensitive: q.IsCaseSensitive(),
 		CombyRule:                    strings.Join(combyRule, ""),
+		MinFileSize:                  minFileSize,
+		MaxFileSize:                  maxFileSize,
+		ExcludeGenerated:             excludeGenerated,
 	}
 	if len(excludePatterns) > 0 {
 		patternInfo.ExcludePattern = unionRegExps(excludePatterns)
@@ -1364,13 +1508,24 @@ func (r *searchResolver) searchTimeoutFieldSet() bool {
 }
 
 func (r *searchResolver) withTimeout(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	d, err := r.searchTimeout()
+	if err != nil {
+		return nil, nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, d)
+	return ctx, cancel, nil
+}
+
+// searchTimeout returns the timeout of the search, which depends on the
+// timeout: and count: fields of the query.
+func (r *searchResolver) searchTimeout() (time.Duration, error) {
 	d := defaultTimeout
 	timeout, _ := r.query.StringValue(query.FieldTimeout)
 	if timeout != "" {
 		var err error
 		d, err = time.ParseDuration(timeout)
 		if err != nil {
-			return nil, nil, errors.WithMessage(err, `invalid "timeout:" value (examples: "timeout:2s", "timeout:200ms")`)
+			return 0, errors.WithMessage(err, `invalid "timeout:" value (examples: "timeout:2s", "timeout:200ms")`)
 		}
 	} else if r.countIsSet() {
 		// If `count:` is set but `timeout:` is not explicitly set, use the max timeout
@@ -1380,8 +1535,7 @@ func (r *searchResolver) withTimeout(ctx context.Context) (context.Context, cont
 	if d.Minutes() > 1 {
 		d = maxTimeout
 	}
-	ctx, cancel := context.WithTimeout(ctx, d)
-	return ctx, cancel, nil
+	return d, nil
 }
 
 func (r *searchResolver) determineResultTypes(args search.TextParameters, forceOnlyResultType string) (resultTypes []string) {
@@ -1486,15 +1640,10 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 		return alertResult, nil
 	}
 
-	options := &getPatternInfoOptions{}
 	if r.patternType == query.SearchTypeStructural {
-		options = &getPatternInfoOptions{performStructuralSearch: true}
 		forceOnlyResultType = "file"
 	}
-	if r.patternType == query.SearchTypeLiteral {
-		options = &getPatternInfoOptions{performLiteralSearch: true}
-	}
-	p, err := r.getPatternInfo(options)
+	p, err := r.getPatternInfo(r.patternInfoOptions())
 	if err != nil {
 		return nil, err
 	}
@@ -1527,6 +1676,21 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 	resultTypes := r.determineResultTypes(args, forceOnlyResultType)
 	tr.LazyPrintf("resultTypes: %v", resultTypes)
 
+	if repo, rev, ok := diffRangeForOtherResults(resultTypes, repos); ok {
+		return alertForDiffRange(r.query.ParseTree(), r.patternType, repo, rev).wrap(), nil
+	}
+
+	rank := r.query.BoolValue(query.FieldRank)
+	if rank {
+		// Which results are returned is only known once all of them are
+		// ranked, so they must not be streamed.
+		ctx = withSearchStream(ctx, nil)
+	}
+
+	// stream is non-nil if results should be sent to the client as soon as
+	// a backend produces them, see StreamSearch.
+	stream := searchStreamFromContext(ctx)
+
 	var (
 		requiredWg sync.WaitGroup
 		optionalWg sync.WaitGroup
@@ -1565,6 +1729,22 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 	// repos, and removes the diff and commit resultTypes if it is breached.
 	resultTypes, alert = alertOnSearchLimit(resultTypes, &args)
 
+	// Reject searches which are estimated to be too expensive before
+	// running them. The plan's request for the indexed repositories is
+	// reused by the search of file contents and paths.
+	var indexed *indexedSearchRequest
+	if max := maxEstimatedSearchCost(); max > 0 {
+		plan, err := r.planSearch(ctx, &args, resultTypes, len(missingRepoRevs))
+		if err != nil {
+			return nil, err
+		}
+		tr.LazyPrintf("estimated cost: %d", plan.cost)
+		if plan.cost > max {
+			return &SearchResultsResolver{alert: alertForSearchCost(plan, max), start: start}, nil
+		}
+		indexed = plan.indexedRequest
+	}
+
 	searchedFileContentsOrPaths := false
 	for _, resultType := range resultTypes {
 		resultType := resultType // shadow so it doesn't change in the goroutine
@@ -1580,7 +1760,7 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 			goroutine.Go(func() {
 				defer wg.Done()
 
-				repoResults, repoCommon, err := searchRepositories(ctx, &args, r.maxResults())
+				repoResults, repoCommon, err := searchRepositories(ctx, &args, r.backendMaxResults())
 				// Timeouts are reported through searchResultsCommon so don't report an error for them
 				if err != nil && !isContextError(ctx, err) {
 					multiErrMu.Lock()
@@ -1597,6 +1777,7 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 					common.update(*repoCommon)
 					commonMu.Unlock()
 				}
+				stream.update(repoResults, repoCommon)
 			})
 		case "symbol":
 			wg := waitGroup(len(resultTypes) == 1)
@@ -1604,7 +1785,7 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 			goroutine.Go(func() {
 				defer wg.Done()
 
-				symbolFileMatches, symbolsCommon, err := searchSymbols(ctx, &args, int(r.maxResults()))
+				symbolFileMatches, symbolsCommon, err := searchSymbols(ctx, &args, int(r.backendMaxResults()))
 				// Timeouts are reported through searchResultsCommon so don't report an error for them
 				if err != nil && !isContextError(ctx, err) {
 					multiErrMu.Lock()
@@ -1629,6 +1810,42 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 					common.update(*symbolsCommon)
 					commonMu.Unlock()
 				}
+				stream.update(fileMatchesToSearchResults(symbolFileMatches), symbolsCommon)
+			})
+		case "symbol-ref":
+			wg := waitGroup(len(resultTypes) == 1)
+			wg.Add(1)
+			goroutine.Go(func() {
+				defer wg.Done()
+
+				refFileMatches, refsCommon, err := searchSymbolRefs(ctx, &args, int(r.backendMaxResults()))
+				// Timeouts are reported through searchResultsCommon so don't report an error for them
+				if err != nil && !isContextError(ctx, err) {
+					multiErrMu.Lock()
+					multiErr = multierror.Append(multiErr, errors.Wrap(err, "symbol reference search failed"))
+					multiErrMu.Unlock()
+				}
+				var added []*FileMatchResolver
+				for _, refFileMatch := range refFileMatches {
+					key := refFileMatch.uri
+					fileMatchesMu.Lock()
+					// A file that also matched another result type is kept
+					// with that type's line matches.
+					if _, ok := fileMatches[key]; !ok {
+						fileMatches[key] = refFileMatch
+						added = append(added, refFileMatch)
+						resultsMu.Lock()
+						results = append(results, refFileMatch)
+						resultsMu.Unlock()
+					}
+					fileMatchesMu.Unlock()
+				}
+				if refsCommon != nil {
+					commonMu.Lock()
+					common.update(*refsCommon)
+					commonMu.Unlock()
+				}
+				stream.update(fileMatchesToSearchResults(added), refsCommon)
 			})
 		case "file", "path":
 			if searchedFileContentsOrPaths {
@@ -1641,7 +1858,7 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 			goroutine.Go(func() {
 				defer wg.Done()
 
-				fileResults, fileCommon, err := searchFilesInRepos(ctx, &args)
+				fileResults, fileCommon, err := searchFilesInReposIndexed(ctx, &args, indexed)
 				// Timeouts are reported through searchResultsCommon so don't report an error for them
 				if err != nil && !isContextError(ctx, err) {
 					multiErrMu.Lock()
@@ -1652,7 +1869,7 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 					// No results for structural search? Automatically search again and force Zoekt to resolve
 					// more potential file matches by setting a higher FileMatchLimit.
 					args.PatternInfo.FileMatchLimit = 1000
-					fileResults, fileCommon, err = searchFilesInRepos(ctx, &args)
+					fileResults, fileCommon, err = searchFilesInReposIndexed(ctx, &args, indexed)
 					if err != nil && !isContextError(ctx, err) {
 						multiErrMu.Lock()
 						multiErr = multierror.Append(multiErr, errors.Wrap(err, "text search failed"))
@@ -1687,6 +1904,9 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 					common.update(*fileCommon)
 					commonMu.Unlock()
 				}
+				// The matches were streamed by searchFilesInReposIndexed
+				// as they were found.
+				stream.update(nil, fileCommon)
 			})
 		case "diff":
 			wg := waitGroup(len(resultTypes) == 1)
@@ -1726,6 +1946,7 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 					common.update(*diffCommon)
 					commonMu.Unlock()
 				}
+				stream.update(diffResults, diffCommon)
 			})
 		case "commit":
 			wg := waitGroup(len(resultTypes) == 1)
@@ -1766,6 +1987,7 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 					common.update(*commitCommon)
 					commonMu.Unlock()
 				}
+				stream.update(commitResults, commitCommon)
 			})
 		case "codemod":
 			wg := waitGroup(true)
@@ -1790,6 +2012,7 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 					common.update(*codemodCommon)
 					commonMu.Unlock()
 				}
+				stream.update(codemodResults, codemodCommon)
 			})
 		}
 	}
@@ -1842,6 +2065,12 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 	}
 
 	r.sortResults(ctx, results)
+	if max := int(r.maxResults()); rank && len(results) > max {
+		// Backends returned more results than the limit for ranking to
+		// choose from, see backendMaxResults.
+		results = results[:max]
+		common.limitHit = true
+	}
 
 	resultsResolver := SearchResultsResolver{
 		start:               start,
@@ -1913,12 +2142,17 @@ func compareSearchResults(a, b searchResultURIGetter, exactFilePatterns map[stri
 	return arepo < brepo
 }
 
+// sortResults sorts results by repository and file name, or by ranking score
+// if the query has rank:yes.
 func (r *searchResolver) sortResults(ctx context.Context, results []SearchResultResolver) {
 	var exactPatterns map[string]struct{}
 	if settings, err := decodedViewerFinalSettings(ctx); err != nil || getBoolPtr(settings.SearchGlobbing, false) {
 		exactPatterns = r.getExactFilePatterns()
 	}
 	sort.Slice(results, func(i, j int) bool { return compareSearchResults(results[i], results[j], exactPatterns) })
+	if r.query.BoolValue(query.FieldRank) {
+		rankResults(ctx, results)
+	}
 }
 
 // getExactFilePatterns returns the set of file patterns without glob syntax.
@@ -1237,21 +1237,63 @@ func Test_SearchResultsResolver_ApproximateResultCount(t *testing.T) {
 }
 
 func TestSearchResolver_evaluateWarning(t *testing.T) {
-	q, _ := query.ProcessAndOr("file:foo or file:bar", query.ParserOptions{SearchType: query.SearchTypeRegex, Globbing: false})
 	wantPrefix := "I'm having trouble understanding that query."
-	andOrQuery, _ := q.(*query.AndOrQuery)
-	got, _ := (&searchResolver{}).evaluate(context.Background(), andOrQuery.Query)
-	t.Run("warn for unsupported and/or query", func(t *testing.T) {
-		if !strings.HasPrefix(got.alert.description, wantPrefix) {
-			t.Fatalf("got alert description %s, want %s", got.alert.description, wantPrefix)
-		}
-	})
-
 	_, err := query.ProcessAndOr("file:foo or or or", query.ParserOptions{SearchType: query.SearchTypeRegex, Globbing: false})
 	gotAlert := alertForQuery("", err)
 	t.Run("warn for unsupported ambiguous and/or query", func(t *testing.T) {
 		if !strings.HasPrefix(gotAlert.description, wantPrefix) {
-			t.Fatalf("got alert description %s, want %s", got.alert.description, wantPrefix)
+			t.Fatalf("got alert description %s, want %s", gotAlert.description, wantPrefix)
+		}
+	})
+}
+
+func TestUnionIntersectMerge(t *testing.T) {
+	repo := &RepositoryResolver{repo: &types.Repo{Name: "foo"}}
+	fileMatch := func(path string, lines ...int32) *FileMatchResolver {
+		var lineMatches []*lineMatch
+		for _, line := range lines {
+			lineMatches = append(lineMatches, &lineMatch{JLineNumber: line})
+		}
+		return &FileMatchResolver{
+			JPath:        path,
+			uri:          "git://foo#" + path,
+			JLineMatches: lineMatches,
+			MatchCount:   len(lineMatches),
+			Repo:         repo,
+		}
+	}
+	commit := func(url string) *commitSearchResultResolver {
+		return &commitSearchResultResolver{url: url}
+	}
+	summarize := func(results *SearchResultsResolver) []string {
+		var keys []string
+		for _, result := range results.SearchResults {
+			key := searchResultKey(result)
+			if fm, ok := result.ToFileMatch(); ok {
+				key = fmt.Sprintf("%s(%d)", key, fm.MatchCount)
+			}
+			keys = append(keys, key)
+		}
+		return keys
+	}
+
+	t.Run("union", func(t *testing.T) {
+		left := &SearchResultsResolver{SearchResults: []SearchResultResolver{fileMatch("a", 1), repo, commit("/c1")}}
+		right := &SearchResultsResolver{SearchResults: []SearchResultResolver{fileMatch("a", 2), fileMatch("b", 1), repo, commit("/c2")}}
+		got := summarize(union(left, right))
+		want := []string{"file:git://foo#a(2)", "repo:foo", "commit:/c1", "file:git://foo#b(1)", "commit:/c2"}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Error(diff)
+		}
+	})
+
+	t.Run("intersect", func(t *testing.T) {
+		left := &SearchResultsResolver{SearchResults: []SearchResultResolver{fileMatch("a", 1), fileMatch("c", 1), repo, commit("/c1")}}
+		right := &SearchResultsResolver{SearchResults: []SearchResultResolver{fileMatch("a", 2), fileMatch("b", 1), repo, commit("/c1")}}
+		got := summarize(intersect(left, right))
+		want := []string{"file:git://foo#a(2)", "repo:foo", "commit:/c1"}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Error(diff)
 		}
 	})
 }
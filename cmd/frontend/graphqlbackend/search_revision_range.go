@@ -0,0 +1,239 @@
+package graphqlbackend
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/endpoint"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/pathmatch"
+	"github.com/sourcegraph/sourcegraph/internal/search"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+)
+
+const (
+	// maxRevisionRangeCommits is the maximum number of commits in a revision
+	// range (such as repo:foo@v1.0..v2.0) that a search will look at.
+	maxRevisionRangeCommits = 1000
+
+	// maxRevisionRangeFileVersions is the maximum number of file versions
+	// introduced in a revision range that a search will read.
+	maxRevisionRangeFileVersions = 5000
+
+	// maxRevisionRangeFileSize is the size above which file versions are not
+	// searched. It matches searcher's default.
+	maxRevisionRangeFileSize = 1 << 20
+
+	// maxRevisionRangeLineMatches is the maximum number of line matches
+	// returned for a single file version.
+	maxRevisionRangeLineMatches = 500
+)
+
+// searchFilesInRevisionRange searches every version of the files in the
+// revision range rev (such as "v1.0..v2.0") of a repository: the files at the
+// base of the range, and every file version that a commit in the range
+// introduced. Each match is attributed to the commits in which the version of
+// the file existed.
+//
+// The files at the base are searched by searcher. The versions introduced in
+// the range are read from gitserver and matched here, because asking searcher
+// to fetch an archive of every commit in the range would be far too slow.
+func searchFilesInRevisionRange(ctx context.Context, searcherURLs *endpoint.Map, repo *types.Repo, gitserverRepo gitserver.Repo, rev string, info *search.TextPatternInfo, fetchTimeout time.Duration) (matches []*FileMatchResolver, limitHit bool, err error) {
+	baseSpec, headSpec, ok := search.RevisionSpecifier{RevSpec: rev}.RevisionRange()
+	if !ok {
+		return nil, false, fmt.Errorf("invalid revision range %q", rev)
+	}
+	if info.IsStructuralPat || info.IsNegated {
+		return nil, false, errors.New("revision ranges can only be searched with literal and regexp patterns")
+	}
+
+	base, err := git.ResolveRevision(ctx, gitserverRepo, nil, baseSpec, git.ResolveRevisionOptions{NoEnsureRevision: true})
+	if err != nil {
+		return nil, false, err
+	}
+	head, err := git.ResolveRevision(ctx, gitserverRepo, nil, headSpec, git.ResolveRevisionOptions{NoEnsureRevision: true})
+	if err != nil {
+		return nil, false, err
+	}
+	commits, err := git.RangeChanges(ctx, gitserverRepo, base, head, maxRevisionRangeCommits)
+	if err != nil {
+		return nil, false, err
+	}
+
+	matcher, err := compileRevisionRangeMatcher(info)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// existence returns the commits in which the version of path that was
+	// introduced by commits[start-1] (or that was at the base of the range,
+	// if start is 0) existed.
+	existence := func(introducedAt api.CommitID, start int, path string) []api.CommitID {
+		ids := []api.CommitID{introducedAt}
+		for _, c := range commits[start:] {
+			for _, change := range c.Changes {
+				if change.Path == path {
+					return ids
+				}
+			}
+			ids = append(ids, c.Commit)
+		}
+		return ids
+	}
+
+	baseMatches, limitHit, err := searchFilesInRepo(ctx, searcherURLs, repo, gitserverRepo, baseSpec, info, fetchTimeout)
+	if err != nil {
+		return nil, false, err
+	}
+	for _, fm := range baseMatches {
+		fm.revisionRangeCommits = existence(base, 0, fm.JPath)
+	}
+	matches = baseMatches
+
+	repoResolver := &RepositoryResolver{repo: repo}
+	seen := map[git.OID]struct{}{}
+	for i, c := range commits {
+		for _, change := range c.Changes {
+			if change.NewOID == (git.OID{}) || !matcher.matchPath(change.Path) {
+				continue
+			}
+			if _, ok := seen[change.NewOID]; ok {
+				// Identical content was already searched at another path or
+				// commit.
+				continue
+			}
+			seen[change.NewOID] = struct{}{}
+
+			if len(seen) > maxRevisionRangeFileVersions || len(matches) >= int(info.FileMatchLimit) {
+				return matches, true, nil
+			}
+			if err := ctx.Err(); err != nil {
+				return nil, false, err
+			}
+
+			data, err := git.ReadFile(ctx, gitserverRepo, c.Commit, change.Path, maxRevisionRangeFileSize+1)
+			if err != nil {
+				return nil, false, err
+			}
+			fm := matcher.match(change.Path, data)
+			if fm == nil {
+				continue
+			}
+			commitRev := string(c.Commit)
+			fm.uri = fileMatchURI(repo.Name, commitRev, change.Path)
+			fm.Repo = repoResolver
+			fm.CommitID = c.Commit
+			fm.InputRev = &commitRev
+			fm.revisionRangeCommits = existence(c.Commit, i+1, change.Path)
+			matches = append(matches, fm)
+		}
+	}
+	return matches, limitHit, nil
+}
+
+// revisionRangeMatcher matches the file versions of a revision range in the
+// same way as searcher matches files.
+type revisionRangeMatcher struct {
+	re                    *regexp.Regexp // nil if the pattern is empty
+	path                  pathmatch.PathMatcher
+	patternMatchesContent bool
+	patternMatchesPath    bool
+}
+
+func compileRevisionRangeMatcher(info *search.TextPatternInfo) (*revisionRangeMatcher, error) {
+	m := &revisionRangeMatcher{
+		patternMatchesContent: info.PatternMatchesContent,
+		patternMatchesPath:    info.PatternMatchesPath,
+	}
+	if info.Pattern != "" {
+		expr := info.Pattern
+		if !info.IsRegExp {
+			expr = regexp.QuoteMeta(expr)
+		}
+		if info.IsWordMatch {
+			expr = `\b` + expr + `\b`
+		}
+		if !info.IsCaseSensitive {
+			expr = "(?i:" + expr + ")"
+		}
+		var err error
+		if m.re, err = regexp.Compile(expr); err != nil {
+			return nil, err
+		}
+	}
+
+	var err error
+	m.path, err = pathmatch.CompilePathPatterns(info.IncludePatterns, info.ExcludePattern, pathmatch.CompileOptions{
+		RegExp:        true,
+		CaseSensitive: info.PathPatternsAreCaseSensitive,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (m *revisionRangeMatcher) matchPath(path string) bool {
+	return m.path.MatchPath(path)
+}
+
+// match returns a file match for the version of path with the given content,
+// or nil if it does not match. The returned match only has the fields
+// describing the match set.
+func (m *revisionRangeMatcher) match(path string, data []byte) *FileMatchResolver {
+	if m.re == nil {
+		return &FileMatchResolver{JPath: path}
+	}
+	if m.patternMatchesPath && m.re.MatchString(path) {
+		return &FileMatchResolver{JPath: path}
+	}
+	if !m.patternMatchesContent || len(data) > maxRevisionRangeFileSize || bytes.IndexByte(data, 0) >= 0 {
+		// Like searcher, skip large and binary files.
+		return nil
+	}
+
+	fm := &FileMatchResolver{JPath: path}
+	for i, line := range strings.Split(string(data), "\n") {
+		locs := m.re.FindAllStringIndex(line, -1)
+		if len(locs) == 0 {
+			continue
+		}
+		if len(fm.JLineMatches) == maxRevisionRangeLineMatches {
+			fm.JLimitHit = true
+			break
+		}
+		lm := &lineMatch{
+			JPreview:    line,
+			JLineNumber: int32(i),
+		}
+		for _, loc := range locs {
+			if loc[0] == loc[1] {
+				// Skip empty matches, such as of "^".
+				continue
+			}
+			offset := utf8.RuneCountInString(line[:loc[0]])
+			length := utf8.RuneCountInString(line[loc[0]:loc[1]])
+			lm.JOffsetAndLengths = append(lm.JOffsetAndLengths, [2]int32{int32(offset), int32(length)})
+		}
+		if len(lm.JOffsetAndLengths) == 0 {
+			continue
+		}
+		if m.re.NumSubexp() > 0 {
+			addCaptureGroups(m.re, lm)
+		}
+		fm.JLineMatches = append(fm.JLineMatches, lm)
+		fm.MatchCount += len(lm.JOffsetAndLengths)
+	}
+	if len(fm.JLineMatches) == 0 {
+		return nil
+	}
+	return fm
+}
@@ -0,0 +1,120 @@
+package graphqlbackend
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/search"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+)
+
+func TestSearchFilesInRevisionRange(t *testing.T) {
+	const (
+		base    api.CommitID = "0000000000000000000000000000000000000000"
+		commit1 api.CommitID = "1111111111111111111111111111111111111111"
+		commit2 api.CommitID = "2222222222222222222222222222222222222222"
+		commit3 api.CommitID = "3333333333333333333333333333333333333333"
+	)
+	oid := func(b byte) git.OID { return git.OID{b} }
+
+	git.Mocks.ResolveRevision = func(spec string, opt git.ResolveRevisionOptions) (api.CommitID, error) {
+		switch spec {
+		case "v1":
+			return base, nil
+		case "v2":
+			return commit3, nil
+		}
+		t.Fatalf("unexpected revision %q", spec)
+		return "", nil
+	}
+	git.Mocks.RangeChanges = func(gotBase, gotHead api.CommitID) ([]*git.CommitChanges, error) {
+		if gotBase != base || gotHead != commit3 {
+			t.Fatalf("got range %s..%s", gotBase, gotHead)
+		}
+		return []*git.CommitChanges{
+			{Commit: commit1, Changes: []git.FileChange{
+				{Path: "a.go", OldOID: oid(1), NewOID: oid(2)},
+				{Path: "b.go", NewOID: oid(3)},
+			}},
+			{Commit: commit2, Changes: []git.FileChange{
+				{Path: "c.txt", NewOID: oid(4)},
+			}},
+			{Commit: commit3, Changes: []git.FileChange{
+				{Path: "a.go", OldOID: oid(2), NewOID: oid(5)},
+				{Path: "b.go", OldOID: oid(3)},
+			}},
+		}, nil
+	}
+	contents := map[string]string{
+		string(commit1) + ":a.go":  "package a\n\n// FooBar is deprecated.\n",
+		string(commit1) + ":b.go":  "package b\n\nvar fooBar, x = 1, fooBar\n",
+		string(commit2) + ":c.txt": "foobar",
+		string(commit3) + ":a.go":  "package a\n",
+	}
+	git.Mocks.ReadFile = func(commit api.CommitID, name string) ([]byte, error) {
+		return []byte(contents[string(commit)+":"+name]), nil
+	}
+	defer git.ResetMocks()
+
+	mockSearchFilesInRepo = func(ctx context.Context, repo *types.Repo, gitserverRepo gitserver.Repo, rev string, info *search.TextPatternInfo, fetchTimeout time.Duration) ([]*FileMatchResolver, bool, error) {
+		if rev != "v1" {
+			t.Fatalf("got base %q, want v1", rev)
+		}
+		return []*FileMatchResolver{{JPath: "a.go"}, {JPath: "d.go"}}, false, nil
+	}
+	defer func() { mockSearchFilesInRepo = nil }()
+
+	repo := &types.Repo{Name: "foo"}
+	info := &search.TextPatternInfo{
+		Pattern:               "foobar",
+		IncludePatterns:       []string{`\.go$`},
+		FileMatchLimit:        100,
+		PatternMatchesContent: true,
+	}
+	matches, limitHit, err := searchFilesInRevisionRange(context.Background(), nil, repo, gitserver.Repo{Name: repo.Name}, "v1..v2", info, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if limitHit {
+		t.Error("limitHit")
+	}
+
+	type result struct {
+		path        string
+		commit      api.CommitID
+		lineMatches [][2]int32 // line number and number of matches
+		commits     []api.CommitID
+	}
+	var got []result
+	for _, fm := range matches {
+		r := result{path: fm.JPath, commit: fm.CommitID, commits: fm.revisionRangeCommits}
+		for _, lm := range fm.JLineMatches {
+			r.lineMatches = append(r.lineMatches, [2]int32{lm.JLineNumber, int32(len(lm.JOffsetAndLengths))})
+		}
+		got = append(got, r)
+	}
+	want := []result{
+		// Matches at the base are attributed to the base and to the commits
+		// up to the next change of the file.
+		{path: "a.go", commits: []api.CommitID{base}},
+		{path: "d.go", commits: []api.CommitID{base, commit1, commit2, commit3}},
+		// Versions introduced in the range.
+		{path: "a.go", commit: commit1, lineMatches: [][2]int32{{2, 1}}, commits: []api.CommitID{commit1, commit2}},
+		{path: "b.go", commit: commit1, lineMatches: [][2]int32{{2, 2}}, commits: []api.CommitID{commit1, commit2}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got  %+v\nwant %+v", got, want)
+	}
+}
+
+func TestSearchFilesInRevisionRange_unsupportedPattern(t *testing.T) {
+	info := &search.TextPatternInfo{Pattern: "foo", IsStructuralPat: true}
+	if _, _, err := searchFilesInRevisionRange(context.Background(), nil, &types.Repo{Name: "foo"}, gitserver.Repo{Name: "foo"}, "v1..v2", info, time.Second); err == nil {
+		t.Error("expected an error for a structural search pattern")
+	}
+}
@@ -0,0 +1,295 @@
+package graphqlbackend
+
+import (
+	"context"
+	"sync"
+)
+
+// Names of the events sent by StreamSearch.
+const (
+	StreamEventMatches  = "matches"
+	StreamEventProgress = "progress"
+	StreamEventDone     = "done"
+	StreamEventError    = "error"
+)
+
+// StreamSender receives the events of a streaming search. The data of an event
+// is a JSON-serializable value that depends on the event name:
+//
+// - StreamEventMatches: []StreamMatch
+// - StreamEventProgress: StreamProgress
+// - StreamEventDone: StreamDone
+// - StreamEventError: StreamError
+type StreamSender func(event string, data interface{})
+
+// StreamMatch is a single search result in a stream. The same file may be sent
+// more than once, for example once with line matches from text search and once
+// with symbols from symbol search. Clients should merge matches with the same
+// type, repository, commit and path.
+type StreamMatch struct {
+	Type        string            `json:"type"` // one of "file", "repo", "commit", "codemod"
+	Repository  string            `json:"repository"`
+	Commit      string            `json:"commit,omitempty"`
+	Path        string            `json:"path,omitempty"`
+	URL         string            `json:"url,omitempty"`
+	LineMatches []StreamLineMatch `json:"lineMatches,omitempty"`
+	Symbols     []StreamSymbol    `json:"symbols,omitempty"`
+	Label       string            `json:"label,omitempty"`
+	Detail      string            `json:"detail,omitempty"`
+	Content     string            `json:"content,omitempty"` // message or diff preview for commit results
+	LimitHit    bool              `json:"limitHit,omitempty"`
+}
+
+type StreamLineMatch struct {
+	Line             string               `json:"line"`
+	LineNumber       int32                `json:"lineNumber"`
+	OffsetAndLengths [][2]int32           `json:"offsetAndLengths"`
+	CaptureGroups    []StreamCaptureGroup `json:"captureGroups,omitempty"`
+}
+
+type StreamCaptureGroup struct {
+	Index           int32    `json:"index"`
+	Name            string   `json:"name,omitempty"`
+	Value           string   `json:"value"`
+	OffsetAndLength [2]int32 `json:"offsetAndLength"`
+}
+
+type StreamSymbol struct {
+	Name          string `json:"name"`
+	ContainerName string `json:"containerName,omitempty"`
+	Kind          string `json:"kind"`
+	Line          int    `json:"line"`
+}
+
+// StreamProgress reports the aggregated progress of all search backends so far.
+type StreamProgress struct {
+	RepositoriesCount int32 `json:"repositoriesCount"`
+	Searched          int   `json:"searched"`
+	Indexed           int   `json:"indexed"`
+	Cloning           int   `json:"cloning"`
+	Missing           int   `json:"missing"`
+	Timedout          int   `json:"timedout"`
+	LimitHit          bool  `json:"limitHit"`
+}
+
+// StreamDone is the final event of a stream, summarizing the search.
+type StreamDone struct {
+	StreamProgress
+	ResultCount         int32        `json:"resultCount"`
+	ElapsedMilliseconds int32        `json:"elapsedMilliseconds"`
+	Alert               *StreamAlert `json:"alert,omitempty"`
+}
+
+type StreamAlert struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+}
+
+type StreamError struct {
+	Message string `json:"message"`
+}
+
+// searchStream sends the results of backends to a StreamSender as they become
+// available. It is safe for concurrent use.
+type searchStream struct {
+	mu     sync.Mutex
+	send   StreamSender
+	sent   map[string]struct{} // keys of results sent so far, see searchResultKey
+	common searchResultsCommon
+}
+
+func newSearchStream(send StreamSender) *searchStream {
+	return &searchStream{send: send, sent: make(map[string]struct{})}
+}
+
+type searchStreamKey struct{}
+
+// withSearchStream returns a context that makes search backends send their
+// results to stream as they become available. A nil stream disables streaming.
+func withSearchStream(ctx context.Context, stream *searchStream) context.Context {
+	return context.WithValue(ctx, searchStreamKey{}, stream)
+}
+
+// searchStreamFromContext returns the stream of ctx, or nil if results should
+// not be streamed.
+func searchStreamFromContext(ctx context.Context) *searchStream {
+	stream, _ := ctx.Value(searchStreamKey{}).(*searchStream)
+	return stream
+}
+
+// update sends results which have not been sent yet, followed by the progress
+// after merging common.
+func (s *searchStream) update(results []SearchResultResolver, common *searchResultsCommon) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sendMatches(results)
+	if common != nil {
+		s.common.update(*common)
+		s.send(StreamEventProgress, streamProgress(&s.common))
+	}
+}
+
+// sendMatches sends matches for results which have not been sent yet. s.mu
+// must be held.
+func (s *searchStream) sendMatches(results []SearchResultResolver) {
+	matches := make([]StreamMatch, 0, len(results))
+	for _, result := range results {
+		key := searchResultKey(result)
+		if fm, ok := result.ToFileMatch(); ok {
+			// A file may be sent once per backend, see StreamMatch.
+			if len(fm.symbols) > 0 {
+				key += "#symbols"
+			}
+		}
+		if _, ok := s.sent[key]; ok {
+			continue
+		}
+		s.sent[key] = struct{}{}
+		matches = append(matches, toStreamMatch(result))
+	}
+	if len(matches) > 0 {
+		s.send(StreamEventMatches, matches)
+	}
+}
+
+// done sends any remaining results of the final result set, and the summary.
+func (s *searchStream) done(result *SearchResultsResolver) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sendMatches(result.SearchResults)
+	done := StreamDone{
+		StreamProgress:      streamProgress(&result.searchResultsCommon),
+		ResultCount:         result.MatchCount(),
+		ElapsedMilliseconds: result.ElapsedMilliseconds(),
+	}
+	if result.alert != nil {
+		done.Alert = &StreamAlert{Title: result.alert.title, Description: result.alert.description}
+	}
+	s.send(StreamEventDone, done)
+}
+
+func fileMatchesToSearchResults(fileMatches []*FileMatchResolver) []SearchResultResolver {
+	results := make([]SearchResultResolver, 0, len(fileMatches))
+	for _, fm := range fileMatches {
+		results = append(results, fm)
+	}
+	return results
+}
+
+func streamProgress(common *searchResultsCommon) StreamProgress {
+	return StreamProgress{
+		RepositoriesCount: common.RepositoriesCount(),
+		Searched:          len(common.searched),
+		Indexed:           len(common.indexed),
+		Cloning:           len(common.cloning),
+		Missing:           len(common.missing),
+		Timedout:          len(common.timedout),
+		LimitHit:          common.LimitHit(),
+	}
+}
+
+func toStreamMatch(result SearchResultResolver) StreamMatch {
+	switch v := result.(type) {
+	case *FileMatchResolver:
+		m := StreamMatch{
+			Type:       "file",
+			Repository: v.Repo.Name(),
+			Commit:     string(v.CommitID),
+			Path:       v.JPath,
+			URL:        v.Resource(),
+			LimitHit:   v.JLimitHit,
+		}
+		for _, lm := range v.JLineMatches {
+			slm := StreamLineMatch{
+				Line:             lm.JPreview,
+				LineNumber:       lm.JLineNumber,
+				OffsetAndLengths: lm.JOffsetAndLengths,
+			}
+			for _, g := range lm.JCaptureGroups {
+				slm.CaptureGroups = append(slm.CaptureGroups, StreamCaptureGroup{
+					Index:           g.JIndex,
+					Name:            g.JName,
+					Value:           g.JValue,
+					OffsetAndLength: [2]int32{g.JOffset, g.JLength},
+				})
+			}
+			m.LineMatches = append(m.LineMatches, slm)
+		}
+		for _, sym := range v.symbols {
+			m.Symbols = append(m.Symbols, StreamSymbol{
+				Name:          sym.symbol.Name,
+				ContainerName: sym.symbol.Parent,
+				Kind:          sym.symbol.Kind,
+				Line:          sym.symbol.Line,
+			})
+		}
+		return m
+	case *RepositoryResolver:
+		return StreamMatch{
+			Type:       "repo",
+			Repository: v.Name(),
+			URL:        v.URL(),
+		}
+	case *commitSearchResultResolver:
+		m := StreamMatch{
+			Type:   "commit",
+			URL:    v.url,
+			Label:  v.label,
+			Detail: v.detail,
+		}
+		if v.commit != nil {
+			m.Repository = v.commit.repoResolver.Name()
+			m.Commit = string(v.commit.oid)
+		}
+		if v.diffPreview != nil {
+			m.Content = v.diffPreview.value
+		} else if v.messagePreview != nil {
+			m.Content = v.messagePreview.value
+		}
+		return m
+	case *codemodResultResolver:
+		m := StreamMatch{
+			Type:    "codemod",
+			Path:    v.path,
+			URL:     v.fileURL,
+			Content: v.diff,
+		}
+		if v.commit != nil {
+			m.Repository = v.commit.repoResolver.Name()
+			m.Commit = string(v.commit.oid)
+		}
+		return m
+	}
+	repo, path := result.searchResultURIs()
+	return StreamMatch{Repository: repo, Path: path}
+}
+
+// StreamSearch runs the search described by args and sends results to send as
+// each search backend (zoekt, searcher, symbols, commit search, ...) produces
+// them, interleaved with progress events. The stream ends with a done event,
+// or an error event if the search failed.
+func StreamSearch(ctx context.Context, args *SearchArgs, send StreamSender) {
+	stream := newSearchStream(send)
+	sendError := func(err error) {
+		stream.mu.Lock()
+		defer stream.mu.Unlock()
+		stream.send(StreamEventError, StreamError{Message: err.Error()})
+	}
+
+	impl, err := NewSearchImplementer(ctx, args)
+	if err != nil {
+		sendError(err)
+		return
+	}
+	result, err := impl.Results(withSearchStream(ctx, stream))
+	if err != nil {
+		sendError(err)
+		return
+	}
+	stream.done(result)
+}
@@ -0,0 +1,68 @@
+package graphqlbackend
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+)
+
+func TestSearchStream(t *testing.T) {
+	type event struct {
+		name string
+		data interface{}
+	}
+	var events []event
+	stream := newSearchStream(func(name string, data interface{}) {
+		events = append(events, event{name: name, data: data})
+	})
+
+	repo := &RepositoryResolver{repo: &types.Repo{Name: "foo"}}
+	fileMatch := &FileMatchResolver{
+		JPath:        "a.go",
+		uri:          "git://foo#a.go",
+		Repo:         repo,
+		JLineMatches: []*lineMatch{{JPreview: "x", JLineNumber: 1, JOffsetAndLengths: [][2]int32{{0, 1}}}},
+		MatchCount:   1,
+	}
+
+	ctx := withSearchStream(context.Background(), stream)
+	searchStreamFromContext(ctx).update([]SearchResultResolver{fileMatch}, nil)
+	searchStreamFromContext(ctx).update([]SearchResultResolver{repo}, &searchResultsCommon{searched: []*types.Repo{repo.repo}})
+	if searchStreamFromContext(withSearchStream(ctx, nil)) != nil {
+		t.Fatal("expected streaming to be disabled")
+	}
+
+	// Results that were already streamed are not sent again.
+	stream.done(&SearchResultsResolver{
+		SearchResults:       []SearchResultResolver{fileMatch, repo},
+		searchResultsCommon: searchResultsCommon{searched: []*types.Repo{repo.repo}},
+	})
+
+	var names []string
+	for _, e := range events {
+		names = append(names, e.name)
+	}
+	if diff := cmp.Diff([]string{StreamEventMatches, StreamEventMatches, StreamEventProgress, StreamEventDone}, names); diff != "" {
+		t.Fatal(diff)
+	}
+
+	wantFile := []StreamMatch{{
+		Type:        "file",
+		Repository:  "foo",
+		Path:        "a.go",
+		URL:         "git://foo#a.go",
+		LineMatches: []StreamLineMatch{{Line: "x", LineNumber: 1, OffsetAndLengths: [][2]int32{{0, 1}}}},
+	}}
+	if diff := cmp.Diff(wantFile, events[0].data); diff != "" {
+		t.Error(diff)
+	}
+	if diff := cmp.Diff(StreamProgress{Searched: 1}, events[2].data); diff != "" {
+		t.Error(diff)
+	}
+	done := events[3].data.(StreamDone)
+	if done.ResultCount != 2 || done.Searched != 1 {
+		t.Errorf("unexpected done event %+v", done)
+	}
+}
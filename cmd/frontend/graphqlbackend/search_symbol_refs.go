@@ -0,0 +1,199 @@
+package graphqlbackend
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/neelance/parallel"
+	"github.com/opentracing/opentracing-go/ext"
+	otlog "github.com/opentracing/opentracing-go/log"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/internal/goroutine"
+	"github.com/sourcegraph/sourcegraph/internal/search"
+	"github.com/sourcegraph/sourcegraph/internal/symbols/protocol"
+	"github.com/sourcegraph/sourcegraph/internal/trace"
+	"github.com/sourcegraph/sourcegraph/internal/trace/ot"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+)
+
+var mockSearchSymbolRefs func(ctx context.Context, args *search.TextParameters, limit int) (res []*FileMatchResolver, common *searchResultsCommon, err error)
+
+// searchSymbolRefs searches the given repos in parallel for the occurrences
+// of the symbols whose names match the search pattern (type:symbol-ref). The
+// occurrences are found by the symbols service, which tokenizes files into
+// identifiers, so they are approximate. Zoekt does not index occurrences, so
+// every repository is searched by the symbols service, up to the limit on
+// unindexed searches.
+//
+// May return partial results and an error
+func searchSymbolRefs(ctx context.Context, args *search.TextParameters, limit int) (res []*FileMatchResolver, common *searchResultsCommon, err error) {
+	if mockSearchSymbolRefs != nil {
+		return mockSearchSymbolRefs(ctx, args, limit)
+	}
+
+	tr, ctx := trace.New(ctx, "Search symbol refs", fmt.Sprintf("query: %+v, numRepoRevs: %d", args.PatternInfo, len(args.Repos)))
+	defer func() {
+		tr.SetError(err)
+		tr.Finish()
+	}()
+
+	if args.PatternInfo.Pattern == "" {
+		return nil, nil, nil
+	}
+
+	common = &searchResultsCommon{partial: make(map[api.RepoName]struct{})}
+	common.repos = make([]*types.Repo, len(args.Repos))
+	for i, repo := range args.Repos {
+		common.repos[i] = repo.Repo
+	}
+
+	searchedRepos, missing := limitSearcherRepos(args.Repos, maxUnindexedRepoRevSearchesPerQuery)
+	common.missing = missing
+
+	var (
+		run = parallel.NewRun(conf.SearchSymbolsParallelism())
+		mu  sync.Mutex
+
+		unflattened [][]*FileMatchResolver
+	)
+	for _, repoRevs := range searchedRepos {
+		repoRevs := repoRevs
+		if ctx.Err() != nil {
+			break
+		}
+		if len(repoRevs.RevSpecs()) == 0 {
+			continue
+		}
+		run.Acquire()
+		goroutine.Go(func() {
+			defer run.Release()
+			matches, repoLimitHit, repoErr := searchSymbolRefsInRepo(ctx, repoRevs, args.PatternInfo, limit)
+			if repoErr != nil {
+				tr.LogFields(otlog.String("repo", string(repoRevs.Repo.Name)), otlog.String("repoErr", repoErr.Error()))
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			repoErr = handleRepoSearchResult(common, repoRevs, repoLimitHit, false, repoErr)
+			if repoErr != nil {
+				if ctx.Err() == nil || errors.Cause(repoErr) != ctx.Err() {
+					// Only record error if it's not directly caused by a context error.
+					run.Error(repoErr)
+				}
+			} else {
+				common.searched = append(common.searched, repoRevs.Repo)
+			}
+			if len(matches) > 0 {
+				common.resultCount += int32(len(matches))
+				unflattened = append(unflattened, matches)
+			}
+		})
+	}
+	err = run.Wait()
+
+	flattened := flattenFileMatches(unflattened, int(args.PatternInfo.FileMatchLimit))
+	res, limitHit := limitLineMatches(flattened, limit)
+	common.limitHit = common.limitHit || limitHit
+	return res, common, err
+}
+
+// searchSymbolRefsInRepo returns a file match for each file of repoRevs with
+// occurrences of the symbols whose names match the pattern, with a line match
+// for each line of an occurrence.
+func searchSymbolRefsInRepo(ctx context.Context, repoRevs *search.RepositoryRevisions, patternInfo *search.TextPatternInfo, limit int) (res []*FileMatchResolver, limitHit bool, err error) {
+	span, ctx := ot.StartSpanFromContext(ctx, "Search symbol refs in repo")
+	defer func() {
+		if err != nil {
+			ext.Error.Set(span, true)
+			span.LogFields(otlog.Error(err))
+		}
+		span.Finish()
+	}()
+	span.SetTag("repo", string(repoRevs.Repo.Name))
+
+	inputRev := repoRevs.RevSpecs()[0]
+	span.SetTag("rev", inputRev)
+	// Do not trigger a repo-updater lookup, like searchSymbolsInRepo.
+	commitID, err := git.ResolveRevision(ctx, repoRevs.GitserverRepo(), nil, inputRev, git.ResolveRevisionOptions{})
+	if err != nil {
+		return nil, false, err
+	}
+	span.SetTag("commit", string(commitID))
+
+	refs, err := backend.Symbols.ListRefs(ctx, search.SymbolsParameters{
+		Repo:            repoRevs.Repo.Name,
+		CommitID:        commitID,
+		Query:           patternInfo.Pattern,
+		IsCaseSensitive: patternInfo.IsCaseSensitive,
+		IsRegExp:        patternInfo.IsRegExp,
+		IncludePatterns: patternInfo.IncludePatterns,
+		ExcludePattern:  patternInfo.ExcludePattern,
+		// Ask for limit + 1 so we can detect whether there are more results than the limit.
+		First: limit + 1,
+	})
+	if len(refs) > limit {
+		refs = refs[:limit]
+		limitHit = true
+	}
+	return symbolRefsToFileMatches(repoRevs.Repo, commitID, inputRev, refs), limitHit, err
+}
+
+// symbolRefsToFileMatches groups refs, which are sorted by path and position,
+// into file matches.
+func symbolRefsToFileMatches(repo *types.Repo, commitID api.CommitID, inputRev string, refs []protocol.SymbolRef) []*FileMatchResolver {
+	repoResolver := NewRepositoryResolver(repo)
+	var (
+		fileMatches []*FileMatchResolver
+		fm          *FileMatchResolver
+		lm          *lineMatch
+	)
+	for _, ref := range refs {
+		if fm == nil || fm.JPath != ref.Path {
+			fm = &FileMatchResolver{
+				JPath:    ref.Path,
+				uri:      fileMatchURI(repo.Name, inputRev, ref.Path),
+				Repo:     repoResolver,
+				CommitID: commitID,
+				InputRev: &inputRev,
+			}
+			fileMatches = append(fileMatches, fm)
+			lm = nil
+		}
+		if lm == nil || lm.JLineNumber != int32(ref.Line-1) {
+			lm = &lineMatch{
+				JPreview:    ref.Preview,
+				JLineNumber: int32(ref.Line - 1),
+			}
+			fm.JLineMatches = append(fm.JLineMatches, lm)
+		}
+		// Offsets and lengths in line matches count characters.
+		offset := ref.Character
+		if offset > len(ref.Preview) {
+			offset = len(ref.Preview)
+		}
+		lm.JOffsetAndLengths = append(lm.JOffsetAndLengths, [2]int32{
+			int32(utf8.RuneCountInString(ref.Preview[:offset])),
+			int32(utf8.RuneCountInString(ref.Name)),
+		})
+		fm.MatchCount++
+	}
+	return fileMatches
+}
+
+// limitLineMatches returns the first file matches of res up to a total of
+// limit matches, and whether any were left out.
+func limitLineMatches(res []*FileMatchResolver, limit int) ([]*FileMatchResolver, bool) {
+	count := 0
+	for i, fm := range res {
+		if count+fm.MatchCount > limit {
+			return res[:i], true
+		}
+		count += fm.MatchCount
+	}
+	return res, false
+}
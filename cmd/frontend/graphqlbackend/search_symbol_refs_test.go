@@ -0,0 +1,60 @@
+package graphqlbackend
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/symbols/protocol"
+)
+
+func TestSymbolRefsToFileMatches(t *testing.T) {
+	refs := []protocol.SymbolRef{
+		{Name: "foo", Path: "a.go", Line: 1, Character: 5, Preview: "func foo() { foo() }"},
+		{Name: "foo", Path: "a.go", Line: 1, Character: 13, Preview: "func foo() { foo() }"},
+		{Name: "foo", Path: "a.go", Line: 3, Character: 4, Preview: "ñ: foo"},
+		{Name: "foo", Path: "b.go", Line: 2, Character: 0, Preview: "foo()"},
+	}
+	fms := symbolRefsToFileMatches(&types.Repo{ID: 1, Name: "repo"}, "c1", "main", refs)
+
+	type match struct {
+		URI         string
+		MatchCount  int
+		LineMatches []lineMatch
+	}
+	var got []match
+	for _, fm := range fms {
+		m := match{URI: fm.uri, MatchCount: fm.MatchCount}
+		for _, lm := range fm.JLineMatches {
+			m.LineMatches = append(m.LineMatches, *lm)
+		}
+		got = append(got, m)
+	}
+	want := []match{
+		{
+			URI:        "git://repo?main#a.go",
+			MatchCount: 3,
+			LineMatches: []lineMatch{
+				{JPreview: "func foo() { foo() }", JLineNumber: 0, JOffsetAndLengths: [][2]int32{{5, 3}, {13, 3}}},
+				// Offsets count characters, not bytes.
+				{JPreview: "ñ: foo", JLineNumber: 2, JOffsetAndLengths: [][2]int32{{3, 3}}},
+			},
+		},
+		{
+			URI:         "git://repo?main#b.go",
+			MatchCount:  1,
+			LineMatches: []lineMatch{{JPreview: "foo()", JLineNumber: 1, JOffsetAndLengths: [][2]int32{{0, 3}}}},
+		},
+	}
+	if diff := cmp.Diff(want, got, cmp.AllowUnexported(lineMatch{})); diff != "" {
+		t.Errorf("mismatch (-want +got):\n%s", diff)
+	}
+
+	if got, limitHit := limitLineMatches(fms, 3); len(got) != 1 || !limitHit {
+		t.Errorf("limitLineMatches(3) = %d file matches, limitHit %t, want 1, true", len(got), limitHit)
+	}
+	if got, limitHit := limitLineMatches(fms, 4); len(got) != 2 || limitHit {
+		t.Errorf("limitLineMatches(4) = %d file matches, limitHit %t, want 2, false", len(got), limitHit)
+	}
+}
@@ -131,6 +131,9 @@ func searchSymbols(ctx context.Context, args *search.TextParameters, limit int)
 			for _, repo := range indexed.Repos() {
 				common.searched = append(common.searched, repo.Repo)
 				common.indexed = append(common.indexed, repo.Repo)
+				for _, rev := range repo.Revs {
+					common.revisions = append(common.revisions, newSearchedRevision(repo.Repo, rev, true))
+				}
 			}
 			for repo := range reposLimitHit {
 				common.partial[api.RepoName(repo)] = struct{}{}
@@ -173,6 +176,9 @@ func searchSymbols(ctx context.Context, args *search.TextParameters, limit int)
 				}
 			} else {
 				common.searched = append(common.searched, repoRevs.Repo)
+				for _, rev := range repoRevs.Revs {
+					common.revisions = append(common.revisions, newSearchedRevision(repoRevs.Repo, rev, false))
+				}
 			}
 			if repoSymbols != nil {
 				addMatches(repoSymbols)
@@ -13,9 +13,11 @@ import (
 	"github.com/google/zoekt"
 	"github.com/graph-gophers/graphql-go"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/actor"
 	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/conf"
 	"github.com/sourcegraph/sourcegraph/internal/db"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
 	searchbackend "github.com/sourcegraph/sourcegraph/internal/search/backend"
 	"github.com/sourcegraph/sourcegraph/internal/search/query"
 	querytypes "github.com/sourcegraph/sourcegraph/internal/search/query/types"
@@ -661,6 +663,82 @@ func TestVersionContext(t *testing.T) {
 	}
 }
 
+func TestVersionContext_database(t *testing.T) {
+	mockDecodedViewerFinalSettings = &schema.Settings{}
+	defer func() { mockDecodedViewerFinalSettings = nil }()
+	defer func() { db.Mocks = db.MockStores{} }()
+	defer git.ResetMocks()
+
+	owner := int32(1)
+	db.Mocks.Users.GetByCurrentAuthUser = func(ctx context.Context) (*types.User, error) {
+		return &types.User{ID: actor.FromContext(ctx).UID}, nil
+	}
+	db.Mocks.Orgs.GetByUserID = func(ctx context.Context, userID int32) ([]*types.Org, error) {
+		return nil, nil
+	}
+	db.Mocks.VersionContexts.GetByName = func(ctx context.Context, name string, opt db.VersionContextsListOptions) (*types.VersionContext, error) {
+		if opt.UserID != owner {
+			return nil, &db.VersionContextNotFoundError{Message: name}
+		}
+		return &types.VersionContext{
+			ID:     1,
+			Name:   name,
+			UserID: &owner,
+			Revisions: []*types.VersionContextRevision{
+				{Repo: "github.com/sourcegraph/foo", LatestTag: "v2024.3.*"},
+				{Repo: "github.com/sourcegraph/bar", RefGlob: "refs/heads/release/*"},
+				{Repo: "github.com/sourcegraph/notag", LatestTag: "v2024.3.*"},
+			},
+		}, nil
+	}
+	db.Mocks.Repos.List = func(ctx context.Context, opts db.ReposListOptions) ([]*types.Repo, error) {
+		var repos []*types.Repo
+		for _, name := range opts.Names {
+			repos = append(repos, &types.Repo{Name: api.RepoName(name)})
+		}
+		return repos, nil
+	}
+	db.Mocks.Repos.Count = func(ctx context.Context, opt db.ReposListOptions) (int, error) { return 0, nil }
+	git.Mocks.ListTags = func(repo gitserver.Repo) ([]*git.Tag, error) {
+		if repo.Name == "github.com/sourcegraph/notag" {
+			return nil, nil
+		}
+		return []*git.Tag{{Name: "v2024.4.0"}, {Name: "v2024.3.1"}, {Name: "v2024.3.0"}}, nil
+	}
+	git.Mocks.ResolveRevision = func(spec string, opt git.ResolveRevisionOptions) (api.CommitID, error) {
+		return "deadbeef", nil
+	}
+
+	qinfo, err := query.ParseAndCheck("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	versionContext := "release-2024.3"
+	resolver := searchResolver{query: qinfo, versionContext: &versionContext}
+
+	ctx := actor.WithActor(context.Background(), &actor.Actor{UID: owner})
+	gotResults, missing, _, _, err := resolver.resolveRepositories(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, reporev := range gotResults {
+		for _, rev := range reporev.Revs {
+			got = append(got, string(reporev.Repo.Name)+"@"+rev.String())
+		}
+	}
+	want := []string{
+		"github.com/sourcegraph/foo@v2024.3.1",
+		"github.com/sourcegraph/bar@*refs/heads/release/*",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+	if len(missing) != 1 || missing[0].Repo.Name != "github.com/sourcegraph/notag" {
+		t.Errorf("got missing %+v, want the repository without a matching tag", missing)
+	}
+}
+
 func TestComputeExcludedRepositories(t *testing.T) {
 	cases := []struct {
 		Name              string
@@ -760,3 +838,41 @@ func TestComputeExcludedRepositories(t *testing.T) {
 		})
 	}
 }
+
+func TestRepoLanguages(t *testing.T) {
+	got, err := repoLanguages([]string{"golang", "Java", "c++"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Go", "Java", "C++"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if _, err := repoLanguages([]string{"stephenhas9cats"}); err == nil {
+		t.Error("got no error, want an error for the unknown language")
+	}
+}
+
+func TestSizeBounds(t *testing.T) {
+	int64Ptr := func(n int64) *int64 { return &n }
+	tests := []struct {
+		values   []string
+		min, max *int64
+	}{
+		{values: nil},
+		{values: []string{">1KB"}, min: int64Ptr(1025)},
+		{values: []string{"<=1KB"}, max: int64Ptr(1024)},
+		{values: []string{">=1KB", ">=2KB", "<1MB", "<=1GB"}, min: int64Ptr(2048), max: int64Ptr(1<<20 - 1)},
+	}
+	for _, test := range tests {
+		min, max, err := sizeBounds(test.values)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(min, test.min) || !reflect.DeepEqual(max, test.max) {
+			t.Errorf("%v: got bounds %v, %v, want %v, %v", test.values, min, max, test.min, test.max)
+		}
+	}
+	if _, _, err := sizeBounds([]string{"10MB"}); err == nil {
+		t.Error("got no error, want an error for the size without a comparison")
+	}
+}
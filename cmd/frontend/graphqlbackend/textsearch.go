@@ -15,6 +15,7 @@ import (
 	"time"
 
 	"github.com/pkg/errors"
+	"github.com/sourcegraph/go-langserver/pkg/lsp"
 	"github.com/sourcegraph/sourcegraph/internal/metrics"
 	"github.com/sourcegraph/sourcegraph/internal/trace"
 
@@ -36,6 +37,10 @@ import (
 
 const maxUnindexedRepoRevSearchesPerQuery = 200
 
+// unindexedFetchTimeoutManyRepos is how long unindexed search waits for the
+// archive of a repository when many repositories are searched.
+const unindexedFetchTimeoutManyRepos = 500 * time.Millisecond
+
 var (
 	// A global limiter on number of concurrent searcher searches.
 	textSearchLimiter = mutablelimiter.New(32)
@@ -64,18 +69,23 @@ var (
 
 // FileMatchResolver is a resolver for the GraphQL type `FileMatch`
 type FileMatchResolver struct {
-	JPath        string       `json:"Path"`
-	JLineMatches []*lineMatch `json:"LineMatches"`
-	JLimitHit    bool         `json:"LimitHit"`
-	MatchCount   int          // Number of matches. Different from len(JLineMatches), as multiple lines may correspond to one logical match.
-	symbols      []*searchSymbolResult
-	uri          string
-	Repo         *RepositoryResolver
-	CommitID     api.CommitID
+	JPath             string            `json:"Path"`
+	JLineMatches      []*lineMatch      `json:"LineMatches"`
+	JLimitHit         bool              `json:"LimitHit"`
+	JMultilineMatches []*multilineMatch `json:"MultilineMatches"` // Matches in JLineMatches which span more than one line.
+	MatchCount        int               // Number of matches. Different from len(JLineMatches), as multiple lines may correspond to one logical match.
+	symbols           []*searchSymbolResult
+	uri               string
+	Repo              *RepositoryResolver
+	CommitID          api.CommitID
 	// InputRev is the Git revspec that the user originally requested to search. It is used to
 	// preserve the original revision specifier from the user instead of navigating them to the
 	// absolute commit ID when they select a result.
 	InputRev *string
+	// revisionRangeCommits is set when a revision range (such as
+	// repo:foo@v1.0..v2.0) was searched. It is the commits in the range in
+	// which this version of the file existed, oldest first.
+	revisionRangeCommits []api.CommitID
 }
 
 func (fm *FileMatchResolver) Equal(other *FileMatchResolver) bool {
@@ -125,10 +135,25 @@ func (fm *FileMatchResolver) Symbols() []*symbolResolver {
 	return symbols
 }
 
+func (fm *FileMatchResolver) RevisionRangeCommits() *[]*GitCommitResolver {
+	if fm.revisionRangeCommits == nil {
+		return nil
+	}
+	commits := make([]*GitCommitResolver, len(fm.revisionRangeCommits))
+	for i, id := range fm.revisionRangeCommits {
+		commits[i] = &GitCommitResolver{repoResolver: fm.Repo, oid: GitObjectID(id)}
+	}
+	return &commits
+}
+
 func (fm *FileMatchResolver) LineMatches() []*lineMatch {
 	return fm.JLineMatches
 }
 
+func (fm *FileMatchResolver) MultilineMatches() []*multilineMatch {
+	return fm.JMultilineMatches
+}
+
 func (fm *FileMatchResolver) LimitHit() bool {
 	return fm.JLimitHit
 }
@@ -157,10 +182,11 @@ func (fm *FileMatchResolver) resultCount() int32 {
 
 // lineMatch is the struct used by vscode to receive search results for a line
 type lineMatch struct {
-	JPreview          string     `json:"Preview"`
-	JOffsetAndLengths [][2]int32 `json:"OffsetAndLengths"`
-	JLineNumber       int32      `json:"LineNumber"`
-	JLimitHit         bool       `json:"LimitHit"`
+	JPreview          string          `json:"Preview"`
+	JOffsetAndLengths [][2]int32      `json:"OffsetAndLengths"`
+	JLineNumber       int32           `json:"LineNumber"`
+	JLimitHit         bool            `json:"LimitHit"`
+	JCaptureGroups    []*captureGroup `json:"CaptureGroups"`
 }
 
 func (lm *lineMatch) Preview() string {
@@ -183,6 +209,26 @@ func (lm *lineMatch) LimitHit() bool {
 	return lm.JLimitHit
 }
 
+func (lm *lineMatch) CaptureGroups() []*captureGroup {
+	return lm.JCaptureGroups
+}
+
+// multilineMatch is a match which spans more than one line. It is unmarshaled
+// from the searcher's protocol.MultilineMatch.
+type multilineMatch struct {
+	JPreview string       `json:"Preview"`
+	JStart   lsp.Position `json:"Start"`
+	JEnd     lsp.Position `json:"End"`
+}
+
+func (mm *multilineMatch) Preview() string {
+	return mm.JPreview
+}
+
+func (mm *multilineMatch) Range() RangeResolver {
+	return NewRangeResolver(lsp.Range{Start: mm.JStart, End: mm.JEnd})
+}
+
 var mockTextSearch func(ctx context.Context, repo gitserver.Repo, commit api.CommitID, p *search.TextPatternInfo, fetchTimeout time.Duration) (matches []*FileMatchResolver, limitHit bool, err error)
 
 // textSearch searches repo@commit with p.
@@ -234,6 +280,15 @@ func textSearch(ctx context.Context, searcherURLs *endpoint.Map, repo gitserver.
 	if p.PathPatternsAreCaseSensitive {
 		q.Set("PathPatternsAreCaseSensitive", "true")
 	}
+	if p.MinFileSize != nil {
+		q.Set("MinFileSize", strconv.FormatInt(*p.MinFileSize, 10))
+	}
+	if p.MaxFileSize != nil {
+		q.Set("MaxFileSize", strconv.FormatInt(*p.MaxFileSize, 10))
+	}
+	if p.ExcludeGenerated {
+		q.Set("ExcludeGenerated", "true")
+	}
 	// TEMP BACKCOMPAT: always set even if false so that searcher can distinguish new frontends that send
 	// these fields from old frontends that do not (and provide a default in the latter case).
 	q.Set("PatternMatchesContent", strconv.FormatBool(p.PatternMatchesContent))
@@ -457,6 +512,13 @@ var mockSearchFilesInRepos func(args *search.TextParameters) ([]*FileMatchResolv
 
 // searchFilesInRepos searches a set of repos for a pattern.
 func searchFilesInRepos(ctx context.Context, args *search.TextParameters) (res []*FileMatchResolver, common *searchResultsCommon, err error) {
+	return searchFilesInReposIndexed(ctx, args, nil)
+}
+
+// searchFilesInReposIndexed is like searchFilesInRepos, but if indexed is
+// non-nil, it searches the indexed repositories with it instead of creating a
+// new request. indexed must have been created for args, as by planSearch.
+func searchFilesInReposIndexed(ctx context.Context, args *search.TextParameters, indexed *indexedSearchRequest) (res []*FileMatchResolver, common *searchResultsCommon, err error) {
 	if mockSearchFilesInRepos != nil {
 		return mockSearchFilesInRepos(args)
 	}
@@ -484,9 +546,11 @@ func searchFilesInRepos(ctx context.Context, args *search.TextParameters) (res [
 		indexedTyp = fileRequest
 	}
 
-	indexed, err := newIndexedSearchRequest(ctx, args, indexedTyp)
-	if err != nil {
-		return nil, nil, err
+	if indexed == nil {
+		indexed, err = newIndexedSearchRequest(ctx, args, indexedTyp)
+		if err != nil {
+			return nil, nil, err
+		}
 	}
 
 	// if there are no indexed repos and this is a structural search
@@ -540,9 +604,18 @@ func searchFilesInRepos(ctx context.Context, args *search.TextParameters) (res [
 		unflattened       [][]*FileMatchResolver
 		flattenedSize     int
 		overLimitCanceled bool // canceled because we were over the limit
+
+		// streamed are the matches sent to stream so far, at most
+		// FileMatchLimit of them.
+		streamed []*FileMatchResolver
 	)
 
-	// addMatches assumes the caller holds mu.
+	// stream receives the matches of zoekt and of each searcher request as
+	// soon as they are available, if the search is streamed.
+	stream := searchStreamFromContext(ctx)
+
+	// addMatches assumes the caller holds mu. The matches that still fit in
+	// FileMatchLimit are streamed, and no more once it is reached.
 	addMatches := func(matches []*FileMatchResolver) {
 		if len(matches) > 0 {
 			common.resultCount += int32(len(matches))
@@ -550,6 +623,13 @@ func searchFilesInRepos(ctx context.Context, args *search.TextParameters) (res [
 				a, b := matches[i].uri, matches[j].uri
 				return a > b
 			})
+			if n := int(args.PatternInfo.FileMatchLimit) - len(streamed); stream != nil && n > 0 {
+				if n > len(matches) {
+					n = len(matches)
+				}
+				streamed = append(streamed, matches[:n]...)
+				stream.update(fileMatchesToSearchResults(matches[:n]), nil)
+			}
 			unflattened = append(unflattened, matches)
 			flattenedSize += len(matches)
 
@@ -586,7 +666,7 @@ func searchFilesInRepos(ctx context.Context, args *search.TextParameters) (res [
 			}
 		} else {
 			// When searching many repos, don't wait long for any single repo to fetch.
-			fetchTimeout = 500 * time.Millisecond
+			fetchTimeout = unindexedFetchTimeoutManyRepos
 		}
 
 		if len(searcherRepos) > 0 {
@@ -638,7 +718,11 @@ func searchFilesInRepos(ctx context.Context, args *search.TextParameters) (res [
 					defer wg.Done()
 					defer done()
 
-					matches, repoLimitHit, err := searchFilesInRepo(ctx, args.SearcherURLs, repoRev.Repo, repoRev.GitserverRepo(), repoRev.RevSpecs()[0], args.PatternInfo, fetchTimeout)
+					searchRepoRev := searchFilesInRepo
+					if _, _, ok := repoRev.Revs[0].RevisionRange(); ok {
+						searchRepoRev = searchFilesInRevisionRange
+					}
+					matches, repoLimitHit, err := searchRepoRev(ctx, args.SearcherURLs, repoRev.Repo, repoRev.GitserverRepo(), repoRev.RevSpecs()[0], args.PatternInfo, fetchTimeout)
 					if err != nil {
 						tr.LogFields(otlog.String("repo", string(repoRev.Repo.Name)), otlog.Error(err), otlog.Bool("timeout", errcode.IsTimeout(err)), otlog.Bool("temporary", errcode.IsTemporary(err)))
 						log15.Warn("searchFilesInRepo failed", "error", err, "repo", repoRev.Repo.Name)
@@ -647,6 +731,7 @@ func searchFilesInRepos(ctx context.Context, args *search.TextParameters) (res [
 					defer mu.Unlock()
 					if ctx.Err() == nil {
 						common.searched = append(common.searched, repoRev.Repo)
+						common.revisions = append(common.revisions, newSearchedRevision(repoRev.Repo, repoRev.Revs[0], false))
 					}
 					if repoLimitHit {
 						// We did not return all results in this repository.
@@ -686,6 +771,9 @@ func searchFilesInRepos(ctx context.Context, args *search.TextParameters) (res [
 			for _, repo := range indexed.Repos() {
 				common.searched = append(common.searched, repo.Repo)
 				common.indexed = append(common.indexed, repo.Repo)
+				for _, rev := range repo.Revs {
+					common.revisions = append(common.revisions, newSearchedRevision(repo.Repo, rev, true))
+				}
 			}
 			for repo := range reposLimitHit {
 				// Repos that aren't included in the result set due to exceeded limits are partially searched
@@ -765,6 +853,16 @@ func searchFilesInRepos(ctx context.Context, args *search.TextParameters) (res [
 		return nil, common, searchErr
 	}
 
+	if stream != nil {
+		// The streamed matches are the results, so that none of the
+		// matches the client received are dropped by flattenFileMatches.
+		sort.Slice(streamed, func(i, j int) bool {
+			a, b := streamed[i].uri, streamed[j].uri
+			return a > b
+		})
+		return streamed, common, nil
+	}
+
 	flattened := flattenFileMatches(unflattened, int(args.PatternInfo.FileMatchLimit))
 	return flattened, common, nil
 }
@@ -2,14 +2,20 @@ package graphqlbackend
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"reflect"
 	"sort"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/google/zoekt"
+	zoektquery "github.com/google/zoekt/query"
 	"github.com/pkg/errors"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/cmd/searcher/protocol"
 	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/conf"
 	"github.com/sourcegraph/sourcegraph/internal/endpoint"
@@ -110,6 +116,122 @@ func TestSearchFilesInRepos(t *testing.T) {
 	}
 }
 
+func TestSearchFilesInRepos_stream(t *testing.T) {
+	mockSearchFilesInRepo = func(ctx context.Context, repo *types.Repo, gitserverRepo gitserver.Repo, rev string, info *search.TextPatternInfo, fetchTimeout time.Duration) (matches []*FileMatchResolver, limitHit bool, err error) {
+		return []*FileMatchResolver{
+			{uri: "git://" + string(repo.Name) + "?" + rev + "#" + "a.go", Repo: NewRepositoryResolver(repo)},
+			{uri: "git://" + string(repo.Name) + "?" + rev + "#" + "b.go", Repo: NewRepositoryResolver(repo)},
+		}, false, nil
+	}
+	defer func() { mockSearchFilesInRepo = nil }()
+
+	q, err := query.ParseAndCheck("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := &search.TextParameters{
+		PatternInfo: &search.TextPatternInfo{
+			FileMatchLimit: 3,
+			Pattern:        "foo",
+		},
+		Repos:        makeRepositoryRevisions("foo/one", "foo/two"),
+		Query:        q,
+		Zoekt:        &searchbackend.Zoekt{Client: &fakeSearcher{}},
+		SearcherURLs: endpoint.Static("test"),
+	}
+
+	// The matches of each repository are streamed as soon as they are
+	// found, until there are FileMatchLimit of them, and the streamed
+	// matches are the results.
+	var streamed []string
+	stream := newSearchStream(func(name string, data interface{}) {
+		if name != StreamEventMatches {
+			t.Errorf("got event %q, want %q", name, StreamEventMatches)
+			return
+		}
+		var urls []string
+		for _, m := range data.([]StreamMatch) {
+			urls = append(urls, m.URL)
+		}
+		streamed = append(streamed, strings.Join(urls, " "))
+	})
+	results, _, err := searchFilesInRepos(withSearchStream(context.Background(), stream), args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(streamed) != 2 || strings.Count(streamed[0], " ") != 1 || strings.Count(streamed[1], " ") != 0 {
+		t.Fatalf("got streamed matches %q, want 2 matches then 1 match", streamed)
+	}
+	var got []string
+	for _, r := range results {
+		got = append(got, r.uri)
+	}
+	sort.Strings(got)
+	want := strings.Fields(strings.Join(streamed, " "))
+	sort.Strings(want)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got results %q, want the streamed matches %q", got, want)
+	}
+}
+
+// countListSearcher counts the calls to List of a zoekt.Searcher.
+type countListSearcher struct {
+	zoekt.Searcher
+	lists int32
+}
+
+func (s *countListSearcher) List(ctx context.Context, q zoektquery.Q) (*zoekt.RepoList, error) {
+	atomic.AddInt32(&s.lists, 1)
+	return s.Searcher.List(ctx, q)
+}
+
+func TestSearchFilesInReposIndexed(t *testing.T) {
+	mockSearchFilesInRepo = func(ctx context.Context, repo *types.Repo, gitserverRepo gitserver.Repo, rev string, info *search.TextPatternInfo, fetchTimeout time.Duration) (matches []*FileMatchResolver, limitHit bool, err error) {
+		return []*FileMatchResolver{{uri: "git://" + string(repo.Name) + "?" + rev + "#" + "a.go"}}, false, nil
+	}
+	defer func() { mockSearchFilesInRepo = nil }()
+
+	searcher := &countListSearcher{Searcher: &fakeSearcher{
+		repos: []*zoekt.RepoListEntry{{
+			Repository: zoekt.Repository{
+				Name:     "foo/indexed",
+				Branches: []zoekt.RepositoryBranch{{Name: "HEAD", Version: "deadbeef"}},
+			},
+		}},
+	}}
+	z := &searchbackend.Zoekt{Client: searcher, DisableCache: true}
+
+	q, err := query.ParseAndCheck("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &searchResolver{query: q, zoekt: z}
+	args := &search.TextParameters{
+		PatternInfo:  &search.TextPatternInfo{Pattern: "foo", FileMatchLimit: defaultMaxSearchResults},
+		Repos:        makeRepositoryRevisions("foo/indexed", "foo/unindexed"),
+		Query:        q,
+		Zoekt:        z,
+		SearcherURLs: endpoint.Static("test"),
+	}
+	plan, err := r.planSearch(context.Background(), args, []string{"file"}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The search reuses the plan's request instead of listing the indexed
+	// repositories again.
+	results, _, err := searchFilesInReposIndexed(context.Background(), args, plan.indexedRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].uri != "git://foo/unindexed?#a.go" {
+		t.Errorf("got results %v, want the match in foo/unindexed", results)
+	}
+	if searcher.lists != 1 {
+		t.Errorf("got %d calls to List, want 1", searcher.lists)
+	}
+}
+
 func TestSearchFilesInRepos_multipleRevsPerRepo(t *testing.T) {
 	mockSearchFilesInRepo = func(ctx context.Context, repo *types.Repo, gitserverRepo gitserver.Repo, rev string, info *search.TextPatternInfo, fetchTimeout time.Duration) (matches []*FileMatchResolver, limitHit bool, err error) {
 		repoName := repo.Name
@@ -173,6 +295,56 @@ func TestSearchFilesInRepos_multipleRevsPerRepo(t *testing.T) {
 	}
 }
 
+func TestSearchFilesInRepos_revisionsSearched(t *testing.T) {
+	mockSearchFilesInRepo = func(ctx context.Context, repo *types.Repo, gitserverRepo gitserver.Repo, rev string, info *search.TextPatternInfo, fetchTimeout time.Duration) (matches []*FileMatchResolver, limitHit bool, err error) {
+		if repo.Name != "foo" || rev != "missing" {
+			t.Errorf("unexpected unindexed search of %s@%s", repo.Name, rev)
+		}
+		return nil, false, nil
+	}
+	defer func() { mockSearchFilesInRepo = nil }()
+
+	z := &searchbackend.Zoekt{Client: &fakeSearcher{
+		repos: []*zoekt.RepoListEntry{{
+			Repository: zoekt.Repository{
+				Name: "foo",
+				Branches: []zoekt.RepositoryBranch{
+					{Name: "HEAD", Version: "deadbeef"},
+					{Name: "release/1", Version: "deadcow"},
+				},
+			},
+		}},
+	}}
+
+	q, err := query.ParseAndCheck("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := &search.TextParameters{
+		PatternInfo: &search.TextPatternInfo{
+			FileMatchLimit: defaultMaxSearchResults,
+			Pattern:        "foo",
+		},
+		Repos:        makeRepositoryRevisions("foo@release/1:missing"),
+		Query:        q,
+		Zoekt:        z,
+		SearcherURLs: endpoint.Static("test"),
+	}
+	_, common, err := searchFilesInRepos(context.Background(), args)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, rev := range common.RevisionsSearched() {
+		got = append(got, fmt.Sprintf("%s@%s indexed=%t", rev.repo.Name, rev.Rev(), rev.Indexed()))
+	}
+	want := []string{"foo@missing indexed=false", "foo@release/1 indexed=true"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
 func TestRepoShouldBeSearched(t *testing.T) {
 	mockTextSearch = func(ctx context.Context, repo gitserver.Repo, commit api.CommitID, p *search.TextPatternInfo, fetchTimeout time.Duration) (matches []*FileMatchResolver, limitHit bool, err error) {
 		repoName := repo.Name
@@ -308,3 +480,42 @@ func TestLimitSearcherRepos(t *testing.T) {
 		})
 	}
 }
+
+func TestFileMatchResolver_multilineMatchesFromSearcher(t *testing.T) {
+	data, err := json.Marshal(protocol.FileMatch{
+		Path: "a.go",
+		LineMatches: []protocol.LineMatch{
+			{Preview: "func foo(", LineNumber: 3, OffsetAndLengths: [][2]int{{5, 4}}},
+			{Preview: "\tbar string,", LineNumber: 4, OffsetAndLengths: [][2]int{{0, 4}}},
+		},
+		MatchCount: 1,
+		MultilineMatches: []protocol.MultilineMatch{{
+			Preview: "func foo(\n\tbar string,",
+			Start:   protocol.Position{Line: 3, Character: 5},
+			End:     protocol.Position{Line: 4, Character: 4},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fm FileMatchResolver
+	if err := json.Unmarshal(data, &fm); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(fm.LineMatches()) != 2 {
+		t.Fatalf("got %d line matches, want 2", len(fm.LineMatches()))
+	}
+	mms := fm.MultilineMatches()
+	if len(mms) != 1 {
+		t.Fatalf("got %d multiline matches, want 1", len(mms))
+	}
+	if got, want := mms[0].Preview(), "func foo(\n\tbar string,"; got != want {
+		t.Errorf("got preview %q, want %q", got, want)
+	}
+	r := mms[0].Range()
+	got := [4]int32{r.Start().Line(), r.Start().Character(), r.End().Line(), r.End().Character()}
+	if want := [4]int32{3, 5, 4, 4}; got != want {
+		t.Errorf("got range %v, want %v", got, want)
+	}
+}
@@ -2,18 +2,53 @@ package graphqlbackend
 
 import (
 	"context"
+	"errors"
+	"strings"
 
 	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
 	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/internal/db"
+	"github.com/sourcegraph/sourcegraph/internal/errcode"
 	"github.com/sourcegraph/sourcegraph/schema"
 )
 
 type versionContextResolver struct {
-	vc *schema.VersionContext
+	vc *types.VersionContext
+}
+
+func marshalVersionContextID(id int32) graphql.ID {
+	return relay.MarshalID("VersionContext", id)
+}
+
+func unmarshalVersionContextID(id graphql.ID) (versionContextID int32, err error) {
+	err = relay.UnmarshalSpec(id, &versionContextID)
+	return
+}
+
+func versionContextByID(ctx context.Context, id graphql.ID) (*versionContextResolver, error) {
+	intID, err := unmarshalVersionContextID(id)
+	if err != nil {
+		return nil, err
+	}
+	vc, err := db.VersionContexts.GetByID(ctx, intID)
+	if err != nil {
+		return nil, err
+	}
+	// 🚨 SECURITY: Make sure the current user has permission to get the version context.
+	if err := backend.CheckVersionContextAccess(ctx, vc); err != nil {
+		return nil, err
+	}
+	return &versionContextResolver{vc: vc}, nil
 }
 
 func (v *versionContextResolver) ID() graphql.ID {
-	return graphql.ID(v.vc.Name)
+	if v.isFromConfig() {
+		return graphql.ID(v.vc.Name)
+	}
+	return marshalVersionContextID(v.vc.ID)
 }
 
 func (v *versionContextResolver) Name() string {
@@ -24,18 +59,255 @@ func (v *versionContextResolver) Description() string {
 	return v.vc.Description
 }
 
+func (v *versionContextResolver) Namespace(ctx context.Context) (*NamespaceResolver, error) {
+	var (
+		n   Namespace
+		err error
+	)
+	switch {
+	case v.vc.OrgID != nil:
+		n, err = NamespaceByID(ctx, MarshalOrgID(*v.vc.OrgID))
+	case v.vc.UserID != nil:
+		n, err = NamespaceByID(ctx, MarshalUserID(*v.vc.UserID))
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &NamespaceResolver{n}, nil
+}
+
+func (v *versionContextResolver) Revisions(ctx context.Context) (*[]*versionContextRevisionResolver, error) {
+	if v.isFromConfig() {
+		return nil, nil
+	}
+	revs := make([]*versionContextRevisionResolver, 0, len(v.vc.Revisions))
+	for _, rev := range v.vc.Revisions {
+		repo, err := RepositoryByIDInt32(ctx, rev.RepoID)
+		if err != nil {
+			// The current user may not have access to all repositories of
+			// the version context.
+			if errcode.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		revs = append(revs, &versionContextRevisionResolver{rev: rev, repo: repo})
+	}
+	return &revs, nil
+}
+
+func (v *versionContextResolver) ViewerCanAdminister(ctx context.Context) bool {
+	if v.isFromConfig() {
+		return false
+	}
+	return backend.CheckVersionContextAccess(ctx, v.vc) == nil
+}
+
+// isFromConfig reports whether the version context is defined in the site
+// configuration, rather than stored in the database.
+func (v *versionContextResolver) isFromConfig() bool {
+	return v.vc.ID == 0
+}
+
 func NewVersionContextResolver(vc *schema.VersionContext) *versionContextResolver {
 	return &versionContextResolver{
-		vc: vc,
+		vc: backend.VersionContextFromConfig(vc),
 	}
 }
 
+type versionContextRevisionResolver struct {
+	rev  *types.VersionContextRevision
+	repo *RepositoryResolver
+}
+
+func (r *versionContextRevisionResolver) Repository() *RepositoryResolver { return r.repo }
+
+func (r *versionContextRevisionResolver) Rev() *string { return nonEmptyStringPtr(r.rev.Rev) }
+
+func (r *versionContextRevisionResolver) RefGlob() *string { return nonEmptyStringPtr(r.rev.RefGlob) }
+
+func (r *versionContextRevisionResolver) LatestTag() *string {
+	return nonEmptyStringPtr(r.rev.LatestTag)
+}
+
+func nonEmptyStringPtr(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
 func (r *schemaResolver) VersionContexts(ctx context.Context) ([]*versionContextResolver, error) {
 	var versionContexts []*versionContextResolver
 
-	for _, vc := range conf.Get().ExperimentalFeatures.VersionContexts {
-		versionContexts = append(versionContexts, NewVersionContextResolver(vc))
+	if ef := conf.Get().ExperimentalFeatures; ef != nil {
+		for _, vc := range ef.VersionContexts {
+			versionContexts = append(versionContexts, NewVersionContextResolver(vc))
+		}
+	}
+
+	user, err := backend.CurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return versionContexts, nil
+	}
+	orgs, err := db.Orgs.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	opt := db.VersionContextsListOptions{UserID: user.ID}
+	for _, org := range orgs {
+		opt.OrgIDs = append(opt.OrgIDs, org.ID)
+	}
+	vcs, err := db.VersionContexts.List(ctx, opt)
+	if err != nil {
+		return nil, err
+	}
+	for _, vc := range vcs {
+		versionContexts = append(versionContexts, &versionContextResolver{vc: vc})
 	}
 
 	return versionContexts, nil
 }
+
+type versionContextRevisionInput struct {
+	Repository graphql.ID
+	Rev        *string
+	RefGlob    *string
+	LatestTag  *string
+}
+
+type versionContextArgs struct {
+	Name        string
+	Description *string
+	Revisions   []*versionContextRevisionInput
+}
+
+// toVersionContext validates the arguments of the version context mutations
+// and returns the version context they describe.
+func (args *versionContextArgs) toVersionContext(ctx context.Context) (*types.VersionContext, error) {
+	name := strings.TrimSpace(args.Name)
+	if name == "" {
+		return nil, errors.New("version context name must not be empty")
+	}
+	// Version contexts of the site configuration take precedence, so a
+	// version context with the same name could never be used.
+	if ef := conf.Get().ExperimentalFeatures; ef != nil {
+		for _, vc := range ef.VersionContexts {
+			if strings.EqualFold(vc.Name, name) {
+				return nil, db.ErrVersionContextNameAlreadyExists
+			}
+		}
+	}
+
+	vc := &types.VersionContext{Name: name}
+	if args.Description != nil {
+		vc.Description = *args.Description
+	}
+	for _, input := range args.Revisions {
+		repoID, err := UnmarshalRepositoryID(input.Repository)
+		if err != nil {
+			return nil, err
+		}
+		// 🚨 SECURITY: Make sure the current user has access to the repository.
+		repo, err := db.Repos.Get(ctx, repoID)
+		if err != nil {
+			return nil, err
+		}
+		rev := &types.VersionContextRevision{RepoID: repo.ID, Repo: repo.Name}
+		if input.Rev != nil {
+			rev.Rev = *input.Rev
+		}
+		if input.RefGlob != nil {
+			rev.RefGlob = *input.RefGlob
+		}
+		if input.LatestTag != nil {
+			rev.LatestTag = *input.LatestTag
+		}
+		if err := backend.ValidateVersionContextRevision(rev); err != nil {
+			return nil, err
+		}
+		vc.Revisions = append(vc.Revisions, rev)
+	}
+	return vc, nil
+}
+
+func (r *schemaResolver) CreateVersionContext(ctx context.Context, args *struct {
+	Namespace graphql.ID
+	versionContextArgs
+}) (*versionContextResolver, error) {
+	vc, err := args.toVersionContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// 🚨 SECURITY: Make sure the current user has permission to create a version context for the specified user or org.
+	switch relay.UnmarshalKind(args.Namespace) {
+	case "User":
+		userID, err := UnmarshalUserID(args.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		if err := backend.CheckSiteAdminOrSameUser(ctx, userID); err != nil {
+			return nil, err
+		}
+		vc.UserID = &userID
+	case "Org":
+		orgID, err := UnmarshalOrgID(args.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		if err := backend.CheckOrgAccess(ctx, orgID); err != nil {
+			return nil, err
+		}
+		vc.OrgID = &orgID
+	default:
+		return nil, errors.New("invalid ID for namespace")
+	}
+
+	vc, err = db.VersionContexts.Create(ctx, vc)
+	if err != nil {
+		return nil, err
+	}
+	return &versionContextResolver{vc: vc}, nil
+}
+
+func (r *schemaResolver) UpdateVersionContext(ctx context.Context, args *struct {
+	ID graphql.ID
+	versionContextArgs
+}) (*versionContextResolver, error) {
+	// 🚨 SECURITY: versionContextByID checks that the current user has permission to update the version context.
+	old, err := versionContextByID(ctx, args.ID)
+	if err != nil {
+		return nil, err
+	}
+	vc, err := args.toVersionContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	vc.ID = old.vc.ID
+
+	vc, err = db.VersionContexts.Update(ctx, vc)
+	if err != nil {
+		return nil, err
+	}
+	return &versionContextResolver{vc: vc}, nil
+}
+
+func (r *schemaResolver) DeleteVersionContext(ctx context.Context, args *struct {
+	ID graphql.ID
+}) (*EmptyResponse, error) {
+	// 🚨 SECURITY: versionContextByID checks that the current user has permission to delete the version context.
+	vc, err := versionContextByID(ctx, args.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.VersionContexts.Delete(ctx, vc.vc.ID); err != nil {
+		return nil, err
+	}
+	return &EmptyResponse{}, nil
+}
@@ -0,0 +1,165 @@
+package graphqlbackend
+
+import (
+	"context"
+	"testing"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/gqltesting"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/actor"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/internal/db"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestMutation_CreateVersionContext(t *testing.T) {
+	resetMocks()
+	defer resetMocks()
+
+	db.Mocks.Repos.Get = func(ctx context.Context, id api.RepoID) (*types.Repo, error) {
+		return &types.Repo{ID: id, Name: "github.com/sourcegraph/sourcegraph"}, nil
+	}
+	var created *types.VersionContext
+	db.Mocks.VersionContexts.Create = func(ctx context.Context, vc *types.VersionContext) (*types.VersionContext, error) {
+		created = vc
+		withID := *vc
+		withID.ID = 1
+		return &withID, nil
+	}
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Context: actor.WithActor(context.Background(), &actor.Actor{UID: 1}),
+			Schema:  mustParseGraphQLSchema(t),
+			Query: `
+				mutation {
+					createVersionContext(
+						namespace: "VXNlcjox",
+						name: "release-2024.3",
+						revisions: [{repository: "UmVwb3NpdG9yeTox", latestTag: "v2024.3.*"}]
+					) {
+						id
+						name
+						viewerCanAdminister
+						revisions {
+							repository {
+								name
+							}
+							rev
+							latestTag
+						}
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"createVersionContext": {
+						"id": "VmVyc2lvbkNvbnRleHQ6MQ==",
+						"name": "release-2024.3",
+						"viewerCanAdminister": true,
+						"revisions": [{
+							"repository": {
+								"name": "github.com/sourcegraph/sourcegraph"
+							},
+							"rev": null,
+							"latestTag": "v2024.3.*"
+						}]
+					}
+				}
+			`,
+		},
+	})
+
+	if created == nil || created.UserID == nil || *created.UserID != 1 {
+		t.Errorf("got %+v, want a version context owned by user 1", created)
+	}
+}
+
+// 🚨 SECURITY: This tests that users can't create version contexts for other users.
+func TestMutation_CreateVersionContext_otherUser(t *testing.T) {
+	resetMocks()
+	defer resetMocks()
+
+	db.Mocks.Users.GetByCurrentAuthUser = func(ctx context.Context) (*types.User, error) {
+		return &types.User{ID: 2}, nil
+	}
+	db.Mocks.Users.GetByID = func(ctx context.Context, id int32) (*types.User, error) {
+		return &types.User{ID: id, Username: "other"}, nil
+	}
+	db.Mocks.VersionContexts.Create = func(ctx context.Context, vc *types.VersionContext) (*types.VersionContext, error) {
+		t.Fatal("version context created for another user")
+		return nil, nil
+	}
+
+	ctx := actor.WithActor(context.Background(), &actor.Actor{UID: 2})
+	args := &struct {
+		Namespace graphql.ID
+		versionContextArgs
+	}{Namespace: MarshalUserID(1), versionContextArgs: versionContextArgs{Name: "ctx"}}
+	_, err := (&schemaResolver{}).CreateVersionContext(ctx, args)
+	if _, ok := err.(*backend.InsufficientAuthorizationError); !ok {
+		t.Errorf("got error %v, want %T", err, &backend.InsufficientAuthorizationError{})
+	}
+}
+
+func TestMutation_CreateVersionContext_configName(t *testing.T) {
+	resetMocks()
+	defer resetMocks()
+
+	conf.Mock(&conf.Unified{SiteConfiguration: schema.SiteConfiguration{
+		ExperimentalFeatures: &schema.ExperimentalFeatures{
+			VersionContexts: []*schema.VersionContext{{Name: "Release"}},
+		},
+	}})
+	defer conf.Mock(nil)
+
+	ctx := actor.WithActor(context.Background(), &actor.Actor{UID: 1})
+	args := &struct {
+		Namespace graphql.ID
+		versionContextArgs
+	}{Namespace: MarshalUserID(1), versionContextArgs: versionContextArgs{Name: "release"}}
+	if _, err := (&schemaResolver{}).CreateVersionContext(ctx, args); err != db.ErrVersionContextNameAlreadyExists {
+		t.Errorf("got error %v, want %v", err, db.ErrVersionContextNameAlreadyExists)
+	}
+}
+
+func TestVersionContexts(t *testing.T) {
+	resetMocks()
+	defer resetMocks()
+
+	conf.Mock(&conf.Unified{SiteConfiguration: schema.SiteConfiguration{
+		ExperimentalFeatures: &schema.ExperimentalFeatures{
+			VersionContexts: []*schema.VersionContext{{Name: "config"}},
+		},
+	}})
+	defer conf.Mock(nil)
+
+	db.Mocks.Users.GetByCurrentAuthUser = func(ctx context.Context) (*types.User, error) {
+		return &types.User{ID: 1}, nil
+	}
+	db.Mocks.Orgs.GetByUserID = func(ctx context.Context, userID int32) ([]*types.Org, error) {
+		return []*types.Org{{ID: 2}}, nil
+	}
+	db.Mocks.VersionContexts.List = func(ctx context.Context, opt db.VersionContextsListOptions) ([]*types.VersionContext, error) {
+		if opt.UserID != 1 || len(opt.OrgIDs) != 1 || opt.OrgIDs[0] != 2 {
+			t.Errorf("got options %+v, want the user and their org", opt)
+		}
+		orgID := int32(2)
+		return []*types.VersionContext{{ID: 1, Name: "db", OrgID: &orgID}}, nil
+	}
+
+	vcs, err := (&schemaResolver{}).VersionContexts(actor.WithActor(context.Background(), &actor.Actor{UID: 1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, vc := range vcs {
+		got = append(got, string(vc.ID()))
+	}
+	if want := []string{"config", "VmVyc2lvbkNvbnRleHQ6MQ=="}; len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("got IDs %v, want %v", got, want)
+	}
+}
@@ -4,22 +4,29 @@ import (
 	"context"
 	"fmt"
 	"net/url"
+	"os"
 	"regexp/syntax"
 	"strings"
+	"sync"
 	"time"
 	"unicode/utf8"
 
 	"github.com/google/zoekt"
 	zoektquery "github.com/google/zoekt/query"
 	"github.com/inconshreveable/log15"
+	"github.com/neelance/parallel"
 	"github.com/pkg/errors"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
 	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
 	"github.com/sourcegraph/sourcegraph/internal/gituri"
+	"github.com/sourcegraph/sourcegraph/internal/goroutine"
+	"github.com/sourcegraph/sourcegraph/internal/linguist"
 	"github.com/sourcegraph/sourcegraph/internal/search"
 	"github.com/sourcegraph/sourcegraph/internal/search/query"
 	"github.com/sourcegraph/sourcegraph/internal/symbols/protocol"
 	"github.com/sourcegraph/sourcegraph/internal/trace"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
 )
 
 type indexedRequestType string
@@ -215,6 +222,17 @@ func zoektSearchOpts(k int, query *search.TextPatternInfo) zoekt.SearchOptions {
 		searchOpts.MaxDocDisplayCount = 2000
 	}
 
+	// Files that don't satisfy the filters that Zoekt can't evaluate are
+	// dropped by filterZoektFiles, so ask for more files to still fill
+	// FileMatchLimit.
+	if query.MinFileSize != nil || query.MaxFileSize != nil || query.ExcludeGenerated {
+		searchOpts.MaxDocDisplayCount *= 2
+	}
+
+	// Whether a file is generated is decided by its contents, such as a
+	// "Code generated" comment, unless .gitattributes decides it.
+	searchOpts.Whole = query.ExcludeGenerated
+
 	if userProbablyWantsToWaitLonger := query.FileMatchLimit > defaultMaxSearchResults; userProbablyWantsToWaitLonger {
 		searchOpts.MaxWallTime *= time.Duration(3 * float64(query.FileMatchLimit) / float64(defaultMaxSearchResults))
 	}
@@ -281,8 +299,20 @@ func zoektSearch(ctx context.Context, args *search.TextParameters, repos *indexe
 		}
 	}
 
+	// Zoekt returns at most MaxDocDisplayCount files. If it returned that many
+	// and some of them are filtered out, files that Zoekt did not return may
+	// satisfy the filters, so the results are not complete.
+	zoektFull := len(resp.Files) >= searchOpts.MaxDocDisplayCount
+	unfiltered := len(resp.Files)
+	resp.Files = filterZoektFiles(ctx, args.PatternInfo, resp.Files)
+	if zoektFull && len(resp.Files) < unfiltered && !limitHit {
+		limitHit = true
+		for _, file := range resp.Files {
+			reposLimitHit[file.Repository] = struct{}{}
+		}
+	}
 	if len(resp.Files) == 0 {
-		return nil, false, nil, nil
+		return nil, limitHit, nil, nil
 	}
 
 	maxLineMatches := 25 + k
@@ -308,6 +338,13 @@ func zoektSearch(ctx context.Context, args *search.TextParameters, repos *indexe
 		limitHit = true
 	}
 
+	// Zoekt only reports the offsets of matches, so we find the values of
+	// capture groups in the matched lines ourselves.
+	captureGroupRegexp, err := compileCaptureGroupRegexp(args.PatternInfo)
+	if err != nil {
+		return nil, false, nil, err
+	}
+
 	matches := make([]*FileMatchResolver, 0, len(resp.Files))
 	repoResolvers := make(RepositoryResolverCache)
 	for _, file := range resp.Files {
@@ -330,6 +367,11 @@ func zoektSearch(ctx context.Context, args *search.TextParameters, repos *indexe
 		if typ != symbolRequest {
 			lines, matchCount = zoektFileMatchToLineMatches(maxLineFragmentMatches, &file)
 		}
+		if captureGroupRegexp != nil {
+			for _, lm := range lines {
+				addCaptureGroups(captureGroupRegexp, lm)
+			}
+		}
 
 		for _, inputRev := range inputRevs {
 			inputRev := inputRev // copy so we can take the pointer
@@ -565,6 +607,107 @@ func queryToZoektQuery(query *search.TextPatternInfo, typ indexedRequestType) (z
 	return zoektquery.Simplify(zoektquery.NewAnd(and...)), nil
 }
 
+// filterZoektFiles returns the files that satisfy the filters of query that
+// Zoekt can't evaluate: the bounds of file sizes, which it doesn't index, and
+// whether files are generated. Like in searcher, a file is generated if the
+// root .gitattributes of its repository says so, and otherwise if
+// linguist.IsGenerated detects it from its path and contents. The files of a
+// repository are kept if its metadata can't be read, so the filters never
+// fail a search.
+func filterZoektFiles(ctx context.Context, query *search.TextPatternInfo, files []zoekt.FileMatch) []zoekt.FileMatch {
+	if query.MinFileSize == nil && query.MaxFileSize == nil && !query.ExcludeGenerated {
+		return files
+	}
+
+	type repoCommit struct {
+		repo   api.RepoName
+		commit api.CommitID
+	}
+	byCommit := map[repoCommit][]zoekt.FileMatch{}
+	for _, file := range files {
+		key := repoCommit{repo: api.RepoName(file.Repository), commit: api.CommitID(file.Version)}
+		byCommit[key] = append(byCommit[key], file)
+	}
+
+	var (
+		mu       sync.Mutex
+		excluded = make(map[repoCommit]map[string]bool, len(byCommit))
+		run      = parallel.NewRun(8) // number of concurrent gitserver requests
+	)
+	for key, files := range byCommit {
+		key, files := key, files
+		run.Acquire()
+		goroutine.Go(func() {
+			defer run.Release()
+
+			paths, err := zoektExcludedFiles(ctx, query, gitserver.Repo{Name: key.repo}, key.commit, files)
+			if err != nil {
+				log15.Warn("failed to filter indexed search results by file size and generated files", "repo", key.repo, "commit", key.commit, "error", err)
+				return
+			}
+			mu.Lock()
+			excluded[key] = paths
+			mu.Unlock()
+		})
+	}
+	_ = run.Wait()
+
+	filtered := files[:0]
+	for _, file := range files {
+		key := repoCommit{repo: api.RepoName(file.Repository), commit: api.CommitID(file.Version)}
+		if !excluded[key][file.FileName] {
+			// The contents were only requested to tell whether the file
+			// is generated.
+			file.Content = nil
+			filtered = append(filtered, file)
+		}
+	}
+	return filtered
+}
+
+// maxGitAttributesSize is the maximum number of bytes of a .gitattributes
+// file that are read.
+const maxGitAttributesSize = 1 << 20
+
+// zoektExcludedFiles returns the paths of the files of repo at commit that
+// don't satisfy the file size bounds of query, or that are generated if query
+// excludes generated files.
+func zoektExcludedFiles(ctx context.Context, query *search.TextPatternInfo, repo gitserver.Repo, commit api.CommitID, files []zoekt.FileMatch) (map[string]bool, error) {
+	excluded := map[string]bool{}
+	if query.MinFileSize != nil || query.MaxFileSize != nil {
+		paths := make([]string, len(files))
+		for i, file := range files {
+			paths[i] = file.FileName
+		}
+		sizes, err := git.FileSizes(ctx, repo, commit, paths)
+		if err != nil {
+			return nil, err
+		}
+		for path, size := range sizes {
+			if (query.MinFileSize != nil && size < *query.MinFileSize) || (query.MaxFileSize != nil && size > *query.MaxFileSize) {
+				excluded[path] = true
+			}
+		}
+	}
+	if query.ExcludeGenerated {
+		data, err := git.ReadFile(ctx, repo, commit, linguist.GitAttributesPath, maxGitAttributesSize)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		attributes := linguist.ParseAttributes(data)
+		for _, file := range files {
+			generated, ok := attributes.Generated(file.FileName)
+			if !ok {
+				generated = linguist.IsGenerated(file.FileName, file.Content)
+			}
+			if generated {
+				excluded[file.FileName] = true
+			}
+		}
+	}
+	return excluded, nil
+}
+
 // zoektIndexedRepos splits the revs into two parts: (1) the repository
 // revisions in indexedSet (indexed) and (2) the repositories that are
 // unindexed.
@@ -678,8 +821,14 @@ func (rb *indexedRepoRevs) Add(reporev *search.RepositoryRevisions, repo *zoekt.
 		}
 	}
 
-	// We found indexed branches! Track them.
+	// We found indexed branches! Track them. Only the indexed revisions are
+	// kept, so that repoBranches[i] <-> repoRevs.Revs[i] holds.
 	if len(indexed) > 0 {
+		if len(unindexed) > 0 {
+			copy := *reporev
+			copy.Revs = indexed
+			reporev = &copy
+		}
 		rb.repoRevs[string(reporev.Repo.Name)] = reporev
 		rb.repoBranches[string(reporev.Repo.Name)] = branches
 		rb.NotHEADOnlySearch = rb.NotHEADOnlySearch || notHEADOnlySearch
@@ -2,9 +2,11 @@ package graphqlbackend
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"sort"
 	"strings"
 	"testing"
@@ -22,10 +24,12 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/db/dbconn"
 	"github.com/sourcegraph/sourcegraph/internal/db/dbtesting"
 	"github.com/sourcegraph/sourcegraph/internal/extsvc"
+	"github.com/sourcegraph/sourcegraph/internal/linguist"
 	"github.com/sourcegraph/sourcegraph/internal/search"
 	searchbackend "github.com/sourcegraph/sourcegraph/internal/search/backend"
 	"github.com/sourcegraph/sourcegraph/internal/search/query"
 	"github.com/sourcegraph/sourcegraph/internal/symbols/protocol"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
 	"github.com/sourcegraph/sourcegraph/schema"
 )
 
@@ -326,6 +330,70 @@ func TestIndexedSearch(t *testing.T) {
 	}
 }
 
+func TestIndexedSearch_filteredLimitHit(t *testing.T) {
+	defer func() { git.Mocks.FileSizes = nil }()
+	// The first 50 files are small enough.
+	git.Mocks.FileSizes = func(commit api.CommitID, paths []string) (map[string]int64, error) {
+		sizes := map[string]int64{}
+		for _, path := range paths {
+			var i int
+			fmt.Sscanf(path, "%d.go", &i)
+			sizes[path] = 1
+			if i >= 50 {
+				sizes[path] = 100
+			}
+		}
+		return sizes, nil
+	}
+
+	searchFiles := func(n int) (int, bool) {
+		t.Helper()
+		files := make([]zoekt.FileMatch, n)
+		for i := range files {
+			files[i] = zoekt.FileMatch{Repository: "foo/bar", Branches: []string{"HEAD"}, FileName: fmt.Sprintf("%d.go", i)}
+		}
+		q, err := query.ParseAndCheck("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		max := int64(10)
+		args := &search.TextParameters{
+			Query:       q,
+			PatternInfo: &search.TextPatternInfo{FileMatchLimit: 100, MaxFileSize: &max},
+			Repos:       makeRepositoryRevisions("foo/bar"),
+			Zoekt: &searchbackend.Zoekt{
+				Client: &fakeSearcher{
+					result: &zoekt.SearchResult{Files: files},
+					repos:  []*zoekt.RepoListEntry{{Repository: zoekt.Repository{Name: "foo/bar", Branches: []zoekt.RepositoryBranch{{Name: "HEAD", Version: "c"}}}}},
+				},
+				DisableCache: true,
+			},
+		}
+		indexed, err := newIndexedSearchRequest(context.Background(), args, textRequest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		indexed.since = func(time.Time) time.Duration { return 0 }
+		fm, limitHit, _, err := indexed.Search(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(fm), limitHit
+	}
+
+	// Zoekt returned all the files it found, so the filtered results are
+	// complete.
+	if got, limitHit := searchFiles(100); got != 50 || limitHit {
+		t.Errorf("got %d files, limitHit %v, want 50 files without limitHit", got, limitHit)
+	}
+
+	// Zoekt stopped at the maximum number of files, of which filtering left
+	// fewer than FileMatchLimit, so other files may match.
+	if got, limitHit := searchFiles(4000); got != 50 || !limitHit {
+		t.Errorf("got %d files, limitHit %v, want 50 files with limitHit", got, limitHit)
+	}
+}
+
 func TestZoektIndexedRepos(t *testing.T) {
 	repos := makeRepositoryRevisions(
 		"foo/indexed-one@",
@@ -401,6 +469,34 @@ func TestZoektIndexedRepos(t *testing.T) {
 	}
 }
 
+func TestZoektIndexedRepos_partiallyIndexed(t *testing.T) {
+	zoektRepos := map[string]*zoekt.Repository{
+		"foo/indexed": {
+			Name: "foo/indexed",
+			Branches: []zoekt.RepositoryBranch{
+				{Name: "HEAD", Version: "deadbeef"},
+				{Name: "release/1", Version: "deadcow"},
+			},
+		},
+	}
+	repos := makeRepositoryRevisions("foo/indexed@missing:release/1")
+
+	indexed, unindexed := zoektIndexedRepos(zoektRepos, repos, nil)
+
+	// Only the indexed revisions are searched with zoekt, in the same order
+	// as the branches.
+	wantIndexed := makeRepositoryRevisions("foo/indexed@release/1")
+	if diff := cmp.Diff(repoRevsSliceToMap(wantIndexed), indexed.repoRevs); diff != "" {
+		t.Error("unexpected indexed:", diff)
+	}
+	if diff := cmp.Diff([]string{"release/1"}, indexed.repoBranches["foo/indexed"]); diff != "" {
+		t.Error("unexpected branches:", diff)
+	}
+	if diff := cmp.Diff(makeRepositoryRevisions("foo/indexed@missing"), unindexed); diff != "" {
+		t.Error("unexpected unindexed:", diff)
+	}
+}
+
 func Benchmark_zoektIndexedRepos(b *testing.B) {
 	repoNames := []string{}
 	zoektRepos := map[string]*zoekt.Repository{}
@@ -979,3 +1075,81 @@ func repoRevsSliceToMap(rs []*search.RepositoryRevisions) map[string]*search.Rep
 	}
 	return m
 }
+
+func TestQueryToZoektQuery_excludeGenerated(t *testing.T) {
+	pattern := &search.TextPatternInfo{IsRegExp: true, Pattern: "foo"}
+	base, err := queryToZoektQuery(pattern, textRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Generated files are excluded by filterZoektFiles, which needs their
+	// contents, not by the query.
+	pattern.ExcludeGenerated = true
+	got, err := queryToZoektQuery(pattern, textRequest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !queryEqual(got, base) {
+		t.Errorf("got query %s, want %s", got, base)
+	}
+	if opts := zoektSearchOpts(1, pattern); !opts.Whole {
+		t.Error("got search options without Whole, want the contents of files")
+	}
+}
+
+func TestFilterZoektFiles(t *testing.T) {
+	defer git.ResetMocks()
+
+	git.Mocks.FileSizes = func(commit api.CommitID, paths []string) (map[string]int64, error) {
+		if commit == "broken" {
+			return nil, errors.New("boom")
+		}
+		sizes := map[string]int64{}
+		for _, path := range paths {
+			sizes[path] = int64(len(path))
+		}
+		return sizes, nil
+	}
+	git.Mocks.ReadFile = func(commit api.CommitID, name string) ([]byte, error) {
+		if name != linguist.GitAttributesPath {
+			t.Errorf("got unexpected read of %q", name)
+		}
+		if commit == "noattributes" {
+			return nil, &os.PathError{Op: "git show", Path: name, Err: os.ErrNotExist}
+		}
+		return []byte("api/* linguist-generated\nkeep.go -linguist-generated\n"), nil
+	}
+
+	generated := []byte("// Code generated by gen. DO NOT EDIT.\npackage a\n")
+	minified := []byte(strings.Repeat("var a=1;", 100))
+	files := []zoekt.FileMatch{
+		{Repository: "a", Version: "c1", FileName: "main.go", Content: []byte("package main\n")},
+		{Repository: "a", Version: "c1", FileName: "api/a.go"},
+		{Repository: "a", Version: "c1", FileName: "cmd/server/main.go"},
+		{Repository: "a", Version: "c1", FileName: "gen.go", Content: generated},
+		{Repository: "a", Version: "c1", FileName: "keep.go", Content: generated},
+		{Repository: "a", Version: "c1", FileName: "app.js", Content: minified},
+		{Repository: "b", Version: "noattributes", FileName: "api/b.go"},
+		{Repository: "b", Version: "noattributes", FileName: "b.pb.go"},
+		{Repository: "c", Version: "broken", FileName: "cmd/server/main.go"},
+	}
+	max := int64(10)
+	got := filterZoektFiles(context.Background(), &search.TextPatternInfo{MaxFileSize: &max, ExcludeGenerated: true}, files)
+
+	var names []string
+	for _, file := range got {
+		names = append(names, file.Repository+"/"+file.FileName)
+	}
+	// keep.go has a generated marker, but .gitattributes says that it is
+	// not generated.
+	want := []string{"a/main.go", "a/keep.go", "b/api/b.go", "c/cmd/server/main.go"}
+	if !cmp.Equal(names, want) {
+		t.Errorf("got files %v, want %v", names, want)
+	}
+	for _, file := range got {
+		if file.Content != nil {
+			t.Errorf("got the contents of %s, want them dropped", file.FileName)
+		}
+	}
+}
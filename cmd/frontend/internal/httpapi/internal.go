@@ -10,6 +10,7 @@ import (
 	"path"
 	"strconv"
 
+	"github.com/google/zoekt"
 	"github.com/gorilla/mux"
 	"github.com/inconshreveable/log15"
 	"github.com/pkg/errors"
@@ -153,7 +154,29 @@ func serveSearchConfiguration(w http.ResponseWriter, r *http.Request) error {
 		return string(commitID), err
 	}
 
-	b, err := searchbackend.GetIndexOptions(&conf.Get().SiteConfiguration, repo, getVersion)
+	listRefs := func() (*searchbackend.RepoRefs, error) {
+		gitRepo := gitserver.Repo{Name: api.RepoName(repo)}
+		branches, err := git.ListBranches(r.Context(), gitRepo, git.BranchesOptions{OrderByCommitterDate: true})
+		if err != nil {
+			return nil, err
+		}
+		tags, err := git.ListTags(r.Context(), gitRepo)
+		if err != nil {
+			return nil, err
+		}
+
+		refs := &searchbackend.RepoRefs{}
+		for _, b := range branches {
+			refs.Branches = append(refs.Branches, zoekt.RepositoryBranch{Name: b.Name, Version: string(b.Head)})
+		}
+		// ListTags orders tags from the most recently created.
+		for _, t := range tags {
+			refs.Tags = append(refs.Tags, zoekt.RepositoryBranch{Name: t.Name, Version: string(t.CommitID)})
+		}
+		return refs, nil
+	}
+
+	b, err := searchbackend.GetIndexOptions(&conf.Get().SiteConfiguration, repo, getVersion, listRefs)
 	if err != nil {
 		return err
 	}
@@ -306,10 +329,11 @@ func serveSavedQueriesSetInfo(w http.ResponseWriter, r *http.Request) error {
 		return errors.Wrap(err, "Decode")
 	}
 	err = db.QueryRunnerState.Set(r.Context(), &db.SavedQueryInfo{
-		Query:        info.Query,
-		LastExecuted: info.LastExecuted,
-		LatestResult: info.LatestResult,
-		ExecDuration: info.ExecDuration,
+		Query:              info.Query,
+		LastExecuted:       info.LastExecuted,
+		LatestResult:       info.LatestResult,
+		ExecDuration:       info.ExecDuration,
+		ResultFingerprints: info.ResultFingerprints,
 	})
 	if err != nil {
 		return errors.Wrap(err, "SavedQueries.Set")
@@ -334,6 +358,36 @@ func serveSavedQueriesDeleteInfo(w http.ResponseWriter, r *http.Request) error {
 	return nil
 }
 
+func serveSavedQueriesLogWebhookDelivery(w http.ResponseWriter, r *http.Request) error {
+	var delivery *api.SavedSearchWebhookDelivery
+	err := json.NewDecoder(r.Body).Decode(&delivery)
+	if err != nil {
+		return errors.Wrap(err, "Decode")
+	}
+	savedSearchID, err := strconv.ParseInt(delivery.Key, 10, 32)
+	if err != nil {
+		return errors.Wrap(err, "invalid saved search key")
+	}
+	d := &types.SavedSearchWebhookDelivery{
+		SavedSearchID: int32(savedSearchID),
+		URL:           delivery.URL,
+		Attempts:      int32(delivery.Attempts),
+	}
+	if delivery.StatusCode != 0 {
+		statusCode := int32(delivery.StatusCode)
+		d.StatusCode = &statusCode
+	}
+	if delivery.Error != "" {
+		d.Error = &delivery.Error
+	}
+	if _, err := db.SavedSearchWebhookDeliveries.Create(r.Context(), d); err != nil {
+		return errors.Wrap(err, "SavedSearchWebhookDeliveries.Create")
+	}
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("OK"))
+	return nil
+}
+
 func serveSettingsGetForSubject(w http.ResponseWriter, r *http.Request) error {
 	var subject api.SettingsSubject
 	if err := json.NewDecoder(r.Body).Decode(&subject); err != nil {
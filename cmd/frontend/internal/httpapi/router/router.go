@@ -10,6 +10,9 @@ const (
 	LSIFUpload = "lsif.upload"
 	GraphQL    = "graphql"
 
+	SearchStream  = "search.stream"
+	SearchPatches = "search.patches"
+
 	SrcCliVersion  = "src-cli.version"
 	SrcCliDownload = "src-cli.download"
 
@@ -27,6 +30,7 @@ const (
 	SavedQueriesGetInfo    = "internal.saved-queries.get-info"
 	SavedQueriesSetInfo    = "internal.saved-queries.set-info"
 	SavedQueriesDeleteInfo = "internal.saved-queries.delete-info"
+	SavedQueriesLogWebhook = "internal.saved-queries.log-webhook-delivery"
 	SettingsGetForSubject  = "internal.settings.get-for-subject"
 	OrgsListUsers          = "internal.orgs.list-users"
 	OrgsGetByName          = "internal.orgs.get-by-name"
@@ -71,6 +75,8 @@ func New(base *mux.Router) *mux.Router {
 	base.Path("/lsif/upload").Methods("POST").Name(LSIFUpload)
 	base.Path("/src-cli/version").Methods("GET").Name(SrcCliVersion)
 	base.Path("/src-cli/{rest:.*}").Methods("GET").Name(SrcCliDownload)
+	base.Path("/search/stream").Methods("GET").Name(SearchStream)
+	base.Path("/search/patches").Methods("GET").Name(SearchPatches)
 
 	// repo contains routes that are NOT specific to a revision. In these routes, the URL may not contain a revspec after the repo (that is, no "github.com/foo/bar@myrevspec").
 	repoPath := `/repos/` + routevar.Repo
@@ -96,6 +102,7 @@ func NewInternal(base *mux.Router) *mux.Router {
 	base.Path("/saved-queries/get-info").Methods("POST").Name(SavedQueriesGetInfo)
 	base.Path("/saved-queries/set-info").Methods("POST").Name(SavedQueriesSetInfo)
 	base.Path("/saved-queries/delete-info").Methods("POST").Name(SavedQueriesDeleteInfo)
+	base.Path("/saved-queries/log-webhook-delivery").Methods("POST").Name(SavedQueriesLogWebhook)
 	base.Path("/settings/get-for-subject").Methods("POST").Name(SettingsGetForSubject)
 	base.Path("/orgs/list-users").Methods("POST").Name(OrgsListUsers)
 	base.Path("/orgs/get-by-name").Methods("POST").Name(OrgsGetByName)
@@ -0,0 +1,58 @@
+package httpapi
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend"
+)
+
+// mockSearchCodemodPatches, if non-nil, replaces
+// graphqlbackend.SearchCodemodPatches in tests.
+var mockSearchCodemodPatches func(ctx context.Context, args *graphqlbackend.SearchArgs) ([]graphqlbackend.CodemodPatch, error)
+
+// serveSearchPatches runs a search with a replace: field and responds with a
+// zip archive that contains one patch per repository revision, named
+// REPO@COMMIT.patch. Each patch can be applied to the repository with git
+// apply. The query parameters are the same as for serveSearchStream.
+func serveSearchPatches(w http.ResponseWriter, r *http.Request) error {
+	args := searchArgsFromQuery(r.URL.Query())
+	if args.Query == "" {
+		http.Error(w, "missing query parameter q", http.StatusBadRequest)
+		return nil
+	}
+
+	searchCodemodPatches := graphqlbackend.SearchCodemodPatches
+	if mockSearchCodemodPatches != nil {
+		searchCodemodPatches = mockSearchCodemodPatches
+	}
+	patches, err := searchCodemodPatches(r.Context(), args)
+	if err != nil {
+		return err
+	}
+
+	// Build the archive in memory so that a failure results in an error
+	// response rather than a truncated archive.
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, patch := range patches {
+		f, err := zw.Create(fmt.Sprintf("%s@%s.patch", patch.Repository, patch.Commit))
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write([]byte(patch.Diff)); err != nil {
+			return err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="patches.zip"`)
+	_, err = w.Write(buf.Bytes())
+	return err
+}
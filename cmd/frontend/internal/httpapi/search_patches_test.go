@@ -0,0 +1,64 @@
+package httpapi
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend"
+)
+
+func TestServeSearchPatches(t *testing.T) {
+	mockSearchCodemodPatches = func(ctx context.Context, args *graphqlbackend.SearchArgs) ([]graphqlbackend.CodemodPatch, error) {
+		if args.Query != `"foo()" replace:"bar()"` || args.PatternType == nil || *args.PatternType != "structural" {
+			t.Errorf("unexpected search args %+v", args)
+		}
+		return []graphqlbackend.CodemodPatch{
+			{Repository: "github.com/a/b", Commit: "c0ffee", Diff: "diff --git a/x b/x\n"},
+			{Repository: "github.com/c/d", Commit: "deadbeef", Diff: "diff --git a/y b/y\n"},
+		}, nil
+	}
+	defer func() { mockSearchCodemodPatches = nil }()
+
+	req := httptest.NewRequest("GET", `/search/patches?q="foo()"+replace:"bar()"&t=structural`, nil)
+	rec := httptest.NewRecorder()
+	if err := serveSearchPatches(rec, req); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := rec.Header().Get("Content-Type"), "application/zip"; got != want {
+		t.Errorf("got content type %q, want %q", got, want)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := ioutil.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got[f.Name] = string(b)
+	}
+	want := map[string]string{
+		"github.com/a/b@c0ffee.patch":   "diff --git a/x b/x\n",
+		"github.com/c/d@deadbeef.patch": "diff --git a/y b/y\n",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got files %v, want %v", got, want)
+	}
+	for name, content := range want {
+		if got[name] != content {
+			t.Errorf("got %q for %s, want %q", got[name], name, content)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/inconshreveable/log15"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend"
+)
+
+// mockStreamSearch, if non-nil, replaces graphqlbackend.StreamSearch in tests.
+var mockStreamSearch func(args *graphqlbackend.SearchArgs, send graphqlbackend.StreamSender)
+
+// serveSearchStream streams search results as Server-Sent Events. Results are
+// sent as soon as a search backend produces them, see
+// graphqlbackend.StreamSearch for the events sent. The query parameters are:
+//
+// - q: the search query
+// - v: the search version (V1 or V2, default V2)
+// - t: the pattern type (literal, regexp or structural)
+// - vc: the version context
+func serveSearchStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "http flushing not supported", http.StatusInternalServerError)
+		return
+	}
+
+	args := searchArgsFromQuery(r.URL.Query())
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	var writeErr error
+	send := func(event string, data interface{}) {
+		if writeErr != nil {
+			// The client went away, drop remaining events.
+			return
+		}
+		b, err := json.Marshal(data)
+		if err != nil {
+			log15.Error("search stream: failed to marshal event", "event", event, "error", err)
+			return
+		}
+		if _, writeErr = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, b); writeErr != nil {
+			return
+		}
+		flusher.Flush()
+	}
+
+	if mockStreamSearch != nil {
+		mockStreamSearch(args, send)
+		return
+	}
+	graphqlbackend.StreamSearch(r.Context(), args, send)
+}
+
+// searchArgsFromQuery returns the search arguments described by the query
+// parameters q, v, t and vc, see serveSearchStream.
+func searchArgsFromQuery(params url.Values) *graphqlbackend.SearchArgs {
+	args := &graphqlbackend.SearchArgs{
+		Version: "V2",
+		Query:   params.Get("q"),
+	}
+	if v := params.Get("v"); v != "" {
+		args.Version = v
+	}
+	if t := params.Get("t"); t != "" {
+		args.PatternType = &t
+	}
+	if vc := params.Get("vc"); vc != "" {
+		args.VersionContext = &vc
+	}
+	return args
+}
@@ -0,0 +1,42 @@
+package httpapi
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend"
+)
+
+func TestServeSearchStream(t *testing.T) {
+	mockStreamSearch = func(args *graphqlbackend.SearchArgs, send graphqlbackend.StreamSender) {
+		if args.Query != "foo" || args.Version != "V2" || args.PatternType == nil || *args.PatternType != "regexp" {
+			t.Errorf("unexpected search args %+v", args)
+		}
+		send(graphqlbackend.StreamEventMatches, []graphqlbackend.StreamMatch{{Type: "repo", Repository: "r"}})
+		send(graphqlbackend.StreamEventProgress, graphqlbackend.StreamProgress{Searched: 1})
+		send(graphqlbackend.StreamEventDone, graphqlbackend.StreamDone{ResultCount: 1})
+	}
+	defer func() { mockStreamSearch = nil }()
+
+	req := httptest.NewRequest("GET", "/search/stream?q=foo&t=regexp", nil)
+	rec := httptest.NewRecorder()
+	serveSearchStream(rec, req)
+
+	if got, want := rec.Header().Get("Content-Type"), "text/event-stream"; got != want {
+		t.Errorf("got content type %q, want %q", got, want)
+	}
+	want := `event: matches
+data: [{"type":"repo","repository":"r"}]
+
+event: progress
+data: {"repositoriesCount":0,"searched":1,"indexed":0,"cloning":0,"missing":0,"timedout":0,"limitHit":false}
+
+event: done
+data: {"repositoriesCount":0,"searched":0,"indexed":0,"cloning":0,"missing":0,"timedout":0,"limitHit":false,"resultCount":1,"elapsedMilliseconds":0}
+
+`
+	if diff := cmp.Diff(want, rec.Body.String()); diff != "" {
+		t.Error(diff)
+	}
+}
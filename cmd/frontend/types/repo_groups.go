@@ -0,0 +1,26 @@
+package types
+
+import (
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+)
+
+// RepoGroup is a repository group stored in the database. Repository groups
+// from the search.repositoryGroups setting are not represented by this type.
+//
+// The repositories of a group are the union of the repositories listed in
+// RepoIDs, the repositories whose names match Pattern and the repositories
+// synced from the external service ExternalServiceID.
+type RepoGroup struct {
+	ID                int32 // the globally unique DB ID
+	Name              string
+	Description       string
+	UserID            *int32       // if non-nil, the owner is this user. UserID/OrgID are mutually exclusive.
+	OrgID             *int32       // if non-nil, the owner is this organization. UserID/OrgID are mutually exclusive.
+	RepoIDs           []api.RepoID // the explicitly listed repositories
+	Pattern           string       // a regular expression that matches repository names, if non-empty
+	ExternalServiceID *int64       // if non-nil, all repositories synced from this external service are included
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+}
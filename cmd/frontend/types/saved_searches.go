@@ -1,5 +1,7 @@
 package types
 
+import "time"
+
 // SavedSearch represents a saved search
 type SavedSearch struct {
 	ID              int32 // the globally unique DB ID
@@ -10,4 +12,19 @@ type SavedSearch struct {
 	UserID          *int32  // if non-nil, the owner is this user. UserID/OrgID are mutually exclusive.
 	OrgID           *int32  // if non-nil, the owner is this organization. UserID/OrgID are mutually exclusive.
 	SlackWebhookURL *string // if non-nil && NotifySlack == true, indicates that this Slack webhook URL should be used instead of the owners default Slack webhook.
+	NotifyWebhook   bool    // whether or not to POST new results of this saved search to WebhookURL
+	WebhookURL      *string // the URL of the outgoing webhook, if NotifyWebhook == true
+	WebhookSecret   *string // if non-nil, the secret used to sign outgoing webhook payloads
+}
+
+// SavedSearchWebhookDelivery is a record of a notification for a saved search
+// that was sent to its outgoing webhook.
+type SavedSearchWebhookDelivery struct {
+	ID            int64
+	SavedSearchID int32
+	URL           string    // the URL the notification was POSTed to
+	Attempts      int32     // the number of attempts made, including retries
+	StatusCode    *int32    // the HTTP status code of the last attempt, if any response was received
+	Error         *string   // if non-nil, the delivery failed with this error
+	CreatedAt     time.Time // when the last attempt finished
 }
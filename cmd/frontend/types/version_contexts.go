@@ -0,0 +1,32 @@
+package types
+
+import (
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+)
+
+// VersionContext is a version context stored in the database, which pins
+// repositories to revisions. Version contexts from the site configuration are
+// represented by schema.VersionContext.
+type VersionContext struct {
+	ID          int32 // the globally unique DB ID
+	Name        string
+	Description string
+	UserID      *int32 // if non-nil, the owner is this user. UserID/OrgID are mutually exclusive.
+	OrgID       *int32 // if non-nil, the owner is this organization. UserID/OrgID are mutually exclusive.
+	Revisions   []*VersionContextRevision
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// VersionContextRevision pins a repository of a version context. At most one
+// of Rev, RefGlob and LatestTag is set. If none is set, the repository is
+// pinned to its default branch.
+type VersionContextRevision struct {
+	RepoID    api.RepoID
+	Repo      api.RepoName // the name of the repository, which is not stored with the revision
+	Rev       string       // a revision, such as a tag, branch or commit
+	RefGlob   string       // a glob of refs, such as "refs/heads/release/*"
+	LatestTag string       // a glob of tag names, of which the most recently created matching tag is used
+}
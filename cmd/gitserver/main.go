@@ -2,6 +2,7 @@
 package main // import "github.com/sourcegraph/sourcegraph/cmd/gitserver"
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net"
@@ -9,6 +10,7 @@ import (
 	"os"
 	"os/signal"
 	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -19,6 +21,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/cmd/gitserver/server"
 	"github.com/sourcegraph/sourcegraph/internal/debugserver"
 	"github.com/sourcegraph/sourcegraph/internal/env"
+	gitserverclient "github.com/sourcegraph/sourcegraph/internal/gitserver"
 	"github.com/sourcegraph/sourcegraph/internal/trace/ot"
 	"github.com/sourcegraph/sourcegraph/internal/tracer"
 )
@@ -28,6 +31,8 @@ var (
 	runRepoCleanup, _ = strconv.ParseBool(env.Get("SRC_RUN_REPO_CLEANUP", "", "Periodically remove inactive repositories."))
 	wantPctFree       = env.Get("SRC_REPOS_DESIRED_PERCENT_FREE", "10", "Target percentage of free space on disk.")
 	janitorInterval   = env.Get("SRC_REPOS_JANITOR_INTERVAL", "1m", "Interval between cleanup runs")
+	rebalanceAddrs    = env.Get("SRC_REBALANCE_GIT_SERVERS", "", "Space-separated list of the gitserver addresses to copy repositories to before changing SRC_GIT_SERVERS.")
+	rebalanceHashing  = env.Get("SRC_REBALANCE_GIT_SERVER_HASHING", "", "The hashing (modulo or rendezvous) to copy repositories to before changing the gitServerHashing site configuration setting.")
 )
 
 func main() {
@@ -50,6 +55,17 @@ func main() {
 		ReposDir:                reposDir,
 		DeleteStaleRepositories: runRepoCleanup,
 		DesiredPercentFree:      wantPctFree2,
+		RebalanceAddrs:          strings.Fields(rebalanceAddrs),
+	}
+	if rebalanceHashing != "" {
+		h, ok := gitserverclient.ParseHashing(rebalanceHashing)
+		if !ok {
+			log.Fatalf("invalid $SRC_REBALANCE_GIT_SERVER_HASHING: %q", rebalanceHashing)
+		}
+		gitserver.RebalanceHashing = h
+	}
+	if gitserver.Hostname, err = os.Hostname(); err != nil {
+		log.Fatalf("failed to get hostname: %s", err)
 	}
 	gitserver.RegisterMetrics()
 
@@ -76,6 +92,16 @@ func main() {
 			time.Sleep(janitorInterval2)
 		}
 	}()
+	if len(gitserver.RebalanceAddrs) > 0 || gitserver.RebalanceHashing != "" {
+		go func() {
+			for {
+				if err := gitserver.Rebalance(context.Background()); err != nil {
+					log15.Error("git-server: rebalancing failed", "error", err)
+				}
+				time.Sleep(janitorInterval2)
+			}
+		}()
+	}
 
 	port := "3178"
 	host := ""
@@ -0,0 +1,212 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	otlog "github.com/opentracing/opentracing-go/log"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
+	"github.com/sourcegraph/sourcegraph/internal/repotrackutil"
+	"github.com/sourcegraph/sourcegraph/internal/trace"
+)
+
+// batchExecAllowlist are the git commands that a batch exec request may run.
+// They only read the repository.
+var batchExecAllowlist = map[string]bool{
+	"blame":        true,
+	"cat-file":     true,
+	"diff":         true,
+	"diff-tree":    true,
+	"for-each-ref": true,
+	"log":          true,
+	"ls-tree":      true,
+	"merge-base":   true,
+	"rev-list":     true,
+	"rev-parse":    true,
+	"show":         true,
+	"show-ref":     true,
+}
+
+// batchExecDenylist are the argument prefixes of allowed git commands that
+// write files or read files outside of the repository.
+var batchExecDenylist = []string{
+	"--output",           // log, show, diff: write the output to a file
+	"--no-index",         // diff: compare files outside of the repository
+	"-O",                 // diff: read the order of files from a file
+	"--contents",         // blame: read the file contents from a file
+	"--ignore-revs-file", // blame: read revisions to ignore from a file
+}
+
+// checkBatchExecCommand returns a non-nil error if args is not allowed in a
+// batch exec request.
+func checkBatchExecCommand(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("empty command")
+	}
+	if !batchExecAllowlist[args[0]] {
+		return fmt.Errorf("git command %q is not allowed in a batch", args[0])
+	}
+	for _, arg := range args[1:] {
+		if arg == "--" {
+			// Paths follow.
+			break
+		}
+		for _, prefix := range batchExecDenylist {
+			if strings.HasPrefix(arg, prefix) {
+				return fmt.Errorf("git argument %q is not allowed in a batch", arg)
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Server) handleBatchExec(w http.ResponseWriter, r *http.Request) {
+	var req protocol.BatchExecRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(req.Commands) == 0 {
+		http.Error(w, "no commands", http.StatusBadRequest)
+		return
+	}
+	for _, args := range req.Commands {
+		if err := checkBatchExecCommand(args); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if err := checkSpecArgSafety(req.Revision); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.batchExec(w, r, &req)
+}
+
+func (s *Server) batchExec(w http.ResponseWriter, r *http.Request, req *protocol.BatchExecRequest) {
+	req.Repo = protocol.NormalizeRepo(req.Repo)
+
+	tr, ctx := trace.New(r.Context(), "batchExec", string(req.Repo))
+	tr.LogFields(
+		otlog.Int("commands", len(req.Commands)),
+		otlog.String("revision", req.Revision),
+		otlog.String("ensure_revision", req.EnsureRevision),
+	)
+	defer tr.Finish()
+
+	dir := s.dir(req.Repo)
+	if !repoCloned(dir) {
+		tr.LogFields(otlog.String("status", s.writeNotCloned(ctx, w, req.Repo, req.URL, dir)))
+		return
+	}
+
+	s.ensureRevision(ctx, req.Repo, req.URL, req.EnsureRevision, dir)
+
+	var commit string
+	if req.Revision != "" {
+		var err error
+		commit, err = resolveCommit(ctx, dir, req.Revision)
+		if err != nil {
+			tr.LogFields(otlog.String("status", "revision-not-found"))
+			w.WriteHeader(http.StatusNotFound)
+			_ = json.NewEncoder(w).Encode(&protocol.NotFoundPayload{RevisionNotFound: true})
+			return
+		}
+		w.Header().Set("X-Exec-Commit", commit)
+	}
+
+	// Flush writes more aggressively than standard net/http so that clients
+	// see the results of the first commands while the later ones run.
+	if fw := newFlushingResponseWriter(w); fw != nil {
+		w = fw
+		defer fw.Close()
+	}
+	w.WriteHeader(http.StatusOK)
+
+	partialClone := isPartialClone(dir)
+	enc := json.NewEncoder(w)
+	for _, args := range req.Commands {
+		if commit != "" {
+			args = replaceBatchExecCommit(args, commit)
+		}
+		result := runBatchExecCommand(ctx, req.Repo, dir, args, partialClone)
+		if err := enc.Encode(result); err != nil {
+			// The client went away.
+			tr.SetError(err)
+			return
+		}
+	}
+}
+
+// resolveCommit returns the ID of the commit that rev refers to in the
+// repository in dir.
+func resolveCommit(ctx context.Context, dir GitDir, rev string) (string, error) {
+	if rev == "HEAD" {
+		if resolved, err := quickRevParseHead(dir); err == nil && isAbsoluteRevision(resolved) {
+			return resolved, nil
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, shortGitCommandTimeout([]string{"rev-parse"}))
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "git", "rev-parse", "--verify", rev+"^{commit}")
+	dir.Set(cmd)
+	out, err := cmd.Output()
+	if err != nil {
+		return "", wrapCmdError(cmd, err)
+	}
+	commit := string(bytes.TrimSpace(out))
+	if !isAbsoluteRevision(commit) {
+		return "", fmt.Errorf("unexpected output from git rev-parse: %q", commit)
+	}
+	return commit, nil
+}
+
+func replaceBatchExecCommit(args []string, commit string) []string {
+	replaced := make([]string, len(args))
+	for i, arg := range args {
+		replaced[i] = strings.Replace(arg, protocol.BatchExecCommit, commit, -1)
+	}
+	return replaced
+}
+
+func runBatchExecCommand(ctx context.Context, repo api.RepoName, dir GitDir, args []string, partialClone bool) *protocol.BatchExecResult {
+	ctx, cancel := context.WithTimeout(ctx, shortGitCommandTimeout(args))
+	defer cancel()
+
+	trackedRepo := repotrackutil.GetTrackedRepo(repo)
+	execRunning.WithLabelValues(args[0], trackedRepo).Inc()
+	defer execRunning.WithLabelValues(args[0], trackedRepo).Dec()
+	start := time.Now()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", args...)
+	dir.Set(cmd)
+	if partialClone {
+		// git fetches the objects that are missing from a partial clone
+		// from the code host when the command reads them.
+		configureRemoteGitCommand(cmd, tlsExternal().(*tlsConfig))
+	}
+	cmd.Stdout = &stdout
+	cmd.Stderr = &limitWriter{W: &stderr, N: 1024}
+
+	exitStatus, err := runCommand(ctx, cmd)
+	status := strconv.Itoa(exitStatus)
+	execDuration.WithLabelValues(args[0], trackedRepo, status).Observe(time.Since(start).Seconds())
+	checkMaybeCorruptRepo(repo, dir, stderr.String())
+
+	return &protocol.BatchExecResult{
+		Stdout:     stdout.Bytes(),
+		Stderr:     stderr.String(),
+		ExitStatus: exitStatus,
+		Error:      errorString(err),
+	}
+}
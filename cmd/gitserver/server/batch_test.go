@@ -0,0 +1,125 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
+)
+
+func TestCheckBatchExecCommand(t *testing.T) {
+	for _, test := range []struct {
+		args    []string
+		wantErr bool
+	}{
+		{args: []string{"rev-parse", "HEAD"}},
+		{args: []string{"show", "--format=%H", "{commit}:README.md"}},
+		{args: []string{"log", "-n1", "--", "--output=file"}},
+		{args: nil, wantErr: true},
+		{args: []string{"fetch", "origin"}, wantErr: true},
+		{args: []string{"symbolic-ref", "HEAD", "refs/heads/x"}, wantErr: true},
+		{args: []string{"log", "--output=/tmp/x"}, wantErr: true},
+		{args: []string{"diff", "--no-index", "/etc/passwd", "/dev/null"}, wantErr: true},
+		{args: []string{"blame", "--contents", "/etc/passwd", "HEAD", "--", "a"}, wantErr: true},
+	} {
+		if err := checkBatchExecCommand(test.args); (err != nil) != test.wantErr {
+			t.Errorf("%q: got error %v, want error %v", test.args, err, test.wantErr)
+		}
+	}
+}
+
+func TestHandleBatchExec(t *testing.T) {
+	reposDir := tmpDir(t)
+	dir := filepath.Join(reposDir, "example.com/foo/bar")
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	runCmd(t, dir, "git", "init", ".")
+	runCmd(t, dir, "sh", "-c", "echo hello > a")
+	runCmd(t, dir, "git", "add", "a")
+	runCmd(t, dir, "git", "commit", "-m", "first")
+	head := strings.TrimSpace(runCmd(t, dir, "git", "rev-parse", "HEAD"))
+
+	s := &Server{ReposDir: reposDir}
+	h := s.Handler()
+	batchExec := func(req protocol.BatchExecRequest) *httptest.ResponseRecorder {
+		t.Helper()
+		body, err := json.Marshal(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest("POST", "/batch-exec", strings.NewReader(string(body))))
+		return rr
+	}
+
+	t.Run("commands", func(t *testing.T) {
+		rr := batchExec(protocol.BatchExecRequest{
+			Repo:     "example.com/foo/bar",
+			Revision: "HEAD",
+			Commands: [][]string{
+				{"rev-parse", protocol.BatchExecCommit},
+				{"show", protocol.BatchExecCommit + ":a"},
+				{"show", protocol.BatchExecCommit + ":missing"},
+				{"log", "--format=%s", protocol.BatchExecCommit},
+			},
+		})
+		if rr.Code != http.StatusOK {
+			t.Fatalf("got status %d, want 200: %s", rr.Code, rr.Body)
+		}
+		if got := rr.Header().Get("X-Exec-Commit"); got != head {
+			t.Errorf("got commit %q, want %q", got, head)
+		}
+
+		dec := json.NewDecoder(rr.Body)
+		var results []protocol.BatchExecResult
+		for dec.More() {
+			var result protocol.BatchExecResult
+			if err := dec.Decode(&result); err != nil {
+				t.Fatal(err)
+			}
+			results = append(results, result)
+		}
+		if len(results) != 4 {
+			t.Fatalf("got %d results, want 4", len(results))
+		}
+		for i, want := range []string{head + "\n", "hello\n", "", "first\n"} {
+			if got := string(results[i].Stdout); got != want {
+				t.Errorf("command %d: got stdout %q, want %q", i, got, want)
+			}
+		}
+		if results[2].ExitStatus == 0 || !strings.Contains(results[2].Stderr, "missing") {
+			t.Errorf("got result %+v for missing file, want non-zero exit status", results[2])
+		}
+	})
+
+	t.Run("revision not found", func(t *testing.T) {
+		rr := batchExec(protocol.BatchExecRequest{
+			Repo:     "example.com/foo/bar",
+			Revision: "doesnotexist",
+			Commands: [][]string{{"rev-parse", protocol.BatchExecCommit}},
+		})
+		var payload protocol.NotFoundPayload
+		if err := json.NewDecoder(rr.Body).Decode(&payload); err != nil {
+			t.Fatal(err)
+		}
+		if rr.Code != http.StatusNotFound || !payload.RevisionNotFound {
+			t.Errorf("got status %d, payload %+v, want revision not found", rr.Code, payload)
+		}
+	})
+
+	t.Run("not allowed", func(t *testing.T) {
+		rr := batchExec(protocol.BatchExecRequest{
+			Repo:     "example.com/foo/bar",
+			Commands: [][]string{{"rev-parse", "HEAD"}, {"update-ref", "refs/heads/x", head}},
+		})
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("got status %d, want 400", rr.Code)
+		}
+	})
+}
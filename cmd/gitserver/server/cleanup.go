@@ -56,6 +56,7 @@ var (
 // 2. Remove stale lock files.
 // 3. Remove inactive repos on sourcegraph.com
 // 4. Reclone repos after a while. (simulate git gc)
+// 5. Run scheduled git maintenance, such as writing commit-graph files.
 func (s *Server) cleanupRepos() {
 	bCtx, bCancel := s.serverContext()
 	defer bCancel()
@@ -166,6 +167,10 @@ func (s *Server) cleanupRepos() {
 		return false, multi
 	}
 
+	runMaintenance := func(dir GitDir) (done bool, err error) {
+		return false, s.maintainRepo(bCtx, dir)
+	}
+
 	type cleanupFn struct {
 		Name string
 		Do   func(GitDir) (bool, error)
@@ -184,6 +189,10 @@ func (s *Server) cleanupRepos() {
 		// these problems. git gc is slow and resource intensive. It is
 		// cheaper and faster to just reclone the repository.
 		{"maybe reclone", maybeReclone},
+		// Repack repositories and write commit-graph and multi-pack-index
+		// files on a schedule. Without them git log and object lookups get
+		// slow on large repositories.
+		{"maybe run maintenance", runMaintenance},
 	}
 
 	err := bestEffortWalk(s.ReposDir, func(dir string, fi os.FileInfo) error {
@@ -0,0 +1,191 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/inconshreveable/log15"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// cloneOptionsRule is a compiled schema.GitCloneOptionsRule.
+type cloneOptionsRule struct {
+	repoPattern *regexp.Regexp
+	filter      string
+	depth       int
+}
+
+var gitCloneOptions = conf.Cached(func() interface{} {
+	var rules []*schema.GitCloneOptionsRule
+	if c := conf.Get().ExperimentalFeatures; c != nil {
+		rules = c.GitCloneOptions
+	}
+	return compileCloneOptionsRules(rules)
+})
+
+func compileCloneOptionsRules(rules []*schema.GitCloneOptionsRule) []cloneOptionsRule {
+	compiled := make([]cloneOptionsRule, 0, len(rules))
+	for _, rule := range rules {
+		re, err := regexp.Compile(rule.RepoPattern)
+		if err != nil {
+			// Skip if there's an error. A user-visible validation error is
+			// shown by the site configuration validation.
+			log15.Error("Site config: unable to compile gitCloneOptions regexp", "regexp", rule.RepoPattern)
+			continue
+		}
+		compiled = append(compiled, cloneOptionsRule{
+			repoPattern: re,
+			filter:      rule.Filter,
+			depth:       rule.Depth,
+		})
+	}
+	return compiled
+}
+
+// cloneOptionsArgs returns the arguments to git clone and git fetch that
+// leave out objects of repo, according to the first gitCloneOptions rule that
+// matches it.
+func cloneOptionsArgs(repo api.RepoName) (args []string, filtered bool) {
+	return cloneOptionsArgsFromRules(gitCloneOptions().([]cloneOptionsRule), repo)
+}
+
+func cloneOptionsArgsFromRules(rules []cloneOptionsRule, repo api.RepoName) (args []string, filtered bool) {
+	for _, rule := range rules {
+		if !rule.repoPattern.MatchString(string(repo)) {
+			continue
+		}
+		if rule.filter != "" {
+			args = append(args, "--filter="+rule.filter)
+		}
+		if rule.depth > 0 {
+			args = append(args, "--depth="+strconv.Itoa(rule.depth))
+		}
+		return args, rule.filter != ""
+	}
+	return nil, false
+}
+
+// isPartialClone reports whether the repository in dir is a partial clone,
+// whose missing objects are fetched from its origin remote on demand.
+func isPartialClone(dir GitDir) bool {
+	b, err := ioutil.ReadFile(dir.Path("config"))
+	if err != nil {
+		return false
+	}
+
+	// We read the config file instead of running git config, since this is
+	// checked for every exec request. Git sets remote.<name>.promisor for
+	// partial clones, and older versions of git also set
+	// extensions.partialClone.
+	var section string
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if strings.HasPrefix(line, "[") {
+			// [section "subsection"]
+			section = strings.Trim(line, "[]")
+			if i := strings.IndexAny(section, " \t"); i >= 0 {
+				section = section[:i]
+			}
+			section = strings.ToLower(section)
+			continue
+		}
+		key, value := line, ""
+		if i := strings.Index(line, "="); i >= 0 {
+			key, value = strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:])
+		}
+		key = strings.ToLower(key)
+		switch {
+		case section == "remote" && key == "promisor" && value == "true":
+			return true
+		case section == "extensions" && key == "partialclone" && value != "":
+			return true
+		}
+	}
+	return false
+}
+
+// fetchMissingObjects fetches the objects of treeish at paths (or of all of
+// treeish if paths is empty) that are missing from the partial clone in dir,
+// in a single fetch. Otherwise, git fetches each missing object separately
+// when it reads it, which is very slow for commands that read many files,
+// such as git archive.
+func fetchMissingObjects(ctx context.Context, dir GitDir, treeish string, paths []string) error {
+	missing, err := missingObjects(ctx, dir, treeish, paths)
+	if err != nil || len(missing) == 0 {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "-c", "fetch.negotiationAlgorithm=noop", "fetch", "origin",
+		"--no-tags", "--no-write-fetch-head", "--recurse-submodules=no", "--filter=blob:none", "--stdin")
+	dir.Set(cmd)
+	cmd.Stdin = strings.NewReader(strings.Join(missing, "\n") + "\n")
+	if output, err := runWith(ctx, cmd, true, nil); err != nil {
+		return errors.Wrapf(err, "failed to fetch %d missing objects. Output: %s", len(missing), output)
+	}
+	return nil
+}
+
+// missingObjects returns the IDs of the objects of treeish at paths (or of
+// all of treeish if paths is empty) that are missing from the partial clone in
+// dir. The blobs at paths that are files are always included, since git
+// cannot tell whether a blob is missing without fetching it.
+func missingObjects(ctx context.Context, dir GitDir, treeish string, paths []string) ([]string, error) {
+	var (
+		trees   []string
+		objects []string
+	)
+	if len(paths) > 0 {
+		// Trees are never filtered out, so listing the entries at paths does
+		// not fetch anything.
+		cmd := exec.CommandContext(ctx, "git", append([]string{"ls-tree", "-z", "--full-tree", treeish, "--"}, paths...)...)
+		dir.Set(cmd)
+		out, err := cmd.Output()
+		if err != nil {
+			return nil, errors.Wrap(err, "git ls-tree")
+		}
+		for _, entry := range strings.Split(string(out), "\x00") {
+			// <mode> SP <type> SP <object> TAB <file>
+			fields := strings.Fields(strings.SplitN(entry, "\t", 2)[0])
+			if len(fields) != 3 {
+				continue
+			}
+			switch fields[1] {
+			case "tree":
+				trees = append(trees, fields[2])
+			case "blob":
+				objects = append(objects, fields[2])
+			}
+		}
+	}
+	if len(trees) == 0 && len(objects) == 0 {
+		// No paths, or paths that are not just files and directories (such
+		// as globs).
+		trees = []string{treeish + "^{tree}"}
+	}
+	if len(trees) == 0 {
+		return objects, nil
+	}
+
+	cmd := exec.CommandContext(ctx, "git", append([]string{"rev-list", "--objects", "--missing=print"}, trees...)...)
+	dir.Set(cmd)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Wrap(err, "git rev-list")
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		if strings.HasPrefix(line, "?") {
+			objects = append(objects, line[1:])
+		}
+	}
+	return objects, nil
+}
@@ -0,0 +1,163 @@
+package server
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/mutablelimiter"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestCloneOptionsArgs(t *testing.T) {
+	rules := []cloneOptionsRule{
+		{repoPattern: regexp.MustCompile(`^github\.com/foo/mono$`), filter: "blob:none"},
+		{repoPattern: regexp.MustCompile(`^github\.com/foo/`), depth: 10},
+		{repoPattern: regexp.MustCompile(`^github\.com/`), filter: "blob:limit=1m", depth: 100},
+	}
+	for _, test := range []struct {
+		repo         api.RepoName
+		wantArgs     []string
+		wantFiltered bool
+	}{
+		{repo: "github.com/foo/mono", wantArgs: []string{"--filter=blob:none"}, wantFiltered: true},
+		{repo: "github.com/foo/bar", wantArgs: []string{"--depth=10"}},
+		{repo: "github.com/bar/baz", wantArgs: []string{"--filter=blob:limit=1m", "--depth=100"}, wantFiltered: true},
+		{repo: "gitlab.com/foo/bar"},
+	} {
+		args, filtered := cloneOptionsArgsFromRules(rules, test.repo)
+		if !reflect.DeepEqual(args, test.wantArgs) || filtered != test.wantFiltered {
+			t.Errorf("%s: got %q, %v, want %q, %v", test.repo, args, filtered, test.wantArgs, test.wantFiltered)
+		}
+	}
+}
+
+func TestIsPartialClone(t *testing.T) {
+	for name, test := range map[string]struct {
+		config string
+		want   bool
+	}{
+		"full":         {config: "[core]\n\tbare = true\n[remote \"origin\"]\n\turl = https://example.com/foo\n\tmirror = true\n"},
+		"promisor":     {config: "[core]\n\tbare = true\n[remote \"origin\"]\n\turl = https://example.com/foo\n\tpromisor = true\n\tpartialclonefilter = blob:none\n", want: true},
+		"partialclone": {config: "[core]\n\trepositoryformatversion = 1\n[extensions]\n\tpartialClone = origin\n", want: true},
+		"other":        {config: "[uploadpack]\n\tpromisor = true\n"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := GitDir(tmpDir(t))
+			writeFile(t, dir.Path("config"), []byte(test.config))
+			if got := isPartialClone(dir); got != test.want {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+
+	if isPartialClone(GitDir(filepath.Join(tmpDir(t), "missing"))) {
+		t.Error("got partial clone for missing repository")
+	}
+}
+
+// createFilterableRepo creates a repository that can be partially cloned,
+// with the files a, dir/b and dir/c.
+func createFilterableRepo(t *testing.T) string {
+	t.Helper()
+	remote := tmpDir(t)
+	runCmd(t, remote, "git", "init", ".")
+	runCmd(t, remote, "git", "config", "uploadpack.allowFilter", "true")
+	runCmd(t, remote, "git", "config", "uploadpack.allowAnySHA1InWant", "true")
+	runCmd(t, remote, "sh", "-c", "mkdir dir && echo a > a && echo b > dir/b && echo c > dir/c")
+	runCmd(t, remote, "git", "add", ".")
+	runCmd(t, remote, "git", "commit", "-m", "first")
+	return remote
+}
+
+func TestFetchMissingObjects(t *testing.T) {
+	remote := createFilterableRepo(t)
+	dir := GitDir(filepath.Join(tmpDir(t), ".git"))
+	runCmd(t, remote, "git", "clone", "--mirror", "--filter=blob:none", "file://"+remote, string(dir))
+	if !isPartialClone(dir) {
+		t.Fatal("expected a partial clone")
+	}
+
+	blobs := map[string]string{}
+	for _, path := range []string{"a", "dir/b", "dir/c"} {
+		blobs[strings.TrimSpace(runCmd(t, remote, "git", "rev-parse", "HEAD:"+path))] = path
+	}
+	missingPaths := func() []string {
+		t.Helper()
+		var paths []string
+		for _, line := range strings.Split(runCmd(t, string(dir), "git", "rev-list", "--objects", "--missing=print", "HEAD^{tree}"), "\n") {
+			if strings.HasPrefix(line, "?") {
+				paths = append(paths, blobs[line[1:]])
+			}
+		}
+		sort.Strings(paths)
+		return paths
+	}
+
+	if got, want := missingPaths(), []string{"a", "dir/b", "dir/c"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got missing %q, want %q", got, want)
+	}
+
+	ctx := context.Background()
+	if err := fetchMissingObjects(ctx, dir, "HEAD", []string{"dir"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := missingPaths(), []string{"a"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got missing %q after fetching dir, want %q", got, want)
+	}
+
+	if err := fetchMissingObjects(ctx, dir, "HEAD", nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := missingPaths(); len(got) != 0 {
+		t.Fatalf("got missing %q after fetching everything, want none", got)
+	}
+}
+
+func TestCloneRepo_cloneOptions(t *testing.T) {
+	orig := gitCloneOptions
+	gitCloneOptions = func() interface{} {
+		return compileCloneOptionsRules([]*schema.GitCloneOptionsRule{{RepoPattern: "^example\\.com/foo/", Filter: "blob:none"}})
+	}
+	defer func() { gitCloneOptions = orig }()
+
+	remote := createFilterableRepo(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s := &Server{
+		ReposDir:         tmpDir(t),
+		ctx:              ctx,
+		locker:           &RepositoryLocker{},
+		cloneLimiter:     mutablelimiter.New(1),
+		cloneableLimiter: mutablelimiter.New(1),
+	}
+
+	const repo = api.RepoName("example.com/foo/bar")
+	if _, err := s.cloneRepo(ctx, repo, "file://"+remote, &cloneOptions{Block: true}); err != nil {
+		t.Fatal(err)
+	}
+	dir := s.dir(repo)
+	if !isPartialClone(dir) {
+		t.Fatal("expected a partial clone")
+	}
+
+	// Fetches keep the repository partial, and read blobs are fetched on
+	// demand.
+	runCmd(t, remote, "sh", "-c", "echo d > d")
+	runCmd(t, remote, "git", "add", "d")
+	runCmd(t, remote, "git", "commit", "-m", "second")
+	if err := s.doRepoUpdate2(repo, "file://"+remote); err != nil {
+		t.Fatal(err)
+	}
+	if got := runCmd(t, string(dir), "git", "rev-list", "--objects", "--missing=print", "HEAD^{tree}"); strings.Count(got, "?") != 4 {
+		t.Errorf("got objects %q, want 4 missing blobs", got)
+	}
+	if got := runCmd(t, string(dir), "git", "show", "HEAD:d"); got != "d\n" {
+		t.Errorf("got contents %q, want %q", got, "d\n")
+	}
+}
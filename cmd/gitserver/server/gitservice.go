@@ -77,12 +77,19 @@ func (s *gitServiceHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}()
 
+	gitProtocol := sanitizeGitProtocol(r.Header.Get("Git-Protocol"))
+
 	args := append([]string{}, uploadPackArgs...)
 	switch svc {
 	case "/info/refs":
 		w.Header().Set("Content-Type", "application/x-git-upload-pack-advertisement")
-		_, _ = w.Write(packetWrite("# service=git-upload-pack\n"))
-		_, _ = w.Write([]byte("0000"))
+		// Like git http-backend, only protocol v0 and v1 responses start
+		// with the service announcement. A protocol v2 response starts with
+		// the capability advertisement.
+		if !strings.Contains(":"+gitProtocol+":", ":version=2:") {
+			_, _ = w.Write(packetWrite("# service=git-upload-pack\n"))
+			_, _ = w.Write([]byte("0000"))
+		}
 		args = append(args, "--advertise-refs")
 	case "/git-upload-pack":
 		w.Header().Set("Content-Type", "application/x-git-upload-pack-result")
@@ -96,12 +103,20 @@ func (s *gitServiceHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	defer body.Close()
 
 	env := os.Environ()
-	if protocol := r.Header.Get("Git-Protocol"); protocol != "" {
-		env = append(env, "GIT_PROTOCOL="+protocol)
+	if gitProtocol != "" {
+		env = append(env, "GIT_PROTOCOL="+gitProtocol)
 	}
 
 	cmd := exec.CommandContext(r.Context(), "git", args...)
 	cmd.Env = env
+	if isPartialClone(GitDir(dir)) {
+		// upload-pack does not fetch the objects missing from a partial
+		// clone by default, since the configuration of a repository could
+		// make git run arbitrary commands. We manage the configuration of
+		// our repositories, so let it fetch them from the code host.
+		cmd.Env = append(cmd.Env, "GIT_NO_LAZY_FETCH=0")
+		configureRemoteGitCommand(cmd, tlsExternal().(*tlsConfig))
+	}
 	cmd.Stdout = flowrateWriter(w)
 	cmd.Stdin = body
 	if err := cmd.Run(); err != nil {
@@ -109,6 +124,20 @@ func (s *gitServiceHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// sanitizeGitProtocol returns the parameters of the Git-Protocol header value
+// that git understands (such as "version=2"), separated by colons.
+func sanitizeGitProtocol(value string) string {
+	var params []string
+	for _, param := range strings.Split(value, ":") {
+		if strings.HasPrefix(param, "version=") {
+			if _, err := strconv.Atoi(strings.TrimPrefix(param, "version=")); err == nil {
+				params = append(params, param)
+			}
+		}
+	}
+	return strings.Join(params, ":")
+}
+
 func packetWrite(str string) []byte {
 	s := strconv.FormatInt(int64(len(str)+4), 16)
 	if len(s)%4 != 0 {
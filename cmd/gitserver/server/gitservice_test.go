@@ -2,6 +2,8 @@ package server
 
 import (
 	"bytes"
+	"io/ioutil"
+	"net/http"
 	"net/http/httptest"
 	"os/exec"
 	"path/filepath"
@@ -75,3 +77,64 @@ func TestGitServiceHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestGitServiceHandler_advertisement(t *testing.T) {
+	root := tmpDir(t)
+	runCmd(t, root, "git", "init", "--bare", filepath.Join(root, "testrepo", ".git"))
+
+	ts := httptest.NewServer(&gitServiceHandler{
+		Dir: func(s string) string {
+			return filepath.Join(root, s, ".git")
+		},
+	})
+	defer ts.Close()
+
+	for _, test := range []struct {
+		gitProtocol string
+		wantPrefix  string
+	}{
+		{gitProtocol: "", wantPrefix: "001e# service=git-upload-pack\n0000"},
+		{gitProtocol: "version=1", wantPrefix: "001e# service=git-upload-pack\n0000000eversion 1\n"},
+		{gitProtocol: "version=2", wantPrefix: "000eversion 2\n"},
+		{gitProtocol: "foo=bar:version=2", wantPrefix: "000eversion 2\n"},
+	} {
+		req, err := http.NewRequest("GET", ts.URL+"/testrepo/info/refs?service=git-upload-pack", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Git-Protocol", test.gitProtocol)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.HasPrefix(body, []byte(test.wantPrefix)) {
+			t.Errorf("%q: got response %q, want prefix %q", test.gitProtocol, body, test.wantPrefix)
+		}
+	}
+}
+
+func TestGitServiceHandler_partialClone(t *testing.T) {
+	remote := createFilterableRepo(t)
+	root := tmpDir(t)
+	runCmd(t, root, "git", "clone", "--mirror", "--filter=blob:none", "file://"+remote, filepath.Join(root, "testrepo", ".git"))
+
+	ts := httptest.NewServer(&gitServiceHandler{
+		Dir: func(s string) string {
+			return filepath.Join(root, s, ".git")
+		},
+	})
+	defer ts.Close()
+
+	// The blobs missing from the partial clone are fetched from its remote
+	// when they are served.
+	dst := tmpDir(t)
+	runCmd(t, dst, "git", "-c", "protocol.version=2", "clone", ts.URL+"/testrepo", ".")
+	if got := runCmd(t, dst, "cat", "dir/b"); got != "b\n" {
+		t.Errorf("got contents %q, want %q", got, "b\n")
+	}
+}
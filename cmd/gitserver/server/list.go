@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"os"
@@ -25,69 +26,76 @@ func (s *Server) handleList(w http.ResponseWriter, r *http.Request) {
 		return
 
 	case query("cloned"):
-		err := godirwalk.Walk(s.ReposDir, &godirwalk.Options{
-			Callback: func(path string, de *godirwalk.Dirent) error {
-				if ctx.Err() != nil {
-					return ctx.Err()
-				}
+		var err error
+		repos, err = s.listCloned(ctx)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
 
-				if s.ignorePath(path) {
-					if de.IsDir() {
-						return filepath.SkipDir
-					}
-					return nil
-				}
+	default:
+		// empty list response for unrecognized URL query
+	}
 
-				// We only care about directories
-				if !de.IsDir() {
-					return nil
-				}
+	if err := json.NewEncoder(w).Encode(repos); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
 
-				// New style git directory layout
-				if filepath.Base(path) == ".git" {
-					name, err := filepath.Rel(s.ReposDir, filepath.Dir(path))
-					if err != nil {
-						return err
-					}
-					repos = append(repos, name)
+// listCloned returns the names of the repositories cloned in s.ReposDir.
+func (s *Server) listCloned(ctx context.Context) ([]string, error) {
+	repos := make([]string, 0)
+	err := godirwalk.Walk(s.ReposDir, &godirwalk.Options{
+		Callback: func(path string, de *godirwalk.Dirent) error {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			if s.ignorePath(path) {
+				if de.IsDir() {
 					return filepath.SkipDir
 				}
+				return nil
+			}
 
-				// For old-style directory layouts we need to do an extra extra
-				// stat to check if this is a repo.
-				if _, err := os.Stat(filepath.Join(path, "HEAD")); os.IsNotExist(err) {
-					// HEAD doesn't exist, so keep recursing
-					return nil
-				} else if err != nil {
-					return err
-				}
+			// We only care about directories
+			if !de.IsDir() {
+				return nil
+			}
 
-				// path is an old style git repo since it contains HEAD
-				name, err := filepath.Rel(s.ReposDir, path)
+			// New style git directory layout
+			if filepath.Base(path) == ".git" {
+				name, err := filepath.Rel(s.ReposDir, filepath.Dir(path))
 				if err != nil {
 					return err
 				}
 				repos = append(repos, name)
 				return filepath.SkipDir
-			},
-			ErrorCallback: func(path string, err error) godirwalk.ErrorAction {
-				// Ignore errors and simply continue with other nodes
-				return godirwalk.SkipNode
-			},
-			Unsorted: true,
-		})
+			}
 
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
+			// For old-style directory layouts we need to do an extra extra
+			// stat to check if this is a repo.
+			if _, err := os.Stat(filepath.Join(path, "HEAD")); os.IsNotExist(err) {
+				// HEAD doesn't exist, so keep recursing
+				return nil
+			} else if err != nil {
+				return err
+			}
 
-	default:
-		// empty list response for unrecognized URL query
-	}
-
-	if err := json.NewEncoder(w).Encode(repos); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
+			// path is an old style git repo since it contains HEAD
+			name, err := filepath.Rel(s.ReposDir, path)
+			if err != nil {
+				return err
+			}
+			repos = append(repos, name)
+			return filepath.SkipDir
+		},
+		ErrorCallback: func(path string, err error) godirwalk.ErrorAction {
+			// Ignore errors and simply continue with other nodes
+			return godirwalk.SkipNode
+		},
+		Unsorted: true,
+	})
+	return repos, err
 }
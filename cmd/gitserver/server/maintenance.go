@@ -0,0 +1,278 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/inconshreveable/log15"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
+)
+
+const (
+	// maintenanceInterval is the least time between two runs of the same
+	// maintenance task on a repository.
+	maintenanceInterval = time.Hour
+	// bitmapRepackInterval is how often we repack a repository that has few
+	// packfiles but no reachability bitmap, for example because it was
+	// just cloned.
+	bitmapRepackInterval = time.Hour * 24 * 7
+
+	// maxPacks is the number of packfiles above which a repository is
+	// repacked. Every fetch adds a packfile, and git has to look objects up
+	// in each of them.
+	maxPacks = 50
+	// maxLooseObjects is the estimated number of loose objects above which a
+	// repository is repacked. This is the default of git's gc.auto.
+	maxLooseObjects = 6700
+)
+
+var maintenanceDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "src_gitserver_maintenance_duration_seconds",
+	Help:    "A histogram of latencies for git maintenance tasks (repack, multi-pack-index, commit-graph).",
+	Buckets: prometheus.ExponentialBuckets(.1, 4, 8), // 100ms -> 27m
+}, []string{"task", "success"})
+
+// maintenanceTask is a git command run on repositories on a schedule to keep
+// git commands on them fast.
+type maintenanceTask struct {
+	name string
+	args []string
+
+	// due reports whether the task should run on a repository with stats,
+	// given the time since the task last ran on it (which is very long if
+	// it never did).
+	due func(stats *protocol.RepoMaintenance, sinceLastRun time.Duration, objects objectFileTimes) bool
+}
+
+// maintenanceTasks are run in order, since repacking changes which
+// multi-pack-index is needed.
+var maintenanceTasks = []maintenanceTask{
+	{
+		// Repacking everything into a single packfile with a reachability
+		// bitmap speeds up fetches from the repository and object counting
+		// commands such as git rev-list --count.
+		name: "repack",
+		args: []string{"repack", "-a", "-d", "-b", "-q"},
+		due: func(stats *protocol.RepoMaintenance, sinceLastRun time.Duration, _ objectFileTimes) bool {
+			if stats.Packs >= maxPacks || stats.LooseObjects >= maxLooseObjects {
+				return true
+			}
+			return !stats.HasBitmap && (stats.Packs > 0 || stats.LooseObjects > 0) && sinceLastRun >= bitmapRepackInterval
+		},
+	},
+	{
+		// A multi-pack-index lets git look objects up once instead of once
+		// per packfile.
+		name: "multi-pack-index",
+		args: []string{"multi-pack-index", "write"},
+		due: func(stats *protocol.RepoMaintenance, _ time.Duration, objects objectFileTimes) bool {
+			return stats.Packs >= 2 && objects.multiPackIndex.Before(objects.newestPack)
+		},
+	},
+	{
+		// A commit-graph with changed-path Bloom filters makes git log
+		// (including git log -- <path>) many times faster on large
+		// repositories. Split commit-graphs are written incrementally, so
+		// this is cheap after fetches.
+		name: "commit-graph",
+		args: []string{"commit-graph", "write", "--reachable", "--split", "--changed-paths"},
+		due: func(stats *protocol.RepoMaintenance, _ time.Duration, objects objectFileTimes) bool {
+			if stats.Packs == 0 && stats.LooseObjects == 0 {
+				return false
+			}
+			return !stats.HasCommitGraph || objects.commitGraph.Before(objects.lastFetched)
+		},
+	},
+}
+
+// maintainRepo runs the maintenance tasks that are due on the repository in
+// dir, and records their results.
+func (s *Server) maintainRepo(ctx context.Context, dir GitDir) error {
+	status, err := readMaintenanceStatus(dir)
+	if err != nil {
+		return err
+	}
+
+	var errs error
+	for _, task := range maintenanceTasks {
+		stats, objects, err := repoObjectStats(dir)
+		if err != nil {
+			return err
+		}
+
+		sinceLastRun := time.Duration(math.MaxInt64)
+		if last, ok := status[task.name]; ok {
+			sinceLastRun = time.Since(last.LastRun)
+		}
+		// Add a jitter to spread out maintenance of repos cloned or fetched
+		// at the same time.
+		if sinceLastRun < maintenanceInterval+jitterDuration(string(dir)+task.name, maintenanceInterval/4) {
+			continue
+		}
+		if !task.due(stats, sinceLastRun, objects) {
+			continue
+		}
+
+		result, err := runMaintenanceTask(ctx, dir, task)
+		status[task.name] = result
+		if err != nil {
+			errs = multierror.Append(errs, err)
+		}
+		if err := writeMaintenanceStatus(dir, status); err != nil {
+			return err
+		}
+	}
+	return errs
+}
+
+func runMaintenanceTask(ctx context.Context, dir GitDir, task maintenanceTask) (*protocol.RepoMaintenanceTask, error) {
+	ctx, cancel := context.WithTimeout(ctx, longGitCommandTimeout)
+	defer cancel()
+
+	log15.Debug("running git maintenance", "repo", dir, "task", task.name)
+	start := time.Now()
+	cmd := exec.CommandContext(ctx, "git", task.args...)
+	dir.Set(cmd)
+	output, err := runWith(ctx, cmd, false, nil)
+	result := &protocol.RepoMaintenanceTask{
+		LastRun:  start,
+		Duration: time.Since(start),
+	}
+	maintenanceDuration.WithLabelValues(task.name, strconv.FormatBool(err == nil)).Observe(result.Duration.Seconds())
+	if err != nil {
+		err = errors.Wrapf(err, "git maintenance %s failed with output %q", task.name, output)
+		result.Error = err.Error()
+		return result, err
+	}
+	return result, nil
+}
+
+// objectFileTimes are the modification times of the files that maintenance
+// tasks write, and of the files they depend on. A time is zero if the file
+// does not exist.
+type objectFileTimes struct {
+	newestPack     time.Time
+	multiPackIndex time.Time
+	commitGraph    time.Time
+	lastFetched    time.Time
+}
+
+// repoObjectStats returns the maintenance statistics of the repository in
+// dir, without the task results. It only reads the file system, since it is
+// called for every repository on every cleanup run.
+func repoObjectStats(dir GitDir) (*protocol.RepoMaintenance, objectFileTimes, error) {
+	var (
+		stats   protocol.RepoMaintenance
+		objects objectFileTimes
+	)
+
+	packs, err := ioutil.ReadDir(dir.Path("objects", "pack"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, objects, err
+	}
+	for _, fi := range packs {
+		name := fi.Name()
+		switch {
+		case strings.HasPrefix(name, "tmp_"):
+			// Interrupted fetches, see cleanTmpFiles.
+		case strings.HasSuffix(name, ".pack"):
+			stats.Packs++
+			if fi.ModTime().After(objects.newestPack) {
+				objects.newestPack = fi.ModTime()
+			}
+		case strings.HasSuffix(name, ".bitmap"):
+			stats.HasBitmap = true
+		case name == "multi-pack-index":
+			stats.HasMultiPackIndex = true
+			objects.multiPackIndex = fi.ModTime()
+		}
+	}
+
+	// Like git gc --auto, we estimate the number of loose objects from the
+	// objects whose ID starts with 17, assuming IDs are evenly distributed.
+	loose, err := ioutil.ReadDir(dir.Path("objects", "17"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, objects, err
+	}
+	stats.LooseObjects = len(loose) * 256
+
+	for _, path := range []string{
+		dir.Path("objects", "info", "commit-graph"),
+		dir.Path("objects", "info", "commit-graphs", "commit-graph-chain"),
+	} {
+		if fi, err := os.Stat(path); err == nil {
+			stats.HasCommitGraph = true
+			if fi.ModTime().After(objects.commitGraph) {
+				objects.commitGraph = fi.ModTime()
+			}
+		}
+	}
+
+	if objects.lastFetched, err = repoLastFetched(dir); err != nil {
+		return nil, objects, err
+	}
+	return &stats, objects, nil
+}
+
+// maintenanceStatusFile is the file in a repository's GIT_DIR that records
+// the last run of each maintenance task on it.
+const maintenanceStatusFile = "sg_maintenance.json"
+
+func readMaintenanceStatus(dir GitDir) (map[string]*protocol.RepoMaintenanceTask, error) {
+	status := map[string]*protocol.RepoMaintenanceTask{}
+	b, err := ioutil.ReadFile(dir.Path(maintenanceStatusFile))
+	if os.IsNotExist(err) {
+		return status, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &status); err != nil {
+		// The next run overwrites the file, so we treat a bad file like a
+		// missing one instead of failing maintenance forever.
+		log15.Warn("ignoring invalid git maintenance status", "repo", dir, "error", err)
+		return map[string]*protocol.RepoMaintenanceTask{}, nil
+	}
+	return status, nil
+}
+
+func writeMaintenanceStatus(dir GitDir, status map[string]*protocol.RepoMaintenanceTask) error {
+	b, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+	path := dir.Path(maintenanceStatusFile)
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0600); err != nil {
+		return errors.Wrap(err, "failed to write git maintenance status")
+	}
+	return os.Rename(tmp, path)
+}
+
+// repoMaintenance returns the status of the scheduled git maintenance of the
+// repository in dir.
+var repoMaintenance = func(dir GitDir) (*protocol.RepoMaintenance, error) {
+	stats, _, err := repoObjectStats(dir)
+	if err != nil {
+		return nil, err
+	}
+	status, err := readMaintenanceStatus(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(status) > 0 {
+		stats.Tasks = status
+	}
+	return stats, nil
+}
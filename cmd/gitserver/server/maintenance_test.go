@@ -0,0 +1,67 @@
+package server
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestMaintainRepo(t *testing.T) {
+	root := tmpDir(t)
+	runCmd(t, root, "git", "init", ".")
+	dir := GitDir(filepath.Join(root, ".git"))
+	commit := func(msg string) {
+		t.Helper()
+		runCmd(t, root, "git", "commit", "--allow-empty", "-m", msg)
+		// Pack only the new objects, like a fetch does.
+		runCmd(t, root, "git", "repack", "-d")
+	}
+	commit("first")
+	commit("second")
+
+	s := &Server{ReposDir: root}
+	ctx := context.Background()
+	maintain := func() {
+		t.Helper()
+		if err := s.maintainRepo(ctx, dir); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The repository was never repacked, so it is repacked with a bitmap and
+	// gets a commit-graph.
+	maintain()
+	stats, err := repoMaintenance(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Packs != 1 || !stats.HasBitmap || !stats.HasCommitGraph || stats.HasMultiPackIndex {
+		t.Fatalf("got %+v after first maintenance, want a single pack with a bitmap and a commit-graph", stats)
+	}
+	for _, task := range []string{"repack", "commit-graph"} {
+		if result := stats.Tasks[task]; result == nil || result.Error != "" {
+			t.Errorf("got %s result %+v, want success", task, result)
+		}
+	}
+	if _, ok := stats.Tasks["multi-pack-index"]; ok {
+		t.Error("multi-pack-index ran on a single pack")
+	}
+
+	// A new pack gets a multi-pack-index. The other tasks ran recently, so
+	// they wait.
+	commit("third")
+	maintain()
+	stats, err = repoMaintenance(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Packs != 2 || !stats.HasMultiPackIndex {
+		t.Fatalf("got %+v after second maintenance, want two packs with a multi-pack-index", stats)
+	}
+	if got := stats.Tasks["multi-pack-index"]; got == nil || got.Error != "" {
+		t.Errorf("got multi-pack-index result %+v, want success", got)
+	}
+	if got := runCmd(t, root, "git", "rev-list", "--count", "HEAD"); got != "3\n" {
+		t.Errorf("got %q commits, want 3", got)
+	}
+}
@@ -0,0 +1,428 @@
+package server
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/inconshreveable/log15"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
+)
+
+var (
+	rebalanceReposRemaining = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "src_gitserver_rebalance_repos_remaining",
+		Help: "number of repositories left to copy to this gitserver before routing can switch to the rebalanced gitservers.",
+	})
+	rebalanceReposCopied = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "src_gitserver_rebalance_repos_copied",
+		Help: "number of repositories copied from other gitservers while rebalancing.",
+	})
+	rebalanceReposRemoved = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "src_gitserver_rebalance_repos_removed",
+		Help: "number of repositories removed after they were rebalanced to other gitservers.",
+	})
+)
+
+// Rebalance moves repositories between gitservers so that routing can switch
+// from the current gitservers (ServiceConnections.GitServers) and hashing
+// (gitServerHashing) to s.RebalanceAddrs and s.RebalanceHashing without
+// cloning any repository from its code host again. If only one of them is
+// set, the other one stays as it is. It is a no-op if neither is set.
+//
+// While the current routing differs from the rebalanced one, Rebalance copies
+// the git directories of the repositories that this gitserver will own from
+// the gitservers that have them, over their /repo-export endpoint. Once every
+// gitserver reports no repositories remaining to copy, routing can be switched
+// by setting SRC_GIT_SERVERS to the same addresses and gitServerHashing to the
+// same hashing. After that, Rebalance removes the repositories that this
+// gitserver no longer owns.
+//
+// This gitserver finds its own address in the rebalanced addresses by its
+// hostname.
+func (s *Server) Rebalance(ctx context.Context) error {
+	if len(s.RebalanceAddrs) == 0 && s.RebalanceHashing == "" {
+		return nil
+	}
+
+	current := routing{
+		addrs:   conf.Get().ServiceConnections.GitServers,
+		hashing: gitserver.ConfHashing(),
+	}
+	target := routing{addrs: s.RebalanceAddrs, hashing: s.RebalanceHashing}
+	if len(target.addrs) == 0 {
+		target.addrs = current.addrs
+	}
+	if target.hashing == "" {
+		target.hashing = current.hashing
+	}
+	replicas := conf.Get().GitServerReplicationFactor
+
+	self, ok := selfAddr(target.addrs, s.Hostname)
+	if !ok {
+		// This gitserver is being removed. Other gitservers copy its
+		// repositories, and it owns none after rebalancing.
+		rebalanceReposRemaining.Set(0)
+		return nil
+	}
+
+	if current.equal(target) {
+		rebalanceReposRemaining.Set(0)
+		return s.removeRebalancedRepos(ctx, self, target, replicas)
+	}
+	return s.copyRebalancedRepos(ctx, self, current, target, replicas)
+}
+
+// routing is an assignment of repositories to gitservers.
+type routing struct {
+	addrs   []string
+	hashing gitserver.Hashing
+}
+
+// isReplica reports whether addr is one of the gitservers that repo is cloned
+// on when each repository is cloned on the given number of gitservers.
+func (r routing) isReplica(repo api.RepoName, replicas int, addr string) bool {
+	if len(r.addrs) == 0 {
+		return false
+	}
+	for _, replica := range gitserver.AddrsForKey(r.hashing, r.addrs, string(repo), replicas) {
+		if replica == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// equal reports whether r and o assign every repository to the same
+// gitservers. The order of the addresses only matters for modulo hashing.
+func (r routing) equal(o routing) bool {
+	if r.hashing != o.hashing {
+		return false
+	}
+	if r.hashing == gitserver.RendezvousHashing {
+		return sameAddrs(r.addrs, o.addrs)
+	}
+	if len(r.addrs) != len(o.addrs) {
+		return false
+	}
+	for i := range r.addrs {
+		if r.addrs[i] != o.addrs[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// copyRebalancedRepos copies the repositories that self is a replica of
+// according to target from the gitservers that have them.
+func (s *Server) copyRebalancedRepos(ctx context.Context, self string, current, target routing, replicas int) error {
+	// sources maps each repository that self will have and does not have to
+	// the gitserver to copy it from, preferring one it is routed to.
+	sources := map[api.RepoName]string{}
+	peers := map[string]bool{}
+	for _, addr := range append(append([]string{}, current.addrs...), target.addrs...) {
+		if addr == self || peers[addr] {
+			continue
+		}
+		peers[addr] = true
+
+		repos, err := s.listPeerCloned(ctx, addr)
+		if err != nil {
+			log15.Warn("rebalance: failed to list repositories of gitserver", "addr", addr, "error", err)
+			continue
+		}
+		for _, name := range repos {
+			repo := protocol.NormalizeRepo(api.RepoName(name))
+			if !target.isReplica(repo, replicas, self) || repoCloned(s.dir(repo)) {
+				continue
+			}
+			if _, ok := sources[repo]; !ok || current.isReplica(repo, replicas, addr) {
+				sources[repo] = addr
+			}
+		}
+	}
+
+	rebalanceReposRemaining.Set(float64(len(sources)))
+	for repo, addr := range sources {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := s.copyRepoFromPeer(ctx, addr, repo); err != nil {
+			log15.Error("rebalance: failed to copy repository", "repo", repo, "from", addr, "error", err)
+			continue
+		}
+		rebalanceReposRemaining.Dec()
+		rebalanceReposCopied.Inc()
+	}
+	return nil
+}
+
+// removeRebalancedRepos removes the repositories that self is not a replica
+// of according to target, once routing uses it.
+func (s *Server) removeRebalancedRepos(ctx context.Context, self string, target routing, replicas int) error {
+	repos, err := s.listCloned(ctx)
+	if err != nil {
+		return err
+	}
+	for _, name := range repos {
+		repo := protocol.NormalizeRepo(api.RepoName(name))
+		if target.isReplica(repo, replicas, self) {
+			continue
+		}
+		if err := s.removeRepoDirectory(s.dir(repo)); err != nil {
+			log15.Error("rebalance: failed to remove repository", "repo", repo, "error", err)
+			continue
+		}
+		log15.Info("rebalance: removed repository owned by another gitserver", "repo", repo)
+		rebalanceReposRemoved.Inc()
+	}
+	return nil
+}
+
+// listPeerCloned returns the repositories cloned on the gitserver at addr.
+func (s *Server) listPeerCloned(ctx context.Context, addr string) ([]string, error) {
+	req, err := http.NewRequest("GET", "http://"+addr+"/list?cloned", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("list: bad HTTP response status %d", resp.StatusCode)
+	}
+	var repos []string
+	err = json.NewDecoder(resp.Body).Decode(&repos)
+	return repos, err
+}
+
+// copyRepoFromPeer copies the git directory of repo from the gitserver at
+// addr. Like a clone, the repository is written to a temporary directory
+// first, and requests for it report a clone in progress meanwhile.
+func (s *Server) copyRepoFromPeer(ctx context.Context, addr string, repo api.RepoName) error {
+	dir := s.dir(repo)
+	lock, ok := s.locker.TryAcquire(dir, "copying from "+addr)
+	if !ok {
+		// A clone or another copy is in progress.
+		return nil
+	}
+	defer lock.Release()
+
+	ctx, cancel, err := s.acquireCloneLimiter(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	if repoCloned(dir) {
+		return nil
+	}
+
+	req, err := http.NewRequest("GET", "http://"+addr+"/repo-export?repo="+url.QueryEscape(string(repo)), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("repo-export: bad HTTP response status %d", resp.StatusCode)
+	}
+
+	tmpPath, err := s.tempDir("rebalance-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpPath)
+	tmpPath = filepath.Join(tmpPath, ".git")
+	if err := extractTar(resp.Body, tmpPath); err != nil {
+		return errors.Wrap(err, "extracting git directory")
+	}
+
+	dstPath := string(dir)
+	if err := os.MkdirAll(filepath.Dir(dstPath), os.ModePerm); err != nil {
+		return err
+	}
+	if err := renameAndSync(tmpPath, dstPath); err != nil {
+		return err
+	}
+	log15.Info("rebalance: copied repository", "repo", repo, "from", addr)
+	return nil
+}
+
+// handleRepoExport writes the git directory of a repository as a tar archive.
+// It is used to copy repositories between gitservers while rebalancing, and
+// must not be exposed outside of the cluster.
+func (s *Server) handleRepoExport(w http.ResponseWriter, r *http.Request) {
+	repo := protocol.NormalizeRepo(api.RepoName(r.URL.Query().Get("repo")))
+	if repo == "" {
+		http.Error(w, "repo is required", http.StatusBadRequest)
+		return
+	}
+	dir := s.dir(repo)
+	if !repoCloned(dir) {
+		http.Error(w, "repository not found", http.StatusNotFound)
+		return
+	}
+
+	// Hold the update lock of the repository so that a fetch or a garbage
+	// collection does not change it while it is exported.
+	s.repoUpdateLocksMu.Lock()
+	mu := s.repoUpdateLocksFor(repo).mu
+	s.repoUpdateLocksMu.Unlock()
+	mu.Lock()
+	defer mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/x-tar")
+	if err := writeTar(w, string(dir)); err != nil {
+		// The response has started, so we can only log the error. The
+		// receiver fails to read a truncated archive.
+		log15.Error("failed to export repository", "repo", repo, "error", err)
+	}
+}
+
+// writeTar writes the directory root as a tar archive to w, with paths
+// relative to root.
+func writeTar(w io.Writer, root string) error {
+	tw := tar.NewWriter(w)
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		if !info.Mode().IsDir() && !info.Mode().IsRegular() {
+			// Git directories only contain directories and regular files.
+			return nil
+		}
+		hdr, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(rel)
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.CopyN(tw, f, info.Size())
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// extractTar extracts the tar archive read from r, as written by writeTar,
+// into the new directory dst.
+func extractTar(r io.Reader, dst string) error {
+	if err := os.MkdirAll(dst, os.ModePerm); err != nil {
+		return err
+	}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		name := filepath.FromSlash(hdr.Name)
+		if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) || filepath.Clean(name) != name {
+			return fmt.Errorf("invalid path in archive: %q", hdr.Name)
+		}
+		path := filepath.Join(dst, name)
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(path, os.ModePerm); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, os.FileMode(hdr.Mode).Perm())
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(f, tr)
+			if err1 := f.Close(); err == nil {
+				err = err1
+			}
+			if err != nil {
+				return err
+			}
+			if err := os.Chtimes(path, hdr.ModTime, hdr.ModTime); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unexpected entry type %q in archive: %q", hdr.Typeflag, hdr.Name)
+		}
+	}
+}
+
+// selfAddr returns the address in addrs of the host with the given hostname.
+// An address matches if its host is the hostname, or a domain name whose
+// first label is the hostname (such as gitserver-0.gitserver:3178 for the
+// hostname gitserver-0 in a Kubernetes StatefulSet).
+func selfAddr(addrs []string, hostname string) (string, bool) {
+	if hostname == "" {
+		return "", false
+	}
+	for _, addr := range addrs {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			host = addr
+		}
+		if host == hostname || strings.HasPrefix(host, hostname+".") {
+			return addr, true
+		}
+	}
+	return "", false
+}
+
+// sameAddrs reports whether a and b contain the same addresses, in any order.
+func sameAddrs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	seen := make(map[string]bool, len(a))
+	for _, addr := range a {
+		seen[addr] = true
+	}
+	for _, addr := range b {
+		if !seen[addr] {
+			return false
+		}
+	}
+	return true
+}
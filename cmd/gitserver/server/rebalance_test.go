@@ -0,0 +1,219 @@
+package server
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/internal/conf/conftypes"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestRebalance(t *testing.T) {
+	mockGitServers := func(addrs []string) {
+		conf.Mock(&conf.Unified{
+			ServiceConnections: conftypes.ServiceConnections{GitServers: addrs},
+		})
+	}
+	defer conf.Mock(nil)
+	mockGitServers(nil)
+
+	newServer := func(hostname string) (*Server, string) {
+		s := &Server{ReposDir: tmpDir(t), Hostname: hostname}
+		ts := httptest.NewServer(s.Handler())
+		t.Cleanup(ts.Close)
+		port := ts.URL[strings.LastIndex(ts.URL, ":")+1:]
+		return s, hostname + ":" + port
+	}
+	a, addrA := newServer("127.0.0.1")
+	b, addrB := newServer("localhost")
+	target := []string{addrA, addrB}
+
+	// All repositories are on a. Create them so that some of them belong on
+	// b after rebalancing.
+	var reposA, reposB []api.RepoName
+	for i := 0; len(reposA) == 0 || len(reposB) == 0; i++ {
+		repo := api.RepoName(fmt.Sprintf("example.com/foo/repo%d", i))
+		dir := filepath.Dir(string(a.dir(repo)))
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		runCmd(t, dir, "git", "init", ".")
+		runCmd(t, dir, "git", "commit", "--allow-empty", "-m", string(repo))
+
+		if gitserver.AddrForKey(gitserver.ModuloHashing, target, string(repo)) == addrB {
+			reposB = append(reposB, repo)
+		} else {
+			reposA = append(reposA, repo)
+		}
+	}
+
+	head := func(s *Server, repo api.RepoName) string {
+		t.Helper()
+		return runCmd(t, string(s.dir(repo)), "git", "log", "--format=%s", "-n1", "HEAD")
+	}
+	assertRepos := func(s *Server, want []api.RepoName) {
+		t.Helper()
+		got, err := s.listCloned(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != len(want) {
+			t.Fatalf("got repositories %v, want %v", got, want)
+		}
+		for _, repo := range want {
+			if got := strings.TrimSpace(head(s, repo)); got != string(repo) {
+				t.Errorf("%s: got HEAD commit %q", repo, got)
+			}
+		}
+	}
+
+	// Routing still uses a only: b copies its repositories from a.
+	a.RebalanceAddrs = target
+	b.RebalanceAddrs = target
+	mockGitServers([]string{addrA})
+	for _, s := range []*Server{a, b} {
+		if err := s.Rebalance(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	assertRepos(a, append(append([]api.RepoName{}, reposA...), reposB...))
+	assertRepos(b, reposB)
+
+	// Routing switched to the rebalanced gitservers: a removes the
+	// repositories that are now on b.
+	mockGitServers(target)
+	for _, s := range []*Server{a, b} {
+		if err := s.Rebalance(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	assertRepos(a, reposA)
+	assertRepos(b, reposB)
+}
+
+func TestExtractTar_invalidPath(t *testing.T) {
+	for _, name := range []string{"../x", "/x", "a/../../x"} {
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0600, Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if err := tw.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		dst := filepath.Join(tmpDir(t), "dst")
+		if err := extractTar(&buf, dst); err == nil || !strings.Contains(err.Error(), "invalid path") {
+			t.Errorf("%s: got error %v, want invalid path", name, err)
+		}
+	}
+}
+
+func TestSelfAddr(t *testing.T) {
+	addrs := []string{"gitserver-0.gitserver:3178", "gitserver-1.gitserver:3178", "10.0.0.2:3178"}
+	for _, test := range []struct {
+		hostname string
+		want     string
+	}{
+		{hostname: "gitserver-1", want: "gitserver-1.gitserver:3178"},
+		{hostname: "10.0.0.2", want: "10.0.0.2:3178"},
+		{hostname: "gitserver", want: ""},
+		{hostname: "gitserver-2", want: ""},
+		{hostname: "", want: ""},
+	} {
+		got, ok := selfAddr(addrs, test.hostname)
+		if got != test.want || ok != (test.want != "") {
+			t.Errorf("%q: got %q, %v, want %q", test.hostname, got, ok, test.want)
+		}
+	}
+}
+
+func TestRebalance_hashing(t *testing.T) {
+	mockRouting := func(addrs []string, hashing gitserver.Hashing) {
+		conf.Mock(&conf.Unified{
+			SiteConfiguration:  schema.SiteConfiguration{GitServerHashing: string(hashing)},
+			ServiceConnections: conftypes.ServiceConnections{GitServers: addrs},
+		})
+	}
+	defer conf.Mock(nil)
+
+	newServer := func(hostname string) (*Server, string) {
+		s := &Server{ReposDir: tmpDir(t), Hostname: hostname}
+		ts := httptest.NewServer(s.Handler())
+		t.Cleanup(ts.Close)
+		port := ts.URL[strings.LastIndex(ts.URL, ":")+1:]
+		return s, hostname + ":" + port
+	}
+	a, addrA := newServer("127.0.0.1")
+	b, addrB := newServer("localhost")
+	addrs := []string{addrA, addrB}
+	servers := map[string]*Server{addrA: a, addrB: b}
+
+	// Create repositories on the gitservers they are on with modulo hashing,
+	// until some of them move to the other gitserver with rendezvous
+	// hashing.
+	var moved int
+	repos := map[api.RepoName]string{}
+	for i := 0; moved == 0 || moved == len(repos); i++ {
+		repo := api.RepoName(fmt.Sprintf("example.com/foo/repo%d", i))
+		from := gitserver.AddrForKey(gitserver.ModuloHashing, addrs, string(repo))
+		to := gitserver.AddrForKey(gitserver.RendezvousHashing, addrs, string(repo))
+		dir := filepath.Dir(string(servers[from].dir(repo)))
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+		runCmd(t, dir, "git", "init", ".")
+		runCmd(t, dir, "git", "commit", "--allow-empty", "-m", string(repo))
+		repos[repo] = to
+		if from != to {
+			moved++
+		}
+	}
+
+	assertCloned := func(wantOnOwnerOnly bool) {
+		t.Helper()
+		for repo, to := range repos {
+			if !repoCloned(servers[to].dir(repo)) {
+				t.Errorf("%s: not cloned on %s", repo, to)
+			}
+			for addr, s := range servers {
+				if addr != to && wantOnOwnerOnly && repoCloned(s.dir(repo)) {
+					t.Errorf("%s: still cloned on %s", repo, addr)
+				}
+			}
+		}
+	}
+
+	// Only the hashing changes: the gitservers copy the repositories they
+	// own with rendezvous hashing.
+	mockRouting(addrs, gitserver.ModuloHashing)
+	for _, s := range servers {
+		s.RebalanceHashing = gitserver.RendezvousHashing
+	}
+	for _, s := range servers {
+		if err := s.Rebalance(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	assertCloned(false)
+
+	// Routing switched to rendezvous hashing: the gitservers remove the
+	// repositories that moved.
+	mockRouting(addrs, gitserver.RendezvousHashing)
+	for _, s := range servers {
+		if err := s.Rebalance(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	assertCloned(true)
+}
@@ -92,6 +92,18 @@ func TestServer_handleRepoInfo(t *testing.T) {
 		repoRemoteURL = func(context.Context, GitDir) (string, error) { return "u", nil }
 		defer func() { repoRemoteURL = origRepoRemoteURL }()
 
+		maintenance := &protocol.RepoMaintenance{
+			Packs:          1,
+			HasBitmap:      true,
+			HasCommitGraph: true,
+			Tasks: map[string]*protocol.RepoMaintenanceTask{
+				"commit-graph": {LastRun: lastFetched, Duration: time.Second},
+			},
+		}
+		origRepoMaintenance := repoMaintenance
+		repoMaintenance = func(dir GitDir) (*protocol.RepoMaintenance, error) { return maintenance, nil }
+		defer func() { repoMaintenance = origRepoMaintenance }()
+
 		want := protocol.RepoInfoResponse{
 			Results: map[api.RepoName]*protocol.RepoInfo{
 				"x": {
@@ -99,6 +111,7 @@ func TestServer_handleRepoInfo(t *testing.T) {
 					LastFetched: &lastFetched,
 					LastChanged: &lastChanged,
 					URL:         "u",
+					Maintenance: maintenance,
 				},
 			},
 		}
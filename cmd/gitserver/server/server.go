@@ -33,6 +33,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/conf"
 	"github.com/sourcegraph/sourcegraph/internal/env"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
 	"github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
 	"github.com/sourcegraph/sourcegraph/internal/honey"
 	"github.com/sourcegraph/sourcegraph/internal/lazyregexp"
@@ -116,6 +117,18 @@ type Server struct {
 	// DiskSizer tells how much disk is free and how large the disk is.
 	DiskSizer DiskSizer
 
+	// RebalanceAddrs, if set, are the addresses of the gitservers to
+	// rebalance repositories to. See Server.Rebalance.
+	RebalanceAddrs []string
+
+	// RebalanceHashing, if set, is the hashing to rebalance repositories to.
+	// See Server.Rebalance.
+	RebalanceHashing gitserver.Hashing
+
+	// Hostname is the hostname of this gitserver, used to find its own
+	// address in RebalanceAddrs.
+	Hostname string
+
 	// skipCloneForTests is set by tests to avoid clones.
 	skipCloneForTests bool
 
@@ -225,6 +238,7 @@ func (s *Server) Handler() http.Handler {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/archive", s.handleArchive)
 	mux.HandleFunc("/exec", s.handleExec)
+	mux.HandleFunc("/batch-exec", s.handleBatchExec)
 	mux.HandleFunc("/list", s.handleList)
 	mux.HandleFunc("/list-gitolite", s.handleListGitolite)
 	mux.HandleFunc("/is-repo-cloneable", s.handleIsRepoCloneable)
@@ -235,6 +249,7 @@ func (s *Server) Handler() http.Handler {
 	mux.HandleFunc("/repo-update", s.handleRepoUpdate)
 	mux.HandleFunc("/getGitolitePhabricatorMetadata", s.handleGetGitolitePhabricatorMetadata)
 	mux.HandleFunc("/create-commit-from-patch", s.handleCreateCommitFromPatch)
+	mux.HandleFunc("/repo-export", s.handleRepoExport)
 	mux.HandleFunc("/ping", func(w http.ResponseWriter, _ *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	})
@@ -502,6 +517,14 @@ func (s *Server) handleArchive(w http.ResponseWriter, r *http.Request) {
 	req.Args = append(req.Args, treeish, "--")
 	req.Args = append(req.Args, paths...)
 
+	// git archive reads every file, so fetch all the files that are missing
+	// from a partial clone at once.
+	if dir := s.dir(req.Repo); isPartialClone(dir) {
+		if err := fetchMissingObjects(r.Context(), dir, treeish, paths); err != nil {
+			log15.Warn("failed to fetch missing objects for archive", "repo", repo, "treeish", treeish, "error", err)
+		}
+	}
+
 	s.exec(w, r, req)
 }
 
@@ -615,37 +638,7 @@ func (s *Server) exec(w http.ResponseWriter, r *http.Request, req *protocol.Exec
 
 	dir := s.dir(req.Repo)
 	if !repoCloned(dir) {
-		cloneProgress, cloneInProgress := s.locker.Status(dir)
-		if cloneInProgress {
-			status = "clone-in-progress"
-			w.WriteHeader(http.StatusNotFound)
-			_ = json.NewEncoder(w).Encode(&protocol.NotFoundPayload{
-				CloneInProgress: true,
-				CloneProgress:   cloneProgress,
-			})
-			return
-		}
-
-		if req.URL == "" {
-			status = "repo-not-found"
-			w.WriteHeader(http.StatusNotFound)
-			_ = json.NewEncoder(w).Encode(&protocol.NotFoundPayload{CloneInProgress: false})
-			return
-		}
-		cloneProgress, err := s.cloneRepo(ctx, req.Repo, req.URL, nil)
-		if err != nil {
-			log15.Debug("error cloning repo", "repo", req.Repo, "err", err)
-			status = "repo-not-found"
-			w.WriteHeader(http.StatusNotFound)
-			_ = json.NewEncoder(w).Encode(&protocol.NotFoundPayload{CloneInProgress: false})
-			return
-		}
-		status = "clone-in-progress"
-		w.WriteHeader(http.StatusNotFound)
-		_ = json.NewEncoder(w).Encode(&protocol.NotFoundPayload{
-			CloneInProgress: true,
-			CloneProgress:   cloneProgress,
-		})
+		status = s.writeNotCloned(ctx, w, req.Repo, req.URL, dir)
 		return
 	}
 
@@ -693,6 +686,11 @@ func (s *Server) exec(w http.ResponseWriter, r *http.Request, req *protocol.Exec
 	cmdStart = time.Now()
 	cmd := exec.CommandContext(ctx, "git", req.Args...)
 	dir.Set(cmd)
+	if isPartialClone(dir) {
+		// git fetches the objects that are missing from a partial clone
+		// from the code host when the command reads them.
+		configureRemoteGitCommand(cmd, tlsExternal().(*tlsConfig))
+	}
 	cmd.Stdout = stdoutW
 	cmd.Stderr = stderrW
 
@@ -711,6 +709,40 @@ func (s *Server) exec(w http.ResponseWriter, r *http.Request, req *protocol.Exec
 	w.Header().Set("X-Exec-Stderr", stderr)
 }
 
+// writeNotCloned writes the response to a request for a repository that is
+// not cloned, and starts cloning it if url is set. It returns the status of
+// the request for instrumentation.
+func (s *Server) writeNotCloned(ctx context.Context, w http.ResponseWriter, repo api.RepoName, url string, dir GitDir) (status string) {
+	cloneProgress, cloneInProgress := s.locker.Status(dir)
+	if cloneInProgress {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(&protocol.NotFoundPayload{
+			CloneInProgress: true,
+			CloneProgress:   cloneProgress,
+		})
+		return "clone-in-progress"
+	}
+
+	if url == "" {
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(&protocol.NotFoundPayload{CloneInProgress: false})
+		return "repo-not-found"
+	}
+	cloneProgress, err := s.cloneRepo(ctx, repo, url, nil)
+	if err != nil {
+		log15.Debug("error cloning repo", "repo", repo, "err", err)
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(&protocol.NotFoundPayload{CloneInProgress: false})
+		return "repo-not-found"
+	}
+	w.WriteHeader(http.StatusNotFound)
+	_ = json.NewEncoder(w).Encode(&protocol.NotFoundPayload{
+		CloneInProgress: true,
+		CloneProgress:   cloneProgress,
+	})
+	return "clone-in-progress"
+}
+
 // setGitAttributes writes our global gitattributes to
 // gitDir/info/attributes. This will override .gitattributes inside of
 // repositories. It is used to unset attributes such as export-ignore.
@@ -833,7 +865,9 @@ func (s *Server) cloneRepo(ctx context.Context, repo api.RepoName, url string, o
 				return err
 			}
 		} else {
-			cmd = exec.CommandContext(ctx, "git", "clone", "--mirror", "--progress", url, tmpPath)
+			args, _ := cloneOptionsArgs(repo)
+			args = append(append([]string{"clone", "--mirror", "--progress"}, args...), url, tmpPath)
+			cmd = exec.CommandContext(ctx, "git", args...)
 		}
 		// see issue #7322: skip LFS content in repositories with Git LFS configured
 		cmd.Env = append(os.Environ(), "GIT_LFS_SKIP_SMUDGE=1")
@@ -1063,14 +1097,7 @@ func (s *Server) doRepoUpdate(ctx context.Context, repo api.RepoName, url string
 	defer span.Finish()
 
 	s.repoUpdateLocksMu.Lock()
-	l, ok := s.repoUpdateLocks[repo]
-	if !ok {
-		l = &locks{
-			once: new(sync.Once),
-			mu:   new(sync.Mutex),
-		}
-		s.repoUpdateLocks[repo] = l
-	}
+	l := s.repoUpdateLocksFor(repo)
 	once := l.once
 	mu := l.mu
 	s.repoUpdateLocksMu.Unlock()
@@ -1103,6 +1130,20 @@ func (s *Server) doRepoUpdate(ctx context.Context, repo api.RepoName, url string
 	}
 }
 
+// repoUpdateLocksFor returns the update locks of repo. The caller must hold
+// s.repoUpdateLocksMu.
+func (s *Server) repoUpdateLocksFor(repo api.RepoName) *locks {
+	l, ok := s.repoUpdateLocks[repo]
+	if !ok {
+		l = &locks{
+			once: new(sync.Once),
+			mu:   new(sync.Mutex),
+		}
+		s.repoUpdateLocks[repo] = l
+	}
+	return l
+}
+
 var (
 	badRefsOnce sync.Once
 	badRefs     []string
@@ -1319,7 +1360,15 @@ func (s *Server) doRepoUpdate2(repo api.RepoName, url string) error {
 	} else if useRefspecOverrides() {
 		cmd = refspecOverridesFetchCmd(ctx, url)
 	} else {
-		cmd = exec.CommandContext(ctx, "git", "fetch", "--prune", url,
+		args, filtered := cloneOptionsArgs(repo)
+		remote := url
+		if filtered || isPartialClone(dir) {
+			// Fetch from the origin remote, whose URL we set above, so that
+			// git keeps it as the promisor remote of the partial clone that
+			// missing objects are fetched from.
+			remote = "origin"
+		}
+		args = append(append([]string{"fetch", "--prune"}, args...), remote,
 			// Normal git refs
 			"+refs/heads/*:refs/heads/*", "+refs/tags/*:refs/tags/*",
 			// GitHub pull requests
@@ -1330,6 +1379,7 @@ func (s *Server) doRepoUpdate2(repo api.RepoName, url string) error {
 			"+refs/pull-requests/*:refs/pull-requests/*",
 			// Possibly deprecated refs for sourcegraph zap experiment?
 			"+refs/sourcegraph/*:refs/sourcegraph/*")
+		cmd = exec.CommandContext(ctx, "git", args...)
 	}
 	dir.Set(cmd)
 
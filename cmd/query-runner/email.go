@@ -43,26 +43,30 @@ func (n *notifier) emailNotify(ctx context.Context) {
 				ownership = "your organization's"
 			}
 
-			plural := ""
-			if n.results.Data.Search.Results.ApproximateResultCount != "1" {
-				plural = "s"
-			}
+			added, moreAdded := listed(n.added)
+			removed, moreRemoved := listed(n.removed)
 			if err := sendEmail(ctx, recipient.spec.userID, "results", newSearchResultsEmailTemplates, struct {
-				URL                    string
-				SavedSearchPageURL     string
-				Description            string
-				Query                  string
-				ApproximateResultCount string
-				Ownership              string
-				PluralResults          string
+				URL                string
+				SavedSearchPageURL string
+				Description        string
+				Query              string
+				Summary            string
+				Added              []string
+				MoreAdded          int
+				Removed            []string
+				MoreRemoved        int
+				Ownership          string
 			}{
-				URL:                    searchURL(n.newQuery, utmSourceEmail),
-				SavedSearchPageURL:     savedSearchListPageURL(utmSourceEmail),
-				Description:            n.query.Description,
-				Query:                  n.query.Query,
-				ApproximateResultCount: n.results.Data.Search.Results.ApproximateResultCount,
-				Ownership:              ownership,
-				PluralResults:          plural,
+				URL:                searchURL(n.newQuery, utmSourceEmail),
+				SavedSearchPageURL: savedSearchListPageURL(utmSourceEmail),
+				Description:        n.query.Description,
+				Query:              n.query.Query,
+				Summary:            n.summary(),
+				Added:              added,
+				MoreAdded:          moreAdded,
+				Removed:            removed,
+				MoreRemoved:        moreRemoved,
+				Ownership:          ownership,
 			}); err != nil {
 				log15.Error("Failed to send email notification for new saved search results.", "userID", recipient.spec.userID, "error", err)
 			}
@@ -71,20 +75,40 @@ func (n *notifier) emailNotify(ctx context.Context) {
 }
 
 var newSearchResultsEmailTemplates = txemail.MustValidate(txtypes.Templates{
-	Subject: `[{{.ApproximateResultCount}} new result{{.PluralResults}}] {{.Description}}`,
+	Subject: `[{{.Summary}}] {{.Description}}`,
 	Text: `
-{{.ApproximateResultCount}} new search result{{.PluralResults}} found for {{.Ownership}} saved search:
+{{.Summary}} found for {{.Ownership}} saved search:
 
   "{{.Description}}"
-
-View the new result{{.PluralResults}} on Sourcegraph: {{.URL}}
+{{if .Added}}
+New results:{{range .Added}}
+  + {{.}}{{end}}{{if .MoreAdded}}
+  ...and {{.MoreAdded}} more{{end}}
+{{end}}{{if .Removed}}
+Removed results:{{range .Removed}}
+  - {{.}}{{end}}{{if .MoreRemoved}}
+  ...and {{.MoreRemoved}} more{{end}}
+{{end}}
+View the results on Sourcegraph: {{.URL}}
 `,
 	HTML: `
-<strong>{{.ApproximateResultCount}}</strong> new search result{{.PluralResults}} found for {{.Ownership}} saved search:
+<strong>{{.Summary}}</strong> found for {{.Ownership}} saved search:
 
 <p style="padding-left: 16px">&quot;{{.Description}}&quot;</p>
-
-<p><a href="{{.URL}}">View the new result{{.PluralResults}} on Sourcegraph</a></p>
+{{if .Added}}
+<p>New results:</p>
+<ul>{{range .Added}}
+  <li><code>{{.}}</code></li>{{end}}{{if .MoreAdded}}
+  <li>...and {{.MoreAdded}} more</li>{{end}}
+</ul>
+{{end}}{{if .Removed}}
+<p>Removed results:</p>
+<ul>{{range .Removed}}
+  <li><code>{{.}}</code></li>{{end}}{{if .MoreRemoved}}
+  <li>...and {{.MoreRemoved}} more</li>{{end}}
+</ul>
+{{end}}
+<p><a href="{{.URL}}">View the results on Sourcegraph</a></p>
 
 <p><a href="{{.SavedSearchPageURL}}">Edit your saved searches on Sourcegraph</a></p>
 `,
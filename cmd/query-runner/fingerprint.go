@@ -0,0 +1,163 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/inconshreveable/log15"
+)
+
+// fingerprintHashLen is the length of the hash prefix of a fingerprint.
+const fingerprintHashLen = 16
+
+// gqlSearchResult is a single search result of gqlSearchQuery. Only the fields
+// of the result's __typename are set.
+type gqlSearchResult struct {
+	Typename string `json:"__typename"`
+
+	// FileMatch
+	Resource    string
+	LineMatches []struct {
+		Preview    string
+		LineNumber int
+	}
+
+	// Repository
+	Name string
+
+	// CommitSearchResult
+	Commit struct {
+		Repository struct {
+			Name string
+		}
+		OID            string
+		AbbreviatedOID string
+		Message        string
+	}
+}
+
+// resultFingerprints returns a fingerprint for each match in results.
+//
+// A fingerprint is a hash which identifies the match, followed by a space and
+// a human readable description of the match. Matches are compared by their
+// hash only, see diffFingerprints.
+//
+// A line match is identified by its file and the line's content with
+// whitespace collapsed. The line number is not part of the hash, so that adding
+// lines above a match or reformatting it does not make it a new match. A commit
+// or diff result is identified by its repository and commit OID, and a
+// repository result by its name.
+func resultFingerprints(results []interface{}) []string {
+	fingerprints := []string{}
+	seen := make(map[string]int)
+	add := func(identity, description string) {
+		// Identical lines in the same file are distinct matches.
+		if n := seen[identity]; n > 0 {
+			seen[identity]++
+			identity = fmt.Sprintf("%s\x00%d", identity, n)
+		} else {
+			seen[identity] = 1
+		}
+		h := sha256.Sum256([]byte(identity))
+		fingerprints = append(fingerprints, hex.EncodeToString(h[:])[:fingerprintHashLen]+" "+description)
+	}
+
+	for _, raw := range results {
+		var result gqlSearchResult
+		b, err := json.Marshal(raw)
+		if err == nil {
+			err = json.Unmarshal(b, &result)
+		}
+		if err != nil {
+			log15.Error("failed to decode search result", "error", err)
+			continue
+		}
+
+		switch result.Typename {
+		case "FileMatch":
+			repo, path := splitResource(result.Resource)
+			for _, lm := range result.LineMatches {
+				content := strings.Join(strings.Fields(lm.Preview), " ")
+				add(
+					"file\x00"+result.Resource+"\x00"+content,
+					fmt.Sprintf("%s › %s:%d: %s", repo, path, lm.LineNumber+1, truncate(content, 100)),
+				)
+			}
+		case "CommitSearchResult":
+			c := result.Commit
+			subject := strings.SplitN(c.Message, "\n", 2)[0]
+			add(
+				"commit\x00"+c.Repository.Name+"\x00"+c.OID,
+				fmt.Sprintf("%s › %s: %s", c.Repository.Name, c.AbbreviatedOID, truncate(subject, 100)),
+			)
+		case "Repository":
+			add("repo\x00"+result.Name, result.Name)
+		default:
+			log15.Warn("unexpected search result type", "type", result.Typename)
+		}
+	}
+	return fingerprints
+}
+
+// diffFingerprints returns the fingerprints in new which are not in old
+// (added), and those in old which are not in new (removed).
+func diffFingerprints(old, new []string) (added, removed []string) {
+	hashes := func(fingerprints []string) map[string]struct{} {
+		m := make(map[string]struct{}, len(fingerprints))
+		for _, fp := range fingerprints {
+			m[fingerprintHash(fp)] = struct{}{}
+		}
+		return m
+	}
+	oldHashes, newHashes := hashes(old), hashes(new)
+
+	for _, fp := range new {
+		if _, ok := oldHashes[fingerprintHash(fp)]; !ok {
+			added = append(added, fp)
+		}
+	}
+	for _, fp := range old {
+		if _, ok := newHashes[fingerprintHash(fp)]; !ok {
+			removed = append(removed, fp)
+		}
+	}
+	return added, removed
+}
+
+func fingerprintHash(fingerprint string) string {
+	if len(fingerprint) < fingerprintHashLen {
+		return fingerprint
+	}
+	return fingerprint[:fingerprintHashLen]
+}
+
+// fingerprintDescriptions returns the human readable descriptions of
+// fingerprints.
+func fingerprintDescriptions(fingerprints []string) []string {
+	descriptions := make([]string, 0, len(fingerprints))
+	for _, fp := range fingerprints {
+		descriptions = append(descriptions, strings.TrimPrefix(fp, fingerprintHash(fp)+" "))
+	}
+	return descriptions
+}
+
+// splitResource returns the repository name and file path of a FileMatch
+// resource, such as git://github.com/foo/bar?rev#path/to/file.
+func splitResource(resource string) (repo, path string) {
+	u, err := url.Parse(resource)
+	if err != nil {
+		return resource, ""
+	}
+	return u.Host + u.Path, u.Fragment
+}
+
+func truncate(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n]) + "…"
+	}
+	return s
+}
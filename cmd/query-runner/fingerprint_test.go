@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func decodeResults(t *testing.T, s string) []interface{} {
+	t.Helper()
+	var results []interface{}
+	if err := json.Unmarshal([]byte(s), &results); err != nil {
+		t.Fatal(err)
+	}
+	return results
+}
+
+func TestResultFingerprints(t *testing.T) {
+	old := resultFingerprints(decodeResults(t, `[
+		{"__typename": "FileMatch", "resource": "git://github.com/foo/bar#a.go", "lineMatches": [
+			{"preview": "x := secret(1)", "lineNumber": 4},
+			{"preview": "y := secret(2)", "lineNumber": 9},
+			{"preview": "y := secret(2)", "lineNumber": 20}
+		]},
+		{"__typename": "Repository", "name": "github.com/foo/baz"}
+	]`))
+	new := resultFingerprints(decodeResults(t, `[
+		{"__typename": "FileMatch", "resource": "git://github.com/foo/bar#a.go", "lineMatches": [
+			{"preview": "x   :=   secret(1)", "lineNumber": 6},
+			{"preview": "y := secret(2)", "lineNumber": 11},
+			{"preview": "z := secret(3)", "lineNumber": 12}
+		]},
+		{"__typename": "CommitSearchResult", "commit": {
+			"repository": {"name": "github.com/foo/qux"},
+			"oid": "deadbeefdeadbeef",
+			"abbreviatedOID": "deadbee",
+			"message": "Add secret\n\nDetails."
+		}}
+	]`))
+
+	added, removed := diffFingerprints(old, new)
+	if want := []string{
+		"github.com/foo/bar › a.go:13: z := secret(3)",
+		"github.com/foo/qux › deadbee: Add secret",
+	}; !reflect.DeepEqual(fingerprintDescriptions(added), want) {
+		t.Errorf("got added %q, want %q", fingerprintDescriptions(added), want)
+	}
+	if want := []string{
+		"github.com/foo/bar › a.go:21: y := secret(2)",
+		"github.com/foo/baz",
+	}; !reflect.DeepEqual(fingerprintDescriptions(removed), want) {
+		t.Errorf("got removed %q, want %q", fingerprintDescriptions(removed), want)
+	}
+}
+
+func TestDiffResults(t *testing.T) {
+	response := func(limitHit bool, results string) *gqlSearchResponse {
+		var v gqlSearchResponse
+		v.Data.Search.Results.LimitHit = limitHit
+		v.Data.Search.Results.Results = decodeResults(t, results)
+		return &v
+	}
+	repo := func(name string) string {
+		return `{"__typename": "Repository", "name": "` + name + `"}`
+	}
+	prev := resultFingerprints(decodeResults(t, "["+repo("a")+","+repo("b")+"]"))
+
+	t.Run("first execution", func(t *testing.T) {
+		fingerprints, added, removed := diffResults(false, true, nil, response(false, "["+repo("a")+"]"))
+		if len(fingerprints) != 1 || added != nil || removed != nil {
+			t.Errorf("got fingerprints %q, added %q, removed %q", fingerprints, added, removed)
+		}
+	})
+
+	t.Run("changed", func(t *testing.T) {
+		_, added, removed := diffResults(false, true, prev, response(false, "["+repo("a")+","+repo("c")+"]"))
+		if got := fingerprintDescriptions(added); !reflect.DeepEqual(got, []string{"c"}) {
+			t.Errorf("got added %q", got)
+		}
+		if got := fingerprintDescriptions(removed); !reflect.DeepEqual(got, []string{"b"}) {
+			t.Errorf("got removed %q", got)
+		}
+	})
+
+	t.Run("limit hit", func(t *testing.T) {
+		_, _, removed := diffResults(false, true, prev, response(true, "["+repo("a")+"]"))
+		if removed != nil {
+			t.Errorf("got removed %q, want none", removed)
+		}
+	})
+
+	t.Run("commit query", func(t *testing.T) {
+		fingerprints, added, removed := diffResults(true, true, prev, response(false, "[]"))
+		if !reflect.DeepEqual(fingerprints, prev) || added != nil || removed != nil {
+			t.Errorf("got fingerprints %q, added %q, removed %q", fingerprints, added, removed)
+		}
+	})
+}
@@ -42,6 +42,9 @@ const gqlSearchQuery = `query Search(
 						offsetAndLengths
 					}
 				}
+				... on Repository {
+					name
+				}
 				... on CommitSearchResult {
 					refs {
 						name
@@ -113,6 +116,7 @@ type gqlSearchResponse struct {
 		Search struct {
 			Results struct {
 				ApproximateResultCount string
+				LimitHit               bool
 				Cloning                []*api.Repo
 				Timedout               []*api.Repo
 				Results                []interface{}
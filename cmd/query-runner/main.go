@@ -138,15 +138,11 @@ func (e *executorT) run(ctx context.Context) error {
 // runQuery runs the given query if an appropriate amount of time has elapsed
 // since it last ran.
 func (e *executorT) runQuery(ctx context.Context, spec api.SavedQueryIDSpec, query api.ConfigSavedQuery) error {
-	if !query.Notify && !query.NotifySlack {
+	if !query.Notify && !query.NotifySlack && !query.NotifyWebhook {
 		// No need to run this query because there will be nobody to notify.
 		return nil
 	}
-	if !strings.Contains(query.Query, "type:diff") && !strings.Contains(query.Query, "type:commit") {
-		// TODO(slimsag): we temporarily do not support non-commit search
-		// queries, since those do not support the after:"time" operator.
-		return nil
-	}
+	isCommitQuery := strings.Contains(query.Query, "type:diff") || strings.Contains(query.Query, "type:commit")
 
 	info, err := api.InternalClient.SavedQueriesGetInfo(ctx, query.Query)
 	if err != nil {
@@ -175,18 +171,21 @@ func (e *executorT) runQuery(ctx context.Context, spec api.SavedQueryIDSpec, que
 		}
 	}
 
-	// Construct a new query which finds search results introduced after the
-	// last time we queried.
-	var latestKnownResult time.Time
-	if info != nil {
-		latestKnownResult = info.LatestResult
-	} else {
-		// We've never executed this search query before, so use the current
-		// time. We'll most certainly find nothing, which is okay.
-		latestKnownResult = time.Now()
+	newQuery := query.Query
+	if isCommitQuery {
+		// Construct a new query which finds search results introduced after the
+		// last time we queried.
+		var latestKnownResult time.Time
+		if info != nil {
+			latestKnownResult = info.LatestResult
+		} else {
+			// We've never executed this search query before, so use the current
+			// time. We'll most certainly find nothing, which is okay.
+			latestKnownResult = time.Now()
+		}
+		afterTime := latestKnownResult.UTC().Format(time.RFC3339)
+		newQuery = strings.Join([]string{query.Query, fmt.Sprintf(`after:"%s"`, afterTime)}, " ")
 	}
-	afterTime := latestKnownResult.UTC().Format(time.RFC3339)
-	newQuery := strings.Join([]string{query.Query, fmt.Sprintf(`after:"%s"`, afterTime)}, " ")
 	if debugPretendSavedQueryResultsExist {
 		debugPretendSavedQueryResultsExist = false
 		newQuery = query.Query
@@ -198,11 +197,26 @@ func (e *executorT) runQuery(ctx context.Context, spec api.SavedQueryIDSpec, que
 	// constantly and potentially causing harm to the system. We'll retry at
 	// our normal interval, regardless of errors.
 	v, execDuration, searchErr := performSearch(ctx, newQuery)
+
+	var prevFingerprints []string
+	if info != nil {
+		prevFingerprints = info.ResultFingerprints
+	}
+	fingerprints, added, removed := prevFingerprints, []string(nil), []string(nil)
+	if searchErr == nil {
+		fingerprints, added, removed = diffResults(isCommitQuery, info != nil, prevFingerprints, v)
+	}
+
+	latestResult := time.Now()
+	if isCommitQuery {
+		latestResult = latestResultTime(info, v, searchErr)
+	}
 	if err := api.InternalClient.SavedQueriesSetInfo(ctx, &api.SavedQueryInfo{
-		Query:        query.Query,
-		LastExecuted: time.Now(),
-		LatestResult: latestResultTime(info, v, searchErr),
-		ExecDuration: execDuration,
+		Query:              query.Query,
+		LastExecuted:       time.Now(),
+		LatestResult:       latestResult,
+		ExecDuration:       execDuration,
+		ResultFingerprints: fingerprints,
 	}); err != nil {
 		return errors.Wrap(err, "SavedQueriesSetInfo")
 	}
@@ -215,13 +229,46 @@ func (e *executorT) runQuery(ctx context.Context, spec api.SavedQueryIDSpec, que
 	// that we don't block other search queries from running in sequence (which
 	// is done intentionally, to ensure no overloading of searcher/gitserver).
 	go func() {
-		if err := notify(context.Background(), spec, query, newQuery, v); err != nil {
+		if err := notify(context.Background(), spec, query, newQuery, added, removed); err != nil {
 			log15.Error("executor: failed to send notifications", "error", err)
 		}
 	}()
 	return nil
 }
 
+// diffResults returns the fingerprints of the results of a saved query
+// execution to store, and the fingerprints of the matches that were added and
+// removed since the previous execution.
+//
+// Commit queries only search for commits after the latest known result, so
+// their results are all new unless they were seen before, and no matches are
+// ever removed. The results of other queries are compared to the results of
+// the previous execution. Their first execution (executed is false, or no
+// fingerprints were recorded yet) only records the results.
+func diffResults(isCommitQuery, executed bool, prevFingerprints []string, v *gqlSearchResponse) (fingerprints, added, removed []string) {
+	fingerprints = resultFingerprints(v.Data.Search.Results.Results)
+	if isCommitQuery {
+		added, _ = diffFingerprints(prevFingerprints, fingerprints)
+		if len(fingerprints) == 0 {
+			// Keep the previously seen commits to avoid notifying about them
+			// again if the next search returns them.
+			fingerprints = prevFingerprints
+		}
+		return fingerprints, added, nil
+	}
+
+	if !executed || prevFingerprints == nil {
+		return fingerprints, nil, nil
+	}
+	added, removed = diffFingerprints(prevFingerprints, fingerprints)
+	if v.Data.Search.Results.LimitHit {
+		// Matches beyond the result limit are not returned, so we cannot tell
+		// whether a match that is missing was actually removed.
+		removed = nil
+	}
+	return fingerprints, added, removed
+}
+
 func performSearch(ctx context.Context, query string) (v *gqlSearchResponse, execDuration time.Duration, err error) {
 	attempts := 0
 	for {
@@ -275,12 +322,13 @@ func latestResultTime(prevInfo *api.SavedQueryInfo, v *gqlSearchResponse, search
 
 var externalURL *url.URL
 
-// notify handles sending notifications for new search results.
-func notify(ctx context.Context, spec api.SavedQueryIDSpec, query api.ConfigSavedQuery, newQuery string, results *gqlSearchResponse) error {
-	if len(results.Data.Search.Results.Results) == 0 {
+// notify handles sending notifications for added and removed search results,
+// given as fingerprints.
+func notify(ctx context.Context, spec api.SavedQueryIDSpec, query api.ConfigSavedQuery, newQuery string, added, removed []string) error {
+	if len(added) == 0 && len(removed) == 0 {
 		return nil
 	}
-	log15.Info("sending notifications", "new_results", len(results.Data.Search.Results.Results), "description", query.Description)
+	log15.Info("sending notifications", "new_results", len(added), "removed_results", len(removed), "description", query.Description)
 
 	// Determine which users to notify.
 	recipients, err := getNotificationRecipients(ctx, spec, query)
@@ -288,18 +336,19 @@ func notify(ctx context.Context, spec api.SavedQueryIDSpec, query api.ConfigSave
 		return err
 	}
 
-	// Send slack notifications.
 	n := &notifier{
 		spec:       spec,
 		query:      query,
 		newQuery:   newQuery,
-		results:    results,
+		added:      fingerprintDescriptions(added),
+		removed:    fingerprintDescriptions(removed),
 		recipients: recipients,
 	}
 
-	// Send Slack and email notifications.
+	// Send Slack, email and webhook notifications.
 	n.slackNotify(ctx)
 	n.emailNotify(ctx)
+	n.webhookNotify(ctx)
 	return nil
 }
 
@@ -307,13 +356,47 @@ type notifier struct {
 	spec       api.SavedQueryIDSpec
 	query      api.ConfigSavedQuery
 	newQuery   string
-	results    *gqlSearchResponse
+	added      []string // descriptions of the new matches
+	removed    []string // descriptions of the matches that no longer exist
 	recipients recipients
 }
 
+// maxListedResults is the maximum number of added or removed matches listed
+// in a notification.
+const maxListedResults = 10
+
+// summary returns a summary of the changed results, such as "3 new results
+// and 1 removed result".
+func (n *notifier) summary() string {
+	count := func(n int, kind string) string {
+		if n == 1 {
+			return fmt.Sprintf("%d %s result", n, kind)
+		}
+		return fmt.Sprintf("%d %s results", n, kind)
+	}
+	switch {
+	case len(n.removed) == 0:
+		return count(len(n.added), "new")
+	case len(n.added) == 0:
+		return count(len(n.removed), "removed")
+	default:
+		return count(len(n.added), "new") + " and " + count(len(n.removed), "removed")
+	}
+}
+
+// listed returns at most maxListedResults of descriptions, and the number of
+// descriptions that were left out.
+func listed(descriptions []string) (list []string, more int) {
+	if len(descriptions) > maxListedResults {
+		return descriptions[:maxListedResults], len(descriptions) - maxListedResults
+	}
+	return descriptions, 0
+}
+
 const (
-	utmSourceEmail = "saved-search-email"
-	utmSourceSlack = "saved-search-slack"
+	utmSourceEmail   = "saved-search-email"
+	utmSourceSlack   = "saved-search-slack"
+	utmSourceWebhook = "saved-search-webhook"
 )
 
 func searchURL(query, utmSource string) string {
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/inconshreveable/log15"
 
@@ -11,17 +12,25 @@ import (
 )
 
 func (n *notifier) slackNotify(ctx context.Context) {
-	plural := ""
-	if n.results.Data.Search.Results.ApproximateResultCount != "1" {
-		plural = "s"
-	}
-
-	text := fmt.Sprintf(`*%s* new result%s found for saved search <%s|"%s">`,
-		n.results.Data.Search.Results.ApproximateResultCount,
-		plural,
+	var b strings.Builder
+	fmt.Fprintf(&b, `*%s* found for saved search <%s|"%s">`,
+		n.summary(),
 		searchURL(n.newQuery, utmSourceSlack),
 		n.query.Description,
 	)
+	writeList := func(prefix string, descriptions []string) {
+		list, more := listed(descriptions)
+		for _, d := range list {
+			fmt.Fprintf(&b, "\n%s `%s`", prefix, d)
+		}
+		if more > 0 {
+			fmt.Fprintf(&b, "\n...and %d more", more)
+		}
+	}
+	writeList("+", n.added)
+	writeList("-", n.removed)
+	text := b.String()
+
 	for _, recipient := range n.recipients {
 		if err := slackNotify(ctx, recipient, text, n.query.SlackWebhookURL); err != nil {
 			log15.Error("Failed to post Slack notification message.", "recipient", recipient, "text", text, "error", err)
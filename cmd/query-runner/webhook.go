@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/inconshreveable/log15"
+	"github.com/pkg/errors"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+)
+
+// Events of a webhook payload, which are also sent in the X-Sourcegraph-Event
+// header.
+const (
+	webhookEventResults = "results"
+	webhookEventTest    = "test"
+)
+
+const (
+	// webhookSignatureHeader is the header which contains the HMAC-SHA256 of
+	// the request body, keyed with the webhook secret of the saved search.
+	webhookSignatureHeader = "X-Sourcegraph-Signature"
+	webhookEventHeader     = "X-Sourcegraph-Event"
+
+	// webhookMaxAttempts is the number of times a webhook delivery is
+	// attempted before it is given up.
+	webhookMaxAttempts = 5
+)
+
+// webhookBackoff is the delay before the first retry of a webhook delivery. It
+// doubles with each further retry.
+var webhookBackoff = 2 * time.Second
+
+// webhookPayload is the JSON body that is POSTed to the outgoing webhook of a
+// saved search.
+type webhookPayload struct {
+	Event       string             `json:"event"`
+	SavedSearch webhookSavedSearch `json:"savedSearch"`
+	SearchURL   string             `json:"searchURL"`
+	Summary     string             `json:"summary"`
+	Added       []string           `json:"added"`   // descriptions of the new matches
+	Removed     []string           `json:"removed"` // descriptions of the matches that no longer exist
+}
+
+type webhookSavedSearch struct {
+	ID          string `json:"id"`
+	Description string `json:"description"`
+	Query       string `json:"query"`
+}
+
+func newWebhookPayload(event string, spec api.SavedQueryIDSpec, query api.ConfigSavedQuery, searchQuery string) *webhookPayload {
+	return &webhookPayload{
+		Event: event,
+		SavedSearch: webhookSavedSearch{
+			ID:          spec.Key,
+			Description: query.Description,
+			Query:       query.Query,
+		},
+		SearchURL: searchURL(searchQuery, utmSourceWebhook),
+		Added:     []string{},
+		Removed:   []string{},
+	}
+}
+
+func (n *notifier) webhookNotify(ctx context.Context) {
+	if !n.query.NotifyWebhook {
+		return
+	}
+	payload := newWebhookPayload(webhookEventResults, n.spec, n.query, n.newQuery)
+	payload.Summary = n.summary()
+	if n.added != nil {
+		payload.Added = n.added
+	}
+	if n.removed != nil {
+		payload.Removed = n.removed
+	}
+	if err := webhookNotify(ctx, n.spec, n.query, payload); err != nil {
+		log15.Error("Failed to deliver webhook notification.", "savedSearch", n.spec.Key, "error", err)
+	}
+	logEvent(0, "SavedSearchWebhookNotificationSent", "results")
+}
+
+func webhookNotifyTest(ctx context.Context, query api.SavedQuerySpecAndConfig) error {
+	if !query.Config.NotifyWebhook {
+		return nil
+	}
+	payload := newWebhookPayload(webhookEventTest, query.Spec, query.Config, query.Config.Query)
+	payload.Summary = "test notification"
+	return webhookNotify(ctx, query.Spec, query.Config, payload)
+}
+
+// webhookNotify delivers payload to the webhook of the saved search, and adds
+// the delivery to the saved search's delivery log.
+func webhookNotify(ctx context.Context, spec api.SavedQueryIDSpec, query api.ConfigSavedQuery, payload *webhookPayload) error {
+	if query.WebhookURL == nil || *query.WebhookURL == "" {
+		return fmt.Errorf("unable to send webhook notification because saved search %s has no webhook URL configured", spec.Key)
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return errors.Wrap(err, "marshal webhook payload")
+	}
+	var secret string
+	if query.WebhookSecret != nil {
+		secret = *query.WebhookSecret
+	}
+
+	attempts, statusCode, deliveryErr := deliverWebhook(ctx, *query.WebhookURL, secret, payload.Event, body)
+
+	delivery := &api.SavedSearchWebhookDelivery{
+		Key:        spec.Key,
+		URL:        *query.WebhookURL,
+		Attempts:   attempts,
+		StatusCode: statusCode,
+	}
+	if deliveryErr != nil {
+		delivery.Error = deliveryErr.Error()
+	}
+	if err := api.InternalClient.SavedQueriesLogWebhookDelivery(ctx, delivery); err != nil {
+		log15.Error("Failed to log webhook delivery.", "savedSearch", spec.Key, "error", err)
+	}
+	return deliveryErr
+}
+
+// deliverWebhook POSTs body to url, retrying with exponential backoff when the
+// request fails or the endpoint responds with a status code that indicates a
+// temporary failure. It returns the number of attempts made and the status
+// code of the last response, if any.
+func deliverWebhook(ctx context.Context, url, secret, event string, body []byte) (attempts, statusCode int, err error) {
+	backoff := webhookBackoff
+	for {
+		attempts++
+		var retry bool
+		statusCode, retry, err = postWebhook(ctx, url, secret, event, body)
+		if err == nil || !retry || attempts == webhookMaxAttempts {
+			return attempts, statusCode, err
+		}
+
+		log15.Debug("Retrying webhook delivery.", "url", url, "attempt", attempts, "error", err)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return attempts, statusCode, ctx.Err()
+		}
+		backoff *= 2
+	}
+}
+
+// postWebhook makes a single attempt to deliver body to url. retry reports
+// whether the failure may be temporary.
+func postWebhook(ctx context.Context, url, secret, event string, body []byte) (statusCode int, retry bool, err error) {
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return 0, false, errors.Wrap(err, "create webhook request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhookEventHeader, event)
+	if secret != "" {
+		req.Header.Set(webhookSignatureHeader, webhookSignature(secret, body))
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	resp, err := http.DefaultClient.Do(req.WithContext(timeoutCtx))
+	if err != nil {
+		return 0, true, errors.Wrap(err, "webhook request")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp.StatusCode, false, nil
+	}
+
+	respBody, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+	retry = resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return resp.StatusCode, retry, fmt.Errorf("webhook responded with %d %s", resp.StatusCode, respBody)
+}
+
+// webhookSignature returns the value of the signature header for body, which
+// receivers can use to verify that the request was sent by Sourcegraph.
+func webhookSignature(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWebhookSignature(t *testing.T) {
+	// Computed with: printf '{"event":"test"}' | openssl dgst -sha256 -hmac s3cr3t
+	want := "sha256=9d87aa496defed5ff1f27af2e32c54344e9ca1379d64f94d91e19193466c0567"
+	if got := webhookSignature("s3cr3t", []byte(`{"event":"test"}`)); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestDeliverWebhook(t *testing.T) {
+	defer func(d time.Duration) { webhookBackoff = d }(webhookBackoff)
+	webhookBackoff = 0
+
+	body := []byte(`{"event":"results"}`)
+	serve := func(statusCodes ...int) (*httptest.Server, *int) {
+		var requests int
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			b, _ := ioutil.ReadAll(r.Body)
+			if string(b) != string(body) {
+				t.Errorf("got body %q, want %q", b, body)
+			}
+			if got, want := r.Header.Get(webhookSignatureHeader), webhookSignature("secret", body); got != want {
+				t.Errorf("got signature %q, want %q", got, want)
+			}
+			if got := r.Header.Get(webhookEventHeader); got != webhookEventResults {
+				t.Errorf("got event %q, want %q", got, webhookEventResults)
+			}
+			w.WriteHeader(statusCodes[requests])
+			requests++
+		})), &requests
+	}
+
+	tests := []struct {
+		name           string
+		statusCodes    []int
+		wantAttempts   int
+		wantStatusCode int
+		wantErr        bool
+	}{
+		{
+			name:           "success",
+			statusCodes:    []int{http.StatusNoContent},
+			wantAttempts:   1,
+			wantStatusCode: http.StatusNoContent,
+		},
+		{
+			name:           "retry temporary failures",
+			statusCodes:    []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK},
+			wantAttempts:   3,
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "no retry for client errors",
+			statusCodes:    []int{http.StatusNotFound},
+			wantAttempts:   1,
+			wantStatusCode: http.StatusNotFound,
+			wantErr:        true,
+		},
+		{
+			name:           "give up",
+			statusCodes:    []int{500, 500, 500, 500, 500, 500},
+			wantAttempts:   webhookMaxAttempts,
+			wantStatusCode: http.StatusInternalServerError,
+			wantErr:        true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			srv, requests := serve(test.statusCodes...)
+			defer srv.Close()
+
+			attempts, statusCode, err := deliverWebhook(context.Background(), srv.URL, "secret", webhookEventResults, body)
+			if attempts != test.wantAttempts || *requests != test.wantAttempts {
+				t.Errorf("got %d attempts and %d requests, want %d", attempts, *requests, test.wantAttempts)
+			}
+			if statusCode != test.wantStatusCode {
+				t.Errorf("got status code %d, want %d", statusCode, test.wantStatusCode)
+			}
+			if (err != nil) != test.wantErr {
+				t.Errorf("got error %v, want error: %v", err, test.wantErr)
+			}
+		})
+	}
+}
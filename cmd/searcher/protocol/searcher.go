@@ -105,6 +105,16 @@ type PatternInfo struct {
 
 	// CombyRule is a rule that constrains matching for structural search. It only applies when IsStructuralPat is true.
 	CombyRule string
+
+	// MinFileSize and MaxFileSize are the inclusive bounds in bytes of the
+	// sizes of files to search. A nil bound means that sizes are unbounded on
+	// that side.
+	MinFileSize, MaxFileSize *int64
+
+	// ExcludeGenerated if true will not return generated files: files that
+	// look generated (see package linguist) or that have the
+	// linguist-generated attribute in the repository's root .gitattributes.
+	ExcludeGenerated bool
 }
 
 func (p *PatternInfo) String() string {
@@ -137,6 +147,15 @@ func (p *PatternInfo) String() string {
 	for _, lang := range p.Languages {
 		args = append(args, fmt.Sprintf("lang:%s", lang))
 	}
+	if p.MinFileSize != nil {
+		args = append(args, fmt.Sprintf("minsize:%d", *p.MinFileSize))
+	}
+	if p.MaxFileSize != nil {
+		args = append(args, fmt.Sprintf("maxsize:%d", *p.MaxFileSize))
+	}
+	if p.ExcludeGenerated {
+		args = append(args, "nogenerated")
+	}
 
 	path := "glob"
 	if p.PathPatternsAreRegExps {
@@ -173,6 +192,11 @@ type FileMatch struct {
 	// MatchCount is the number of matches. Different from len(LineMatches), as multiple lines may correspond to one logical match.
 	MatchCount int
 
+	// MultilineMatches contains a MultilineMatch for each match that spans
+	// more than one line. Every line of such a match is also included in
+	// LineMatches.
+	MultilineMatches []MultilineMatch
+
 	// LimitHit is true if LineMatches may not include all LineMatches.
 	LimitHit bool
 }
@@ -193,4 +217,52 @@ type LineMatch struct {
 
 	// LimitHit is true if OffsetAndLengths may not include all OffsetAndLengths.
 	LimitHit bool
+
+	// CaptureGroups contains the values of the capture groups of a regexp
+	// pattern in the matches which start on this line. It is empty if the
+	// pattern has no capture groups.
+	CaptureGroups []CaptureGroup
+}
+
+// CaptureGroup is the value of a capture group of a regexp pattern in a
+// match, for example of the group (\d+) in the pattern `version (\d+)`. Groups
+// which did not participate in a match are omitted.
+type CaptureGroup struct {
+	// Index is the number of the group in the pattern, starting at 1.
+	Index int
+
+	// Name is the name of the group, or empty if the group is not named.
+	Name string
+
+	// Value is the captured text. It may contain newlines if the match spans
+	// more than one line.
+	Value string
+
+	// Offset and Length are the position of Value in the line. Like
+	// OffsetAndLengths, they are measured in characters, not bytes.
+	Offset int
+	Length int
+}
+
+// MultilineMatch is a single match that spans more than one line, for example
+// a match of the regexp `foo\(\s*\n\s*bar`.
+type MultilineMatch struct {
+	// Preview is the matched lines, separated by newlines.
+	Preview string
+
+	// Start is the position of the first character of the match.
+	Start Position
+
+	// End is the position just after the last character of the match.
+	End Position
+}
+
+// Position is a position in a file.
+type Position struct {
+	// Line is the 0-based line number.
+	Line int
+
+	// Character is the 0-based offset in the line, measured in characters,
+	// not bytes.
+	Character int
 }
@@ -0,0 +1,81 @@
+package search
+
+import (
+	"github.com/sourcegraph/sourcegraph/cmd/searcher/protocol"
+	"github.com/sourcegraph/sourcegraph/internal/linguist"
+	"github.com/sourcegraph/sourcegraph/internal/store"
+)
+
+// fileFilter decides which files of an archive to search based on their
+// sizes and on whether they are generated. A nil *fileFilter matches all
+// files.
+type fileFilter struct {
+	minSize, maxSize *int64
+	excludeGenerated bool
+
+	// attributes are the attributes of the root .gitattributes of the
+	// archive, if excludeGenerated is set.
+	attributes *linguist.Attributes
+}
+
+// newFileFilter returns the filter of the files of zf described by p, or nil
+// if p does not filter files by size or by whether they are generated.
+func newFileFilter(p *protocol.PatternInfo, zf *store.ZipFile) *fileFilter {
+	if p.MinFileSize == nil && p.MaxFileSize == nil && !p.ExcludeGenerated {
+		return nil
+	}
+	ff := &fileFilter{
+		minSize:          p.MinFileSize,
+		maxSize:          p.MaxFileSize,
+		excludeGenerated: p.ExcludeGenerated,
+	}
+	if p.ExcludeGenerated {
+		for i := range zf.Files {
+			if f := &zf.Files[i]; f.Name == linguist.GitAttributesPath {
+				ff.attributes = linguist.ParseAttributes(zf.DataFor(f))
+				break
+			}
+		}
+	}
+	return ff
+}
+
+// Match reports whether f should be searched.
+//
+// The archive does not contain the contents of binary files and of files
+// over the size limit, but it records their size.
+func (ff *fileFilter) Match(zf *store.ZipFile, f *store.SrcFile) bool {
+	if ff == nil {
+		return true
+	}
+	size := int64(f.Size)
+	if (ff.minSize != nil && size < *ff.minSize) || (ff.maxSize != nil && size > *ff.maxSize) {
+		return false
+	}
+	if ff.excludeGenerated {
+		if generated, ok := ff.attributes.Generated(f.Name); ok {
+			return !generated
+		}
+		return !linguist.IsGenerated(f.Name, zf.DataFor(f))
+	}
+	return true
+}
+
+// filterFileMatches returns the matches of files in zf that ff matches.
+func (ff *fileFilter) filterFileMatches(zf *store.ZipFile, matches []protocol.FileMatch) []protocol.FileMatch {
+	if ff == nil {
+		return matches
+	}
+	files := make(map[string]*store.SrcFile, len(zf.Files))
+	for i := range zf.Files {
+		files[zf.Files[i].Name] = &zf.Files[i]
+	}
+	filtered := matches[:0]
+	for _, m := range matches {
+		if f, ok := files[m.Path]; ok && !ff.Match(zf, f) {
+			continue
+		}
+		filtered = append(filtered, m)
+	}
+	return filtered
+}
@@ -150,6 +150,7 @@ func (s *Service) search(ctx context.Context, p *protocol.Request) (matches []pr
 	span.SetTag("fileMatchLimit", p.FileMatchLimit)
 	span.SetTag("patternMatchesContent", p.PatternMatchesContent)
 	span.SetTag("patternMatchesPath", p.PatternMatchesPath)
+	span.SetTag("excludeGenerated", p.ExcludeGenerated)
 	span.SetTag("deadline", p.Deadline)
 	defer func(start time.Time) {
 		code := "200"
@@ -231,9 +232,12 @@ func (s *Service) search(ctx context.Context, p *protocol.Request) (matches []pr
 	archiveFiles.Observe(float64(nFiles))
 	archiveSize.Observe(float64(bytes))
 
+	filter := newFileFilter(&p.PatternInfo, zf)
 	if p.IsStructuralPat {
 		matches, limitHit, err = structuralSearch(ctx, zipPath, p.Pattern, p.CombyRule, p.Languages, p.IncludePatterns, p.Repo)
+		matches = filter.filterFileMatches(zf, matches)
 	} else {
+		rg.filter = filter
 		matches, limitHit, err = regexSearch(ctx, rg, zf, p.FileMatchLimit, p.PatternMatchesContent, p.PatternMatchesPath)
 	}
 	return matches, limitHit, false, err
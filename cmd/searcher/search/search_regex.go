@@ -56,6 +56,10 @@ type readerGrep struct {
 	// whether a file path matches (and should be searched).
 	matchPath pathmatch.PathMatcher
 
+	// filter decides which files to search based on their sizes and contents.
+	// It is set once the archive to search is known.
+	filter *fileFilter
+
 	// literalSubstring is used to test if a file is worth considering for
 	// matches. literalSubstring is guaranteed to appear in any match found by
 	// re. It is the output of the longestLiteral function. It is only set if
@@ -136,6 +140,7 @@ func (rg *readerGrep) Copy() *readerGrep {
 		re:               rg.re,
 		ignoreCase:       rg.ignoreCase,
 		matchPath:        rg.matchPath,
+		filter:           rg.filter,
 		literalSubstring: rg.literalSubstring,
 	}
 }
@@ -152,10 +157,12 @@ func (rg *readerGrep) matchString(s string) bool {
 	return rg.re.MatchString(s)
 }
 
-// Find returns a LineMatch for each line that matches rg in reader.
-// LimitHit is true if some matches may not have been included in the result.
+// Find returns a LineMatch for each line that matches rg in reader, and a
+// MultilineMatch for each match that spans more than one line. fm.LimitHit is
+// true if some matches may not have been included in the result. fm.Path is
+// not set.
 // NOTE: This is not safe to use concurrently.
-func (rg *readerGrep) Find(zf *store.ZipFile, f *store.SrcFile) (matches []protocol.LineMatch, limitHit bool, err error) {
+func (rg *readerGrep) Find(zf *store.ZipFile, f *store.SrcFile) (fm protocol.FileMatch, err error) {
 	// fileMatchBuf is what we run match on, fileBuf is the original
 	// data (for Preview).
 	fileBuf := zf.DataFor(f)
@@ -182,10 +189,17 @@ func (rg *readerGrep) Find(zf *store.ZipFile, f *store.SrcFile) (matches []proto
 	// per-line. Additionally if we have a non-empty literalSubstring, we use
 	// that to prune out files since doing bytes.Index is very fast.
 	if !bytes.Contains(fileMatchBuf, rg.literalSubstring) {
-		return nil, false, nil
+		return fm, nil
 	}
 
-	locs := rg.re.FindAllIndex(fileMatchBuf, maxLineMatches+1)
+	// Only pay for the submatch indices if there are capture groups to
+	// report.
+	var locs [][]int
+	if rg.re.NumSubexp() > 0 {
+		locs = rg.re.FindAllSubmatchIndex(fileMatchBuf, maxLineMatches+1)
+	} else {
+		locs = rg.re.FindAllIndex(fileMatchBuf, maxLineMatches+1)
+	}
 	lastStart := 0
 	lastLineNumber := 0
 	lastMatchIndex := 0
@@ -216,15 +230,25 @@ func (rg *readerGrep) Find(zf *store.ZipFile, f *store.SrcFile) (matches []proto
 
 		lastMatchIndex = matchIndex
 		lastLineNumber = lineNumber
-		matches = appendMatches(matches, fileBuf[lineStart:lineEnd], fileMatchBuf[lineStart:lineEnd], lineNumber, start-lineStart, end-lineStart)
+		n := len(fm.LineMatches)
+		fm.LineMatches = appendMatches(fm.LineMatches, fileBuf[lineStart:lineEnd], fileMatchBuf[lineStart:lineEnd], lineNumber, start-lineStart, end-lineStart)
+		fm.MatchCount++
+		if len(match) > 2 {
+			addCaptureGroups(fm.LineMatches[n:], rg.re.SubexpNames(), fileBuf, lineStart, match)
+		}
 
-		if len(matches) > maxLineMatches {
-			matches = matches[:maxLineMatches]
-			limitHit = true
+		if len(fm.LineMatches) > maxLineMatches {
+			fm.LineMatches = fm.LineMatches[:maxLineMatches]
+			fm.LimitHit = true
+		}
+		if lines := fm.LineMatches[n:]; len(lines) > 1 {
+			fm.MultilineMatches = append(fm.MultilineMatches, multilineMatch(lines))
+		}
+		if fm.LimitHit {
 			break
 		}
 	}
-	return matches, limitHit, nil
+	return fm, nil
 }
 
 func hydrateLineNumbers(fileBuf []byte, lastLineNumber, lastMatchIndex, lineStart int, match []int) (lineNumber, matchIndex int) {
@@ -283,15 +307,63 @@ func appendMatches(matches []protocol.LineMatch, fileBuf []byte, matchLineBuf []
 	return matches
 }
 
+// addCaptureGroups adds the capture groups of a match to lines, the
+// LineMatches of the match. match contains the submatch indices of the match
+// in fileBuf, and lineStart is the index of the first line of the match in
+// fileBuf. Each group is added to the line on which it starts.
+func addCaptureGroups(lines []protocol.LineMatch, names []string, fileBuf []byte, lineStart int, match []int) {
+	for i := 1; 2*i+1 < len(match); i++ {
+		start, end := match[2*i], match[2*i+1]
+		if start < 0 {
+			// The group did not participate in the match.
+			continue
+		}
+		line := bytes.Count(fileBuf[lineStart:start], []byte{'\n'})
+		if line >= len(lines) {
+			continue
+		}
+		groupLineStart := lineStart
+		if idx := bytes.LastIndexByte(fileBuf[lineStart:start], '\n'); idx >= 0 {
+			groupLineStart += idx + 1
+		}
+		lines[line].CaptureGroups = append(lines[line].CaptureGroups, protocol.CaptureGroup{
+			Index: i,
+			Name:  names[i],
+			// Copy the value, since fileBuf may not be used after the
+			// ZipFile has been closed.
+			Value:  string(fileBuf[start:end]),
+			Offset: utf8.RuneCount(fileBuf[groupLineStart:start]),
+			Length: utf8.RuneCount(fileBuf[start:end]),
+		})
+	}
+}
+
+// multilineMatch returns the MultilineMatch for a single match which was
+// split into lines, one LineMatch per line with a single offset and length.
+func multilineMatch(lines []protocol.LineMatch) protocol.MultilineMatch {
+	first, last := lines[0], lines[len(lines)-1]
+	previews := make([]string, 0, len(lines))
+	for _, line := range lines {
+		previews = append(previews, line.Preview)
+	}
+	return protocol.MultilineMatch{
+		Preview: strings.Join(previews, "\n"),
+		Start: protocol.Position{
+			Line:      first.LineNumber,
+			Character: first.OffsetAndLengths[0][0],
+		},
+		End: protocol.Position{
+			Line:      last.LineNumber,
+			Character: last.OffsetAndLengths[0][0] + last.OffsetAndLengths[0][1],
+		},
+	}
+}
+
 // FindZip is a convenience function to run Find on f.
 func (rg *readerGrep) FindZip(zf *store.ZipFile, f *store.SrcFile) (protocol.FileMatch, error) {
-	lm, limitHit, err := rg.Find(zf, f)
-	return protocol.FileMatch{
-		Path:        f.Name,
-		LineMatches: lm,
-		MatchCount:  len(lm),
-		LimitHit:    limitHit,
-	}, err
+	fm, err := rg.Find(zf, f)
+	fm.Path = f.Name
+	return fm, err
 }
 
 // regexSearch concurrently searches files in zr looking for matches using rg.
@@ -341,7 +413,7 @@ func regexSearch(ctx context.Context, rg *readerGrep, zf *store.ZipFile, fileMat
 		// Fast path for only matching file paths (or with a nil pattern, which matches all files,
 		// so is effectively matching only on file paths).
 		for _, f := range files {
-			if rg.matchPath.MatchPath(f.Name) && rg.matchString(f.Name) {
+			if rg.matchPath.MatchPath(f.Name) && rg.matchString(f.Name) && rg.filter.Match(zf, &f) {
 				if len(matches) < fileMatchLimit {
 					matches = append(matches, protocol.FileMatch{Path: f.Name})
 				} else {
@@ -387,7 +459,7 @@ func regexSearch(ctx context.Context, rg *readerGrep, zf *store.ZipFile, fileMat
 				filesmu.Unlock()
 
 				// decide whether to process, record that decision
-				if !rg.matchPath.MatchPath(f.Name) {
+				if !rg.matchPath.MatchPath(f.Name) || !rg.filter.Match(zf, f) {
 					atomic.AddUint32(&filesSkipped, 1)
 					continue
 				}
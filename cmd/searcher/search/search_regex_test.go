@@ -522,6 +522,111 @@ func TestPathMatches(t *testing.T) {
 	}
 }
 
+func TestMultilineMatches(t *testing.T) {
+	zipData, err := testutil.CreateZip(map[string]string{
+		"a.go": "func foo(\n\tbar string,\n) {}\nfoo(bar)\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	zf, err := store.MockZipFile(zipData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rg, err := compile(&protocol.PatternInfo{
+		Pattern:  `foo\(\s*\n\s*bar`,
+		IsRegExp: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fileMatches, _, err := regexSearch(context.Background(), rg, zf, 10, true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []protocol.FileMatch{{
+		Path: "a.go",
+		LineMatches: []protocol.LineMatch{
+			{Preview: "func foo(", LineNumber: 0, OffsetAndLengths: [][2]int{{5, 5}}},
+			{Preview: "\tbar string,", LineNumber: 1, OffsetAndLengths: [][2]int{{0, 4}}},
+		},
+		MatchCount: 1,
+		MultilineMatches: []protocol.MultilineMatch{{
+			Preview: "func foo(\n\tbar string,",
+			Start:   protocol.Position{Line: 0, Character: 5},
+			End:     protocol.Position{Line: 1, Character: 4},
+		}},
+	}}
+	if !reflect.DeepEqual(fileMatches, want) {
+		t.Fatalf("got file matches %+v, want %+v", fileMatches, want)
+	}
+}
+
+func TestCaptureGroups(t *testing.T) {
+	zipData, err := testutil.CreateZip(map[string]string{
+		"a.go": "const Version = \"v1.2\"\nconst version = \"V3.4\"\nfoo(\n\tbar)\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	zf, err := store.MockZipFile(zipData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		pattern string
+		want    [][]protocol.CaptureGroup // capture groups of each line match
+	}{
+		{
+			// Values are reported as written in the file, even though
+			// the search is case-insensitive.
+			pattern: `version = "v(?P<major>\d)\.(\d)?"`,
+			want: [][]protocol.CaptureGroup{
+				{{Index: 1, Name: "major", Value: "1", Offset: 18, Length: 1}, {Index: 2, Value: "2", Offset: 20, Length: 1}},
+				{{Index: 1, Name: "major", Value: "3", Offset: 18, Length: 1}, {Index: 2, Value: "4", Offset: 20, Length: 1}},
+			},
+		},
+		{
+			// Groups that don't participate in a match are omitted.
+			pattern: `(foo)\(|(baz)`,
+			want:    [][]protocol.CaptureGroup{{{Index: 1, Value: "foo", Offset: 0, Length: 3}}},
+		},
+		{
+			// A group is added to the line on which it starts.
+			pattern: `foo\(\s*(\w+)`,
+			want:    [][]protocol.CaptureGroup{nil, {{Index: 1, Value: "bar", Offset: 1, Length: 3}}},
+		},
+		{
+			pattern: `const`,
+			want:    [][]protocol.CaptureGroup{nil, nil},
+		},
+	} {
+		t.Run(test.pattern, func(t *testing.T) {
+			rg, err := compile(&protocol.PatternInfo{Pattern: test.pattern, IsRegExp: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			fileMatches, _, err := regexSearch(context.Background(), rg, zf, 10, true, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(fileMatches) != 1 {
+				t.Fatalf("got %d file matches, want 1", len(fileMatches))
+			}
+			var got [][]protocol.CaptureGroup
+			for _, lm := range fileMatches[0].LineMatches {
+				got = append(got, lm.CaptureGroups)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got capture groups %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
 // githubStore fetches from github and caches across test runs.
 var githubStore = &store.Store{
 	FetchTar: testutil.FetchTarFromGithub,
@@ -68,15 +68,21 @@ func highlightMultipleLines(r *comby.Match) (matches []protocol.LineMatch) {
 func ToFileMatch(combyMatches []comby.FileMatch) (matches []protocol.FileMatch) {
 	for _, m := range combyMatches {
 		var lineMatches []protocol.LineMatch
+		var multilineMatches []protocol.MultilineMatch
 		for _, r := range m.Matches {
-			lineMatches = append(lineMatches, highlightMultipleLines(&r)...)
+			lines := highlightMultipleLines(&r)
+			lineMatches = append(lineMatches, lines...)
+			if len(lines) > 1 {
+				multilineMatches = append(multilineMatches, multilineMatch(lines))
+			}
 		}
 		matches = append(matches,
 			protocol.FileMatch{
-				Path:        m.URI,
-				LineMatches: lineMatches,
-				MatchCount:  len(m.Matches),
-				LimitHit:    false,
+				Path:             m.URI,
+				LineMatches:      lineMatches,
+				MatchCount:       len(m.Matches),
+				MultilineMatches: multilineMatches,
+				LimitHit:         false,
 			})
 	}
 	return matches
@@ -239,6 +239,109 @@ file++.plus:1:filename contains regex metachars
 	}
 }
 
+func TestSearch_fileFilters(t *testing.T) {
+	files := map[string]string{
+		".gitattributes":     "api/*.go linguist-generated\nschema.pb.go -linguist-generated\n",
+		"main.go":            "package main // hello\n",
+		"api/client.go":      "package api // hello\n",
+		"bindata.go":         "// Code generated by go-bindata. DO NOT EDIT.\npackage main // hello\n",
+		"schema.pb.go":       "package main // hello\n",
+		"web/bundle.min.js":  "var hello;\n",
+		"web/bundle.js":      strings.Repeat("var hello;", 20),
+		"docs/hello.md":      "hello\n",
+		"docs/big_hello.txt": "hello\n" + strings.Repeat("x", 1024),
+		"docs/big_hello.png": "\x00hello" + strings.Repeat("x", 1024),
+	}
+
+	int64Ptr := func(n int64) *int64 { return &n }
+	cases := []struct {
+		name string
+		arg  protocol.PatternInfo
+		want string
+	}{
+		{
+			name: "exclude generated",
+			arg:  protocol.PatternInfo{Pattern: "hello", ExcludeGenerated: true},
+			want: `
+docs/big_hello.txt:1:hello
+docs/hello.md:1:hello
+main.go:1:package main // hello
+schema.pb.go:1:package main // hello
+`,
+		},
+		{
+			name: "max size",
+			arg:  protocol.PatternInfo{Pattern: "hello", IncludePatterns: []string{"docs/*"}, MaxFileSize: int64Ptr(1024)},
+			want: `
+docs/hello.md:1:hello
+`,
+		},
+		{
+			name: "min size",
+			arg:  protocol.PatternInfo{Pattern: "hello", IncludePatterns: []string{"docs/*"}, MinFileSize: int64Ptr(1024)},
+			want: `
+docs/big_hello.txt:1:hello
+`,
+		},
+		{
+			name: "paths",
+			arg:  protocol.PatternInfo{Pattern: "bundle", PatternMatchesPath: true, ExcludeGenerated: true},
+			want: "",
+		},
+		{
+			// The content of binary files is not in the archive, but their
+			// size is.
+			name: "binary max size",
+			arg:  protocol.PatternInfo{Pattern: "png", PatternMatchesPath: true, MaxFileSize: int64Ptr(1024)},
+			want: "",
+		},
+		{
+			name: "binary min size",
+			arg:  protocol.PatternInfo{Pattern: "png", PatternMatchesPath: true, MinFileSize: int64Ptr(1024)},
+			want: `
+docs/big_hello.png
+`,
+		},
+	}
+
+	store, cleanup, err := newStore(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	ts := httptest.NewServer(&search.Service{Store: store})
+	defer ts.Close()
+
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			test.arg.PatternMatchesContent = true
+			req := protocol.Request{
+				Repo:         "foo",
+				URL:          "u",
+				Commit:       "deadbeefdeadbeefdeadbeefdeadbeefdeadbeef",
+				PatternInfo:  test.arg,
+				FetchTimeout: "2000ms",
+			}
+			m, err := doSearch(ts.URL, &req)
+			if err != nil {
+				t.Fatalf("%v failed: %s", test.arg, err)
+			}
+			sort.Sort(sortByPath(m))
+			got := toString(m)
+			if len(test.want) > 0 {
+				test.want = test.want[1:]
+			}
+			if got != test.want {
+				d, err := testutil.Diff(test.want, got)
+				if err != nil {
+					t.Fatal(err)
+				}
+				t.Fatalf("%s unexpected response:\n%s", test.arg.String(), d)
+			}
+		})
+	}
+}
+
 func TestSearch_badrequest(t *testing.T) {
 	cases := []protocol.Request{
 		// Bad regexp
@@ -392,6 +495,15 @@ func doSearch(u string, p *protocol.Request) ([]protocol.FileMatch, error) {
 	if p.PatternMatchesPath {
 		form.Set("PatternMatchesPath", "true")
 	}
+	if p.MinFileSize != nil {
+		form.Set("MinFileSize", strconv.FormatInt(*p.MinFileSize, 10))
+	}
+	if p.MaxFileSize != nil {
+		form.Set("MaxFileSize", strconv.FormatInt(*p.MaxFileSize, 10))
+	}
+	if p.ExcludeGenerated {
+		form.Set("ExcludeGenerated", "true")
+	}
 	resp, err := http.PostForm(u, form)
 	if err != nil {
 		return nil, err
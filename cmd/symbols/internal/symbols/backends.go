@@ -0,0 +1,111 @@
+package symbols
+
+import (
+	"context"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sourcegraph/sourcegraph/cmd/symbols/parser"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/symbols/protocol"
+	"github.com/src-d/enry/v2"
+)
+
+// commitParsers holds the parsers of the backends for the files of one
+// commit, which are created the first time a file is routed to each backend.
+type commitParsers struct {
+	repo     api.RepoName
+	commitID api.CommitID
+
+	mu      sync.Mutex
+	parsers map[string]*commitParser // by backend name
+}
+
+type commitParser struct {
+	once   sync.Once
+	parser parser.CommitParser
+	err    error
+}
+
+func newCommitParsers(repo api.RepoName, commitID api.CommitID) *commitParsers {
+	return &commitParsers{repo: repo, commitID: commitID, parsers: map[string]*commitParser{}}
+}
+
+// get returns the parser of backend for the commit, creating it if this is
+// the first file routed to backend.
+func (c *commitParsers) get(ctx context.Context, backend parser.Backend) (parser.CommitParser, error) {
+	c.mu.Lock()
+	p, ok := c.parsers[backend.Name()]
+	if !ok {
+		p = &commitParser{}
+		c.parsers[backend.Name()] = p
+	}
+	c.mu.Unlock()
+
+	p.once.Do(func() {
+		p.parser, p.err = backend.ForCommit(ctx, c.repo, c.commitID)
+	})
+	return p.parser, p.err
+}
+
+// parseWithBackend parses the file in req with the backend for its language.
+// It returns ok false if there is no such backend, or if the backend does not
+// support the file, in which case the file should be parsed by ctags.
+func (s *Service) parseWithBackend(ctx context.Context, parsers *commitParsers, req parseRequest) (symbols []protocol.Symbol, ok bool, err error) {
+	if len(s.Backends) == 0 {
+		return nil, false, nil
+	}
+	language := enry.GetLanguage(path.Base(req.path), req.data)
+	backend, ok := s.Backends[language]
+	if !ok {
+		return nil, false, nil
+	}
+
+	p, err := parsers.get(ctx, backend)
+	if err == nil {
+		symbols, err = p.Parse(ctx, parser.File{
+			Repo:     parsers.repo,
+			CommitID: parsers.commitID,
+			Path:     req.path,
+			Language: language,
+			Content:  req.data,
+		})
+	}
+	if err == parser.ErrUnsupported {
+		backendParses.WithLabelValues(backend.Name(), "unsupported").Inc()
+		return nil, false, nil
+	}
+	if err != nil {
+		backendParses.WithLabelValues(backend.Name(), "error").Inc()
+		return nil, true, err
+	}
+	backendParses.WithLabelValues(backend.Name(), "success").Inc()
+	return symbols, true, nil
+}
+
+// backendsCacheKey returns a string that describes which backends parse which
+// languages, for use in the keys of the disk cache. Databases written with
+// different backends are not reused.
+func (s *Service) backendsCacheKey() string {
+	if len(s.Backends) == 0 {
+		return ""
+	}
+	routes := make([]string, 0, len(s.Backends))
+	for language, backend := range s.Backends {
+		routes = append(routes, language+"="+backend.Name())
+	}
+	sort.Strings(routes)
+	return strings.Join(routes, ",")
+}
+
+var backendParses = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "symbols_parse_backend_parses",
+	Help: "The total number of files parsed by symbol parser backends other than ctags, by outcome.",
+}, []string{"backend", "outcome"})
+
+func init() {
+	prometheus.MustRegister(backendParses)
+}
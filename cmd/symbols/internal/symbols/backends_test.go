@@ -0,0 +1,102 @@
+package symbols
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"sort"
+	"sync/atomic"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/symbols/internal/pkg/ctags"
+	"github.com/sourcegraph/sourcegraph/cmd/symbols/parser"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/symbols/protocol"
+)
+
+func TestService_backends(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { os.RemoveAll(tmpDir) }()
+
+	files := map[string]string{
+		"a.ts": "ts",
+		"b.ts": "unsupported",
+		"c.go": "go",
+	}
+	var forCommits int32
+	service := Service{
+		FetchTar: func(ctx context.Context, repo gitserver.Repo, commit api.CommitID) (io.ReadCloser, error) {
+			return createTar(files)
+		},
+		NewParser: func() (ctags.Parser, error) {
+			return contentParser{}, nil
+		},
+		Backends: map[string]parser.Backend{"TypeScript": fakeBackend{forCommits: &forCommits}},
+		Path:     tmpDir,
+	}
+	if err := service.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := service.search(context.Background(), protocol.SearchArgs{Repo: "r", CommitID: "c", First: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, s := range result.Symbols {
+		got = append(got, s.Path+":"+s.Name+":"+s.Kind)
+	}
+	sort.Strings(got)
+
+	// b.ts is not supported by the backend, so it is parsed by ctags like
+	// c.go.
+	want := []string{"a.ts:fromBackend:TypeScript", "b.ts:unsupported:", "c.go:go:"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// a.ts and b.ts share the parser of the commit.
+	if forCommits != 1 {
+		t.Errorf("got %d calls to ForCommit, want 1", forCommits)
+	}
+}
+
+func TestService_backendsCacheKey(t *testing.T) {
+	s := &Service{}
+	if key := s.backendsCacheKey(); key != "" {
+		t.Errorf("got %q without backends, want empty", key)
+	}
+	s.Backends = map[string]parser.Backend{"TypeScript": fakeBackend{}, "Kotlin": fakeBackend{}}
+	if key, want := s.backendsCacheKey(), "Kotlin=fake,TypeScript=fake"; key != want {
+		t.Errorf("got %q, want %q", key, want)
+	}
+}
+
+// fakeBackend is a parser.Backend that returns a single symbol for each file
+// whose content is "ts", of the kind of its language. It counts the calls to
+// ForCommit in forCommits, if set.
+type fakeBackend struct {
+	forCommits *int32
+}
+
+func (fakeBackend) Name() string { return "fake" }
+
+func (b fakeBackend) ForCommit(ctx context.Context, repo api.RepoName, commitID api.CommitID) (parser.CommitParser, error) {
+	if b.forCommits != nil {
+		atomic.AddInt32(b.forCommits, 1)
+	}
+	return b, nil
+}
+
+func (fakeBackend) Parse(ctx context.Context, file parser.File) ([]protocol.Symbol, error) {
+	if string(file.Content) != "ts" {
+		return nil, parser.ErrUnsupported
+	}
+	return []protocol.Symbol{{Name: "fromBackend", Path: file.Path, Kind: file.Language}}, nil
+}
@@ -0,0 +1,288 @@
+package symbols
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/inconshreveable/log15"
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/symbols/protocol"
+	"github.com/sourcegraph/sourcegraph/internal/trace/ot"
+)
+
+// maxIncrementalChangedPaths is the maximum number of paths that may change
+// between a cached commit and a new commit for the symbols of the new commit
+// to be parsed incrementally. Above it, parsing the full archive is about as
+// fast, and the list of paths becomes too long to send to gitserver.
+const maxIncrementalChangedPaths = 1000
+
+// maxIncrementalAncestors is the maximum number of ancestors of a commit that
+// are looked up in the cache for a base to parse the symbols of the commit
+// incrementally from.
+const maxIncrementalAncestors = 100
+
+// Changes are the paths that changed between two commits.
+type Changes struct {
+	Added    []string
+	Modified []string
+	Deleted  []string
+}
+
+// ParseGitDiffNameStatus parses the output of `git diff -z --name-status
+// --no-renames A B`, which is a NUL-separated list of status letters followed
+// by paths.
+func ParseGitDiffNameStatus(out []byte) (Changes, error) {
+	var changes Changes
+	if len(out) == 0 {
+		return changes, nil
+	}
+	fields := bytes.Split(bytes.TrimSuffix(out, []byte{0}), []byte{0})
+	if len(fields)%2 != 0 {
+		return Changes{}, fmt.Errorf("unexpected git diff output %q", out)
+	}
+	for i := 0; i < len(fields); i += 2 {
+		status, path := fields[i], string(fields[i+1])
+		if len(status) == 0 {
+			return Changes{}, fmt.Errorf("unexpected git diff output %q", out)
+		}
+		switch status[0] {
+		case 'A':
+			changes.Added = append(changes.Added, path)
+		case 'M', 'T':
+			changes.Modified = append(changes.Modified, path)
+		case 'D':
+			changes.Deleted = append(changes.Deleted, path)
+		default:
+			return Changes{}, fmt.Errorf("unexpected git diff status %q for %q", status, path)
+		}
+	}
+	return changes, nil
+}
+
+// cachedCommit is a commit whose symbols database is in the disk cache.
+type cachedCommit struct {
+	commitID api.CommitID
+	path     string
+}
+
+// nearestCachedAncestor returns the nearest ancestor of repo@commitID whose
+// symbols database is in the disk cache. The changes between an ancestor and
+// the commit are usually few, and since the cache is on disk it is found
+// after the service restarts.
+func (s *Service) nearestCachedAncestor(ctx context.Context, repo api.RepoName, commitID api.CommitID) (cachedCommit, bool, error) {
+	ancestors, err := s.GitAncestors(ctx, repo, commitID, maxIncrementalAncestors)
+	if err != nil {
+		return cachedCommit{}, false, err
+	}
+	for _, ancestor := range ancestors {
+		if ancestor == commitID {
+			continue
+		}
+		if path, ok := s.cache.Cached(s.dbCacheKey(repo, ancestor)); ok {
+			return cachedCommit{commitID: ancestor, path: path}, true, nil
+		}
+	}
+	return cachedCommit{}, false, nil
+}
+
+// writeSymbolsToNewDB writes the symbols of repo@commitID to the blank
+// database file dbFile. If the database of an ancestor of the commit is
+// cached, it is copied and only the files that changed between the two
+// commits are parsed. Otherwise all files are parsed.
+func (s *Service) writeSymbolsToNewDB(ctx context.Context, dbFile string, repo api.RepoName, commitID api.CommitID) error {
+	if s.GitAncestors == nil || s.GitDiff == nil || s.FetchTarPaths == nil {
+		return s.writeAllSymbolsToNewDB(ctx, dbFile, repo, commitID)
+	}
+
+	base, ok, err := s.nearestCachedAncestor(ctx, repo, commitID)
+	if err != nil {
+		if ctx.Err() != nil {
+			return err
+		}
+		log15.Warn("Unable to look up the ancestors of a commit, parsing all files.", "repo", repo, "commit", commitID, "error", err)
+	}
+	if ok {
+		err := s.writeSymbolsIncrementally(ctx, dbFile, repo, base, commitID)
+		if err == nil {
+			incrementalParses.Inc()
+			return nil
+		}
+		if ctx.Err() != nil {
+			return err
+		}
+		log15.Warn("Unable to parse repository symbols incrementally, parsing all files.", "repo", repo, "base", base.commitID, "commit", commitID, "error", err)
+		if err := os.Remove(dbFile); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return s.writeAllSymbolsToNewDB(ctx, dbFile, repo, commitID)
+}
+
+// writeSymbolsIncrementally writes the symbols of repo@commitID to dbFile by
+// copying the database of the cached commit base, deleting the symbols of
+// the files that changed since base, and parsing the added and modified
+// files. The refs table ends up the same as if all files were parsed: the
+// refs to names that are no longer names of symbols are deleted, and if
+// there are new names, the identifiers of the unchanged files are read again
+// to find their refs to them.
+func (s *Service) writeSymbolsIncrementally(ctx context.Context, dbFile string, repo api.RepoName, base cachedCommit, commitID api.CommitID) (err error) {
+	span, ctx := ot.StartSpanFromContext(ctx, "writeSymbolsIncrementally")
+	defer func() {
+		if err != nil {
+			span.SetTag("err", err.Error())
+		}
+		span.Finish()
+	}()
+	span.SetTag("repo", string(repo))
+	span.SetTag("base", string(base.commitID))
+	span.SetTag("commit", string(commitID))
+
+	changes, err := s.GitDiff(ctx, repo, base.commitID, commitID)
+	if err != nil {
+		return errors.Wrap(err, "GitDiff")
+	}
+	changed := len(changes.Added) + len(changes.Modified) + len(changes.Deleted)
+	span.SetTag("changed", changed)
+	if changed > maxIncrementalChangedPaths {
+		return fmt.Errorf("%d paths changed, which is more than the limit of %d", changed, maxIncrementalChangedPaths)
+	}
+
+	// The base database may have been evicted from the cache since it was
+	// found, in which case copying it fails.
+	if err := copyFile(base.path, dbFile); err != nil {
+		return err
+	}
+
+	db, err := sqlx.Open("sqlite3_with_pcre", dbFile)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	baseNames, err := symbolNames(tx)
+	if err != nil {
+		return err
+	}
+
+	deleteStatement, err := tx.Preparex("DELETE FROM symbols WHERE path = ?")
+	if err != nil {
+		return err
+	}
+	deleteRefsStatement, err := tx.Preparex("DELETE FROM refs WHERE path = ?")
+	if err != nil {
+		return err
+	}
+	for _, paths := range [][]string{changes.Modified, changes.Deleted} {
+		for _, path := range paths {
+			if _, err := deleteStatement.Exec(path); err != nil {
+				return err
+			}
+			if _, err := deleteRefsStatement.Exec(path); err != nil {
+				return err
+			}
+		}
+	}
+
+	paths := append(append([]string{}, changes.Added...), changes.Modified...)
+	if len(paths) > 0 {
+		if err := s.insertSymbols(ctx, tx, repo, commitID, paths); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec("DELETE FROM refs WHERE name NOT IN (SELECT name FROM symbols)"); err != nil {
+		return err
+	}
+	names, err := symbolNames(tx)
+	if err != nil {
+		return err
+	}
+	newNames := map[string]struct{}{}
+	for name := range names {
+		if _, ok := baseNames[name]; !ok {
+			newNames[name] = struct{}{}
+		}
+	}
+	span.SetTag("newNames", len(newNames))
+	if len(newNames) > 0 {
+		if err := s.insertUnchangedFileRefs(ctx, tx, repo, commitID, paths, newNames); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// insertUnchangedFileRefs inserts the refs to names of the files of
+// repo@commitID other than changedPaths, whose refs were inserted when they
+// were parsed. The files are not parsed again, only their identifiers are
+// read.
+func (s *Service) insertUnchangedFileRefs(ctx context.Context, tx *sqlx.Tx, repo api.RepoName, commitID api.CommitID, changedPaths []string, names map[string]struct{}) error {
+	changed := make(map[string]struct{}, len(changedPaths))
+	for _, path := range changedPaths {
+		changed[path] = struct{}{}
+	}
+
+	files, errChan, err := s.fetchRepositoryArchive(ctx, repo, commitID, nil)
+	if err != nil {
+		return err
+	}
+	var refs []protocol.SymbolRef
+	for req := range files {
+		if _, ok := changed[req.path]; ok {
+			continue
+		}
+		for _, ref := range identifierRefs(req.path, req.data) {
+			if _, ok := names[ref.Name]; ok {
+				refs = append(refs, ref)
+			}
+		}
+	}
+	if err := <-errChan; err != nil {
+		return err
+	}
+	return insertRefsToNames(tx, refs, names)
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+var incrementalParses = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "symbols_parse_incremental",
+	Help: "The total number of commits whose symbols were parsed incrementally from the symbols of another commit.",
+})
+
+func init() {
+	prometheus.MustRegister(incrementalParses)
+}
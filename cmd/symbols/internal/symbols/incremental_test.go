@@ -0,0 +1,233 @@
+package symbols
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/symbols/internal/pkg/ctags"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/symbols/protocol"
+)
+
+func TestParseGitDiffNameStatus(t *testing.T) {
+	got, err := ParseGitDiffNameStatus([]byte("A\x00a.go\x00M\x00dir/b.go\x00D\x00c.go\x00T\x00d\x00"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Changes{
+		Added:    []string{"a.go"},
+		Modified: []string{"dir/b.go", "d"},
+		Deleted:  []string{"c.go"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	if got, err := ParseGitDiffNameStatus(nil); err != nil || !reflect.DeepEqual(got, Changes{}) {
+		t.Errorf("got %+v, %v for empty output, want no changes", got, err)
+	}
+
+	for _, out := range []string{"A\x00", "R100\x00a\x00b\x00"} {
+		if _, err := ParseGitDiffNameStatus([]byte(out)); err == nil {
+			t.Errorf("expected an error for %q", out)
+		}
+	}
+}
+
+func TestService_incremental(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { os.RemoveAll(tmpDir) }()
+
+	// The content of each file is the name of its only symbol. c2 and c3
+	// are children of c1 on different branches.
+	commits := map[api.CommitID]map[string]string{
+		"c1": {"a.js": "a1", "b.js": "b1", "c.js": "c1"},
+		"c2": {"a.js": "a2", "b.js": "b1", "d.js": "d2"},
+		"c3": {"a.js": "a1", "b.js": "b3", "c.js": "c1"},
+	}
+	ancestors := map[api.CommitID][]api.CommitID{
+		"c1": {"c1"},
+		"c2": {"c2", "c1"},
+		"c3": {"c3", "c1"},
+	}
+	diffs := map[[2]api.CommitID]Changes{
+		{"c1", "c2"}: {Added: []string{"d.js"}, Modified: []string{"a.js"}, Deleted: []string{"c.js"}},
+		{"c1", "c3"}: {Modified: []string{"b.js"}},
+	}
+	var fetchedPaths [][]string
+	newService := func() *Service {
+		service := &Service{
+			FetchTar: func(ctx context.Context, repo gitserver.Repo, commit api.CommitID) (io.ReadCloser, error) {
+				fetchedPaths = append(fetchedPaths, nil)
+				return createTar(commits[commit])
+			},
+			FetchTarPaths: func(ctx context.Context, repo gitserver.Repo, commit api.CommitID, paths []string) (io.ReadCloser, error) {
+				fetchedPaths = append(fetchedPaths, paths)
+				files := map[string]string{}
+				for _, path := range paths {
+					files[path] = commits[commit][path]
+				}
+				return createTar(files)
+			},
+			GitDiff: func(ctx context.Context, repo api.RepoName, commitA, commitB api.CommitID) (Changes, error) {
+				changes, ok := diffs[[2]api.CommitID{commitA, commitB}]
+				if !ok {
+					t.Fatalf("unexpected diff %s..%s", commitA, commitB)
+				}
+				return changes, nil
+			},
+			GitAncestors: func(ctx context.Context, repo api.RepoName, commit api.CommitID, n int) ([]api.CommitID, error) {
+				return ancestors[commit], nil
+			},
+			NewParser: func() (ctags.Parser, error) {
+				return contentParser{}, nil
+			},
+			Path: tmpDir,
+		}
+		if err := service.Start(); err != nil {
+			t.Fatal(err)
+		}
+		return service
+	}
+	service := newService()
+
+	symbols := func(commit api.CommitID) []string {
+		result, err := service.search(context.Background(), protocol.SearchArgs{Repo: "r", CommitID: commit, First: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, s := range result.Symbols {
+			names = append(names, s.Path+":"+s.Name)
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	if got, want := symbols("c1"), []string{"a.js:a1", "b.js:b1", "c.js:c1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got symbols %v at c1, want %v", got, want)
+	}
+	if got, want := symbols("c2"), []string{"a.js:a2", "b.js:b1", "d.js:d2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got symbols %v at c2, want %v", got, want)
+	}
+
+	// After a restart, c3 is parsed from its cached ancestor c1, not from
+	// c2 which was cached last.
+	service = newService()
+	if got, want := symbols("c3"), []string{"a.js:a1", "b.js:b3", "c.js:c1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got symbols %v at c3, want %v", got, want)
+	}
+
+	// c1 was parsed from a full archive, and c2 and c3 only from the added
+	// and modified files. Since they have new symbol names, the identifiers
+	// of their other files were read from a full archive too.
+	if want := [][]string{nil, {"d.js", "a.js"}, nil, {"b.js"}, nil}; !reflect.DeepEqual(fetchedPaths, want) {
+		t.Errorf("got fetched paths %q, want %q", fetchedPaths, want)
+	}
+}
+
+func TestService_incrementalRefs(t *testing.T) {
+	// The first line of each file is the name of its only symbol. c2 is a
+	// child of c1 that adds the symbol d, which the unchanged c.js refers
+	// to, and deletes the symbol b, which c.js refers to as well.
+	commits := map[api.CommitID]map[string]string{
+		"c1": {"a.js": "a\nb", "b.js": "b\nc", "c.js": "c\na b d"},
+		"c2": {"a.js": "a\nd", "c.js": "c\na b d", "d.js": "d"},
+	}
+	var diffs int
+	newService := func() *Service {
+		tmpDir, err := ioutil.TempDir("", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { os.RemoveAll(tmpDir) })
+
+		service := &Service{
+			FetchTar: func(ctx context.Context, repo gitserver.Repo, commit api.CommitID) (io.ReadCloser, error) {
+				return createTar(commits[commit])
+			},
+			FetchTarPaths: func(ctx context.Context, repo gitserver.Repo, commit api.CommitID, paths []string) (io.ReadCloser, error) {
+				files := map[string]string{}
+				for _, path := range paths {
+					files[path] = commits[commit][path]
+				}
+				return createTar(files)
+			},
+			GitDiff: func(ctx context.Context, repo api.RepoName, commitA, commitB api.CommitID) (Changes, error) {
+				diffs++
+				return Changes{Added: []string{"d.js"}, Modified: []string{"a.js"}, Deleted: []string{"b.js"}}, nil
+			},
+			GitAncestors: func(ctx context.Context, repo api.RepoName, commit api.CommitID, n int) ([]api.CommitID, error) {
+				if commit == "c2" {
+					return []api.CommitID{"c2", "c1"}, nil
+				}
+				return []api.CommitID{commit}, nil
+			},
+			NewParser: func() (ctags.Parser, error) {
+				return firstLineParser{}, nil
+			},
+			Path: tmpDir,
+		}
+		if err := service.Start(); err != nil {
+			t.Fatal(err)
+		}
+		return service
+	}
+
+	refs := func(service *Service, commit api.CommitID) []string {
+		result, err := service.search(context.Background(), protocol.SearchArgs{Repo: "r", CommitID: commit, Refs: true, First: 100})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var refs []string
+		for _, r := range result.Refs {
+			refs = append(refs, fmt.Sprintf("%s:%d:%d:%s", r.Path, r.Line, r.Character, r.Name))
+		}
+		return refs
+	}
+
+	incremental := newService()
+	refs(incremental, "c1")
+	got := refs(incremental, "c2")
+	if diffs != 1 {
+		t.Fatalf("got %d diffs, want c2 to be parsed incrementally from c1", diffs)
+	}
+	want := refs(newService(), "c2")
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got refs %q from the incremental parse, want %q as from a full parse", got, want)
+	}
+	if want := []string{"a.js:1:0:a", "a.js:2:0:d", "c.js:1:0:c", "c.js:2:0:a", "c.js:2:4:d", "d.js:1:0:d"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got refs %q, want %q", got, want)
+	}
+}
+
+// firstLineParser is a ctags.Parser that returns a single symbol for each
+// file, whose name is the first line of the file.
+type firstLineParser struct{}
+
+func (firstLineParser) Parse(name string, content []byte) ([]ctags.Entry, error) {
+	return []ctags.Entry{{Name: strings.SplitN(string(content), "\n", 2)[0], Path: name}}, nil
+}
+
+func (firstLineParser) Close() {}
+
+// contentParser is a ctags.Parser that returns a single symbol for each file,
+// whose name is the content of the file.
+type contentParser struct{}
+
+func (contentParser) Parse(name string, content []byte) ([]ctags.Entry, error) {
+	return []ctags.Entry{{Name: strings.TrimSpace(string(content)), Path: name}}, nil
+}
+
+func (contentParser) Close() {}
@@ -37,7 +37,10 @@ func (s *Service) startParsers() error {
 	return nil
 }
 
-func (s *Service) parseUncached(ctx context.Context, repo api.RepoName, commitID api.CommitID, callback func(symbol protocol.Symbol) error) (err error) {
+// parseUncached parses the files at paths (or all files, if paths is empty) of
+// repo@commitID and calls callback for each symbol. If refCallback is not nil,
+// it is called for each identifier of the files.
+func (s *Service) parseUncached(ctx context.Context, repo api.RepoName, commitID api.CommitID, paths []string, callback func(symbol protocol.Symbol) error, refCallback func(ref protocol.SymbolRef) error) (err error) {
 	span, ctx := ot.StartSpanFromContext(ctx, "parseUncached")
 	defer func() {
 		if err != nil {
@@ -48,13 +51,15 @@ func (s *Service) parseUncached(ctx context.Context, repo api.RepoName, commitID
 	}()
 	span.SetTag("repo", string(repo))
 	span.SetTag("commit", string(commitID))
+	span.SetTag("paths", len(paths))
 
 	tr := nettrace.New("parseUncached", string(repo))
 	tr.LazyPrintf("commitID: %s", commitID)
 
 	totalSymbols := 0
+	totalRefs := 0
 	defer func() {
-		tr.LazyPrintf("symbols=%d", totalSymbols)
+		tr.LazyPrintf("symbols=%d refs=%d", totalSymbols, totalRefs)
 		if err != nil {
 			tr.LazyPrintf("error: %s", err)
 			tr.SetError()
@@ -63,7 +68,7 @@ func (s *Service) parseUncached(ctx context.Context, repo api.RepoName, commitID
 	}()
 
 	tr.LazyPrintf("fetch")
-	parseRequests, errChan, err := s.fetchRepositoryArchive(ctx, repo, commitID)
+	parseRequests, errChan, err := s.fetchRepositoryArchive(ctx, repo, commitID, paths)
 	tr.LazyPrintf("fetch (returned chans)")
 	if err != nil {
 		return err
@@ -73,9 +78,10 @@ func (s *Service) parseUncached(ctx context.Context, repo api.RepoName, commitID
 	defer cancel()
 
 	var (
-		mu  sync.Mutex // protects symbols and err
-		wg  sync.WaitGroup
-		sem = make(chan struct{}, runtime.GOMAXPROCS(0))
+		mu      sync.Mutex // protects symbols and err
+		wg      sync.WaitGroup
+		sem     = make(chan struct{}, runtime.GOMAXPROCS(0))
+		parsers = newCommitParsers(repo, commitID)
 	)
 	tr.LazyPrintf("parse")
 	totalParseRequests := 0
@@ -96,21 +102,33 @@ func (s *Service) parseUncached(ctx context.Context, repo api.RepoName, commitID
 				wg.Done()
 				<-sem
 			}()
-			entries, parseErr := s.parse(ctx, req)
+			symbols, parseErr := s.parseFile(ctx, parsers, req)
 			if parseErr != nil && parseErr != context.Canceled && parseErr != context.DeadlineExceeded {
 				log15.Error("Error parsing symbols.", "repo", repo, "commitID", commitID, "path", req.path, "dataSize", len(req.data), "error", parseErr)
 			}
-			if len(entries) > 0 {
+			var refs []protocol.SymbolRef
+			if refCallback != nil {
+				refs = identifierRefs(req.path, req.data)
+			}
+			if len(symbols) > 0 || len(refs) > 0 {
 				mu.Lock()
 				defer mu.Unlock()
-				for _, e := range entries {
-					if e.Name == "" || strings.HasPrefix(e.Name, "__anon") || strings.HasPrefix(e.Parent, "__anon") || strings.HasPrefix(e.Name, "AnonymousFunction") || strings.HasPrefix(e.Parent, "AnonymousFunction") {
+				for _, symbol := range symbols {
+					if symbol.Name == "" || strings.HasPrefix(symbol.Name, "__anon") || strings.HasPrefix(symbol.Parent, "__anon") || strings.HasPrefix(symbol.Name, "AnonymousFunction") || strings.HasPrefix(symbol.Parent, "AnonymousFunction") {
 						continue
 					}
 					totalSymbols++
-					err = callback(entryToSymbol(e))
+					err = callback(symbol)
+					if err != nil {
+						log15.Error("Failed to add symbol", "symbol", symbol, "error", err)
+						return
+					}
+				}
+				for _, ref := range refs {
+					totalRefs++
+					err = refCallback(ref)
 					if err != nil {
-						log15.Error("Failed to add symbol", "symbol", e, "error", err)
+						log15.Error("Failed to add symbol reference", "ref", ref, "error", err)
 						return
 					}
 				}
@@ -118,11 +136,28 @@ func (s *Service) parseUncached(ctx context.Context, repo api.RepoName, commitID
 		}(req)
 	}
 	wg.Wait()
-	tr.LazyPrintf("parse (done) totalParseRequests=%d symbols=%d", totalParseRequests, totalSymbols)
+	tr.LazyPrintf("parse (done) totalParseRequests=%d symbols=%d refs=%d", totalParseRequests, totalSymbols, totalRefs)
 
 	return <-errChan
 }
 
+// parseFile returns the symbols of the file in req. The file is parsed by the
+// backend for its language, if there is one that supports it, and by ctags
+// otherwise.
+func (s *Service) parseFile(ctx context.Context, parsers *commitParsers, req parseRequest) ([]protocol.Symbol, error) {
+	symbols, ok, err := s.parseWithBackend(ctx, parsers, req)
+	if ok {
+		return symbols, err
+	}
+
+	entries, err := s.parse(ctx, req)
+	symbols = make([]protocol.Symbol, len(entries))
+	for i, e := range entries {
+		symbols[i] = entryToSymbol(e)
+	}
+	return symbols, err
+}
+
 // parse gets a parser from the pool and uses it to satisfy the parse request.
 func (s *Service) parse(ctx context.Context, req parseRequest) (entries []ctags.Entry, err error) {
 	parseQueueSize.Inc()
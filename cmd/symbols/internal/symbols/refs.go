@@ -0,0 +1,275 @@
+package symbols
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/keegancsmith/sqlf"
+	"github.com/opentracing/opentracing-go/ext"
+	otlog "github.com/opentracing/opentracing-go/log"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/symbols/protocol"
+	"github.com/sourcegraph/sourcegraph/internal/trace/ot"
+)
+
+const (
+	// maxRefLineLength is the length in bytes of the longest line whose
+	// identifiers are recorded as references. Longer lines are usually
+	// minified or generated code.
+	maxRefLineLength = 1000
+
+	// maxRefsPerFile is the maximum number of identifiers recorded as
+	// references per file.
+	maxRefsPerFile = 10000
+)
+
+// refInDB is the same as `protocol.SymbolRef`, but with two additional
+// columns: namelowercase and pathlowercase, which enable indexed case
+// insensitive queries. The preview is not stored, because most lines have
+// several identifiers: it is read from the file when refs are searched, see
+// setRefPreviews.
+type refInDB struct {
+	Name          string
+	NameLowercase string // derived from `Name`
+	Path          string
+	PathLowercase string // derived from `Path`
+	Line          int
+	Character     int
+}
+
+func refToRefInDB(ref protocol.SymbolRef) refInDB {
+	return refInDB{
+		Name:          ref.Name,
+		NameLowercase: strings.ToLower(ref.Name),
+		Path:          ref.Path,
+		PathLowercase: strings.ToLower(ref.Path),
+		Line:          ref.Line,
+		Character:     ref.Character,
+	}
+}
+
+func refInDBToRef(refInDB refInDB) protocol.SymbolRef {
+	return protocol.SymbolRef{
+		Name:      refInDB.Name,
+		Path:      refInDB.Path,
+		Line:      refInDB.Line,
+		Character: refInDB.Character,
+	}
+}
+
+// identifierRefs returns an occurrence for each identifier in the file at
+// path, without its preview. Which identifiers are occurrences of symbols is
+// only known once all files are parsed, see insertRefs.
+func identifierRefs(path string, data []byte) []protocol.SymbolRef {
+	var refs []protocol.SymbolRef
+	for lineNumber := 1; len(data) > 0; lineNumber++ {
+		line := data
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			line, data = data[:i], data[i+1:]
+		} else {
+			data = nil
+		}
+		if len(line) > maxRefLineLength {
+			continue
+		}
+
+		for i := 0; i < len(line); {
+			r, size := utf8.DecodeRune(line[i:])
+			if !isIdentifierStart(r) {
+				i += size
+				continue
+			}
+			start := i
+			for i < len(line) {
+				r, size := utf8.DecodeRune(line[i:])
+				if !isIdentifierStart(r) && !unicode.IsDigit(r) {
+					break
+				}
+				i += size
+			}
+			if len(refs) == maxRefsPerFile {
+				return refs
+			}
+			refs = append(refs, protocol.SymbolRef{
+				Name:      string(line[start:i]),
+				Path:      path,
+				Line:      lineNumber,
+				Character: start,
+			})
+		}
+	}
+	return refs
+}
+
+func isIdentifierStart(r rune) bool {
+	return r == '_' || r == '$' || unicode.IsLetter(r)
+}
+
+// createRefsTable creates the refs table, which holds the occurrences of the
+// names of symbols. Its indexes are created by createRefsIndexes once the
+// refs are inserted, which is faster than updating them on every insert.
+func createRefsTable(tx *sqlx.Tx) error {
+	// The column names are the lowercase version of fields in `refInDB`
+	// because sqlx lowercases struct fields by default.
+	_, err := tx.Exec(
+		`CREATE TABLE IF NOT EXISTS refs (
+			name VARCHAR(256) NOT NULL,
+			namelowercase VARCHAR(256) NOT NULL,
+			path VARCHAR(4096) NOT NULL,
+			pathlowercase VARCHAR(4096) NOT NULL,
+			line INT NOT NULL,
+			character INT NOT NULL
+		)`)
+	return err
+}
+
+// createRefsIndexes creates the indexes of the refs table.
+func createRefsIndexes(tx *sqlx.Tx) error {
+	for _, column := range []string{"name", "namelowercase", "path"} {
+		_, err := tx.Exec(fmt.Sprintf(`CREATE INDEX refs_%s_index ON refs(%s);`, column, column))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insertRefs inserts the refs whose name is the name of a symbol in the
+// symbols table, which are usually few of them.
+func insertRefs(tx *sqlx.Tx, refs []protocol.SymbolRef) error {
+	names, err := symbolNames(tx)
+	if err != nil {
+		return err
+	}
+	return insertRefsToNames(tx, refs, names)
+}
+
+// symbolNames returns the set of the names of the symbols in the symbols
+// table.
+func symbolNames(tx *sqlx.Tx) (map[string]struct{}, error) {
+	var names []string
+	if err := tx.Select(&names, `SELECT DISTINCT name FROM symbols`); err != nil {
+		return nil, err
+	}
+	set := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		set[name] = struct{}{}
+	}
+	return set, nil
+}
+
+// insertRefsToNames inserts the refs whose name is in names.
+func insertRefsToNames(tx *sqlx.Tx, refs []protocol.SymbolRef, names map[string]struct{}) error {
+	insertStatement, err := tx.PrepareNamed(
+		fmt.Sprintf(
+			"INSERT INTO refs %s VALUES %s",
+			"( name,  namelowercase,  path,  pathlowercase,  line,  character)",
+			"(:name, :namelowercase, :path, :pathlowercase, :line, :character)"))
+	if err != nil {
+		return err
+	}
+	for _, ref := range refs {
+		if _, ok := names[ref.Name]; !ok {
+			continue
+		}
+		refInDBValue := refToRefInDB(ref)
+		if _, err := insertStatement.Exec(&refInDBValue); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setRefPreviews sets the preview of each of refs to the line it is on in
+// the files of repo@commitID.
+func (s *Service) setRefPreviews(ctx context.Context, repo api.RepoName, commitID api.CommitID, refs []protocol.SymbolRef) error {
+	if len(refs) == 0 {
+		return nil
+	}
+
+	// lines maps each path to the previews of its lines that have refs,
+	// by line number.
+	lines := map[string]map[int]string{}
+	var paths []string
+	for _, ref := range refs {
+		if lines[ref.Path] == nil {
+			lines[ref.Path] = map[int]string{}
+			paths = append(paths, ref.Path)
+		}
+		lines[ref.Path][ref.Line] = ""
+	}
+	if s.FetchTarPaths == nil {
+		paths = nil
+	}
+
+	files, errChan, err := s.fetchRepositoryArchive(ctx, repo, commitID, paths)
+	if err != nil {
+		return err
+	}
+	for req := range files {
+		previews, ok := lines[req.path]
+		if !ok {
+			continue
+		}
+		data := req.data
+		for lineNumber := 1; len(data) > 0; lineNumber++ {
+			line := data
+			if i := bytes.IndexByte(data, '\n'); i >= 0 {
+				line, data = data[:i], data[i+1:]
+			} else {
+				data = nil
+			}
+			if _, ok := previews[lineNumber]; ok {
+				previews[lineNumber] = string(line)
+			}
+		}
+	}
+	if err := <-errChan; err != nil {
+		return err
+	}
+
+	for i := range refs {
+		refs[i].Preview = lines[refs[i].Path][refs[i].Line]
+	}
+	return nil
+}
+
+func filterRefs(ctx context.Context, db *sqlx.DB, args protocol.SearchArgs) (res []protocol.SymbolRef, err error) {
+	span, _ := ot.StartSpanFromContext(ctx, "filterRefs")
+	defer func() {
+		if err != nil {
+			ext.Error.Set(span, true)
+			span.LogFields(otlog.Error(err))
+		}
+		span.Finish()
+	}()
+
+	if args.First < 0 || args.First > maxFirst {
+		args.First = maxFirst
+	}
+
+	var sqlQuery *sqlf.Query
+	if conditions := searchConditions(args); len(conditions) == 0 {
+		sqlQuery = sqlf.Sprintf("SELECT * FROM refs ORDER BY path, line, character LIMIT %s", args.First)
+	} else {
+		sqlQuery = sqlf.Sprintf("SELECT * FROM refs WHERE %s ORDER BY path, line, character LIMIT %s", sqlf.Join(conditions, "AND"), args.First)
+	}
+
+	var refsInDB []refInDB
+	err = db.Select(&refsInDB, sqlQuery.Query(sqlf.PostgresBindVar), sqlQuery.Args()...)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, refInDB := range refsInDB {
+		res = append(res, refInDBToRef(refInDB))
+	}
+
+	span.SetTag("hits", len(res))
+	return res, nil
+}
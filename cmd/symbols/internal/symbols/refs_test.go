@@ -0,0 +1,35 @@
+package symbols
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/internal/symbols/protocol"
+)
+
+func TestIdentifierRefs(t *testing.T) {
+	data := "func foo() {\n\treturn bar_1($x, 2f, ñu)\n" + strings.Repeat("a ", maxRefLineLength) + "\nbaz"
+	got := identifierRefs("a.go", []byte(data))
+	ref := func(name string, line, character int) protocol.SymbolRef {
+		return protocol.SymbolRef{Name: name, Path: "a.go", Line: line, Character: character}
+	}
+	want := []protocol.SymbolRef{
+		ref("func", 1, 0),
+		ref("foo", 1, 5),
+		ref("return", 2, 1),
+		ref("bar_1", 2, 8),
+		ref("$x", 2, 14),
+		ref("f", 2, 19),
+		ref("ñu", 2, 22),
+		// The long line is skipped.
+		ref("baz", 4, 0),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	if got := identifierRefs("a.go", []byte(strings.Repeat("a\n", maxRefsPerFile+1))); len(got) != maxRefsPerFile {
+		t.Errorf("got %d refs, want %d", len(got), maxRefsPerFile)
+	}
+}
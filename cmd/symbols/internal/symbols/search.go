@@ -85,6 +85,17 @@ func (s *Service) search(ctx context.Context, args protocol.SearchArgs) (result
 	defer db.Close()
 
 	result = &protocol.SearchResult{}
+	if args.Refs {
+		refs, err := filterRefs(ctx, db, args)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.setRefPreviews(ctx, args.Repo, args.CommitID, refs); err != nil {
+			return nil, err
+		}
+		result.Refs = refs
+		return result, nil
+	}
 	res, err := filterSymbols(ctx, db, args)
 	if err != nil {
 		return nil, err
@@ -95,10 +106,10 @@ func (s *Service) search(ctx context.Context, args protocol.SearchArgs) (result
 
 // getDBFile returns the path to the sqlite3 database for the repo@commit
 // specified in `args`. If the database doesn't already exist in the disk cache,
-// it will create a new one and write all the symbols into it.
+// it will create a new one and write the symbols into it.
 func (s *Service) getDBFile(ctx context.Context, args protocol.SearchArgs) (string, error) {
-	diskcacheFile, err := s.cache.OpenWithPath(ctx, fmt.Sprintf("%d-%s@%s", symbolsDBVersion, args.Repo, args.CommitID), func(fetcherCtx context.Context, tempDBFile string) error {
-		err := s.writeAllSymbolsToNewDB(fetcherCtx, tempDBFile, args.Repo, args.CommitID)
+	diskcacheFile, err := s.cache.OpenWithPath(ctx, s.dbCacheKey(args.Repo, args.CommitID), func(fetcherCtx context.Context, tempDBFile string) error {
+		err := s.writeSymbolsToNewDB(fetcherCtx, tempDBFile, args.Repo, args.CommitID)
 		if err != nil {
 			if err == context.Canceled {
 				log15.Error("Unable to parse repository symbols within the context", "repo", args.Repo, "commit", args.CommitID, "query", args.Query)
@@ -115,6 +126,16 @@ func (s *Service) getDBFile(ctx context.Context, args protocol.SearchArgs) (stri
 	return diskcacheFile.File.Name(), err
 }
 
+// dbCacheKey returns the disk cache key of the sqlite3 database for
+// repo@commitID.
+func (s *Service) dbCacheKey(repo api.RepoName, commitID api.CommitID) string {
+	key := fmt.Sprintf("%d-%s@%s", symbolsDBVersion, repo, commitID)
+	if backends := s.backendsCacheKey(); backends != "" {
+		key += "-" + backends
+	}
+	return key
+}
+
 // isLiteralEquality checks if the given regex matches literal strings exactly.
 // Returns whether or not the regex is exact, along with the literal string if
 // so.
@@ -143,11 +164,38 @@ func filterSymbols(ctx context.Context, db *sqlx.DB, args protocol.SearchArgs) (
 		span.Finish()
 	}()
 
-	const maxFirst = 500
 	if args.First < 0 || args.First > maxFirst {
 		args.First = maxFirst
 	}
 
+	var sqlQuery *sqlf.Query
+	if conditions := searchConditions(args); len(conditions) == 0 {
+		sqlQuery = sqlf.Sprintf("SELECT * FROM symbols LIMIT %s", args.First)
+	} else {
+		sqlQuery = sqlf.Sprintf("SELECT * FROM symbols WHERE %s LIMIT %s", sqlf.Join(conditions, "AND"), args.First)
+	}
+
+	var symbolsInDB []symbolInDB
+	err = db.Select(&symbolsInDB, sqlQuery.Query(sqlf.PostgresBindVar), sqlQuery.Args()...)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, symbolInDB := range symbolsInDB {
+		res = append(res, symbolInDBToSymbol(symbolInDB))
+	}
+
+	span.SetTag("hits", len(res))
+	return res, nil
+}
+
+// maxFirst is the maximum number of symbols or references returned by a
+// search.
+const maxFirst = 500
+
+// searchConditions returns the conditions on the name and path columns of the
+// symbols and refs tables for the query and file patterns of args.
+func searchConditions(args protocol.SearchArgs) []*sqlf.Query {
 	makeCondition := func(column string, regex string) []*sqlf.Query {
 		conditions := []*sqlf.Query{}
 
@@ -189,33 +237,14 @@ func filterSymbols(ctx context.Context, db *sqlx.DB, args protocol.SearchArgs) (
 		conditions = append(conditions, makeCondition("path", includePattern)...)
 	}
 	conditions = append(conditions, negateAll(makeCondition("path", args.ExcludePattern))...)
-
-	var sqlQuery *sqlf.Query
-	if len(conditions) == 0 {
-		sqlQuery = sqlf.Sprintf("SELECT * FROM symbols LIMIT %s", args.First)
-	} else {
-		sqlQuery = sqlf.Sprintf("SELECT * FROM symbols WHERE %s LIMIT %s", sqlf.Join(conditions, "AND"), args.First)
-	}
-
-	var symbolsInDB []symbolInDB
-	err = db.Select(&symbolsInDB, sqlQuery.Query(sqlf.PostgresBindVar), sqlQuery.Args()...)
-	if err != nil {
-		return nil, err
-	}
-
-	for _, symbolInDB := range symbolsInDB {
-		res = append(res, symbolInDBToSymbol(symbolInDB))
-	}
-
-	span.SetTag("hits", len(res))
-	return res, nil
+	return conditions
 }
 
 // The version of the symbols database schema. This is included in the database
 // filenames to prevent a newer version of the symbols service from attempting
 // to read from a database created by an older (and likely incompatible) symbols
 // service. Increment this when you change the database schema.
-const symbolsDBVersion = 3
+const symbolsDBVersion = 5
 
 // symbolInDB is the same as `protocol.Symbol`, but with two additional columns:
 // namelowercase and pathlowercase, which enable indexed case insensitive
@@ -285,10 +314,40 @@ func (s *Service) writeAllSymbolsToNewDB(ctx context.Context, dbFile string, rep
 		return err
 	}
 
+	err = createSymbolsTable(tx)
+	if err != nil {
+		return err
+	}
+
+	err = createRefsTable(tx)
+	if err != nil {
+		return err
+	}
+
+	err = s.insertSymbols(ctx, tx, repoName, commitID, nil)
+	if err != nil {
+		return err
+	}
+
+	err = createRefsIndexes(tx)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// createSymbolsTable creates the symbols table and its indexes.
+func createSymbolsTable(tx *sqlx.Tx) error {
 	// The column names are the lowercase version of fields in `symbolInDB`
 	// because sqlx lowercases struct fields by default. See
 	// http://jmoiron.github.io/sqlx/#query
-	_, err = tx.Exec(
+	_, err := tx.Exec(
 		`CREATE TABLE IF NOT EXISTS symbols (
 			name VARCHAR(256) NOT NULL,
 			namelowercase VARCHAR(256) NOT NULL,
@@ -328,6 +387,13 @@ func (s *Service) writeAllSymbolsToNewDB(ctx context.Context, dbFile string, rep
 		return err
 	}
 
+	return nil
+}
+
+// insertSymbols parses the files at paths (or all files, if paths is empty)
+// of repo@commit and inserts their symbols into the symbols table, and the
+// occurrences of the names of symbols into the refs table.
+func (s *Service) insertSymbols(ctx context.Context, tx *sqlx.Tx, repoName api.RepoName, commitID api.CommitID, paths []string) error {
 	insertStatement, err := tx.PrepareNamed(
 		fmt.Sprintf(
 			"INSERT INTO symbols %s VALUES %s",
@@ -337,19 +403,28 @@ func (s *Service) writeAllSymbolsToNewDB(ctx context.Context, dbFile string, rep
 		return err
 	}
 
-	err = s.parseUncached(ctx, repoName, commitID, func(symbol protocol.Symbol) error {
+	// The identifiers of a file can only be told apart from the names of
+	// symbols once the symbols of all files are inserted, so they are kept in
+	// memory until then. Their names are interned, because most identifiers
+	// occur many times.
+	var refs []protocol.SymbolRef
+	names := map[string]string{}
+	err = s.parseUncached(ctx, repoName, commitID, paths, func(symbol protocol.Symbol) error {
 		symbolInDBValue := symbolToSymbolInDB(symbol)
 		_, err := insertStatement.Exec(&symbolInDBValue)
 		return err
+	}, func(ref protocol.SymbolRef) error {
+		if name, ok := names[ref.Name]; ok {
+			ref.Name = name
+		} else {
+			names[ref.Name] = ref.Name
+		}
+		refs = append(refs, ref)
+		return nil
 	})
 	if err != nil {
 		return err
 	}
 
-	err = tx.Commit()
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return insertRefs(tx, refs)
 }
@@ -10,6 +10,7 @@ import (
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sourcegraph/sourcegraph/cmd/symbols/internal/pkg/ctags"
+	"github.com/sourcegraph/sourcegraph/cmd/symbols/parser"
 	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/diskcache"
 	"github.com/sourcegraph/sourcegraph/internal/gitserver"
@@ -22,12 +23,29 @@ type Service struct {
 	// determine if the error is a bad request (eg invalid repo).
 	FetchTar func(context.Context, gitserver.Repo, api.CommitID) (io.ReadCloser, error)
 
+	// FetchTarPaths is like FetchTar, but the archive only includes the given paths.
+	FetchTarPaths func(context.Context, gitserver.Repo, api.CommitID, []string) (io.ReadCloser, error)
+
+	// GitDiff returns the paths that changed between two commits of a repository. If it,
+	// GitAncestors or FetchTarPaths is nil, the symbols of every commit are parsed from a full
+	// archive instead of incrementally from the symbols of a cached commit.
+	GitDiff func(ctx context.Context, repo api.RepoName, commitA, commitB api.CommitID) (Changes, error)
+
+	// GitAncestors returns up to n ancestors of a commit of a repository, nearest first, and
+	// possibly the commit itself. The symbols of a commit are parsed incrementally from those
+	// of the nearest ancestor in the cache.
+	GitAncestors func(ctx context.Context, repo api.RepoName, commit api.CommitID, n int) ([]api.CommitID, error)
+
 	// MaxConcurrentFetchTar is the maximum number of concurrent calls allowed
 	// to FetchTar. It defaults to 15.
 	MaxConcurrentFetchTar int
 
 	NewParser func() (ctags.Parser, error)
 
+	// Backends maps the names of languages (as detected by enry, such as "TypeScript") to the
+	// backends that parse the files in those languages instead of ctags.
+	Backends map[string]parser.Backend
+
 	// NumParserProcesses is the maximum number of ctags parser child processes to run.
 	NumParserProcesses int
 
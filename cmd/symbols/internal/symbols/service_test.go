@@ -22,6 +22,7 @@ import (
 
 func init() {
 	sqliteutil.SetLocalLibpath()
+	sqliteutil.MustRegisterSqlite3WithPcre()
 }
 
 func TestIsLiteralEquality(t *testing.T) {
@@ -57,8 +58,6 @@ func TestIsLiteralEquality(t *testing.T) {
 }
 
 func TestService(t *testing.T) {
-	sqliteutil.MustRegisterSqlite3WithPcre()
-
 	tmpDir, err := ioutil.TempDir("", "")
 	if err != nil {
 		t.Fatal(err)
@@ -129,6 +128,14 @@ func TestService(t *testing.T) {
 			args: search.SymbolsParameters{ExcludePattern: "a.js", IsCaseSensitive: true, First: 10},
 			want: protocol.SearchResult{},
 		},
+		"refs": {
+			args: search.SymbolsParameters{Query: "^X$", Refs: true, First: 10},
+			want: protocol.SearchResult{Refs: []protocol.SymbolRef{{Name: "x", Path: "a.js", Line: 1, Character: 4, Preview: "var x = 1"}}},
+		},
+		"refsnotsymbols": {
+			args: search.SymbolsParameters{Query: "^var$", Refs: true, First: 10},
+			want: protocol.SearchResult{},
+		},
 	}
 	for label, test := range tests {
 		t.Run(label, func(t *testing.T) {
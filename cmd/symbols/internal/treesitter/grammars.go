@@ -0,0 +1,179 @@
+package treesitter
+
+import (
+	sitter "github.com/smacker/go-tree-sitter"
+	"github.com/smacker/go-tree-sitter/javascript"
+	"github.com/smacker/go-tree-sitter/kotlin"
+	"github.com/smacker/go-tree-sitter/python"
+	"github.com/smacker/go-tree-sitter/typescript/tsx"
+	"github.com/smacker/go-tree-sitter/typescript/typescript"
+)
+
+// grammar describes how the symbols of a language are declared in its syntax
+// trees.
+type grammar struct {
+	language func() *sitter.Language
+
+	// kinds maps the types of the nodes that declare symbols to the ctags
+	// kinds of the symbols.
+	kinds map[string]string
+
+	// refine, if set, returns the kind of the symbol declared by n, given its
+	// kind in kinds, or "" if n declares no symbol. It tells apart the
+	// declarations that share a node type, such as constants and variables.
+	refine func(n *sitter.Node, kind string, src []byte) string
+
+	// methodKind is the kind of the functions declared in classes, if their
+	// nodes have the same type as other functions.
+	methodKind string
+
+	// skip is the set of the types of the nodes whose descendants declare no
+	// symbols worth listing, such as anonymous functions.
+	skip map[string]bool
+}
+
+func (g *grammar) kindOf(n *sitter.Node, parent scope, src []byte) string {
+	kind, ok := g.kinds[n.Type()]
+	if !ok {
+		return ""
+	}
+	if g.refine != nil {
+		kind = g.refine(n, kind, src)
+	}
+	if kind == "function" && g.methodKind != "" && (parent.kind == "class" || parent.kind == "interface" || parent.kind == "object") {
+		kind = g.methodKind
+	}
+	return kind
+}
+
+// grammars maps the names of languages, as detected by enry, to their
+// grammars.
+var grammars = map[string]*grammar{
+	"TypeScript": typeScriptGrammar(typescript.GetLanguage),
+	"TSX":        typeScriptGrammar(tsx.GetLanguage),
+	"JavaScript": javaScriptGrammar,
+	"Kotlin":     kotlinGrammar,
+	"Python":     pythonGrammar,
+}
+
+var javaScriptGrammar = &grammar{
+	language: javascript.GetLanguage,
+	kinds: map[string]string{
+		"class_declaration":              "class",
+		"function_declaration":           "function",
+		"generator_function_declaration": "generator",
+		"method_definition":              "method",
+		"field_definition":               "field",
+		"variable_declarator":            "variable",
+	},
+	refine: refineJavaScript,
+	skip:   javaScriptSkip,
+}
+
+func typeScriptGrammar(language func() *sitter.Language) *grammar {
+	return &grammar{
+		language: language,
+		kinds: map[string]string{
+			"class_declaration":              "class",
+			"abstract_class_declaration":     "class",
+			"interface_declaration":          "interface",
+			"enum_declaration":               "enum",
+			"type_alias_declaration":         "alias",
+			"internal_module":                "namespace",
+			"module":                         "namespace",
+			"function_declaration":           "function",
+			"function_signature":             "function",
+			"generator_function_declaration": "generator",
+			"method_definition":              "method",
+			"method_signature":               "method",
+			"abstract_method_signature":      "method",
+			"public_field_definition":        "property",
+			"property_signature":             "property",
+			"variable_declarator":            "variable",
+
+			// The members of enums.
+			"property_identifier": "enumerator",
+			"enum_assignment":     "enumerator",
+		},
+		refine: refineJavaScript,
+		skip:   javaScriptSkip,
+	}
+}
+
+// refineJavaScript tells apart constants and variables, and the members of
+// enums and other property names.
+func refineJavaScript(n *sitter.Node, kind string, src []byte) string {
+	switch n.Type() {
+	case "variable_declarator":
+		if p := n.Parent(); p != nil && p.Type() == "lexical_declaration" && p.Child(0).Type() == "const" {
+			return "constant"
+		}
+	case "property_identifier":
+		if p := n.Parent(); p == nil || p.Type() != "enum_body" {
+			return ""
+		}
+	}
+	return kind
+}
+
+var javaScriptSkip = map[string]bool{
+	"arrow_function":      true,
+	"function":            true,
+	"function_expression": true,
+	"generator_function":  true,
+	"class_static_block":  true,
+}
+
+var kotlinGrammar = &grammar{
+	language: kotlin.GetLanguage,
+	kinds: map[string]string{
+		"package_header":       "package",
+		"class_declaration":    "class",
+		"object_declaration":   "object",
+		"companion_object":     "object",
+		"type_alias":           "typealias",
+		"function_declaration": "method",
+		"property_declaration": "variable",
+		"enum_entry":           "constant",
+	},
+	refine: func(n *sitter.Node, kind string, src []byte) string {
+		switch n.Type() {
+		case "class_declaration":
+			for i := 0; i < int(n.ChildCount()); i++ {
+				switch n.Child(i).Type() {
+				case "interface":
+					return "interface"
+				case "enum_class_body":
+					return "enum"
+				}
+			}
+		case "property_declaration":
+			for i := 0; i < int(n.NamedChildCount()); i++ {
+				if c := n.NamedChild(i); c.Type() == "binding_pattern_kind" && c.Content(src) == "val" {
+					return "constant"
+				}
+			}
+		}
+		return kind
+	},
+	skip: map[string]bool{
+		"lambda_literal":        true,
+		"anonymous_function":    true,
+		"anonymous_initializer": true,
+		"getter":                true,
+		"setter":                true,
+	},
+}
+
+var pythonGrammar = &grammar{
+	language: python.GetLanguage,
+	kinds: map[string]string{
+		"class_definition":    "class",
+		"function_definition": "function",
+		"assignment":          "variable",
+	},
+	methodKind: "member",
+	skip: map[string]bool{
+		"lambda": true,
+	},
+}
@@ -0,0 +1,170 @@
+// Package treesitter implements a symbol parser backend that extracts the
+// symbols of files from their tree-sitter syntax trees, in process, for
+// languages that ctags parses poorly.
+package treesitter
+
+import (
+	"bytes"
+	"context"
+
+	sitter "github.com/smacker/go-tree-sitter"
+
+	"github.com/sourcegraph/sourcegraph/cmd/symbols/parser"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/symbols/protocol"
+)
+
+// NewBackend returns the tree-sitter symbol parser backend, named
+// "treesitter". It parses files of the languages in grammars and returns
+// parser.ErrUnsupported for the others.
+func NewBackend() parser.Backend {
+	return backend{}
+}
+
+type backend struct{}
+
+func (backend) Name() string { return "treesitter" }
+
+// ForCommit implements parser.Backend. The files of a commit have nothing in
+// common that the backend needs, so it is its own parser.
+func (b backend) ForCommit(ctx context.Context, repo api.RepoName, commitID api.CommitID) (parser.CommitParser, error) {
+	return b, nil
+}
+
+func (backend) Parse(ctx context.Context, file parser.File) ([]protocol.Symbol, error) {
+	g, ok := grammars[file.Language]
+	if !ok {
+		return nil, parser.ErrUnsupported
+	}
+
+	// Parsers are cheap to create and can't be used concurrently, so each
+	// file gets its own.
+	p := sitter.NewParser()
+	defer p.Close()
+	p.SetLanguage(g.language())
+	tree, err := p.ParseCtx(ctx, nil, file.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	w := walker{
+		grammar: g,
+		file:    file,
+		lines:   bytes.Split(file.Content, []byte("\n")),
+	}
+	w.walk(tree.RootNode(), scope{})
+	return w.symbols, nil
+}
+
+// scope is the symbol that the symbols declared in its body belong to.
+type scope struct {
+	name, kind string
+}
+
+type walker struct {
+	grammar *grammar
+	file    parser.File
+	lines   [][]byte
+	symbols []protocol.Symbol
+}
+
+// walk appends the symbols declared in the descendants of n, which are in
+// parent, to w.symbols.
+func (w *walker) walk(n *sitter.Node, parent scope) {
+	for i := 0; i < int(n.NamedChildCount()); i++ {
+		child := n.NamedChild(i)
+		if w.grammar.skip[child.Type()] {
+			continue
+		}
+
+		childScope := parent
+		if kind := w.grammar.kindOf(child, parent, w.file.Content); kind != "" {
+			if name := declarationName(child, w.file.Content); name != "" {
+				w.symbols = append(w.symbols, w.symbol(child, name, kind, parent))
+				if functionKinds[kind] {
+					// Like ctags, skip the local declarations of functions.
+					continue
+				}
+				if scopeKinds[kind] {
+					childScope = scope{name: name, kind: kind}
+				}
+			}
+		}
+		w.walk(child, childScope)
+	}
+}
+
+func (w *walker) symbol(n *sitter.Node, name, kind string, parent scope) protocol.Symbol {
+	row := int(n.StartPoint().Row)
+	var line []byte
+	if row < len(w.lines) {
+		line = bytes.TrimSuffix(w.lines[row], []byte("\r"))
+	}
+	return protocol.Symbol{
+		Name:       name,
+		Path:       w.file.Path,
+		Line:       row + 1,
+		Kind:       kind,
+		Language:   w.file.Language,
+		Parent:     parent.name,
+		ParentKind: parent.kind,
+		Pattern:    parser.Pattern(string(line)),
+	}
+}
+
+// identifierTypes are the types of the nodes of the names of declarations.
+var identifierTypes = map[string]bool{
+	"identifier":                  true,
+	"type_identifier":             true,
+	"property_identifier":         true,
+	"private_property_identifier": true,
+	"simple_identifier":           true,
+}
+
+// declarationName returns the name of the symbol declared by n, or "" if it
+// has no simple name (such as destructuring assignments and computed
+// property names).
+func declarationName(n *sitter.Node, src []byte) string {
+	for _, field := range []string{"name", "left"} {
+		if c := n.ChildByFieldName(field); c != nil {
+			if identifierTypes[c.Type()] {
+				return c.Content(src)
+			}
+			return ""
+		}
+	}
+	if identifierTypes[n.Type()] {
+		return n.Content(src)
+	}
+
+	// Some grammars (such as Kotlin's) have no field names, so look for the
+	// first identifier among the children.
+	for i := 0; i < int(n.NamedChildCount()); i++ {
+		c := n.NamedChild(i)
+		switch {
+		case identifierTypes[c.Type()]:
+			return c.Content(src)
+		case c.Type() == "variable_declaration":
+			return declarationName(c, src)
+		}
+	}
+	return ""
+}
+
+// functionKinds are the kinds of the symbols whose bodies are not walked.
+var functionKinds = map[string]bool{
+	"function":  true,
+	"generator": true,
+	"method":    true,
+	"member":    true,
+}
+
+// scopeKinds are the kinds of the symbols that are the parents of the
+// symbols declared in their bodies.
+var scopeKinds = map[string]bool{
+	"class":     true,
+	"interface": true,
+	"enum":      true,
+	"object":    true,
+	"namespace": true,
+}
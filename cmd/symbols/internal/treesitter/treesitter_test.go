@@ -0,0 +1,154 @@
+package treesitter
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/symbols/parser"
+	"github.com/sourcegraph/sourcegraph/internal/symbols/protocol"
+)
+
+func TestParse(t *testing.T) {
+	for _, test := range []struct {
+		language string
+		content  string
+		want     []string
+	}{
+		{
+			language: "TypeScript",
+			content: `export class A extends B {
+  x: number = 1
+  m(): void { const local = 1 }
+}
+interface I { f(): string; p: number }
+enum E { X, Y = 2 }
+type T = string
+export function f(): void { let local = 2 }
+const c = () => { let local = 3 }, d = 4
+let v = 5
+namespace N { export const w = 6 }
+`,
+			want: []string{
+				"1:A:class:",
+				"2:x:property:class A",
+				"3:m:method:class A",
+				"5:I:interface:",
+				"5:f:method:interface I",
+				"5:p:property:interface I",
+				"6:E:enum:",
+				"6:X:enumerator:enum E",
+				"6:Y:enumerator:enum E",
+				"7:T:alias:",
+				"8:f:function:",
+				"9:c:constant:",
+				"9:d:constant:",
+				"10:v:variable:",
+				"11:N:namespace:",
+				"11:w:constant:namespace N",
+			},
+		},
+		{
+			language: "TSX",
+			content:  "export const C = () => <div>{x}</div>\n",
+			want:     []string{"1:C:constant:"},
+		},
+		{
+			language: "JavaScript",
+			content:  "class A {\n  m() { var local }\n}\nfunction f() {}\nvar v = 1\n",
+			want: []string{
+				"1:A:class:",
+				"2:m:method:class A",
+				"4:f:function:",
+				"5:v:variable:",
+			},
+		},
+		{
+			language: "Kotlin",
+			content: `package com.example
+
+class A {
+  fun m() { val local = 1 }
+  var p = 2
+}
+interface I { fun f(): String }
+object O { fun g() {} }
+enum class E { X, Y }
+typealias T = String
+fun top() {}
+val v = 3
+`,
+			want: []string{
+				"1:com.example:package:",
+				"3:A:class:",
+				"4:m:method:class A",
+				"5:p:variable:class A",
+				"7:I:interface:",
+				"7:f:method:interface I",
+				"8:O:object:",
+				"8:g:method:object O",
+				"9:E:enum:",
+				"9:X:constant:enum E",
+				"9:Y:constant:enum E",
+				"10:T:typealias:",
+				"11:top:method:",
+				"12:v:constant:",
+			},
+		},
+		{
+			language: "Python",
+			content:  "class A:\n    x = 1\n    def m(self):\n        local = 1\n\ndef f():\n    pass\n\nv = 2\na, b = 3, 4\n",
+			want: []string{
+				"1:A:class:",
+				"2:x:variable:class A",
+				"3:m:member:class A",
+				"6:f:function:",
+				"9:v:variable:",
+			},
+		},
+	} {
+		t.Run(test.language, func(t *testing.T) {
+			symbols, err := parse(test.language, test.content)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, s := range symbols {
+				got = append(got, fmt.Sprintf("%d:%s:%s:%s", s.Line, s.Name, s.Kind, strings.TrimSpace(s.ParentKind+" "+s.Parent)))
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParse_symbol(t *testing.T) {
+	symbols, err := parse("TypeScript", "class A {\r\n  m(): string { return 'a/b' }\r\n}\r\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []protocol.Symbol{
+		{Name: "A", Path: "a.ts", Line: 1, Kind: "class", Language: "TypeScript", Pattern: "/^class A {$/"},
+		{Name: "m", Path: "a.ts", Line: 2, Kind: "method", Language: "TypeScript", Parent: "A", ParentKind: "class", Pattern: `/^  m(): string { return 'a\/b' }$/`},
+	}
+	if !reflect.DeepEqual(symbols, want) {
+		t.Errorf("got %+v, want %+v", symbols, want)
+	}
+}
+
+func TestParse_unsupported(t *testing.T) {
+	if _, err := parse("Go", "package main"); err != parser.ErrUnsupported {
+		t.Errorf("got error %v, want %v", err, parser.ErrUnsupported)
+	}
+}
+
+func parse(language, content string) ([]protocol.Symbol, error) {
+	p, err := NewBackend().ForCommit(context.Background(), "r", "c")
+	if err != nil {
+		return nil, err
+	}
+	return p.Parse(context.Background(), parser.File{Repo: "r", CommitID: "c", Path: "a.ts", Language: language, Content: []byte(content)})
+}
@@ -3,93 +3,9 @@
 package main
 
 import (
-	"context"
-	"io"
-	"log"
-	"net"
-	"net/http"
-	"os"
-	"os/signal"
-	"runtime"
-	"strconv"
-	"time"
-
-	"github.com/inconshreveable/log15"
-
-	"github.com/sourcegraph/sourcegraph/cmd/symbols/internal/pkg/ctags"
-	"github.com/sourcegraph/sourcegraph/cmd/symbols/internal/symbols"
-	"github.com/sourcegraph/sourcegraph/internal/api"
-	"github.com/sourcegraph/sourcegraph/internal/debugserver"
-	"github.com/sourcegraph/sourcegraph/internal/env"
-	"github.com/sourcegraph/sourcegraph/internal/gitserver"
-	"github.com/sourcegraph/sourcegraph/internal/sqliteutil"
-	"github.com/sourcegraph/sourcegraph/internal/trace/ot"
-	"github.com/sourcegraph/sourcegraph/internal/tracer"
+	"github.com/sourcegraph/sourcegraph/cmd/symbols/shared"
 )
 
-const port = "3184"
-
 func main() {
-	var (
-		cacheDir       = env.Get("CACHE_DIR", "/tmp/symbols-cache", "directory to store cached symbols")
-		cacheSizeMB    = env.Get("SYMBOLS_CACHE_SIZE_MB", "100000", "maximum size of the disk cache in megabytes")
-		ctagsProcesses = env.Get("CTAGS_PROCESSES", strconv.Itoa(runtime.GOMAXPROCS(0)), "number of ctags child processes to run")
-	)
-
-	env.Lock()
-	env.HandleHelpFlag()
-	log.SetFlags(0)
-	tracer.Init()
-
-	sqliteutil.MustRegisterSqlite3WithPcre()
-
-	go debugserver.Start()
-
-	service := symbols.Service{
-		FetchTar: func(ctx context.Context, repo gitserver.Repo, commit api.CommitID) (io.ReadCloser, error) {
-			return gitserver.DefaultClient.Archive(ctx, repo, gitserver.ArchiveOptions{Treeish: string(commit), Format: "tar"})
-		},
-		NewParser: ctags.New,
-		Path:      cacheDir,
-	}
-	if mb, err := strconv.ParseInt(cacheSizeMB, 10, 64); err != nil {
-		log.Fatalf("Invalid SYMBOLS_CACHE_SIZE_MB: %s", err)
-	} else {
-		service.MaxCacheSizeBytes = mb * 1000 * 1000
-	}
-	var err error
-	service.NumParserProcesses, err = strconv.Atoi(ctagsProcesses)
-	if err != nil {
-		log.Fatalf("Invalid CTAGS_PROCESSES: %s", err)
-	}
-	if err := service.Start(); err != nil {
-		log.Fatalln("Start:", err)
-	}
-	handler := ot.Middleware(service.Handler())
-
-	host := ""
-	if env.InsecureDev {
-		host = "127.0.0.1"
-	}
-	addr := net.JoinHostPort(host, port)
-	server := &http.Server{Addr: addr, Handler: handler}
-	go shutdownOnSIGINT(server)
-
-	log15.Info("symbols: listening", "addr", addr)
-	err = server.ListenAndServe()
-	if err != http.ErrServerClosed {
-		log.Fatal(err)
-	}
-}
-
-func shutdownOnSIGINT(s *http.Server) {
-	c := make(chan os.Signal, 1)
-	signal.Notify(c, os.Interrupt)
-	<-c
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-	err := s.Shutdown(ctx)
-	if err != nil {
-		log.Fatal("graceful server shutdown failed, will exit:", err)
-	}
+	shared.Main(nil)
 }
@@ -0,0 +1,66 @@
+// Package parser defines the interface of symbol parser backends, which the
+// symbols service can use instead of ctags to extract the symbols of files
+// in some languages.
+package parser
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/symbols/protocol"
+)
+
+// File is a file whose symbols are extracted.
+type File struct {
+	Repo     api.RepoName
+	CommitID api.CommitID
+	Path     string
+
+	// Language is the name of the language of the file, as detected by enry
+	// (such as "TypeScript").
+	Language string
+
+	Content []byte
+}
+
+// Backend extracts the symbols of files.
+type Backend interface {
+	// Name is the name of the backend, used to route languages to it in the
+	// SYMBOLS_PARSER_BACKENDS environment variable.
+	Name() string
+
+	// ForCommit returns the parser of the files of repo@commitID. It is
+	// called once per parse of the files of a commit, before the first file
+	// that is routed to the backend, so that the backend can look up what
+	// the files have in common once, such as the precise code intelligence
+	// upload of the commit. If the backend can't extract the symbols of any
+	// file of the commit, it returns ErrUnsupported and the files are parsed
+	// by ctags instead.
+	ForCommit(ctx context.Context, repo api.RepoName, commitID api.CommitID) (CommitParser, error)
+}
+
+// CommitParser extracts the symbols of the files of a commit. It must be safe
+// for concurrent use.
+type CommitParser interface {
+	// Parse returns the symbols of the file. The Path of each symbol must be
+	// the path of the file. If the backend can't extract the symbols of this
+	// file, for example because it is not covered by the precise code
+	// intelligence upload of its commit, it returns ErrUnsupported and the
+	// file is parsed by ctags instead.
+	Parse(ctx context.Context, file File) ([]protocol.Symbol, error)
+}
+
+// ErrUnsupported is returned by Backend.ForCommit and CommitParser.Parse if
+// files should be parsed by ctags instead.
+var ErrUnsupported = errors.New("file not supported by symbol parser backend")
+
+// Pattern returns the ctags search pattern of the line of a symbol, such as
+// /^func main() {$/. Like ctags, it escapes backslashes and slashes so that
+// the line can be recovered from the pattern.
+func Pattern(line string) string {
+	return "/^" + patternEscaper.Replace(line) + "$/"
+}
+
+var patternEscaper = strings.NewReplacer(`\`, `\\`, `/`, `\/`)
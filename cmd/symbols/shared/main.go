@@ -0,0 +1,171 @@
+// Package shared contains the symbols service's main function, which is
+// shared by the OSS and enterprise commands.
+package shared
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/inconshreveable/log15"
+	"github.com/src-d/enry/v2"
+
+	"github.com/sourcegraph/sourcegraph/cmd/symbols/internal/pkg/ctags"
+	"github.com/sourcegraph/sourcegraph/cmd/symbols/internal/symbols"
+	"github.com/sourcegraph/sourcegraph/cmd/symbols/internal/treesitter"
+	"github.com/sourcegraph/sourcegraph/cmd/symbols/parser"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/debugserver"
+	"github.com/sourcegraph/sourcegraph/internal/env"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/sqliteutil"
+	"github.com/sourcegraph/sourcegraph/internal/trace/ot"
+	"github.com/sourcegraph/sourcegraph/internal/tracer"
+)
+
+const port = "3184"
+
+var parserBackends = env.Get("SYMBOLS_PARSER_BACKENDS", "", "comma-separated list of language=backend pairs, such as TypeScript=lsif, of the languages to parse with a symbol parser backend instead of ctags")
+
+// EnterpriseInit is a function that allows enterprise code to provide symbol
+// parser backends. It is called after the environment is locked.
+type EnterpriseInit func() []parser.Backend
+
+func Main(enterpriseInit EnterpriseInit) {
+	var (
+		cacheDir       = env.Get("CACHE_DIR", "/tmp/symbols-cache", "directory to store cached symbols")
+		cacheSizeMB    = env.Get("SYMBOLS_CACHE_SIZE_MB", "100000", "maximum size of the disk cache in megabytes")
+		ctagsProcesses = env.Get("CTAGS_PROCESSES", strconv.Itoa(runtime.GOMAXPROCS(0)), "number of ctags child processes to run")
+	)
+
+	env.Lock()
+	env.HandleHelpFlag()
+	log.SetFlags(0)
+	tracer.Init()
+
+	sqliteutil.MustRegisterSqlite3WithPcre()
+
+	go debugserver.Start()
+
+	available := []parser.Backend{treesitter.NewBackend()}
+	if enterpriseInit != nil {
+		available = append(available, enterpriseInit()...)
+	}
+	backends, err := routeBackends(parserBackends, available)
+	if err != nil {
+		log.Fatalf("Invalid SYMBOLS_PARSER_BACKENDS: %s", err)
+	}
+
+	service := symbols.Service{
+		FetchTar: func(ctx context.Context, repo gitserver.Repo, commit api.CommitID) (io.ReadCloser, error) {
+			return gitserver.DefaultClient.Archive(ctx, repo, gitserver.ArchiveOptions{Treeish: string(commit), Format: "tar"})
+		},
+		FetchTarPaths: func(ctx context.Context, repo gitserver.Repo, commit api.CommitID, paths []string) (io.ReadCloser, error) {
+			return gitserver.DefaultClient.Archive(ctx, repo, gitserver.ArchiveOptions{Treeish: string(commit), Format: "tar", Paths: paths})
+		},
+		GitDiff: func(ctx context.Context, repo api.RepoName, commitA, commitB api.CommitID) (symbols.Changes, error) {
+			cmd := gitserver.DefaultClient.Command("git", "diff", "-z", "--name-status", "--no-renames", string(commitA), string(commitB))
+			cmd.Repo = gitserver.Repo{Name: repo}
+			out, stderr, err := cmd.DividedOutput(ctx)
+			if err != nil {
+				return symbols.Changes{}, fmt.Errorf("git command %v failed (output: %q): %s", cmd.Args, stderr, err)
+			}
+			return symbols.ParseGitDiffNameStatus(out)
+		},
+		GitAncestors: func(ctx context.Context, repo api.RepoName, commit api.CommitID, n int) ([]api.CommitID, error) {
+			cmd := gitserver.DefaultClient.Command("git", "rev-list", "--max-count="+strconv.Itoa(n), string(commit))
+			cmd.Repo = gitserver.Repo{Name: repo}
+			out, stderr, err := cmd.DividedOutput(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("git command %v failed (output: %q): %s", cmd.Args, stderr, err)
+			}
+			var ancestors []api.CommitID
+			for _, line := range strings.Fields(string(out)) {
+				ancestors = append(ancestors, api.CommitID(line))
+			}
+			return ancestors, nil
+		},
+		NewParser: ctags.New,
+		Backends:  backends,
+		Path:      cacheDir,
+	}
+	if mb, err := strconv.ParseInt(cacheSizeMB, 10, 64); err != nil {
+		log.Fatalf("Invalid SYMBOLS_CACHE_SIZE_MB: %s", err)
+	} else {
+		service.MaxCacheSizeBytes = mb * 1000 * 1000
+	}
+	service.NumParserProcesses, err = strconv.Atoi(ctagsProcesses)
+	if err != nil {
+		log.Fatalf("Invalid CTAGS_PROCESSES: %s", err)
+	}
+	if err := service.Start(); err != nil {
+		log.Fatalln("Start:", err)
+	}
+	handler := ot.Middleware(service.Handler())
+
+	host := ""
+	if env.InsecureDev {
+		host = "127.0.0.1"
+	}
+	addr := net.JoinHostPort(host, port)
+	server := &http.Server{Addr: addr, Handler: handler}
+	go shutdownOnSIGINT(server)
+
+	log15.Info("symbols: listening", "addr", addr)
+	err = server.ListenAndServe()
+	if err != http.ErrServerClosed {
+		log.Fatal(err)
+	}
+}
+
+func shutdownOnSIGINT(s *http.Server) {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt)
+	<-c
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	err := s.Shutdown(ctx)
+	if err != nil {
+		log.Fatal("graceful server shutdown failed, will exit:", err)
+	}
+}
+
+// routeBackends parses the value of SYMBOLS_PARSER_BACKENDS, and returns a map
+// from language names to the backends in available that parse them.
+func routeBackends(routes string, available []parser.Backend) (map[string]parser.Backend, error) {
+	byName := make(map[string]parser.Backend, len(available))
+	for _, b := range available {
+		byName[b.Name()] = b
+	}
+
+	backends := map[string]parser.Backend{}
+	for _, route := range strings.Split(routes, ",") {
+		route = strings.TrimSpace(route)
+		if route == "" {
+			continue
+		}
+		i := strings.Index(route, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("%q is not of the form language=backend", route)
+		}
+		language, name := strings.TrimSpace(route[:i]), strings.TrimSpace(route[i+1:])
+		if lang, ok := enry.GetLanguageByAlias(language); ok {
+			language = lang
+		}
+		b, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown symbol parser backend %q for %s", name, language)
+		}
+		backends[language] = b
+	}
+	return backends, nil
+}
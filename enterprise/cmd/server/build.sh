@@ -10,4 +10,5 @@ export SERVER_PKG=${SERVER_PKG:-github.com/sourcegraph/sourcegraph/enterprise/cm
   github.com/sourcegraph/sourcegraph/enterprise/cmd/frontend \
   github.com/sourcegraph/sourcegraph/enterprise/cmd/repo-updater \
   github.com/sourcegraph/sourcegraph/enterprise/cmd/precise-code-intel-bundle-manager \
-  github.com/sourcegraph/sourcegraph/enterprise/cmd/precise-code-intel-worker
+  github.com/sourcegraph/sourcegraph/enterprise/cmd/precise-code-intel-worker \
+  github.com/sourcegraph/sourcegraph/enterprise/cmd/symbols
@@ -0,0 +1,21 @@
+#!/usr/bin/env bash
+
+# This script builds the enterprise symbols go binary.
+# Requires a single argument which is the path to the target bindir.
+
+cd "$(dirname "${BASH_SOURCE[0]}")/../../.."
+set -eu
+
+OUTPUT="${1:?no output path provided}"
+
+# Environment for building linux binaries
+export GO111MODULE=on
+export GOARCH=amd64
+export GOOS=linux
+
+# Get additional build args
+. ./dev/libsqlite3-pcre/go-build-args.sh
+
+echo "--- go build"
+pkg="github.com/sourcegraph/sourcegraph/enterprise/cmd/symbols"
+go build -trimpath -ldflags "-X github.com/sourcegraph/sourcegraph/internal/version.version=$VERSION  -X github.com/sourcegraph/sourcegraph/internal/version.timestamp=$(date +%s)" -buildmode exe -tags dist -o "$OUTPUT/$(basename $pkg)" "$pkg"
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/sourcegraph/sourcegraph/cmd/symbols/parser"
+	bundles "github.com/sourcegraph/sourcegraph/enterprise/internal/codeintel/bundles/client"
+	"github.com/sourcegraph/sourcegraph/enterprise/internal/codeintel/store"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/db"
+	"github.com/sourcegraph/sourcegraph/internal/errcode"
+	"github.com/sourcegraph/sourcegraph/internal/symbols/protocol"
+)
+
+// lsifBackend is a symbol parser backend that reads the symbols of a file from
+// the precise code intelligence upload of its commit. The symbols of a file
+// are the ranges which are their own definition.
+type lsifBackend struct {
+	store               store.Store
+	bundleManagerClient bundles.BundleManagerClient
+}
+
+func (b *lsifBackend) Name() string { return "lsif" }
+
+// ForCommit looks up the uploads of repo@commitID once for all of its files.
+func (b *lsifBackend) ForCommit(ctx context.Context, repoName api.RepoName, commitID api.CommitID) (parser.CommitParser, error) {
+	repo, err := db.Repos.GetByName(ctx, repoName)
+	if err != nil {
+		if errcode.IsNotFound(err) {
+			return nil, parser.ErrUnsupported
+		}
+		return nil, err
+	}
+
+	// An empty path that need not enclose the roots matches the uploads of
+	// every root.
+	dumps, err := b.store.FindClosestDumps(ctx, int(repo.ID), string(commitID), "", false, "")
+	if err != nil {
+		return nil, err
+	}
+	// The upload of another commit can't be used, because the files may have
+	// changed since.
+	var commitDumps []store.Dump
+	for _, dump := range dumps {
+		if dump.Commit == string(commitID) {
+			commitDumps = append(commitDumps, dump)
+		}
+	}
+	if len(commitDumps) == 0 {
+		return nil, parser.ErrUnsupported
+	}
+	return &lsifCommitParser{bundleManagerClient: b.bundleManagerClient, dumps: commitDumps}, nil
+}
+
+// lsifCommitParser reads the symbols of the files of a commit from its
+// uploads.
+type lsifCommitParser struct {
+	bundleManagerClient bundles.BundleManagerClient
+	dumps               []store.Dump
+}
+
+func (p *lsifCommitParser) Parse(ctx context.Context, file parser.File) ([]protocol.Symbol, error) {
+	dump, ok := dumpForPath(p.dumps, file.Path)
+	if !ok {
+		return nil, parser.ErrUnsupported
+	}
+
+	pathInBundle := strings.TrimPrefix(file.Path, dump.Root)
+	lines := strings.Split(string(file.Content), "\n")
+	ranges, err := p.bundleManagerClient.BundleClient(dump.ID).Ranges(ctx, pathInBundle, 0, len(lines))
+	if err != nil {
+		return nil, err
+	}
+	return definitionSymbols(file, pathInBundle, lines, ranges), nil
+}
+
+// dumpForPath returns the upload in dumps whose root encloses path most
+// closely.
+func dumpForPath(dumps []store.Dump, path string) (store.Dump, bool) {
+	var (
+		closest store.Dump
+		ok      bool
+	)
+	for _, dump := range dumps {
+		if strings.HasPrefix(path, dump.Root) && (!ok || len(dump.Root) > len(closest.Root)) {
+			closest, ok = dump, true
+		}
+	}
+	return closest, ok
+}
+
+// definitionSymbols returns a symbol for each range of the file that is its
+// own definition.
+func definitionSymbols(file parser.File, pathInBundle string, lines []string, ranges []bundles.CodeIntelligenceRange) []protocol.Symbol {
+	var symbols []protocol.Symbol
+	for _, r := range ranges {
+		if !isDefinition(r, pathInBundle) {
+			continue
+		}
+		start, end := r.Range.Start, r.Range.End
+		if start.Line != end.Line || start.Line >= len(lines) {
+			continue
+		}
+		line := lines[start.Line]
+		name := utf16Slice(line, start.Character, end.Character)
+		if name == "" {
+			continue
+		}
+
+		kind, signature := parseHoverText(r.HoverText)
+		symbols = append(symbols, protocol.Symbol{
+			Name:      name,
+			Path:      file.Path,
+			Line:      start.Line + 1,
+			Kind:      kind,
+			Language:  file.Language,
+			Signature: signature,
+			Pattern:   parser.Pattern(line),
+		})
+	}
+	return symbols
+}
+
+func isDefinition(r bundles.CodeIntelligenceRange, path string) bool {
+	for _, d := range r.Definitions {
+		if d.Path == path && d.Range == r.Range {
+			return true
+		}
+	}
+	return false
+}
+
+// utf16Slice returns the substring of s between the LSP character offsets
+// start and end, which count UTF-16 code units.
+func utf16Slice(s string, start, end int) string {
+	units := utf16.Encode([]rune(s))
+	if start < 0 || end > len(units) || start >= end {
+		return ""
+	}
+	return string(utf16.Decode(units[start:end]))
+}
+
+// hoverKinds maps the first word of the code in hover texts to ctags kinds.
+var hoverKinds = map[string]string{
+	"func":        "function",
+	"function":    "function",
+	"fun":         "function",
+	"def":         "function",
+	"(function)":  "function",
+	"(method)":    "method",
+	"method":      "method",
+	"constructor": "method",
+	"class":       "class",
+	"interface":   "interface",
+	"type":        "type",
+	"enum":        "enum",
+	"const":       "constant",
+	"(constant)":  "constant",
+	"let":         "variable",
+	"var":         "variable",
+	"val":         "variable",
+	"(variable)":  "variable",
+	"field":       "field",
+	"(property)":  "property",
+	"property":    "property",
+	"package":     "package",
+	"module":      "module",
+	"namespace":   "namespace",
+}
+
+// parseHoverText returns the kind and signature of a symbol from its hover
+// text, which starts with a fenced code block of its declaration, such as
+// "```go\nfunc Foo() error\n```".
+func parseHoverText(hover string) (kind, signature string) {
+	for _, line := range strings.Split(hover, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "```") {
+			continue
+		}
+		signature = line
+		break
+	}
+	for _, word := range strings.Fields(signature) {
+		if k, ok := hoverKinds[word]; ok {
+			return k, signature
+		}
+		// Skip modifiers, such as "export" or "public", which precede the
+		// keyword.
+		switch word {
+		case "export", "public", "private", "protected", "internal", "static", "abstract", "async", "declare", "default", "open", "data", "override", "suspend":
+			continue
+		}
+		break
+	}
+	return "", signature
+}
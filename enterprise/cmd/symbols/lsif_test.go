@@ -0,0 +1,107 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/symbols/parser"
+	bundles "github.com/sourcegraph/sourcegraph/enterprise/internal/codeintel/bundles/client"
+	"github.com/sourcegraph/sourcegraph/enterprise/internal/codeintel/store"
+	"github.com/sourcegraph/sourcegraph/internal/symbols/protocol"
+)
+
+func TestDefinitionSymbols(t *testing.T) {
+	lines := []string{
+		"export class Föo {",
+		"  bar(): Föo { return new Föo() } // a/b\\c",
+		"}",
+	}
+	r := func(line, start, end int) bundles.Range {
+		return bundles.Range{
+			Start: bundles.Position{Line: line, Character: start},
+			End:   bundles.Position{Line: line, Character: end},
+		}
+	}
+	ranges := []bundles.CodeIntelligenceRange{
+		{
+			Range:       r(0, 13, 16),
+			Definitions: []bundles.Location{{Path: "src/a.ts", Range: r(0, 13, 16)}},
+			HoverText:   "```ts\nclass Föo\n```",
+		},
+		{
+			Range:       r(1, 2, 5),
+			Definitions: []bundles.Location{{Path: "src/a.ts", Range: r(1, 2, 5)}},
+			HoverText:   "```ts\n(method) Föo.bar(): Föo\n```\n\nReturns a new Föo.",
+		},
+		{
+			// A reference to the class, not a definition.
+			Range:       r(1, 9, 12),
+			Definitions: []bundles.Location{{Path: "src/a.ts", Range: r(0, 13, 16)}},
+		},
+	}
+	file := parser.File{Path: "web/src/a.ts", Language: "TypeScript"}
+
+	got := definitionSymbols(file, "src/a.ts", lines, ranges)
+	want := []protocol.Symbol{
+		{
+			Name:      "Föo",
+			Path:      "web/src/a.ts",
+			Line:      1,
+			Kind:      "class",
+			Language:  "TypeScript",
+			Signature: "class Föo",
+			Pattern:   "/^export class Föo {$/",
+		},
+		{
+			Name:      "bar",
+			Path:      "web/src/a.ts",
+			Line:      2,
+			Kind:      "method",
+			Language:  "TypeScript",
+			Signature: "(method) Föo.bar(): Föo",
+			Pattern:   `/^  bar(): Föo { return new Föo() } \/\/ a\/b\\c$/`,
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestParseHoverText(t *testing.T) {
+	for _, test := range []struct {
+		hover, kind, signature string
+	}{
+		{"```go\nfunc Foo() error\n```", "function", "func Foo() error"},
+		{"```kotlin\npublic open fun foo(): Unit\n```", "function", "public open fun foo(): Unit"},
+		{"```typescript\nexport const x: number\n```", "constant", "export const x: number"},
+		{"```ts\nfoo: string\n```", "", "foo: string"},
+		{"", "", ""},
+	} {
+		kind, signature := parseHoverText(test.hover)
+		if kind != test.kind || signature != test.signature {
+			t.Errorf("parseHoverText(%q) = %q, %q, want %q, %q", test.hover, kind, signature, test.kind, test.signature)
+		}
+	}
+}
+
+func TestDumpForPath(t *testing.T) {
+	dumps := []store.Dump{
+		{ID: 1, Root: ""},
+		{ID: 2, Root: "web/"},
+		{ID: 3, Root: "web/shared/"},
+	}
+	for path, want := range map[string]int{
+		"main.go":              1,
+		"web/src/a.ts":         2,
+		"web/shared/src/b.ts":  3,
+		"web/sharedx/src/c.ts": 2,
+	} {
+		if dump, ok := dumpForPath(dumps, path); !ok || dump.ID != want {
+			t.Errorf("%s: got dump %d, %v, want %d", path, dump.ID, ok, want)
+		}
+	}
+
+	if _, ok := dumpForPath(dumps[1:], "main.go"); ok {
+		t.Error("got a dump for a path outside of every root")
+	}
+}
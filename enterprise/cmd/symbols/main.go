@@ -0,0 +1,56 @@
+// Command symbols is the enterprise symbols service. It can read the symbols
+// of files from precise code intelligence uploads instead of ctags.
+package main
+
+import (
+	"log"
+
+	"github.com/inconshreveable/log15"
+	"github.com/opentracing/opentracing-go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sourcegraph/sourcegraph/cmd/symbols/parser"
+	"github.com/sourcegraph/sourcegraph/cmd/symbols/shared"
+	bundles "github.com/sourcegraph/sourcegraph/enterprise/internal/codeintel/bundles/client"
+	"github.com/sourcegraph/sourcegraph/enterprise/internal/codeintel/store"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/internal/db/basestore"
+	"github.com/sourcegraph/sourcegraph/internal/db/dbconn"
+	"github.com/sourcegraph/sourcegraph/internal/env"
+	"github.com/sourcegraph/sourcegraph/internal/observation"
+	"github.com/sourcegraph/sourcegraph/internal/trace"
+)
+
+var bundleManagerURL = env.Get("PRECISE_CODE_INTEL_BUNDLE_MANAGER_URL", "", "HTTP address for internal LSIF bundle manager server. If set, the lsif symbol parser backend is available.")
+
+func main() {
+	shared.Main(enterpriseInit)
+}
+
+func enterpriseInit() []parser.Backend {
+	if bundleManagerURL == "" {
+		return nil
+	}
+
+	observationContext := &observation.Context{
+		Logger:     log15.Root(),
+		Tracer:     &trace.Tracer{Tracer: opentracing.GlobalTracer()},
+		Registerer: prometheus.DefaultRegisterer,
+	}
+
+	postgresDSN := conf.Get().ServiceConnections.PostgresDSN
+	conf.Watch(func() {
+		if newDSN := conf.Get().ServiceConnections.PostgresDSN; postgresDSN != newDSN {
+			log.Fatalf("detected repository DSN change, restarting to take effect: %s", newDSN)
+		}
+	})
+	if err := dbconn.ConnectToDB(postgresDSN); err != nil {
+		log.Fatalf("failed to connect to database: %s", err)
+	}
+
+	return []parser.Backend{
+		&lsifBackend{
+			store:               store.NewObserved(store.NewWithHandle(basestore.NewHandleWithDB(dbconn.Global)), observationContext),
+			bundleManagerClient: bundles.New(bundleManagerURL),
+		},
+	}
+}
@@ -2,11 +2,9 @@ package gitserver
 
 import (
 	"context"
-	"os"
 
 	"github.com/pkg/errors"
 	"github.com/sourcegraph/sourcegraph/enterprise/internal/codeintel/store"
-	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
 )
 
@@ -17,17 +15,16 @@ func FileExists(ctx context.Context, store store.Store, repositoryID int, commit
 		return false, err
 	}
 
-	if _, err := git.ResolveRevision(ctx, repo, nil, commit, git.ResolveRevisionOptions{}); err != nil {
-		return false, errors.Wrap(err, "git.ResolveRevision")
+	// Resolve the commit and list the file in a single request to gitserver.
+	_, results, err := git.ExecBatch(ctx, repo, commit, [][]string{
+		{"ls-tree", "--name-only", git.BatchCommit, "--", file},
+	})
+	if err != nil {
+		return false, errors.Wrap(err, "git.ExecBatch")
 	}
-
-	if _, err := git.Stat(ctx, repo, api.CommitID(commit), file); err != nil {
-		if os.IsNotExist(err) {
-			return false, nil
-		}
-
-		return false, err
+	if results[0].ExitCode != 0 {
+		return false, errors.Errorf("git ls-tree failed: %s", results[0].Stderr)
 	}
 
-	return true, nil
+	return len(results[0].Stdout) > 0, nil
 }
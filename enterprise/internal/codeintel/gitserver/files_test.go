@@ -0,0 +1,45 @@
+package gitserver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sourcegraph/sourcegraph/enterprise/internal/codeintel/store/mocks"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+)
+
+func TestFileExists(t *testing.T) {
+	mockStore := mocks.NewMockStore()
+	mockStore.RepoNameFunc.SetDefaultReturn("github.com/sourcegraph/sourcegraph", nil)
+	defer git.ResetMocks()
+
+	for _, test := range []struct {
+		stdout string
+		want   bool
+	}{
+		{stdout: "go.mod\n", want: true},
+		{stdout: "", want: false},
+	} {
+		var calls [][]string
+		git.Mocks.ExecBatch = func(rev string, commands [][]string) (api.CommitID, []*git.BatchResult, error) {
+			if rev != "deadbeef" {
+				t.Errorf("unexpected revision. want=%q have=%q", "deadbeef", rev)
+			}
+			calls = append(calls, commands...)
+			return "deadbeef", []*git.BatchResult{{Stdout: []byte(test.stdout)}}, nil
+		}
+
+		exists, err := FileExists(context.Background(), mockStore, 42, "deadbeef", "go.mod")
+		if err != nil {
+			t.Fatalf("unexpected error checking if file exists: %s", err)
+		}
+		if exists != test.want {
+			t.Errorf("unexpected exists value. want=%v have=%v", test.want, exists)
+		}
+		if diff := cmp.Diff([][]string{{"ls-tree", "--name-only", git.BatchCommit, "--", "go.mod"}}, calls); diff != "" {
+			t.Errorf("unexpected commands (-want +got):\n%s", diff)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
 	gql "github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend"
 	"github.com/sourcegraph/sourcegraph/enterprise/internal/codeintel/resolvers"
 )
@@ -82,6 +83,16 @@ func (r *QueryResolver) References(ctx context.Context, args *gql.LSIFPagedQuery
 		return nil, err
 	}
 
+	if args.VersionContext != nil && *args.VersionContext != "" {
+		vc, err := backend.ResolveVersionContext(ctx, *args.VersionContext)
+		if err != nil {
+			return nil, err
+		}
+		if locations, err = resolvers.FilterLocationsByVersionContext(ctx, vc, locations); err != nil {
+			return nil, err
+		}
+	}
+
 	return NewLocationConnectionResolver(locations, strPtr(cursor), r.locationResolver), nil
 }
 
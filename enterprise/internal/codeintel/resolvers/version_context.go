@@ -0,0 +1,64 @@
+package resolvers
+
+import (
+	"context"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/errcode"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+)
+
+// FilterLocationsByVersionContext returns the locations that are in the repositories of the
+// given version context, at the commits of the revisions that it pins them to. Locations in
+// repositories pinned to a ref glob are kept at any commit.
+func FilterLocationsByVersionContext(ctx context.Context, vc *types.VersionContext, locations []AdjustedLocation) ([]AdjustedLocation, error) {
+	if len(locations) == 0 {
+		return nil, nil
+	}
+
+	var (
+		commits   = map[api.RepoName]map[string]struct{}{}
+		anyCommit = map[api.RepoName]bool{}
+	)
+	for _, rev := range vc.Revisions {
+		if rev.RefGlob != "" {
+			anyCommit[rev.Repo] = true
+			continue
+		}
+
+		commit, err := resolveVersionContextRevisionCommit(ctx, rev)
+		if err != nil {
+			// The revision does not exist, so no location can match it.
+			if errcode.IsNotFound(err) || gitserver.IsRevisionNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		if commits[rev.Repo] == nil {
+			commits[rev.Repo] = map[string]struct{}{}
+		}
+		commits[rev.Repo][string(commit)] = struct{}{}
+	}
+
+	filtered := locations[:0]
+	for _, location := range locations {
+		repo := api.RepoName(location.Dump.RepositoryName)
+		if _, ok := commits[repo][location.Dump.Commit]; ok || anyCommit[repo] {
+			filtered = append(filtered, location)
+		}
+	}
+	return filtered, nil
+}
+
+// resolveVersionContextRevisionCommit returns the commit that rev pins its repository to.
+func resolveVersionContextRevisionCommit(ctx context.Context, rev *types.VersionContextRevision) (api.CommitID, error) {
+	repo := gitserver.Repo{Name: rev.Repo}
+	spec, err := backend.ResolveVersionContextRevision(ctx, repo, rev)
+	if err != nil {
+		return "", err
+	}
+	return git.ResolveRevision(ctx, repo, nil, spec.RevSpec, git.ResolveRevisionOptions{})
+}
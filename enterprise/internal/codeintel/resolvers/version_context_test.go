@@ -0,0 +1,60 @@
+package resolvers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/enterprise/internal/codeintel/store"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/vcs/git"
+)
+
+func TestFilterLocationsByVersionContext(t *testing.T) {
+	defer git.ResetMocks()
+	git.Mocks.ListTags = func(repo gitserver.Repo) ([]*git.Tag, error) {
+		return []*git.Tag{{Name: "v2024.3.1"}, {Name: "v2024.3.0"}}, nil
+	}
+	git.Mocks.ResolveRevision = func(spec string, opt git.ResolveRevisionOptions) (api.CommitID, error) {
+		if spec == "missing" {
+			return "", &gitserver.RevisionNotFoundError{Spec: spec}
+		}
+		return api.CommitID("commit-of-" + spec), nil
+	}
+
+	vc := &types.VersionContext{
+		Revisions: []*types.VersionContextRevision{
+			{Repo: "a", Rev: "v1"},
+			{Repo: "b", LatestTag: "v2024.3.*"},
+			{Repo: "c", RefGlob: "refs/heads/release/*"},
+			{Repo: "d", Rev: "missing"},
+		},
+	}
+	location := func(repo, commit string) AdjustedLocation {
+		return AdjustedLocation{Dump: store.Dump{RepositoryName: repo, Commit: commit}}
+	}
+	locations := []AdjustedLocation{
+		location("a", "commit-of-v1"),
+		location("a", "other"),
+		location("b", "commit-of-v2024.3.1"),
+		location("b", "commit-of-v2024.3.0"),
+		location("c", "any"),
+		location("d", "commit-of-missing"),
+		location("e", "commit-of-v1"),
+	}
+
+	filtered, err := FilterLocationsByVersionContext(context.Background(), vc, locations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []AdjustedLocation{
+		location("a", "commit-of-v1"),
+		location("b", "commit-of-v2024.3.1"),
+		location("c", "any"),
+	}
+	if diff := cmp.Diff(want, filtered); diff != "" {
+		t.Errorf("unexpected locations (-want +got):\n%s", diff)
+	}
+}
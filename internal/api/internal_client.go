@@ -98,6 +98,9 @@ type ConfigSavedQuery struct {
 	UserID          *int32  `json:"userID"`
 	OrgID           *int32  `json:"orgID"`
 	SlackWebhookURL *string `json:"slackWebhookURL"`
+	NotifyWebhook   bool    `json:"notifyWebhook,omitempty"`
+	WebhookURL      *string `json:"webhookURL"`
+	WebhookSecret   *string `json:"webhookSecret"`
 }
 
 func (sq ConfigSavedQuery) Equals(other ConfigSavedQuery) bool {
@@ -148,6 +151,11 @@ type SavedQueryInfo struct {
 
 	// ExecDuration is the amount of time it took for the query to execute.
 	ExecDuration time.Duration
+
+	// ResultFingerprints identify the results of the last execution of the
+	// search query, so that results which were added or removed since can be
+	// determined. It is nil if no fingerprints were recorded yet.
+	ResultFingerprints []string
 }
 
 // SavedQueriesGetInfo gets the info from the DB for the given saved query. nil
@@ -170,6 +178,33 @@ func (c *internalClient) SavedQueriesDeleteInfo(ctx context.Context, query strin
 	return c.postInternal(ctx, "saved-queries/delete-info", query, nil)
 }
 
+// SavedSearchWebhookDelivery describes a notification for a saved search that
+// was sent to its outgoing webhook.
+type SavedSearchWebhookDelivery struct {
+	// Key is the key of the saved search, see ConfigSavedQuery.
+	Key string
+
+	// URL is the URL that the notification was POSTed to.
+	URL string
+
+	// Attempts is the number of attempts that were made, including retries.
+	Attempts int
+
+	// StatusCode is the HTTP status code of the last attempt, or zero if no
+	// response was received.
+	StatusCode int
+
+	// Error describes why the delivery failed. It is empty if the delivery
+	// succeeded.
+	Error string
+}
+
+// SavedQueriesLogWebhookDelivery adds the delivery to the webhook delivery log
+// of its saved search.
+func (c *internalClient) SavedQueriesLogWebhookDelivery(ctx context.Context, delivery *SavedSearchWebhookDelivery) error {
+	return c.postInternal(ctx, "saved-queries/log-webhook-delivery", delivery, nil)
+}
+
 func (c *internalClient) SettingsGetForSubject(ctx context.Context, subject SettingsSubject) (parsed *schema.Settings, settings *Settings, err error) {
 	err = c.postInternal(ctx, "settings/get-for-subject", subject, &settings)
 	if err == nil {
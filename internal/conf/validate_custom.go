@@ -2,7 +2,9 @@ package conf
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/url"
+	"regexp"
 	"strings"
 
 	"github.com/sourcegraph/sourcegraph/internal/conf/conftypes"
@@ -62,6 +64,14 @@ func validateCustom(cfg Unified) (problems Problems) {
 		}
 	}
 
+	if cfg.ExperimentalFeatures != nil {
+		for _, rule := range cfg.ExperimentalFeatures.GitCloneOptions {
+			if _, err := regexp.Compile(rule.RepoPattern); err != nil {
+				invalid(NewSiteProblem(fmt.Sprintf("gitCloneOptions: not a valid regexp: %s. See the valid syntax: https://golang.org/pkg/regexp/", rule.RepoPattern)))
+			}
+		}
+	}
+
 	for _, f := range contributedValidators {
 		problems = append(problems, f(cfg)...)
 	}
@@ -14,6 +14,8 @@ type MockStores struct {
 	Users         MockUsers
 	UserEmails    MockUserEmails
 
+	SavedSearchWebhookDeliveries MockSavedSearchWebhookDeliveries
+
 	Phabricator MockPhabricator
 
 	ExternalAccounts MockExternalAccounts
@@ -25,4 +27,8 @@ type MockStores struct {
 	Authz MockAuthz
 
 	Secrets MockSecrets
+
+	VersionContexts MockVersionContexts
+
+	RepoGroups MockRepoGroups
 }
@@ -0,0 +1,507 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/inconshreveable/log15"
+	"github.com/keegancsmith/sqlf"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/db/dbconn"
+	"github.com/sourcegraph/sourcegraph/internal/db/dbutil"
+)
+
+// RepoGroupNotFoundError occurs when a repository group is not found.
+type RepoGroupNotFoundError struct {
+	Message string
+}
+
+func (e *RepoGroupNotFoundError) Error() string {
+	return fmt.Sprintf("repository group not found: %s", e.Message)
+}
+
+func (e *RepoGroupNotFoundError) NotFound() bool {
+	return true
+}
+
+// ErrRepoGroupNameAlreadyExists occurs when a repository group is created or
+// renamed with the name of another repository group of the same owner.
+var ErrRepoGroupNameAlreadyExists = errors.New("repository group name is already taken")
+
+// InvalidRepoGroupPatternError occurs when the pattern of a repository group
+// is not a valid regular expression.
+type InvalidRepoGroupPatternError struct {
+	Message string
+}
+
+func (e *InvalidRepoGroupPatternError) Error() string {
+	return fmt.Sprintf("invalid repository group pattern: %s", e.Message)
+}
+
+// pqInvalidRegularExpression is the Postgres error code of an invalid
+// regular expression.
+const pqInvalidRegularExpression = "2201B"
+
+type repoGroups struct{}
+
+// RepoGroupsListOptions specifies the options for listing repository groups.
+type RepoGroupsListOptions struct {
+	// UserID, if non-zero, lists the repository groups owned by this user.
+	UserID int32
+	// OrgIDs lists the repository groups owned by these organizations.
+	//
+	// If UserID is zero and OrgIDs is empty, all repository groups are listed.
+	OrgIDs []int32
+	// Names, if non-empty, lists only the repository groups with these names.
+	Names []string
+}
+
+// GetByID returns the repository group with the given ID, with its explicitly
+// listed repositories.
+//
+// 🚨 SECURITY: This method does NOT verify the user's identity or that the
+// user is an admin. It is the callers responsibility to ensure only the owner
+// of the repository group, members of its organization or site admins can
+// access it.
+func (s *repoGroups) GetByID(ctx context.Context, id int32) (*types.RepoGroup, error) {
+	if Mocks.RepoGroups.GetByID != nil {
+		return Mocks.RepoGroups.GetByID(ctx, id)
+	}
+
+	groups, err := s.list(ctx, sqlf.Sprintf("id=%d", id))
+	if err != nil {
+		return nil, err
+	}
+	if len(groups) == 0 {
+		return nil, &RepoGroupNotFoundError{fmt.Sprintf("id %d", id)}
+	}
+	return groups[0], nil
+}
+
+// List lists the repository groups matching opt, ordered by name, with their
+// explicitly listed repositories.
+//
+// 🚨 SECURITY: This method does NOT verify the user's identity or that the
+// user is an admin. It is the callers responsibility to ensure only the
+// owners, members of the organizations or site admins can access the returned
+// repository groups.
+func (s *repoGroups) List(ctx context.Context, opt RepoGroupsListOptions) ([]*types.RepoGroup, error) {
+	if Mocks.RepoGroups.List != nil {
+		return Mocks.RepoGroups.List(ctx, opt)
+	}
+
+	var conds []*sqlf.Query
+	if opt.UserID != 0 {
+		conds = append(conds, sqlf.Sprintf("user_id=%d", opt.UserID))
+	}
+	for _, orgID := range opt.OrgIDs {
+		conds = append(conds, sqlf.Sprintf("org_id=%d", orgID))
+	}
+	cond := sqlf.Sprintf("TRUE")
+	if len(conds) > 0 {
+		cond = sqlf.Sprintf("(%s)", sqlf.Join(conds, "OR"))
+	}
+	if len(opt.Names) > 0 {
+		names := make([]*sqlf.Query, len(opt.Names))
+		for i, name := range opt.Names {
+			names[i] = sqlf.Sprintf("%s", name)
+		}
+		cond = sqlf.Sprintf("%s AND name IN (%s)", cond, sqlf.Join(names, ","))
+	}
+	return s.list(ctx, cond)
+}
+
+func (s *repoGroups) list(ctx context.Context, cond *sqlf.Query) ([]*types.RepoGroup, error) {
+	q := sqlf.Sprintf(`SELECT
+		id,
+		name,
+		description,
+		user_id,
+		org_id,
+		pattern,
+		external_service_id,
+		created_at,
+		updated_at
+		FROM repo_groups WHERE %s ORDER BY name ASC, id ASC`, cond)
+
+	rows, err := dbconn.Global.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+	if err != nil {
+		return nil, errors.Wrap(err, "QueryContext")
+	}
+	defer rows.Close()
+
+	var (
+		groups []*types.RepoGroup
+		byID   = map[int32]*types.RepoGroup{}
+		ids    []*sqlf.Query
+	)
+	for rows.Next() {
+		var g types.RepoGroup
+		if err := rows.Scan(&g.ID, &g.Name, &g.Description, &g.UserID, &g.OrgID, &g.Pattern, &g.ExternalServiceID, &g.CreatedAt, &g.UpdatedAt); err != nil {
+			return nil, errors.Wrap(err, "Scan")
+		}
+		groups = append(groups, &g)
+		byID[g.ID] = &g
+		ids = append(ids, sqlf.Sprintf("%d", g.ID))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(groups) == 0 {
+		return nil, nil
+	}
+
+	q = sqlf.Sprintf(`SELECT
+		m.repo_group_id,
+		m.repo_id
+		FROM repo_group_repos m
+		JOIN repo ON repo.id=m.repo_id
+		WHERE m.repo_group_id IN (%s) AND repo.deleted_at IS NULL
+		ORDER BY m.repo_id ASC`, sqlf.Join(ids, ","))
+
+	rows, err = dbconn.Global.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+	if err != nil {
+		return nil, errors.Wrap(err, "QueryContext(repos)")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			groupID int32
+			repoID  api.RepoID
+		)
+		if err := rows.Scan(&groupID, &repoID); err != nil {
+			return nil, errors.Wrap(err, "Scan(repos)")
+		}
+		g := byID[groupID]
+		g.RepoIDs = append(g.RepoIDs, repoID)
+	}
+	return groups, rows.Err()
+}
+
+// ListRepos returns the repositories of each of the given repository groups,
+// keyed by group ID. The repositories of a group are those listed explicitly,
+// those whose name matches its pattern and those synced from its external
+// service.
+//
+// 🚨 SECURITY: The repositories are filtered by the repository permissions of
+// the current user, but it is the callers responsibility to ensure the user
+// has access to the repository groups.
+func (s *repoGroups) ListRepos(ctx context.Context, ids ...int32) (map[int32][]*types.Repo, error) {
+	if Mocks.RepoGroups.ListRepos != nil {
+		return Mocks.RepoGroups.ListRepos(ctx, ids...)
+	}
+
+	if len(ids) == 0 {
+		return map[int32][]*types.Repo{}, nil
+	}
+	items := make([]*sqlf.Query, len(ids))
+	for i := range ids {
+		items[i] = sqlf.Sprintf("%d", ids[i])
+	}
+
+	// Each kind of membership is looked up with its own query, so that each
+	// can use the index on repo_group_repos, repo.name or repo.sources
+	// instead of matching every repository against every group.
+	var (
+		repoIDs      []api.RepoID
+		groupsByRepo = map[api.RepoID][]int32{}
+		members      = map[int32]map[api.RepoID]bool{}
+	)
+	add := func(groupID int32, repoID api.RepoID) {
+		if members[groupID] == nil {
+			members[groupID] = map[api.RepoID]bool{}
+		}
+		if members[groupID][repoID] {
+			return
+		}
+		members[groupID][repoID] = true
+		if _, ok := groupsByRepo[repoID]; !ok {
+			repoIDs = append(repoIDs, repoID)
+		}
+		groupsByRepo[repoID] = append(groupsByRepo[repoID], groupID)
+	}
+
+	q := sqlf.Sprintf(`SELECT
+		m.repo_group_id,
+		m.repo_id
+		FROM repo_group_repos m
+		JOIN repo ON repo.id=m.repo_id
+		WHERE m.repo_group_id IN (%s) AND repo.deleted_at IS NULL`, sqlf.Join(items, ","))
+	rows, err := dbconn.Global.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+	if err != nil {
+		return nil, errors.Wrap(err, "QueryContext(explicit)")
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			groupID int32
+			repoID  api.RepoID
+		)
+		if err := rows.Scan(&groupID, &repoID); err != nil {
+			return nil, errors.Wrap(err, "Scan(explicit)")
+		}
+		add(groupID, repoID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	type groupRules struct {
+		id      int32
+		pattern string
+		source  string
+	}
+	q = sqlf.Sprintf(`SELECT
+		g.id,
+		g.pattern,
+		COALESCE('extsvc:' || lower(es.kind) || ':' || es.id, '')
+		FROM repo_groups g
+		LEFT JOIN external_services es ON es.id=g.external_service_id AND es.deleted_at IS NULL
+		WHERE g.id IN (%s) AND (g.pattern <> '' OR es.id IS NOT NULL)`, sqlf.Join(items, ","))
+	rows, err = dbconn.Global.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+	if err != nil {
+		return nil, errors.Wrap(err, "QueryContext(groups)")
+	}
+	defer rows.Close()
+	var rules []groupRules
+	for rows.Next() {
+		var r groupRules
+		if err := rows.Scan(&r.id, &r.pattern, &r.source); err != nil {
+			return nil, errors.Wrap(err, "Scan(groups)")
+		}
+		rules = append(rules, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, r := range rules {
+		if r.pattern != "" {
+			// The expression matches the repo_name_trgm index.
+			matches, err := listRepoIDs(ctx, sqlf.Sprintf("lower(name::text) ~* %s", r.pattern))
+			if pqErr, ok := errors.Cause(err).(*pq.Error); ok && pqErr.Code == pqInvalidRegularExpression {
+				// Patterns are validated when a group is saved, but don't fail
+				// the search of every group if one was saved before that.
+				log15.Warn("Invalid repository group pattern.", "repoGroup", r.id, "pattern", r.pattern, "err", err)
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			for _, repoID := range matches {
+				add(r.id, repoID)
+			}
+		}
+		if r.source != "" {
+			// The expression matches the repo_sources_gin_idx index.
+			matches, err := listRepoIDs(ctx, sqlf.Sprintf("sources ? %s", r.source))
+			if err != nil {
+				return nil, err
+			}
+			for _, repoID := range matches {
+				add(r.id, repoID)
+			}
+		}
+	}
+
+	// 🚨 SECURITY: Repos.GetByIDs enforces repository permissions.
+	repos, err := Repos.GetByIDs(ctx, repoIDs...)
+	if err != nil {
+		return nil, err
+	}
+	reposByGroup := make(map[int32][]*types.Repo, len(ids))
+	for _, repo := range repos {
+		for _, groupID := range groupsByRepo[repo.ID] {
+			reposByGroup[groupID] = append(reposByGroup[groupID], repo)
+		}
+	}
+	return reposByGroup, nil
+}
+
+// Create creates a new repository group with its explicitly listed
+// repositories. The ID field must be zero, or an error will be returned.
+//
+// 🚨 SECURITY: This method does NOT verify the user's identity or that the
+// user is an admin. It is the callers responsibility to ensure the user has
+// proper permissions to create the repository group in its namespace.
+func (s *repoGroups) Create(ctx context.Context, g *types.RepoGroup) (*types.RepoGroup, error) {
+	if Mocks.RepoGroups.Create != nil {
+		return Mocks.RepoGroups.Create(ctx, g)
+	}
+
+	if g.ID != 0 {
+		return nil, errors.New("newRepoGroup.ID must be zero")
+	}
+
+	created := *g
+	err := dbutil.Transaction(ctx, dbconn.Global, func(tx *sql.Tx) error {
+		if err := validateRepoGroupPattern(ctx, tx, g.Pattern); err != nil {
+			return err
+		}
+		err := tx.QueryRowContext(ctx, `INSERT INTO repo_groups(
+				name,
+				description,
+				user_id,
+				org_id,
+				pattern,
+				external_service_id
+			) VALUES($1, $2, $3, $4, $5, $6) RETURNING id, created_at, updated_at`,
+			g.Name,
+			g.Description,
+			g.UserID,
+			g.OrgID,
+			g.Pattern,
+			g.ExternalServiceID,
+		).Scan(&created.ID, &created.CreatedAt, &created.UpdatedAt)
+		if err != nil {
+			return repoGroupsError(err)
+		}
+		return insertRepoGroupRepos(ctx, tx, created.ID, g.RepoIDs)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// Update updates the name, description and membership of an existing
+// repository group. The explicitly listed repositories replace all of its
+// previously listed repositories. The owner of a repository group can't be
+// changed.
+//
+// 🚨 SECURITY: This method does NOT verify the user's identity or that the
+// user is an admin. It is the callers responsibility to ensure the user has
+// proper permissions to perform the update.
+func (s *repoGroups) Update(ctx context.Context, g *types.RepoGroup) (*types.RepoGroup, error) {
+	if Mocks.RepoGroups.Update != nil {
+		return Mocks.RepoGroups.Update(ctx, g)
+	}
+
+	updated := *g
+	err := dbutil.Transaction(ctx, dbconn.Global, func(tx *sql.Tx) error {
+		if err := validateRepoGroupPattern(ctx, tx, g.Pattern); err != nil {
+			return err
+		}
+		err := tx.QueryRowContext(ctx, `UPDATE repo_groups
+			SET name=$1, description=$2, pattern=$3, external_service_id=$4, updated_at=now()
+			WHERE id=$5 RETURNING user_id, org_id, created_at, updated_at`,
+			g.Name,
+			g.Description,
+			g.Pattern,
+			g.ExternalServiceID,
+			g.ID,
+		).Scan(&updated.UserID, &updated.OrgID, &updated.CreatedAt, &updated.UpdatedAt)
+		if err == sql.ErrNoRows {
+			return &RepoGroupNotFoundError{fmt.Sprintf("id %d", g.ID)}
+		}
+		if err != nil {
+			return repoGroupsError(err)
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM repo_group_repos WHERE repo_group_id=$1", g.ID); err != nil {
+			return errors.Wrap(err, "DELETE")
+		}
+		return insertRepoGroupRepos(ctx, tx, g.ID, g.RepoIDs)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// Delete deletes a repository group.
+//
+// 🚨 SECURITY: This method does NOT verify the user's identity or that the
+// user is an admin. It is the callers responsibility to ensure the user has
+// proper permissions to delete the repository group.
+func (s *repoGroups) Delete(ctx context.Context, id int32) error {
+	if Mocks.RepoGroups.Delete != nil {
+		return Mocks.RepoGroups.Delete(ctx, id)
+	}
+
+	res, err := dbconn.Global.ExecContext(ctx, "DELETE FROM repo_groups WHERE id=$1", id)
+	if err != nil {
+		return err
+	}
+	nrows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if nrows == 0 {
+		return &RepoGroupNotFoundError{fmt.Sprintf("id %d", id)}
+	}
+	return nil
+}
+
+// listRepoIDs returns the IDs of the repositories that are not deleted and
+// match cond.
+func listRepoIDs(ctx context.Context, cond *sqlf.Query) ([]api.RepoID, error) {
+	q := sqlf.Sprintf("SELECT id FROM repo WHERE deleted_at IS NULL AND %s", cond)
+	rows, err := dbconn.Global.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+	if err != nil {
+		return nil, errors.Wrap(err, "QueryContext(repo)")
+	}
+	defer rows.Close()
+
+	var ids []api.RepoID
+	for rows.Next() {
+		var id api.RepoID
+		if err := rows.Scan(&id); err != nil {
+			return nil, errors.Wrap(err, "Scan(repo)")
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// validateRepoGroupPattern checks that pattern is a valid regular expression
+// for Postgres. Patterns are matched by Postgres, whose regular expression
+// syntax differs from Go's.
+func validateRepoGroupPattern(ctx context.Context, tx *sql.Tx, pattern string) error {
+	if pattern == "" {
+		return nil
+	}
+	if _, err := tx.ExecContext(ctx, "SELECT '' ~* $1", pattern); err != nil {
+		return repoGroupsError(err)
+	}
+	return nil
+}
+
+func insertRepoGroupRepos(ctx context.Context, tx *sql.Tx, repoGroupID int32, repoIDs []api.RepoID) error {
+	if len(repoIDs) == 0 {
+		return nil
+	}
+	values := make([]*sqlf.Query, len(repoIDs))
+	for i, repoID := range repoIDs {
+		values[i] = sqlf.Sprintf("(%d, %d)", repoGroupID, repoID)
+	}
+	q := sqlf.Sprintf(`INSERT INTO repo_group_repos(
+		repo_group_id,
+		repo_id
+	) VALUES %s ON CONFLICT DO NOTHING`, sqlf.Join(values, ","))
+	if _, err := tx.ExecContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...); err != nil {
+		return errors.Wrap(err, "INSERT repos")
+	}
+	return nil
+}
+
+func repoGroupsError(err error) error {
+	if pqErr, ok := err.(*pq.Error); ok {
+		if pqErr.Code == pqInvalidRegularExpression {
+			return &InvalidRepoGroupPatternError{Message: pqErr.Message}
+		}
+		switch pqErr.Constraint {
+		case "repo_groups_user_id_name_unique", "repo_groups_org_id_name_unique":
+			return ErrRepoGroupNameAlreadyExists
+		case "repo_groups_has_one_namespace":
+			return errors.New("repository group must be owned by exactly one user or organization")
+		case "repo_groups_external_service_id_fkey":
+			return errors.New("external service of the repository group does not exist")
+		}
+	}
+	return err
+}
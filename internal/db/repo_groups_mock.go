@@ -0,0 +1,16 @@
+package db
+
+import (
+	"context"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+)
+
+type MockRepoGroups struct {
+	GetByID   func(ctx context.Context, id int32) (*types.RepoGroup, error)
+	List      func(ctx context.Context, opt RepoGroupsListOptions) ([]*types.RepoGroup, error)
+	ListRepos func(ctx context.Context, ids ...int32) (map[int32][]*types.Repo, error)
+	Create    func(ctx context.Context, g *types.RepoGroup) (*types.RepoGroup, error)
+	Update    func(ctx context.Context, g *types.RepoGroup) (*types.RepoGroup, error)
+	Delete    func(ctx context.Context, id int32) error
+}
@@ -0,0 +1,113 @@
+package db
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/db/dbtesting"
+	"github.com/sourcegraph/sourcegraph/internal/errcode"
+)
+
+func TestRepoGroups(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	dbtesting.SetupGlobalTestDB(t)
+	ctx := context.Background()
+	user, err := Users.Create(ctx, NewUser{DisplayName: "test", Email: "test@test.com", Username: "test", Password: "test", EmailVerificationCode: "c2"})
+	if err != nil {
+		t.Fatal("can't create user", err)
+	}
+	var repoIDs []api.RepoID
+	for _, name := range []api.RepoName{"github.com/a/frontend", "github.com/a/backend", "github.com/b/frontend"} {
+		createRepo(ctx, t, &types.Repo{Name: name})
+		repo, err := Repos.GetByName(ctx, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		repoIDs = append(repoIDs, repo.ID)
+	}
+
+	g, err := RepoGroups.Create(ctx, &types.RepoGroup{
+		Name:    "platform",
+		UserID:  &user.ID,
+		RepoIDs: []api.RepoID{repoIDs[1]},
+		Pattern: "/frontend$",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.ID == 0 || g.CreatedAt.IsZero() {
+		t.Errorf("got %+v, want ID and CreatedAt to be set", g)
+	}
+
+	if _, err := RepoGroups.Create(ctx, &types.RepoGroup{Name: "PLATFORM", UserID: &user.ID}); err != ErrRepoGroupNameAlreadyExists {
+		t.Errorf("got error %v creating a duplicate name, want %v", err, ErrRepoGroupNameAlreadyExists)
+	}
+
+	// Named groups are valid in Go, but not in Postgres.
+	if _, err := RepoGroups.Create(ctx, &types.RepoGroup{Name: "invalid", UserID: &user.ID, Pattern: "(?P<name>frontend)"}); err == nil {
+		t.Error("got no error creating a group with an invalid pattern")
+	} else if _, ok := err.(*InvalidRepoGroupPatternError); !ok {
+		t.Errorf("got error %v creating a group with an invalid pattern, want *InvalidRepoGroupPatternError", err)
+	}
+
+	reposByGroup, err := RepoGroups.ListRepos(ctx, g.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []api.RepoID
+	for _, repo := range reposByGroup[g.ID] {
+		got = append(got, repo.ID)
+	}
+	if !reflect.DeepEqual(got, repoIDs) {
+		t.Errorf("got repos %v, want %v", got, repoIDs)
+	}
+
+	g.Description = "updated"
+	g.RepoIDs = []api.RepoID{repoIDs[0]}
+	g.Pattern = ""
+	if _, err := RepoGroups.Update(ctx, g); err != nil {
+		t.Fatal(err)
+	}
+	updated, err := RepoGroups.GetByID(ctx, g.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Description != "updated" || updated.Pattern != "" || !reflect.DeepEqual(updated.RepoIDs, []api.RepoID{repoIDs[0]}) {
+		t.Errorf("got %+v, want the update", updated)
+	}
+
+	list, err := RepoGroups.List(ctx, RepoGroupsListOptions{UserID: user.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].ID != g.ID {
+		t.Errorf("got %+v, want the repository group of the user", list)
+	}
+	list, err = RepoGroups.List(ctx, RepoGroupsListOptions{UserID: user.ID, Names: []string{"other"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 0 {
+		t.Errorf("got %+v, want none for another name", list)
+	}
+	list, err = RepoGroups.List(ctx, RepoGroupsListOptions{OrgIDs: []int32{user.ID + 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 0 {
+		t.Errorf("got %+v, want none for another org", list)
+	}
+
+	if err := RepoGroups.Delete(ctx, g.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RepoGroups.GetByID(ctx, g.ID); !errcode.IsNotFound(err) {
+		t.Errorf("got error %v, want not found", err)
+	}
+}
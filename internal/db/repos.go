@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/keegancsmith/sqlf"
+	"github.com/lib/pq"
 	"github.com/pkg/errors"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
 	"github.com/sourcegraph/sourcegraph/internal/api"
@@ -16,6 +17,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/db/dbconn"
 	"github.com/sourcegraph/sourcegraph/internal/db/dbutil"
 	"github.com/sourcegraph/sourcegraph/internal/db/query"
+	"github.com/sourcegraph/sourcegraph/internal/extsvc"
 	"github.com/sourcegraph/sourcegraph/internal/trace"
 )
 
@@ -117,6 +119,58 @@ func (s *repos) GetByIDs(ctx context.Context, ids ...api.RepoID) ([]*types.Repo,
 	return s.getReposBySQL(ctx, true, q)
 }
 
+// ListStars returns the number of stars of the given repositories on their
+// code host, as of the last sync of their metadata. Repositories of code hosts
+// that don't have stars are omitted.
+//
+// 🚨 SECURITY: This does not check that the current user can access the
+// repositories. Callers must only pass the IDs of repositories they are
+// allowed to see.
+func (s *repos) ListStars(ctx context.Context, ids ...api.RepoID) (map[api.RepoID]int, error) {
+	if Mocks.Repos.ListStars != nil {
+		return Mocks.Repos.ListStars(ctx, ids...)
+	}
+
+	stars := make(map[api.RepoID]int, len(ids))
+	if len(ids) == 0 {
+		return stars, nil
+	}
+
+	ints := make([]int64, len(ids))
+	for i, id := range ids {
+		ints[i] = int64(id)
+	}
+	q := sqlf.Sprintf(listStarsQueryFmtstr, pq.Array(ints))
+	rows, err := dbconn.Global.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			id    api.RepoID
+			count int
+		)
+		if err := rows.Scan(&id, &count); err != nil {
+			return nil, err
+		}
+		stars[id] = count
+	}
+	return stars, rows.Err()
+}
+
+// listStarsQueryFmtstr reads the stars of repositories from the metadata of
+// GitHub repositories (StargazerCount) and GitLab projects (star_count).
+const listStarsQueryFmtstr = `
+-- source: internal/db/repos.go:ListStars
+SELECT id, COALESCE(metadata->>'StargazerCount', metadata->>'star_count')::integer
+FROM repo
+WHERE id = ANY(%s)
+AND deleted_at IS NULL
+AND COALESCE(metadata->>'StargazerCount', metadata->>'star_count') IS NOT NULL
+`
+
 func (s *repos) Count(ctx context.Context, opt ReposListOptions) (int, error) {
 	if Mocks.Repos.Count != nil {
 		return Mocks.Repos.Count(ctx, opt)
@@ -266,6 +320,37 @@ type ReposListOptions struct {
 	// OnlyArchived excludes non-archived repositories from the list.
 	OnlyArchived bool
 
+	// DescriptionPatterns is a list of regular expressions, all of which must
+	// match the descriptions of all repositories returned in the list.
+	DescriptionPatterns []string
+
+	// ExcludeDescriptionPatterns is a list of regular expressions, none of
+	// which may match the descriptions of repositories returned in the list.
+	ExcludeDescriptionPatterns []string
+
+	// Topics is a list of topics, all of which repositories returned in the
+	// list must have on their code host. Topics are compared
+	// case-insensitively.
+	Topics []string
+
+	// ExcludeTopics is a list of topics, none of which repositories returned
+	// in the list may have on their code host.
+	ExcludeTopics []string
+
+	// Languages is a list of languages, all of which must be the primary
+	// language of repositories returned in the list on their code host.
+	// Languages are compared case-insensitively.
+	Languages []string
+
+	// ExcludeLanguages is a list of languages, none of which may be the
+	// primary language of repositories returned in the list.
+	ExcludeLanguages []string
+
+	// MinSize and MaxSize, if non-nil, are the inclusive bounds of the size
+	// in bytes of repositories returned in the list on their code host.
+	// Repositories of code hosts that don't report sizes are excluded.
+	MinSize, MaxSize *int64
+
 	// NoCloned excludes cloned repositories from the list.
 	NoCloned bool
 
@@ -478,6 +563,30 @@ func (*repos) listSQL(opt ReposListOptions) (conds []*sqlf.Query, err error) {
 	if opt.OnlyPrivate {
 		conds = append(conds, sqlf.Sprintf("private"))
 	}
+	for _, pattern := range opt.DescriptionPatterns {
+		conds = append(conds, sqlf.Sprintf("description ~* %s", pattern))
+	}
+	for _, pattern := range opt.ExcludeDescriptionPatterns {
+		conds = append(conds, sqlf.Sprintf("description !~* %s", pattern))
+	}
+	for _, topic := range opt.Topics {
+		conds = append(conds, sqlf.Sprintf("%s IN ("+repoTopicsQueryFmtstr+")", strings.ToLower(topic), extsvc.TypeGitHub, extsvc.TypeGitLab))
+	}
+	for _, topic := range opt.ExcludeTopics {
+		conds = append(conds, sqlf.Sprintf("%s NOT IN ("+repoTopicsQueryFmtstr+")", strings.ToLower(topic), extsvc.TypeGitHub, extsvc.TypeGitLab))
+	}
+	for _, language := range opt.Languages {
+		conds = append(conds, sqlf.Sprintf(repoLanguageQueryFmtstr+" = %s", extsvc.TypeGitHub, extsvc.TypeBitbucketCloud, strings.ToLower(language)))
+	}
+	for _, language := range opt.ExcludeLanguages {
+		conds = append(conds, sqlf.Sprintf(repoLanguageQueryFmtstr+" IS DISTINCT FROM %s", extsvc.TypeGitHub, extsvc.TypeBitbucketCloud, strings.ToLower(language)))
+	}
+	if opt.MinSize != nil {
+		conds = append(conds, sqlf.Sprintf(repoSizeQueryFmtstr+" >= %s", extsvc.TypeGitHub, extsvc.TypeBitbucketCloud, *opt.MinSize))
+	}
+	if opt.MaxSize != nil {
+		conds = append(conds, sqlf.Sprintf(repoSizeQueryFmtstr+" <= %s", extsvc.TypeGitHub, extsvc.TypeBitbucketCloud, *opt.MaxSize))
+	}
 	if len(opt.Names) > 0 {
 		queries := make([]*sqlf.Query, 0, len(opt.Names))
 		for _, repo := range opt.Names {
@@ -499,6 +608,39 @@ func (*repos) listSQL(opt ReposListOptions) (conds []*sqlf.Query, err error) {
 	return conds, nil
 }
 
+// The metadata of repositories on their code host is stored as JSON in the
+// metadata column, in a format that depends on the code host. These
+// expressions read the topics, primary language and size of repositories from
+// the metadata of the code hosts that report them.
+const (
+	// repoTopicsQueryFmtstr selects the lowercase topics of a repository from
+	// the metadata of GitHub repositories and GitLab projects.
+	repoTopicsQueryFmtstr = `
+SELECT lower(n->'Topic'->>'Name')
+FROM jsonb_array_elements(CASE WHEN external_service_type = %s AND jsonb_typeof(metadata->'RepositoryTopics'->'Nodes') = 'array' THEN metadata->'RepositoryTopics'->'Nodes' ELSE '[]' END) n
+WHERE n->'Topic'->>'Name' IS NOT NULL
+UNION ALL
+SELECT lower(t)
+FROM jsonb_array_elements_text(CASE WHEN external_service_type = %s AND jsonb_typeof(metadata->'tag_list') = 'array' THEN metadata->'tag_list' ELSE '[]' END) t
+`
+
+	// repoLanguageQueryFmtstr is the lowercase primary language of a
+	// repository from the metadata of GitHub and Bitbucket Cloud repositories.
+	repoLanguageQueryFmtstr = `
+lower(CASE external_service_type
+	WHEN %s THEN metadata->'PrimaryLanguage'->>'Name'
+	WHEN %s THEN NULLIF(metadata->>'language', '')
+END)`
+
+	// repoSizeQueryFmtstr is the size in bytes of a repository from the
+	// metadata of GitHub (in kilobytes) and Bitbucket Cloud repositories.
+	repoSizeQueryFmtstr = `
+CASE external_service_type
+	WHEN %s THEN (metadata->>'DiskUsage')::bigint * 1024
+	WHEN %s THEN (metadata->>'size')::bigint
+END`
+)
+
 // parseIncludePattern either (1) parses the pattern into a list of exact possible
 // string values and LIKE patterns if such a list can be determined from the pattern,
 // and (2) returns the original regexp if those patterns are not equivalent to the
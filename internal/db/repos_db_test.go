@@ -249,6 +249,35 @@ func TestRepos_GetByIDs(t *testing.T) {
 	}
 }
 
+func TestRepos_ListStars(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	dbtesting.SetupGlobalTestDB(t)
+	ctx := context.Background()
+
+	repos := mustCreate(ctx, t,
+		&types.Repo{Name: "github.com/a/b"},
+		&types.Repo{Name: "gitlab.com/a/b"},
+		&types.Repo{Name: "bitbucket.org/a/b"},
+	)
+	for i, metadata := range []string{`{"StargazerCount": 12}`, `{"star_count": 3}`, `{}`} {
+		if _, err := dbconn.Global.ExecContext(ctx, "UPDATE repo SET metadata = $1 WHERE id = $2", metadata, repos[i].ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stars, err := Repos.ListStars(ctx, repos[0].ID, repos[1].ID, repos[2].ID, 404)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[api.RepoID]int{repos[0].ID: 12, repos[1].ID: 3}
+	if !reflect.DeepEqual(stars, want) {
+		t.Errorf("got %v, want %v", stars, want)
+	}
+}
+
 func TestRepos_List(t *testing.T) {
 	if testing.Short() {
 		t.Skip()
@@ -274,6 +303,66 @@ func TestRepos_List(t *testing.T) {
 	}
 }
 
+func TestRepos_List_metadata(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	MockAuthzFilter = func(ctx context.Context, repos []*types.Repo, p authz.Perms) ([]*types.Repo, error) {
+		return repos, nil
+	}
+	defer func() { MockAuthzFilter = nil }()
+	dbtesting.SetupGlobalTestDB(t)
+	ctx := context.Background()
+	ctx = actor.WithActor(ctx, &actor.Actor{})
+
+	legacy := mustCreate(ctx, t, &types.Repo{Name: "github.com/a/legacy", RepoFields: &types.RepoFields{Description: "The old billing system"}})
+	modern := mustCreate(ctx, t, &types.Repo{Name: "github.com/a/modern", RepoFields: &types.RepoFields{Description: "The new billing system"}})
+	project := mustCreate(ctx, t, &types.Repo{Name: "gitlab.com/a/project"})
+	cloud := mustCreate(ctx, t, &types.Repo{Name: "bitbucket.org/a/cloud"})
+	for _, m := range []struct {
+		repo        *types.Repo
+		serviceType string
+		metadata    string
+	}{
+		{legacy[0], "github", `{"PrimaryLanguage": {"Name": "Java"}, "DiskUsage": 20480, "RepositoryTopics": {"Nodes": [{"Topic": {"Name": "legacy"}}]}}`},
+		{modern[0], "github", `{"PrimaryLanguage": null, "DiskUsage": 100, "RepositoryTopics": {"Nodes": null}}`},
+		{project[0], "gitlab", `{"tag_list": ["Legacy", "billing"]}`},
+		{cloud[0], "bitbucketCloud", `{"language": "java", "size": 1024}`},
+	} {
+		if _, err := dbconn.Global.ExecContext(ctx, "UPDATE repo SET external_service_type = $1, metadata = $2 WHERE id = $3", m.serviceType, m.metadata, m.repo.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	size := func(n int64) *int64 { return &n }
+	tests := []struct {
+		name string
+		opt  ReposListOptions
+		want []*types.Repo
+	}{
+		{"description", ReposListOptions{DescriptionPatterns: []string{"billing"}, ExcludeDescriptionPatterns: []string{"^the new"}}, legacy},
+		{"topic", ReposListOptions{Topics: []string{"legacy"}}, append(append([]*types.Repo(nil), legacy...), project...)},
+		{"exclude topic", ReposListOptions{ExcludeTopics: []string{"LEGACY"}}, append(append([]*types.Repo(nil), modern...), cloud...)},
+		{"language", ReposListOptions{Languages: []string{"java"}}, append(append([]*types.Repo(nil), legacy...), cloud...)},
+		{"exclude language", ReposListOptions{ExcludeLanguages: []string{"Java"}}, append(append([]*types.Repo(nil), modern...), project...)},
+		{"min size", ReposListOptions{MinSize: size(10 << 20)}, legacy},
+		{"max size", ReposListOptions{MaxSize: size(200 << 10)}, append(append([]*types.Repo(nil), modern...), cloud...)},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.opt.OnlyRepoIDs = true
+			repos, err := Repos.List(ctx, test.opt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := sortedRepoNames(repos), sortedRepoNames(test.want); !reflect.DeepEqual(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
 func TestRepos_List_fork(t *testing.T) {
 	if testing.Short() {
 		t.Skip()
@@ -15,6 +15,7 @@ type MockRepos struct {
 	GetByIDs  func(ctx context.Context, ids ...api.RepoID) ([]*types.Repo, error)
 	List      func(v0 context.Context, v1 ReposListOptions) ([]*types.Repo, error)
 	Count     func(ctx context.Context, opt ReposListOptions) (int, error)
+	ListStars func(ctx context.Context, ids ...api.RepoID) (map[api.RepoID]int, error)
 }
 
 func (s *MockRepos) MockGet(t *testing.T, wantRepo api.RepoID) (called *bool) {
@@ -0,0 +1,108 @@
+package db
+
+import (
+	"context"
+
+	"github.com/keegancsmith/sqlf"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/db/dbconn"
+)
+
+// maxWebhookDeliveriesPerSavedSearch is the number of most recent deliveries
+// that are kept for each saved search.
+const maxWebhookDeliveriesPerSavedSearch = 100
+
+type savedSearchWebhookDeliveries struct{}
+
+// Create records a delivery to the outgoing webhook of a saved search, and
+// prunes all but the most recent deliveries of the saved search.
+func (s *savedSearchWebhookDeliveries) Create(ctx context.Context, d *types.SavedSearchWebhookDelivery) (*types.SavedSearchWebhookDelivery, error) {
+	if Mocks.SavedSearchWebhookDeliveries.Create != nil {
+		return Mocks.SavedSearchWebhookDeliveries.Create(ctx, d)
+	}
+
+	created := *d
+	err := dbconn.Global.QueryRowContext(ctx, `INSERT INTO saved_search_webhook_deliveries(
+			saved_search_id,
+			url,
+			attempts,
+			status_code,
+			error
+		) VALUES($1, $2, $3, $4, $5) RETURNING id, created_at`,
+		d.SavedSearchID,
+		d.URL,
+		d.Attempts,
+		d.StatusCode,
+		d.Error,
+	).Scan(&created.ID, &created.CreatedAt)
+	if err != nil {
+		return nil, errors.Wrap(err, "INSERT")
+	}
+
+	_, err = dbconn.Global.ExecContext(ctx, `DELETE FROM saved_search_webhook_deliveries
+		WHERE saved_search_id=$1 AND id NOT IN (
+			SELECT id FROM saved_search_webhook_deliveries WHERE saved_search_id=$1 ORDER BY id DESC LIMIT $2
+		)`,
+		d.SavedSearchID,
+		maxWebhookDeliveriesPerSavedSearch,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "DELETE")
+	}
+	return &created, nil
+}
+
+// ListBySavedSearchID lists the most recent deliveries of a saved search,
+// newest first.
+//
+// 🚨 SECURITY: This method does NOT verify the user's identity or that the
+// user is an admin. It is the callers responsibility to ensure only users with
+// access to the saved search can access the returned deliveries.
+func (s *savedSearchWebhookDeliveries) ListBySavedSearchID(ctx context.Context, savedSearchID int32, limit int) ([]*types.SavedSearchWebhookDelivery, error) {
+	if Mocks.SavedSearchWebhookDeliveries.ListBySavedSearchID != nil {
+		return Mocks.SavedSearchWebhookDeliveries.ListBySavedSearchID(ctx, savedSearchID, limit)
+	}
+	return s.list(ctx, sqlf.Sprintf("saved_search_id=%d", savedSearchID), limit)
+}
+
+// ListFailed lists the most recent failed deliveries of all saved searches,
+// newest first.
+//
+// 🚨 SECURITY: This method does NOT verify the user's identity or that the
+// user is an admin. It is the callers responsibility to ensure only site
+// admins can access the returned deliveries.
+func (s *savedSearchWebhookDeliveries) ListFailed(ctx context.Context, limit int) ([]*types.SavedSearchWebhookDelivery, error) {
+	if Mocks.SavedSearchWebhookDeliveries.ListFailed != nil {
+		return Mocks.SavedSearchWebhookDeliveries.ListFailed(ctx, limit)
+	}
+	return s.list(ctx, sqlf.Sprintf("error IS NOT NULL"), limit)
+}
+
+func (s *savedSearchWebhookDeliveries) list(ctx context.Context, cond *sqlf.Query, limit int) ([]*types.SavedSearchWebhookDelivery, error) {
+	q := sqlf.Sprintf(`SELECT
+		id,
+		saved_search_id,
+		url,
+		attempts,
+		status_code,
+		error,
+		created_at
+		FROM saved_search_webhook_deliveries WHERE %s ORDER BY created_at DESC, id DESC LIMIT %d`, cond, limit)
+
+	rows, err := dbconn.Global.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+	if err != nil {
+		return nil, errors.Wrap(err, "QueryContext")
+	}
+	defer rows.Close()
+
+	var deliveries []*types.SavedSearchWebhookDelivery
+	for rows.Next() {
+		var d types.SavedSearchWebhookDelivery
+		if err := rows.Scan(&d.ID, &d.SavedSearchID, &d.URL, &d.Attempts, &d.StatusCode, &d.Error, &d.CreatedAt); err != nil {
+			return nil, errors.Wrap(err, "Scan")
+		}
+		deliveries = append(deliveries, &d)
+	}
+	return deliveries, rows.Err()
+}
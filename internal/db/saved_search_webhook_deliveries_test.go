@@ -0,0 +1,68 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/db/dbtesting"
+)
+
+func TestSavedSearchWebhookDeliveries(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	dbtesting.SetupGlobalTestDB(t)
+	ctx := context.Background()
+	_, err := Users.Create(ctx, NewUser{DisplayName: "test", Email: "test@test.com", Username: "test", Password: "test", EmailVerificationCode: "c2"})
+	if err != nil {
+		t.Fatal("can't create user", err)
+	}
+	userID := int32(1)
+	webhookURL := "https://example.com/hook"
+	ss, err := SavedSearches.Create(ctx, &types.SavedSearch{
+		Query:         "test",
+		Description:   "test",
+		UserID:        &userID,
+		NotifyWebhook: true,
+		WebhookURL:    &webhookURL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statusOK, statusUnavailable := int32(200), int32(503)
+	errUnavailable := "unexpected status code 503"
+	for _, d := range []*types.SavedSearchWebhookDelivery{
+		{SavedSearchID: ss.ID, URL: webhookURL, Attempts: 4, StatusCode: &statusUnavailable, Error: &errUnavailable},
+		{SavedSearchID: ss.ID, URL: webhookURL, Attempts: 1, StatusCode: &statusOK},
+	} {
+		created, err := SavedSearchWebhookDeliveries.Create(ctx, d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if created.ID == 0 || created.CreatedAt.IsZero() {
+			t.Errorf("got %+v, want ID and CreatedAt to be set", created)
+		}
+	}
+
+	deliveries, err := SavedSearchWebhookDeliveries.ListBySavedSearchID(ctx, ss.ID, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveries) != 2 {
+		t.Fatalf("got %d deliveries, want 2", len(deliveries))
+	}
+	if deliveries[0].Attempts != 1 || deliveries[0].Error != nil {
+		t.Errorf("got newest delivery %+v, want the successful one", deliveries[0])
+	}
+
+	failed, err := SavedSearchWebhookDeliveries.ListFailed(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(failed) != 1 || failed[0].Error == nil || *failed[0].Error != errUnavailable {
+		t.Errorf("got failed deliveries %+v, want the one with error %q", failed, errUnavailable)
+	}
+}
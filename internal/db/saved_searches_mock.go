@@ -15,3 +15,9 @@ type MockSavedSearches struct {
 	Delete                    func(ctx context.Context, id int32) error
 	GetByID                   func(ctx context.Context, id int32) (*api.SavedQuerySpecAndConfig, error)
 }
+
+type MockSavedSearchWebhookDeliveries struct {
+	Create              func(ctx context.Context, d *types.SavedSearchWebhookDelivery) (*types.SavedSearchWebhookDelivery, error)
+	ListBySavedSearchID func(ctx context.Context, savedSearchID int32, limit int) ([]*types.SavedSearchWebhookDelivery, error)
+	ListFailed          func(ctx context.Context, limit int) ([]*types.SavedSearchWebhookDelivery, error)
+}
@@ -17,6 +17,8 @@ var (
 
 	SurveyResponses = &surveyResponses{}
 
+	SavedSearchWebhookDeliveries = &savedSearchWebhookDeliveries{}
+
 	ExternalAccounts = &userExternalAccounts{}
 
 	OrgInvitations = &orgInvitations{}
@@ -24,4 +26,8 @@ var (
 	Authz AuthzStore = &authzStore{}
 
 	Secrets = &secrets{}
+
+	VersionContexts = &versionContexts{}
+
+	RepoGroups = &repoGroups{}
 )
@@ -0,0 +1,334 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/keegancsmith/sqlf"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/db/dbconn"
+	"github.com/sourcegraph/sourcegraph/internal/db/dbutil"
+)
+
+// VersionContextNotFoundError occurs when a version context is not found.
+type VersionContextNotFoundError struct {
+	Message string
+}
+
+func (e *VersionContextNotFoundError) Error() string {
+	return fmt.Sprintf("version context not found: %s", e.Message)
+}
+
+func (e *VersionContextNotFoundError) NotFound() bool {
+	return true
+}
+
+// ErrVersionContextNameAlreadyExists occurs when a version context is created
+// or renamed with the name of another version context of the same owner.
+var ErrVersionContextNameAlreadyExists = errors.New("version context name is already taken")
+
+type versionContexts struct{}
+
+// VersionContextsListOptions specifies the options for listing version
+// contexts.
+type VersionContextsListOptions struct {
+	// UserID, if non-zero, lists the version contexts owned by this user.
+	UserID int32
+	// OrgIDs lists the version contexts owned by these organizations.
+	//
+	// If UserID is zero and OrgIDs is empty, all version contexts are listed.
+	OrgIDs []int32
+}
+
+// GetByID returns the version context with the given ID, with its revisions.
+//
+// 🚨 SECURITY: This method does NOT verify the user's identity or that the
+// user is an admin. It is the callers responsibility to ensure only the owner
+// of the version context, members of its organization or site admins can
+// access it.
+func (s *versionContexts) GetByID(ctx context.Context, id int32) (*types.VersionContext, error) {
+	if Mocks.VersionContexts.GetByID != nil {
+		return Mocks.VersionContexts.GetByID(ctx, id)
+	}
+	vcs, err := s.list(ctx, sqlf.Sprintf("id=%d", id))
+	if err != nil {
+		return nil, err
+	}
+	if len(vcs) == 0 {
+		return nil, &VersionContextNotFoundError{fmt.Sprintf("id %d", id)}
+	}
+	return vcs[0], nil
+}
+
+// GetByName returns the version context with the given name owned by the
+// user or one of the organizations of opt, with its revisions. Names are case
+// insensitive and unique per owner. The version context of the user takes
+// precedence over those of organizations. If opt has no owners, no version
+// context is found.
+//
+// 🚨 SECURITY: This method does NOT verify the user's identity or that the
+// user is an admin. It is the callers responsibility to ensure only the owner
+// of the version context, members of its organization or site admins can
+// access it.
+func (s *versionContexts) GetByName(ctx context.Context, name string, opt VersionContextsListOptions) (*types.VersionContext, error) {
+	if Mocks.VersionContexts.GetByName != nil {
+		return Mocks.VersionContexts.GetByName(ctx, name, opt)
+	}
+
+	owners := opt.ownerConds()
+	if len(owners) == 0 {
+		return nil, &VersionContextNotFoundError{fmt.Sprintf("name %s", name)}
+	}
+	vcs, err := s.list(ctx, sqlf.Sprintf("name=%s AND (%s)", name, sqlf.Join(owners, "OR")))
+	if err != nil {
+		return nil, err
+	}
+	for _, vc := range vcs {
+		if vc.UserID != nil {
+			return vc, nil
+		}
+	}
+	if len(vcs) == 0 {
+		return nil, &VersionContextNotFoundError{fmt.Sprintf("name %s", name)}
+	}
+	return vcs[0], nil
+}
+
+// List lists the version contexts matching opt, ordered by name, with their
+// revisions.
+//
+// 🚨 SECURITY: This method does NOT verify the user's identity or that the
+// user is an admin. It is the callers responsibility to ensure only the
+// owners, members of the organizations or site admins can access the returned
+// version contexts.
+func (s *versionContexts) List(ctx context.Context, opt VersionContextsListOptions) ([]*types.VersionContext, error) {
+	if Mocks.VersionContexts.List != nil {
+		return Mocks.VersionContexts.List(ctx, opt)
+	}
+
+	cond := sqlf.Sprintf("TRUE")
+	if conds := opt.ownerConds(); len(conds) > 0 {
+		cond = sqlf.Sprintf("(%s)", sqlf.Join(conds, "OR"))
+	}
+	return s.list(ctx, cond)
+}
+
+// ownerConds returns the conditions that match the version contexts owned by
+// the user or the organizations of opt.
+func (opt VersionContextsListOptions) ownerConds() []*sqlf.Query {
+	var conds []*sqlf.Query
+	if opt.UserID != 0 {
+		conds = append(conds, sqlf.Sprintf("user_id=%d", opt.UserID))
+	}
+	for _, orgID := range opt.OrgIDs {
+		conds = append(conds, sqlf.Sprintf("org_id=%d", orgID))
+	}
+	return conds
+}
+
+func (s *versionContexts) list(ctx context.Context, cond *sqlf.Query) ([]*types.VersionContext, error) {
+	q := sqlf.Sprintf(`SELECT
+		id,
+		name,
+		description,
+		user_id,
+		org_id,
+		created_at,
+		updated_at
+		FROM version_contexts WHERE %s ORDER BY name ASC, id ASC`, cond)
+
+	rows, err := dbconn.Global.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+	if err != nil {
+		return nil, errors.Wrap(err, "QueryContext")
+	}
+	defer rows.Close()
+
+	var (
+		vcs  []*types.VersionContext
+		byID = map[int32]*types.VersionContext{}
+		ids  []*sqlf.Query
+	)
+	for rows.Next() {
+		var vc types.VersionContext
+		if err := rows.Scan(&vc.ID, &vc.Name, &vc.Description, &vc.UserID, &vc.OrgID, &vc.CreatedAt, &vc.UpdatedAt); err != nil {
+			return nil, errors.Wrap(err, "Scan")
+		}
+		vcs = append(vcs, &vc)
+		byID[vc.ID] = &vc
+		ids = append(ids, sqlf.Sprintf("%d", vc.ID))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(vcs) == 0 {
+		return nil, nil
+	}
+
+	q = sqlf.Sprintf(`SELECT
+		r.version_context_id,
+		r.repo_id,
+		repo.name,
+		r.rev,
+		r.ref_glob,
+		r.latest_tag
+		FROM version_context_revisions r
+		JOIN repo ON repo.id=r.repo_id
+		WHERE r.version_context_id IN (%s) AND repo.deleted_at IS NULL
+		ORDER BY r.id ASC`, sqlf.Join(ids, ","))
+
+	rows, err = dbconn.Global.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+	if err != nil {
+		return nil, errors.Wrap(err, "QueryContext(revisions)")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			vcID int32
+			rev  types.VersionContextRevision
+		)
+		if err := rows.Scan(&vcID, &rev.RepoID, &rev.Repo, &rev.Rev, &rev.RefGlob, &rev.LatestTag); err != nil {
+			return nil, errors.Wrap(err, "Scan(revisions)")
+		}
+		vc := byID[vcID]
+		vc.Revisions = append(vc.Revisions, &rev)
+	}
+	return vcs, rows.Err()
+}
+
+// Create creates a new version context with its revisions. The ID field must
+// be zero, or an error will be returned.
+//
+// 🚨 SECURITY: This method does NOT verify the user's identity or that the
+// user is an admin. It is the callers responsibility to ensure the user has
+// proper permissions to create the version context in its namespace.
+func (s *versionContexts) Create(ctx context.Context, vc *types.VersionContext) (*types.VersionContext, error) {
+	if Mocks.VersionContexts.Create != nil {
+		return Mocks.VersionContexts.Create(ctx, vc)
+	}
+
+	if vc.ID != 0 {
+		return nil, errors.New("newVersionContext.ID must be zero")
+	}
+
+	created := *vc
+	err := dbutil.Transaction(ctx, dbconn.Global, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, `INSERT INTO version_contexts(
+				name,
+				description,
+				user_id,
+				org_id
+			) VALUES($1, $2, $3, $4) RETURNING id, created_at, updated_at`,
+			vc.Name,
+			vc.Description,
+			vc.UserID,
+			vc.OrgID,
+		).Scan(&created.ID, &created.CreatedAt, &created.UpdatedAt)
+		if err != nil {
+			return versionContextsError(err)
+		}
+		return insertVersionContextRevisions(ctx, tx, created.ID, vc.Revisions)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// Update updates the name, description and revisions of an existing version
+// context. The revisions replace all of its previous revisions. The owner of a
+// version context can't be changed.
+//
+// 🚨 SECURITY: This method does NOT verify the user's identity or that the
+// user is an admin. It is the callers responsibility to ensure the user has
+// proper permissions to perform the update.
+func (s *versionContexts) Update(ctx context.Context, vc *types.VersionContext) (*types.VersionContext, error) {
+	if Mocks.VersionContexts.Update != nil {
+		return Mocks.VersionContexts.Update(ctx, vc)
+	}
+
+	updated := *vc
+	err := dbutil.Transaction(ctx, dbconn.Global, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, `UPDATE version_contexts
+			SET name=$1, description=$2, updated_at=now()
+			WHERE id=$3 RETURNING user_id, org_id, created_at, updated_at`,
+			vc.Name,
+			vc.Description,
+			vc.ID,
+		).Scan(&updated.UserID, &updated.OrgID, &updated.CreatedAt, &updated.UpdatedAt)
+		if err == sql.ErrNoRows {
+			return &VersionContextNotFoundError{fmt.Sprintf("id %d", vc.ID)}
+		}
+		if err != nil {
+			return versionContextsError(err)
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM version_context_revisions WHERE version_context_id=$1", vc.ID); err != nil {
+			return errors.Wrap(err, "DELETE")
+		}
+		return insertVersionContextRevisions(ctx, tx, vc.ID, vc.Revisions)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// Delete deletes a version context and its revisions.
+//
+// 🚨 SECURITY: This method does NOT verify the user's identity or that the
+// user is an admin. It is the callers responsibility to ensure the user has
+// proper permissions to delete the version context.
+func (s *versionContexts) Delete(ctx context.Context, id int32) error {
+	if Mocks.VersionContexts.Delete != nil {
+		return Mocks.VersionContexts.Delete(ctx, id)
+	}
+
+	res, err := dbconn.Global.ExecContext(ctx, "DELETE FROM version_contexts WHERE id=$1", id)
+	if err != nil {
+		return err
+	}
+	nrows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if nrows == 0 {
+		return &VersionContextNotFoundError{fmt.Sprintf("id %d", id)}
+	}
+	return nil
+}
+
+func insertVersionContextRevisions(ctx context.Context, tx *sql.Tx, versionContextID int32, revs []*types.VersionContextRevision) error {
+	if len(revs) == 0 {
+		return nil
+	}
+	values := make([]*sqlf.Query, len(revs))
+	for i, rev := range revs {
+		values[i] = sqlf.Sprintf("(%d, %d, %s, %s, %s)", versionContextID, rev.RepoID, rev.Rev, rev.RefGlob, rev.LatestTag)
+	}
+	q := sqlf.Sprintf(`INSERT INTO version_context_revisions(
+		version_context_id,
+		repo_id,
+		rev,
+		ref_glob,
+		latest_tag
+	) VALUES %s`, sqlf.Join(values, ","))
+	if _, err := tx.ExecContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...); err != nil {
+		return errors.Wrap(err, "INSERT revisions")
+	}
+	return nil
+}
+
+func versionContextsError(err error) error {
+	if pqErr, ok := err.(*pq.Error); ok {
+		switch pqErr.Constraint {
+		case "version_contexts_user_id_name_unique", "version_contexts_org_id_name_unique":
+			return ErrVersionContextNameAlreadyExists
+		case "version_contexts_has_one_namespace":
+			return errors.New("version context must be owned by exactly one user or organization")
+		}
+	}
+	return err
+}
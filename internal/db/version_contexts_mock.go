@@ -0,0 +1,16 @@
+package db
+
+import (
+	"context"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+)
+
+type MockVersionContexts struct {
+	GetByID   func(ctx context.Context, id int32) (*types.VersionContext, error)
+	GetByName func(ctx context.Context, name string, opt VersionContextsListOptions) (*types.VersionContext, error)
+	List      func(ctx context.Context, opt VersionContextsListOptions) ([]*types.VersionContext, error)
+	Create    func(ctx context.Context, vc *types.VersionContext) (*types.VersionContext, error)
+	Update    func(ctx context.Context, vc *types.VersionContext) (*types.VersionContext, error)
+	Delete    func(ctx context.Context, id int32) error
+}
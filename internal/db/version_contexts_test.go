@@ -0,0 +1,108 @@
+package db
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/db/dbtesting"
+	"github.com/sourcegraph/sourcegraph/internal/errcode"
+)
+
+func TestVersionContexts(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	dbtesting.SetupGlobalTestDB(t)
+	ctx := context.Background()
+	user, err := Users.Create(ctx, NewUser{DisplayName: "test", Email: "test@test.com", Username: "test", Password: "test", EmailVerificationCode: "c2"})
+	if err != nil {
+		t.Fatal("can't create user", err)
+	}
+	createRepo(ctx, t, &types.Repo{Name: "a/b"})
+	repo, err := Repos.GetByName(ctx, "a/b")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vc, err := VersionContexts.Create(ctx, &types.VersionContext{
+		Name:        "release-2024.3",
+		Description: "test",
+		UserID:      &user.ID,
+		Revisions: []*types.VersionContextRevision{
+			{RepoID: repo.ID, LatestTag: "v2024.3.*"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vc.ID == 0 || vc.CreatedAt.IsZero() {
+		t.Errorf("got %+v, want ID and CreatedAt to be set", vc)
+	}
+
+	if _, err := VersionContexts.Create(ctx, &types.VersionContext{Name: "RELEASE-2024.3", UserID: &user.ID}); err != ErrVersionContextNameAlreadyExists {
+		t.Errorf("got error %v creating a duplicate name, want %v", err, ErrVersionContextNameAlreadyExists)
+	}
+
+	// Names are unique per owner.
+	other, err := Users.Create(ctx, NewUser{DisplayName: "other", Email: "other@test.com", Username: "other", Password: "other", EmailVerificationCode: "c3"})
+	if err != nil {
+		t.Fatal("can't create user", err)
+	}
+	if _, err := VersionContexts.Create(ctx, &types.VersionContext{Name: "release-2024.3", UserID: &other.ID}); err != nil {
+		t.Fatalf("got error %v creating a name of another user, want none", err)
+	}
+
+	got, err := VersionContexts.GetByName(ctx, "release-2024.3", VersionContextsListOptions{UserID: user.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != vc.ID {
+		t.Errorf("got version context %d, want %d of the user", got.ID, vc.ID)
+	}
+	if _, err := VersionContexts.GetByName(ctx, "release-2024.3", VersionContextsListOptions{OrgIDs: []int32{user.ID + 100}}); !errcode.IsNotFound(err) {
+		t.Errorf("got error %v for another namespace, want not found", err)
+	}
+	wantRevisions := []*types.VersionContextRevision{{RepoID: repo.ID, Repo: "a/b", LatestTag: "v2024.3.*"}}
+	if !reflect.DeepEqual(got.Revisions, wantRevisions) {
+		t.Errorf("got revisions %+v, want %+v", got.Revisions, wantRevisions)
+	}
+
+	vc.Description = "updated"
+	vc.Revisions = []*types.VersionContextRevision{{RepoID: repo.ID, Rev: "v2024.3.1"}}
+	if _, err := VersionContexts.Update(ctx, vc); err != nil {
+		t.Fatal(err)
+	}
+	got, err = VersionContexts.GetByID(ctx, vc.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantRevisions = []*types.VersionContextRevision{{RepoID: repo.ID, Repo: "a/b", Rev: "v2024.3.1"}}
+	if got.Description != "updated" || !reflect.DeepEqual(got.Revisions, wantRevisions) {
+		t.Errorf("got %+v with revisions %+v, want the update", got, got.Revisions)
+	}
+
+	list, err := VersionContexts.List(ctx, VersionContextsListOptions{UserID: user.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].ID != vc.ID {
+		t.Errorf("got %+v, want the version context of the user", list)
+	}
+	list, err = VersionContexts.List(ctx, VersionContextsListOptions{OrgIDs: []int32{user.ID + 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 0 {
+		t.Errorf("got %+v, want none for another org", list)
+	}
+
+	if err := VersionContexts.Delete(ctx, vc.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VersionContexts.GetByID(ctx, vc.ID); !errcode.IsNotFound(err) {
+		t.Errorf("got error %v, want not found", err)
+	}
+}
@@ -138,6 +138,17 @@ func (s *Store) OpenWithPath(ctx context.Context, key string, fetcher FetcherWit
 	}
 }
 
+// Cached returns the path of the item with key if it is in the cache,
+// without fetching it. The item may be evicted at any time after Cached
+// returns.
+func (s *Store) Cached(key string) (string, bool) {
+	path := s.path(key)
+	if _, err := os.Stat(path); err != nil {
+		return "", false
+	}
+	return path, true
+}
+
 // path returns the path for key.
 func (s *Store) path(key string) string {
 	// path uses a sha256 hash of the key since we want to use it for the
@@ -43,6 +43,9 @@ func TestOpen(t *testing.T) {
 	}
 
 	// Cache should be empty
+	if _, ok := store.Cached("key"); ok {
+		t.Fatal("Expected key to not be cached")
+	}
 	_, usedCache := do()
 	if usedCache {
 		t.Fatal("Expected fetcher to be called on empty cache")
@@ -53,6 +56,9 @@ func TestOpen(t *testing.T) {
 	if !usedCache {
 		t.Fatal("Expected fetcher to not be called when cached")
 	}
+	if path, ok := store.Cached("key"); !ok || path != f.Path {
+		t.Fatalf("got cached path %q, %v, want %q", path, ok, f.Path)
+	}
 
 	// Evict, then we should not use the cache
 	os.Remove(f.Path)
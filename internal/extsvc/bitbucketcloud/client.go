@@ -232,6 +232,8 @@ type Repo struct {
 	Description string `json:"description"`
 	Parent      *Repo  `json:"parent"`
 	IsPrivate   bool   `json:"is_private"`
+	Language    string `json:"language"`
+	Size        int64  `json:"size"` // in bytes
 	Links       Links  `json:"links"`
 }
 
@@ -26,15 +26,37 @@ func SplitRepositoryNameWithOwner(nameWithOwner string) (owner, repo string, err
 
 // Repository is a GitHub repository.
 type Repository struct {
-	ID               string // ID of repository (GitHub GraphQL ID, not GitHub database ID)
-	DatabaseID       int64  // The integer database id
-	NameWithOwner    string // full name of repository ("owner/name")
-	Description      string // description of repository
-	URL              string // the web URL of this repository ("https://github.com/foo/bar")
-	IsPrivate        bool   // whether the repository is private
-	IsFork           bool   // whether the repository is a fork of another repository
-	IsArchived       bool   // whether the repository is archived on the code host
-	ViewerPermission string // ADMIN, WRITE, READ, or empty if unknown. Only the graphql api populates this. https://developer.github.com/v4/enum/repositorypermission/
+	ID               string           // ID of repository (GitHub GraphQL ID, not GitHub database ID)
+	DatabaseID       int64            // The integer database id
+	NameWithOwner    string           // full name of repository ("owner/name")
+	Description      string           // description of repository
+	URL              string           // the web URL of this repository ("https://github.com/foo/bar")
+	IsPrivate        bool             // whether the repository is private
+	IsFork           bool             // whether the repository is a fork of another repository
+	IsArchived       bool             // whether the repository is archived on the code host
+	ViewerPermission string           // ADMIN, WRITE, READ, or empty if unknown. Only the graphql api populates this. https://developer.github.com/v4/enum/repositorypermission/
+	StargazerCount   int              // the number of users who starred the repository
+	PrimaryLanguage  *Language        // the primary language of the repository, if any
+	DiskUsage        int              // the size of the repository on disk in kilobytes
+	RepositoryTopics RepositoryTopics // the topics of the repository
+}
+
+// Language is a programming language of a repository.
+type Language struct {
+	Name string
+}
+
+// RepositoryTopics are the topics of a repository, such as "go" or
+// "code-search".
+type RepositoryTopics struct {
+	Nodes []RepositoryTopic
+}
+
+// RepositoryTopic is a topic of a repository.
+type RepositoryTopic struct {
+	Topic struct {
+		Name string
+	}
 }
 
 // repositoryFieldsGraphQLFragment returns a GraphQL fragment that contains the fields needed to populate the
@@ -52,12 +74,17 @@ fragment RepositoryFields on Repository {
 	isFork
 	isArchived
 	viewerPermission
+	stargazerCount
+	primaryLanguage { name }
+	diskUsage
+	repositoryTopics(first: 100) { nodes { topic { name } } }
 }
 	`
 	}
 	// Some fields are not yet available on GitHub Enterprise yet
 	// or are available but too new to expect our customers to have updated:
 	// - viewerPermission
+	// - stargazerCount
 	return `
 fragment RepositoryFields on Repository {
 	id
@@ -68,6 +95,9 @@ fragment RepositoryFields on Repository {
 	isPrivate
 	isFork
 	isArchived
+	primaryLanguage { name }
+	diskUsage
+	repositoryTopics(first: 100) { nodes { topic { name } } }
 }
 	`
 }
@@ -228,6 +258,10 @@ type restRepository struct {
 	Fork        bool
 	Archived    bool
 	Permissions restRepositoryPermissions `json:"permissions"`
+	Stargazers  int                       `json:"stargazers_count"`
+	Language    string
+	Size        int // in kilobytes
+	Topics      []string
 }
 
 // getRepositoryFromAPI attempts to fetch a repository from the GitHub API without use of the redis cache.
@@ -259,7 +293,32 @@ func convertRestRepo(restRepo restRepository) *Repository {
 		IsFork:           restRepo.Fork,
 		IsArchived:       restRepo.Archived,
 		ViewerPermission: convertRestRepoPermissions(restRepo.Permissions),
+		StargazerCount:   restRepo.Stargazers,
+		PrimaryLanguage:  convertRestRepoLanguage(restRepo.Language),
+		DiskUsage:        restRepo.Size,
+		RepositoryTopics: convertRestRepoTopics(restRepo.Topics),
+	}
+}
+
+// convertRestRepoLanguage converts the primary language returned by the rest
+// API to a standard format.
+func convertRestRepoLanguage(language string) *Language {
+	if language == "" {
+		return nil
+	}
+	return &Language{Name: language}
+}
+
+// convertRestRepoTopics converts the topics returned by the rest API to a
+// standard format.
+func convertRestRepoTopics(topics []string) RepositoryTopics {
+	var t RepositoryTopics
+	for _, name := range topics {
+		var node RepositoryTopic
+		node.Topic.Name = name
+		t.Nodes = append(t.Nodes, node)
 	}
+	return t
 }
 
 // convertRestRepoPermissions converts repo information returned by the rest API
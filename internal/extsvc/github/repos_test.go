@@ -426,7 +426,7 @@ func repoListsAreEqual(a []*Repository, b []*Repository) bool {
 		return false
 	}
 	for i := 0; i < len(a); i++ {
-		if *a[i] != *b[i] {
+		if !reflect.DeepEqual(a[i], b[i]) {
 			return false
 		}
 	}
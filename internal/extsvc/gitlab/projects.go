@@ -27,6 +27,8 @@ type Project struct {
 	Visibility        Visibility     `json:"visibility"`                    // "private", "internal", or "public"
 	ForkedFromProject *ProjectCommon `json:"forked_from_project,omitempty"` // If non-nil, the project from which this project was forked
 	Archived          bool           `json:"archived"`
+	StarCount         int            `json:"star_count"` // the number of users who starred the project
+	TagList           []string       `json:"tag_list"`   // the topics of the project
 }
 
 type ProjectCommon struct {
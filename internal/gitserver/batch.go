@@ -0,0 +1,130 @@
+package gitserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/opentracing/opentracing-go/ext"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
+	"github.com/sourcegraph/sourcegraph/internal/trace/ot"
+	"github.com/sourcegraph/sourcegraph/internal/vcs"
+)
+
+// BatchCmd is a list of git commands to be executed remotely in a single
+// request. The commands run in order against the same commit.
+type BatchCmd struct {
+	client *Client
+
+	Repo           // the repository to execute the commands in
+	EnsureRevision string
+
+	// Revision is resolved to a commit ID before any command runs.
+	// protocol.BatchExecCommit in the arguments of the commands is replaced
+	// by that commit ID.
+	Revision string
+
+	Commands [][]string // the arguments to git of each command
+}
+
+// BatchCommand creates a new BatchCmd that runs its commands against the
+// commit that revision resolves to. If revision is empty, the commands run
+// as given.
+func (c *Client) BatchCommand(revision string) *BatchCmd {
+	return &BatchCmd{client: c, Revision: revision}
+}
+
+// Add appends a git command to b, and returns its index in the results of
+// b.Run. The arguments must not include "git".
+func (b *BatchCmd) Add(args ...string) int {
+	b.Commands = append(b.Commands, args)
+	return len(b.Commands) - 1
+}
+
+// Run runs the commands and returns the commit that b.Revision resolved to
+// (empty if b.Revision is empty), and the result of each command in order.
+// A command that exits with a non-zero status does not stop the others, and
+// is not an error.
+//
+// If the revision does not exist, the error is a *RevisionNotFoundError.
+func (b *BatchCmd) Run(ctx context.Context) (_ api.CommitID, _ []*protocol.BatchExecResult, errRes error) {
+	repoName := protocol.NormalizeRepo(b.Repo.Name)
+
+	span, ctx := ot.StartSpanFromContext(ctx, "Client.BatchCmd.Run")
+	defer func() {
+		if errRes != nil {
+			ext.Error.Set(span, true)
+			span.SetTag("err", errRes.Error())
+		}
+		span.Finish()
+	}()
+	span.SetTag("repo", b.Repo.Name)
+	span.SetTag("revision", b.Revision)
+	span.SetTag("commands", len(b.Commands))
+
+	// Check that ctx is not expired.
+	if err := ctx.Err(); err != nil {
+		deadlineExceededCounter.Inc()
+		return "", nil, err
+	}
+
+	req := &protocol.BatchExecRequest{
+		Repo:           repoName,
+		URL:            b.Repo.URL,
+		EnsureRevision: b.EnsureRevision,
+		Revision:       b.Revision,
+		Commands:       b.Commands,
+	}
+	resp, err := b.client.doWithFailover(ctx, repoName, "POST", "batch-exec", req)
+	if err != nil {
+		return "", nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		results, err := readBatchExecResults(resp.Body, len(b.Commands))
+		return api.CommitID(resp.Header.Get("X-Exec-Commit")), results, err
+
+	case http.StatusNotFound:
+		var payload protocol.NotFoundPayload
+		if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+			return "", nil, err
+		}
+		if payload.RevisionNotFound {
+			return "", nil, &RevisionNotFoundError{Repo: repoName, Spec: b.Revision}
+		}
+		return "", nil, &vcs.RepoNotExistError{Repo: repoName, CloneInProgress: payload.CloneInProgress, CloneProgress: payload.CloneProgress}
+
+	case http.StatusBadRequest:
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", nil, &badRequestError{error: fmt.Errorf("invalid batch exec request: %s", body)}
+
+	default:
+		return "", nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+}
+
+// readBatchExecResults reads the stream of n results of a batch exec
+// request. If the stream ends early, it returns the results read so far and
+// an error.
+func readBatchExecResults(r io.Reader, n int) ([]*protocol.BatchExecResult, error) {
+	results := make([]*protocol.BatchExecResult, 0, n)
+	dec := json.NewDecoder(r)
+	for len(results) < n {
+		var result protocol.BatchExecResult
+		if err := dec.Decode(&result); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return results, errors.Wrapf(err, "reading result %d of %d of batch exec", len(results)+1, n)
+		}
+		results = append(results, &result)
+	}
+	return results, nil
+}
@@ -13,6 +13,7 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -60,6 +61,12 @@ func NewClient(cli httpcli.Doer) *Client {
 		Addrs: func(ctx context.Context) []string {
 			return conf.Get().ServiceConnections.GitServers
 		},
+		ReplicationFactor: func(ctx context.Context) int {
+			return conf.Get().GitServerReplicationFactor
+		},
+		Hashing: func(ctx context.Context) Hashing {
+			return ConfHashing()
+		},
 		HTTPClient:  cli,
 		HTTPLimiter: parallel.NewRun(500),
 		// Use the binary name for UserAgent. This should effectively identify
@@ -82,15 +89,47 @@ type Client struct {
 	// concurrent use. It may return different results at different times.
 	Addrs func(ctx context.Context) []string
 
+	// ReplicationFactor is a function which should return the number of
+	// gitservers each repository is cloned on. If it is nil or returns less
+	// than 1, each repository is cloned on one gitserver.
+	ReplicationFactor func(ctx context.Context) int
+
+	// Hashing is a function which should return the way repositories are
+	// assigned to gitservers. If it is nil, ModuloHashing is used.
+	Hashing func(ctx context.Context) Hashing
+
+	// health tracks the gitservers that recently failed to respond.
+	health addrHealth
+
 	// UserAgent is a string identifing who the client is. It will be logged in
 	// the telemetry in gitserver.
 	UserAgent string
 }
 
 // AddrForRepo returns the gitserver address to use for the given repo name.
+// If the repository is replicated, it is the first of its replicas that is not
+// down.
 func (c *Client) AddrForRepo(ctx context.Context, repo api.RepoName) string {
+	return c.ReplicaAddrsForRepo(ctx, repo)[0]
+}
+
+// ReplicaAddrsForRepo returns the addresses of the gitservers the given repo
+// is cloned on, in the order in which they should be tried: the replicas that
+// are not down in order of preference, followed by the replicas that are down.
+func (c *Client) ReplicaAddrsForRepo(ctx context.Context, repo api.RepoName) []string {
 	repo = protocol.NormalizeRepo(repo) // in case the caller didn't already normalize it
-	return c.addrForKey(ctx, string(repo))
+	addrs := c.Addrs(ctx)
+	if len(addrs) == 0 {
+		panic("unexpected state: no gitserver addresses")
+	}
+	return c.health.sort(AddrsForKey(c.hashing(ctx), addrs, string(repo), c.replicationFactor(ctx)))
+}
+
+func (c *Client) replicationFactor(ctx context.Context) int {
+	if c.ReplicationFactor == nil {
+		return 1
+	}
+	return c.ReplicationFactor(ctx)
 }
 
 // addrForKey returns the gitserver address to use for the given string key,
@@ -100,13 +139,114 @@ func (c *Client) addrForKey(ctx context.Context, key string) string {
 	if len(addrs) == 0 {
 		panic("unexpected state: no gitserver addresses")
 	}
-	return addrForKey(addrs, key)
+	return AddrForKey(c.hashing(ctx), addrs, key)
+}
+
+func (c *Client) hashing(ctx context.Context) Hashing {
+	if c.Hashing == nil {
+		return ModuloHashing
+	}
+	return c.Hashing(ctx)
+}
+
+// Hashing is a way of assigning keys, such as repository names, to gitserver
+// addresses.
+type Hashing string
+
+const (
+	// ModuloHashing assigns a key to the address at the index of the hash of
+	// the key modulo the number of addresses, and its replicas to the
+	// addresses that follow it. The order of the addresses matters, and
+	// changing the number of addresses moves nearly all keys. It is the
+	// default.
+	ModuloHashing Hashing = "modulo"
+
+	// RendezvousHashing scores every address by hashing it together with the
+	// key, and assigns the key to the addresses with the highest scores.
+	// Adding an address only moves the keys that the new address scores
+	// highest for, and removing an address only moves the keys it had, so
+	// changing the number of gitservers moves about 1/n of the repositories
+	// instead of nearly all of them. The order of the addresses does not
+	// matter.
+	RendezvousHashing Hashing = "rendezvous"
+)
+
+// ParseHashing returns the Hashing named by s, as in the gitServerHashing
+// site configuration setting. It returns ModuloHashing if s is empty, and
+// false if s is not a known hashing.
+func ParseHashing(s string) (Hashing, bool) {
+	switch Hashing(s) {
+	case "", ModuloHashing:
+		return ModuloHashing, true
+	case RendezvousHashing:
+		return RendezvousHashing, true
+	}
+	return "", false
 }
 
-func addrForKey(addrs []string, key string) string {
+// ConfHashing returns the Hashing configured by the gitServerHashing site
+// configuration setting.
+func ConfHashing() Hashing {
+	h, ok := ParseHashing(conf.Get().GitServerHashing)
+	if !ok {
+		return ModuloHashing
+	}
+	return h
+}
+
+// AddrForKey returns the address in addrs to use for the given string key,
+// such as a normalized repository name, with the given hashing. addrs must
+// not be empty.
+func AddrForKey(h Hashing, addrs []string, key string) string {
+	return AddrsForKey(h, addrs, key, 1)[0]
+}
+
+// AddrsForKey returns the n addresses in addrs that key is assigned to with
+// the given hashing, in order of preference, or all of addrs if there are
+// fewer than n of them. The first address is AddrForKey(h, addrs, key). n is
+// at least 1.
+func AddrsForKey(h Hashing, addrs []string, key string, n int) []string {
+	if n < 1 {
+		n = 1
+	}
+	if n > len(addrs) {
+		n = len(addrs)
+	}
+	if h == RendezvousHashing {
+		return rendezvousAddrsForKey(addrs, key, n)
+	}
+
 	sum := md5.Sum([]byte(key))
-	serverIndex := binary.BigEndian.Uint64(sum[:]) % uint64(len(addrs))
-	return addrs[serverIndex]
+	first := binary.BigEndian.Uint64(sum[:]) % uint64(len(addrs))
+	result := make([]string, n)
+	for i := range result {
+		result[i] = addrs[(first+uint64(i))%uint64(len(addrs))]
+	}
+	return result
+}
+
+func rendezvousAddrsForKey(addrs []string, key string, n int) []string {
+	type scoredAddr struct {
+		addr  string
+		score uint64
+	}
+	scored := make([]scoredAddr, len(addrs))
+	for i, addr := range addrs {
+		sum := md5.Sum([]byte(addr + "\x00" + key))
+		scored[i] = scoredAddr{addr: addr, score: binary.BigEndian.Uint64(sum[:])}
+	}
+	sort.Slice(scored, func(i, j int) bool {
+		if scored[i].score != scored[j].score {
+			return scored[i].score > scored[j].score
+		}
+		return scored[i].addr < scored[j].addr
+	})
+
+	result := make([]string, n)
+	for i := range result {
+		result[i] = scored[i].addr
+	}
+	return result
 }
 
 // ArchiveOptions contains options for the Archive func.
@@ -144,6 +284,15 @@ func (a *archiveReader) Close() error {
 // ArchiveURL returns a URL from which an archive of the given Git repository can
 // be downloaded from.
 func (c *Client) ArchiveURL(ctx context.Context, repo Repo, opt ArchiveOptions) *url.URL {
+	return &url.URL{
+		Scheme:   "http",
+		Host:     c.AddrForRepo(ctx, repo.Name),
+		Path:     "/archive",
+		RawQuery: archiveQuery(repo, opt).Encode(),
+	}
+}
+
+func archiveQuery(repo Repo, opt ArchiveOptions) url.Values {
 	q := url.Values{
 		"repo":    {string(repo.Name)},
 		"treeish": {opt.Treeish},
@@ -153,13 +302,7 @@ func (c *Client) ArchiveURL(ctx context.Context, repo Repo, opt ArchiveOptions)
 	for _, path := range opt.Paths {
 		q.Add("path", path)
 	}
-
-	return &url.URL{
-		Scheme:   "http",
-		Host:     c.AddrForRepo(ctx, repo.Name),
-		Path:     "/archive",
-		RawQuery: q.Encode(),
-	}
+	return q
 }
 
 // Archive produces an archive from a Git repository.
@@ -181,8 +324,7 @@ func (c *Client) Archive(ctx context.Context, repo Repo, opt ArchiveOptions) (_
 		return nil, err
 	}
 
-	u := c.ArchiveURL(ctx, repo, opt)
-	resp, err := c.do(ctx, repo.Name, "GET", u.String(), nil)
+	resp, err := c.doWithFailover(ctx, repo.Name, "GET", "archive?"+archiveQuery(repo, opt).Encode(), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -249,7 +391,7 @@ func (c *Cmd) sendExec(ctx context.Context) (_ io.ReadCloser, _ http.Header, err
 		EnsureRevision: c.EnsureRevision,
 		Args:           c.Args[1:],
 	}
-	resp, err := c.client.httpPost(ctx, repoName, "exec", req)
+	resp, err := c.client.doWithFailover(ctx, repoName, "POST", "exec", req)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -476,15 +618,19 @@ func (c *Client) ListGitolite(ctx context.Context, gitoliteHost string) (list []
 	return list, err
 }
 
-// ListCloned lists all cloned repositories
+// ListCloned lists all cloned repositories. A replicated repository is listed
+// once, if it is cloned on any of its replicas.
 func (c *Client) ListCloned(ctx context.Context) ([]string, error) {
 	var (
 		wg    sync.WaitGroup
 		mu    sync.Mutex
 		err   error
+		seen  = map[string]bool{}
 		repos []string
 	)
 	addrs := c.Addrs(ctx)
+	n := c.replicationFactor(ctx)
+	h := c.hashing(ctx)
 	for _, addr := range addrs {
 		wg.Add(1)
 		go func(addr string) {
@@ -495,8 +641,11 @@ func (c *Client) ListCloned(ctx context.Context) ([]string, error) {
 			if len(r) > 0 {
 				filtered := r[:0]
 				for _, repo := range r {
-					if addrForKey(addrs, repo) == addr {
-						filtered = append(filtered, repo)
+					for _, replica := range AddrsForKey(h, addrs, repo, n) {
+						if replica == addr {
+							filtered = append(filtered, repo)
+							break
+						}
 					}
 				}
 				r = filtered
@@ -505,7 +654,12 @@ func (c *Client) ListCloned(ctx context.Context) ([]string, error) {
 			if e != nil {
 				err = e
 			}
-			repos = append(repos, r...)
+			for _, repo := range r {
+				if !seen[repo] {
+					seen[repo] = true
+					repos = append(repos, repo)
+				}
+			}
 			mu.Unlock()
 		}(addr)
 	}
@@ -559,16 +713,47 @@ func (c *Client) doListOne(ctx context.Context, urlSuffix, addr string) ([]strin
 // Repo updates are not guaranteed to occur. If a repo has been updated
 // recently (within the Since duration specified in the request), the
 // update won't happen.
+//
+// If the repository is replicated, it is updated on all its replicas, and the
+// response of the first replica that succeeded is returned.
 func (c *Client) RequestRepoUpdate(ctx context.Context, repo Repo, since time.Duration) (*protocol.RepoUpdateResponse, error) {
 	req := &protocol.RepoUpdateRequest{
 		Repo:  repo.Name,
 		URL:   repo.URL,
 		Since: since,
 	}
-	resp, err := c.httpPost(ctx, repo.Name, "repo-update", req)
-	if err != nil {
-		return nil, err
+	resps, errs := c.doAll(ctx, repo.Name, "POST", "repo-update", req)
+
+	var (
+		info     *protocol.RepoUpdateResponse
+		firstErr error
+	)
+	for i, resp := range resps {
+		err := errs[i]
+		var replicaInfo *protocol.RepoUpdateResponse
+		if err == nil {
+			replicaInfo, err = readRepoUpdateResponse(resp)
+		}
+		if err != nil {
+			if len(resps) > 1 {
+				log15.Warn("failed to update repository replica", "repo", repo.Name, "error", err)
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if info == nil {
+			info = replicaInfo
+		}
+	}
+	if info == nil {
+		return nil, firstErr
 	}
+	return info, nil
+}
+
+func readRepoUpdateResponse(resp *http.Response) (*protocol.RepoUpdateResponse, error) {
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
 		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 200))
@@ -576,7 +761,7 @@ func (c *Client) RequestRepoUpdate(ctx context.Context, repo Repo, since time.Du
 	}
 
 	var info *protocol.RepoUpdateResponse
-	err = json.NewDecoder(resp.Body).Decode(&info)
+	err := json.NewDecoder(resp.Body).Decode(&info)
 	return info, err
 }
 
@@ -644,7 +829,7 @@ func (c *Client) IsRepoCloned(ctx context.Context, repo api.RepoName) (bool, err
 	req := &protocol.IsRepoClonedRequest{
 		Repo: repo,
 	}
-	resp, err := c.httpPost(ctx, repo, "is-repo-cloned", req)
+	resp, err := c.doWithFailover(ctx, repo, "POST", "is-repo-cloned", req)
 	if err != nil {
 		return false, err
 	}
@@ -804,22 +989,28 @@ func (c *Client) RepoInfo(ctx context.Context, repos ...api.RepoName) (*protocol
 	return &res, err.ErrorOrNil()
 }
 
-// Remove removes the repository clone from gitserver.
+// Remove removes the repository clone from gitserver, from all its replicas
+// if it is replicated.
 func (c *Client) Remove(ctx context.Context, repo api.RepoName) error {
 	req := &protocol.RepoDeleteRequest{
 		Repo: repo,
 	}
-	resp, err := c.httpPost(ctx, repo, "delete", req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		// best-effort inclusion of body in error message
-		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 200))
-		return &url.Error{URL: resp.Request.URL.String(), Op: "RepoRemove", Err: fmt.Errorf("RepoRemove: http status %d: %s", resp.StatusCode, string(body))}
+	resps, errs := c.doAll(ctx, repo, "POST", "delete", req)
+
+	err := new(multierror.Error)
+	for i, resp := range resps {
+		if errs[i] != nil {
+			err = multierror.Append(err, errs[i])
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			// best-effort inclusion of body in error message
+			body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 200))
+			err = multierror.Append(err, &url.Error{URL: resp.Request.URL.String(), Op: "RepoRemove", Err: fmt.Errorf("RepoRemove: http status %d: %s", resp.StatusCode, string(body))})
+		}
+		resp.Body.Close()
 	}
-	return nil
+	return err.ErrorOrNil()
 }
 
 func (c *Client) httpPost(ctx context.Context, repo api.RepoName, op string, payload interface{}) (resp *http.Response, err error) {
@@ -829,9 +1020,78 @@ func (c *Client) httpPost(ctx context.Context, repo api.RepoName, op string, pay
 // do performs a request to a gitserver, sharding based on the given
 // repo name (the repo name is otherwise not used).
 func (c *Client) do(ctx context.Context, repo api.RepoName, method, op string, payload interface{}) (resp *http.Response, err error) {
+	return c.doAddr(ctx, c.AddrForRepo(ctx, repo), repo, method, op, payload)
+}
+
+// doWithFailover performs a request like do, to the replicas of the given
+// repo in turn. It moves on to the next replica if a gitserver does not
+// respond, or responds with 404 Not Found because the repository is not
+// cloned on it or is still being cloned. If no replica has the repository,
+// the first 404 Not Found response is returned.
+func (c *Client) doWithFailover(ctx context.Context, repo api.RepoName, method, op string, payload interface{}) (*http.Response, error) {
+	var (
+		notFound *http.Response
+		firstErr error
+	)
+	for _, addr := range c.ReplicaAddrsForRepo(ctx, repo) {
+		resp, err := c.doAddr(ctx, addr, repo, method, op, payload)
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if resp.StatusCode != http.StatusNotFound {
+			if notFound != nil {
+				notFound.Body.Close()
+			}
+			return resp, nil
+		}
+		if notFound != nil {
+			resp.Body.Close()
+			continue
+		}
+		notFound = resp
+	}
+
+	if notFound != nil {
+		return notFound, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return nil, firstErr
+}
+
+// doAll performs a request like do on every replica of the given repo
+// concurrently, and returns the responses in the order of
+// ReplicaAddrsForRepo.
+func (c *Client) doAll(ctx context.Context, repo api.RepoName, method, op string, payload interface{}) ([]*http.Response, []error) {
+	addrs := c.ReplicaAddrsForRepo(ctx, repo)
+	resps := make([]*http.Response, len(addrs))
+	errs := make([]error, len(addrs))
+
+	var wg sync.WaitGroup
+	for i, addr := range addrs {
+		wg.Add(1)
+		go func(i int, addr string) {
+			defer wg.Done()
+			resps[i], errs[i] = c.doAddr(ctx, addr, repo, method, op, payload)
+		}(i, addr)
+	}
+	wg.Wait()
+	return resps, errs
+}
+
+// doAddr performs a request to the gitserver at addr. It records whether the
+// gitserver responded, so that requests avoid it while it is down.
+func (c *Client) doAddr(ctx context.Context, addr string, repo api.RepoName, method, op string, payload interface{}) (resp *http.Response, err error) {
 	span, ctx := ot.StartSpanFromContext(ctx, "Client.do")
 	defer func() {
-		span.LogKV("repo", string(repo), "method", method, "op", op)
+		span.LogKV("repo", string(repo), "method", method, "op", op, "addr", addr)
 		if err != nil {
 			ext.Error.Set(span, true)
 			span.SetTag("err", err.Error())
@@ -844,12 +1104,7 @@ func (c *Client) do(ctx context.Context, repo api.RepoName, method, op string, p
 		return nil, err
 	}
 
-	uri := op
-	if !strings.HasPrefix(op, "http") {
-		uri = "http://" + c.AddrForRepo(ctx, repo) + "/" + op
-	}
-
-	req, err := http.NewRequest(method, uri, bytes.NewReader(reqBody))
+	req, err := http.NewRequest(method, "http://"+addr+"/"+op, bytes.NewReader(reqBody))
 	if err != nil {
 		return nil, err
 	}
@@ -869,7 +1124,15 @@ func (c *Client) do(ctx context.Context, repo api.RepoName, method, op string, p
 		nethttp.ClientTrace(false))
 	defer ht.Finish()
 
-	return c.HTTPClient.Do(req)
+	resp, err = c.HTTPClient.Do(req)
+	if err != nil {
+		if ctx.Err() == nil {
+			c.health.markDown(addr)
+		}
+		return nil, err
+	}
+	c.health.markUp(addr)
+	return resp, nil
 }
 
 // CreateCommitFromPatch will attempt to create a commit from a patch
@@ -4,7 +4,9 @@ import (
 	"archive/zip"
 	"bytes"
 	"context"
+	"crypto/md5"
 	"encoding/base64"
+	"encoding/binary"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -14,6 +16,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"sort"
+	"sync"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -23,6 +26,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/gitserver"
 	"github.com/sourcegraph/sourcegraph/internal/httpcli"
+	"github.com/sourcegraph/sourcegraph/internal/vcs"
 )
 
 func TestClient_ListCloned(t *testing.T) {
@@ -240,3 +244,241 @@ func createSimpleGitRepo(t *testing.T, root string) string {
 
 	return dir
 }
+
+func TestAddrForKey_modulo(t *testing.T) {
+	addrs := []string{"gitserver-0", "gitserver-1", "gitserver-2"}
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("github.com/foo/repo-%d", i)
+		// Modulo hashing must keep assigning repositories to the gitservers
+		// they were cloned on before hashing was configurable.
+		sum := md5.Sum([]byte(key))
+		want := addrs[binary.BigEndian.Uint64(sum[:])%uint64(len(addrs))]
+		if got := gitserver.AddrForKey(gitserver.ModuloHashing, addrs, key); got != want {
+			t.Fatalf("%s: got %s, want %s", key, got, want)
+		}
+		if got := gitserver.AddrForKey("", addrs, key); got != want {
+			t.Fatalf("%s: got %s for empty hashing, want %s", key, got, want)
+		}
+	}
+}
+
+func TestAddrForKey_rendezvous(t *testing.T) {
+	addrs := []string{"gitserver-0", "gitserver-1", "gitserver-2", "gitserver-3"}
+	grown := append(append([]string{}, addrs...), "gitserver-4")
+	reversed := []string{"gitserver-3", "gitserver-2", "gitserver-1", "gitserver-0"}
+
+	const n = 10000
+	counts := map[string]int{}
+	moved := 0
+	for i := 0; i < n; i++ {
+		key := fmt.Sprintf("github.com/foo/repo-%d", i)
+		addr := gitserver.AddrForKey(gitserver.RendezvousHashing, addrs, key)
+		counts[addr]++
+
+		if got := gitserver.AddrForKey(gitserver.RendezvousHashing, reversed, key); got != addr {
+			t.Fatalf("%s: got %s for reversed addrs, want %s", key, got, addr)
+		}
+
+		// Adding a gitserver only moves keys to the new gitserver.
+		if newAddr := gitserver.AddrForKey(gitserver.RendezvousHashing, grown, key); newAddr != addr {
+			if newAddr != "gitserver-4" {
+				t.Fatalf("%s: moved from %s to %s, want it to move to gitserver-4 or not at all", key, addr, newAddr)
+			}
+			moved++
+		}
+	}
+
+	// About 1/5 of the keys should move to the new gitserver, and the keys
+	// should be spread evenly.
+	if moved < n/5*8/10 || moved > n/5*12/10 {
+		t.Errorf("got %d of %d keys moved, want about %d", moved, n, n/5)
+	}
+	for _, addr := range addrs {
+		if counts[addr] < n/4*8/10 || counts[addr] > n/4*12/10 {
+			t.Errorf("got %d keys for %s, want about %d", counts[addr], addr, n/4)
+		}
+	}
+}
+
+func TestAddrsForKey(t *testing.T) {
+	addrs := []string{"gitserver-0", "gitserver-1", "gitserver-2", "gitserver-3"}
+	for _, h := range []gitserver.Hashing{gitserver.ModuloHashing, gitserver.RendezvousHashing} {
+		for i := 0; i < 100; i++ {
+			key := fmt.Sprintf("github.com/foo/repo-%d", i)
+			got := gitserver.AddrsForKey(h, addrs, key, 2)
+			if len(got) != 2 || got[0] == got[1] {
+				t.Fatalf("%s %s: got %v, want 2 distinct addrs", h, key, got)
+			}
+			if want := gitserver.AddrForKey(h, addrs, key); got[0] != want {
+				t.Fatalf("%s %s: got first replica %s, want %s", h, key, got[0], want)
+			}
+		}
+
+		if got := gitserver.AddrsForKey(h, addrs, "k", 0); len(got) != 1 {
+			t.Errorf("%s: got %v for 0 replicas, want 1 addr", h, got)
+		}
+		if got := gitserver.AddrsForKey(h, addrs, "k", 10); len(got) != len(addrs) {
+			t.Errorf("%s: got %v for 10 replicas, want all addrs", h, got)
+		}
+	}
+}
+
+func TestParseHashing(t *testing.T) {
+	for s, want := range map[string]gitserver.Hashing{
+		"":           gitserver.ModuloHashing,
+		"modulo":     gitserver.ModuloHashing,
+		"rendezvous": gitserver.RendezvousHashing,
+	} {
+		if got, ok := gitserver.ParseHashing(s); !ok || got != want {
+			t.Errorf("%q: got %q, %v, want %q", s, got, ok, want)
+		}
+	}
+	if _, ok := gitserver.ParseHashing("consistent"); ok {
+		t.Error("got ok for unknown hashing")
+	}
+}
+
+func TestClient_replicas(t *testing.T) {
+	addrs := []string{"gitserver-0", "gitserver-1", "gitserver-2"}
+	const repo = "github.com/foo/bar"
+	replicas := gitserver.AddrsForKey(gitserver.ModuloHashing, addrs, repo, 2)
+	primary, secondary := replicas[0], replicas[1]
+
+	var (
+		mu       sync.Mutex
+		requests []string
+		handlers map[string]func(r *http.Request) (*http.Response, error)
+	)
+	execResponse := func(body string) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(bytes.NewBufferString(body)),
+			Trailer:    http.Header{"X-Exec-Exit-Status": {"0"}},
+		}, nil
+	}
+	notFoundResponse := func(cloneInProgress bool) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusNotFound,
+			Body:       ioutil.NopCloser(bytes.NewBufferString(fmt.Sprintf(`{"cloneInProgress": %v}`, cloneInProgress))),
+		}, nil
+	}
+	connectionError := func(*http.Request) (*http.Response, error) {
+		return nil, errors.New("connection refused")
+	}
+
+	cli := &gitserver.Client{
+		Addrs:             func(ctx context.Context) []string { return addrs },
+		ReplicationFactor: func(ctx context.Context) int { return 2 },
+		HTTPClient: httpcli.DoerFunc(func(r *http.Request) (*http.Response, error) {
+			mu.Lock()
+			requests = append(requests, r.URL.Host+r.URL.Path)
+			h := handlers[r.URL.Host]
+			mu.Unlock()
+			if h == nil {
+				return nil, fmt.Errorf("unexpected request: %s", r.URL)
+			}
+			return h(r)
+		}),
+	}
+	output := func() (string, error) {
+		cmd := cli.Command("git", "rev-parse", "HEAD")
+		cmd.Repo = gitserver.Repo{Name: repo}
+		out, err := cmd.Output(context.Background())
+		return string(out), err
+	}
+	reset := func(h map[string]func(r *http.Request) (*http.Response, error)) {
+		mu.Lock()
+		defer mu.Unlock()
+		handlers, requests = h, nil
+	}
+	assertRequests := func(want ...string) {
+		t.Helper()
+		mu.Lock()
+		defer mu.Unlock()
+		sort.Strings(requests)
+		sort.Strings(want)
+		if !cmp.Equal(want, requests, cmpopts.EquateEmpty()) {
+			t.Errorf("requests mismatch (-want +got):\n%s", cmp.Diff(want, requests))
+		}
+	}
+
+	t.Run("clone in progress on primary", func(t *testing.T) {
+		reset(map[string]func(r *http.Request) (*http.Response, error){
+			primary:   func(*http.Request) (*http.Response, error) { return notFoundResponse(true) },
+			secondary: func(*http.Request) (*http.Response, error) { return execResponse("secondary") },
+		})
+		if out, err := output(); err != nil || out != "secondary" {
+			t.Fatalf("got %q, %v, want output from secondary", out, err)
+		}
+		assertRequests(primary+"/exec", secondary+"/exec")
+	})
+
+	t.Run("not cloned on any replica", func(t *testing.T) {
+		reset(map[string]func(r *http.Request) (*http.Response, error){
+			primary:   func(*http.Request) (*http.Response, error) { return notFoundResponse(true) },
+			secondary: func(*http.Request) (*http.Response, error) { return notFoundResponse(false) },
+		})
+		_, err := output()
+		if e, ok := err.(*vcs.RepoNotExistError); !ok || !e.CloneInProgress {
+			t.Fatalf("got error %v, want clone in progress from primary", err)
+		}
+	})
+
+	t.Run("primary down", func(t *testing.T) {
+		reset(map[string]func(r *http.Request) (*http.Response, error){
+			primary:   connectionError,
+			secondary: func(*http.Request) (*http.Response, error) { return execResponse("secondary") },
+		})
+		if out, err := output(); err != nil || out != "secondary" {
+			t.Fatalf("got %q, %v, want output from secondary", out, err)
+		}
+		assertRequests(primary+"/exec", secondary+"/exec")
+
+		// The primary is skipped while it is down.
+		if got := cli.AddrForRepo(context.Background(), repo); got != secondary {
+			t.Errorf("got addr %s, want %s while primary is down", got, secondary)
+		}
+		reset(handlers)
+		if out, err := output(); err != nil || out != "secondary" {
+			t.Fatalf("got %q, %v, want output from secondary", out, err)
+		}
+		assertRequests(secondary + "/exec")
+	})
+
+	t.Run("repo update on all replicas", func(t *testing.T) {
+		reset(map[string]func(r *http.Request) (*http.Response, error){
+			primary: connectionError,
+			secondary: func(*http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(bytes.NewBufferString(`{}`))}, nil
+			},
+		})
+		if _, err := cli.RequestRepoUpdate(context.Background(), gitserver.Repo{Name: repo}, 0); err != nil {
+			t.Fatal(err)
+		}
+		assertRequests(primary+"/repo-update", secondary+"/repo-update")
+	})
+}
+
+func TestClient_ListCloned_replicated(t *testing.T) {
+	addrs := []string{"gitserver-0", "gitserver-1", "gitserver-2"}
+	repos := []string{"repo-a", "repo-b", "repo-c", "repo-d"}
+	cli := &gitserver.Client{
+		Addrs:             func(ctx context.Context) []string { return addrs },
+		ReplicationFactor: func(ctx context.Context) int { return 2 },
+		HTTPClient: httpcli.DoerFunc(func(r *http.Request) (*http.Response, error) {
+			// Every gitserver has every repository.
+			return &http.Response{
+				Body: ioutil.NopCloser(bytes.NewBufferString(`["repo-a", "repo-b", "repo-c", "repo-d"]`)),
+			}, nil
+		}),
+	}
+
+	got, err := cli.ListCloned(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(got)
+	if !cmp.Equal(repos, got) {
+		t.Errorf("mismatch for (-want +got):\n%s", cmp.Diff(repos, got))
+	}
+}
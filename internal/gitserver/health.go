@@ -0,0 +1,85 @@
+package gitserver
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// addrDownDuration is how long a gitserver that failed to respond is
+// considered down. Requests go to other replicas meanwhile, if there are any.
+const addrDownDuration = 10 * time.Second
+
+var addrMarkedDownCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "src_gitserver_client_addr_marked_down",
+	Help: "Times that a gitserver was considered down after failing to respond.",
+}, []string{"addr"})
+
+func init() {
+	prometheus.MustRegister(addrMarkedDownCounter)
+}
+
+// addrHealth tracks the gitservers that failed to respond recently. The zero
+// value considers all gitservers up, and is safe for concurrent use.
+type addrHealth struct {
+	mu        sync.Mutex
+	downUntil map[string]time.Time
+
+	// now is set by tests.
+	now func() time.Time
+}
+
+func (h *addrHealth) timeNow() time.Time {
+	if h.now != nil {
+		return h.now()
+	}
+	return time.Now()
+}
+
+// markDown records that the gitserver at addr failed to respond.
+func (h *addrHealth) markDown(addr string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.downUntil == nil {
+		h.downUntil = map[string]time.Time{}
+	}
+	h.downUntil[addr] = h.timeNow().Add(addrDownDuration)
+	addrMarkedDownCounter.WithLabelValues(addr).Inc()
+}
+
+// markUp records that the gitserver at addr responded.
+func (h *addrHealth) markUp(addr string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.downUntil, addr)
+}
+
+// isDown reports whether the gitserver at addr failed to respond recently.
+// The caller must hold h.mu.
+func (h *addrHealth) isDown(addr string, now time.Time) bool {
+	until, ok := h.downUntil[addr]
+	return ok && now.Before(until)
+}
+
+// sort returns addrs with the addresses that are down moved after the ones
+// that are up, keeping their order otherwise.
+func (h *addrHealth) sort(addrs []string) []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.downUntil) == 0 || len(addrs) < 2 {
+		return addrs
+	}
+
+	now := h.timeNow()
+	up := addrs[:0:0]
+	var down []string
+	for _, addr := range addrs {
+		if h.isDown(addr, now) {
+			down = append(down, addr)
+		} else {
+			up = append(up, addr)
+		}
+	}
+	return append(up, down...)
+}
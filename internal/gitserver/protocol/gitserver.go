@@ -24,6 +24,41 @@ type ExecRequest struct {
 	Opt            *RemoteOpts `json:"opt"`
 }
 
+// BatchExecRequest is a request to execute several git commands inside a
+// git repository in a single round trip. The commands run in order.
+type BatchExecRequest struct {
+	Repo           api.RepoName `json:"repo"`
+	URL            string       `json:"url,omitempty"`
+	EnsureRevision string       `json:"ensureRevision"`
+
+	// Revision is resolved to a commit ID once, before any command runs.
+	// BatchExecCommit in the arguments of the commands is replaced by that
+	// commit ID, so that all commands see the same commit even if the
+	// revision changes while they run. If Revision is empty, the commands
+	// run as given.
+	Revision string `json:"revision,omitempty"`
+
+	// Commands are the arguments to git of each command, such as
+	// ["rev-parse", "HEAD"]. Only read-only commands are allowed.
+	Commands [][]string `json:"commands"`
+}
+
+// BatchExecCommit is replaced by the commit ID that the revision of a
+// BatchExecRequest resolved to in the arguments of its commands, as in
+// "{commit}:README.md".
+const BatchExecCommit = "{commit}"
+
+// BatchExecResult is the result of a command of a BatchExecRequest. The
+// response to a BatchExecRequest is a stream of JSON-encoded
+// BatchExecResults, one for each command in order. The commit ID that the
+// revision resolved to is in the X-Exec-Commit response header.
+type BatchExecResult struct {
+	Stdout     []byte `json:"stdout"`
+	Stderr     string `json:"stderr"`
+	ExitStatus int    `json:"exitStatus"`
+	Error      string `json:"error,omitempty"` // an error running the command, as opposed to a non-zero exit status
+}
+
 // RemoteOpts configures interactions with a remote repository.
 type RemoteOpts struct {
 	SSH   *SSHConfig   `json:"ssh"`   // SSH configuration for communication with the remote
@@ -73,6 +108,10 @@ type NotFoundPayload struct {
 
 	// CloneProgress is a progress message from the running clone command.
 	CloneProgress string `json:"cloneProgress,omitempty"`
+
+	// RevisionNotFound is true if the repository is cloned, but the revision
+	// of a BatchExecRequest does not exist.
+	RevisionNotFound bool `json:"revisionNotFound,omitempty"`
 }
 
 // IsRepoCloneableRequest is a request to determine if a repo is cloneable.
@@ -122,6 +161,31 @@ type RepoInfo struct {
 	// recloned automatically, so this time is likely to move forward
 	// periodically.
 	CloneTime *time.Time
+
+	// Maintenance is the status of the scheduled git maintenance of the
+	// repository. It is nil if the repository is not cloned.
+	Maintenance *RepoMaintenance
+}
+
+// RepoMaintenance is the status of the scheduled git maintenance of a
+// repository, which repacks it and writes commit-graph and multi-pack-index
+// files to keep git commands fast.
+type RepoMaintenance struct {
+	Packs             int  // the number of packfiles
+	LooseObjects      int  // an estimate of the number of loose objects
+	HasBitmap         bool // whether a packfile has a reachability bitmap
+	HasMultiPackIndex bool // whether the repository has a multi-pack-index
+	HasCommitGraph    bool // whether the repository has a commit-graph
+
+	// Tasks is the last run of each maintenance task, by task name.
+	Tasks map[string]*RepoMaintenanceTask
+}
+
+// RepoMaintenanceTask is the last run of a maintenance task on a repository.
+type RepoMaintenanceTask struct {
+	LastRun  time.Time
+	Duration time.Duration
+	Error    string // empty if the task succeeded
 }
 
 // RepoInfoResponse is the response to a repository information request
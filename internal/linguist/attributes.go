@@ -0,0 +1,110 @@
+package linguist
+
+import (
+	"bufio"
+	"bytes"
+	"path"
+	"strings"
+
+	"github.com/gobwas/glob"
+)
+
+// GitAttributesPath is the path of the .gitattributes file at the root of a
+// repository. Only the root .gitattributes file is considered.
+const GitAttributesPath = ".gitattributes"
+
+// generatedAttribute is the attribute that overrides whether Linguist
+// considers a file generated.
+const generatedAttribute = "linguist-generated"
+
+// Attributes are the linguist-generated attributes set in a .gitattributes
+// file.
+type Attributes struct {
+	rules []attributeRule
+}
+
+type attributeRule struct {
+	pattern glob.Glob
+	base    bool // whether pattern matches base names instead of paths
+	value   *bool
+}
+
+// ParseAttributes parses the linguist-generated attributes of the
+// .gitattributes file with the given content. Lines with invalid patterns
+// are ignored, like git does.
+func ParseAttributes(data []byte) *Attributes {
+	var a Attributes
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		var (
+			value *bool
+			found bool
+		)
+		for _, attr := range fields[1:] {
+			switch attr {
+			case generatedAttribute, generatedAttribute + "=true":
+				value, found = boolPtr(true), true
+			case "-" + generatedAttribute, generatedAttribute + "=false":
+				value, found = boolPtr(false), true
+			case "!" + generatedAttribute:
+				value, found = nil, true
+			}
+		}
+		if !found {
+			continue
+		}
+
+		for _, pattern := range attributePatterns(fields[0]) {
+			g, err := glob.Compile(strings.TrimPrefix(pattern, "/"), '/')
+			if err != nil {
+				continue
+			}
+			a.rules = append(a.rules, attributeRule{
+				pattern: g,
+				base:    !strings.Contains(pattern, "/"),
+				value:   value,
+			})
+		}
+	}
+	return &a
+}
+
+// attributePatterns returns the globs equivalent to the .gitattributes
+// pattern p. A leading "**/" also matches files at the root.
+func attributePatterns(p string) []string {
+	if strings.HasPrefix(p, "**/") {
+		return []string{p, "/" + strings.TrimPrefix(p, "**/")}
+	}
+	return []string{p}
+}
+
+// Generated returns whether the file at path is marked as generated by a
+// linguist-generated attribute. If no attribute applies to the file, ok is
+// false. A nil *Attributes has no attributes.
+func (a *Attributes) Generated(name string) (generated, ok bool) {
+	if a == nil {
+		return false, false
+	}
+	// The last matching line takes precedence, like in git.
+	for i := len(a.rules) - 1; i >= 0; i-- {
+		r := a.rules[i]
+		subject := name
+		if r.base {
+			subject = path.Base(name)
+		}
+		if r.pattern.Match(subject) {
+			if r.value == nil {
+				return false, false
+			}
+			return *r.value, true
+		}
+	}
+	return false, false
+}
+
+func boolPtr(b bool) *bool { return &b }
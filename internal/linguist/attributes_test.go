@@ -0,0 +1,39 @@
+package linguist
+
+import "testing"
+
+func TestAttributes_Generated(t *testing.T) {
+	a := ParseAttributes([]byte(`
+# Generated clients
+*.js text eol=lf
+api/*.ts linguist-generated
+/schema/** linguist-generated=true
+schema/README.md -linguist-generated
+**/gen/*.go linguist-generated
+docs/*.md !linguist-generated
+docs/[ linguist-generated
+`))
+
+	type result struct{ generated, ok bool }
+	tests := map[string]result{
+		"main.js":               {},
+		"api/client.ts":         {true, true},
+		"web/api/client.ts":     {},
+		"schema/a/b.json":       {true, true},
+		"schema/README.md":      {false, true},
+		"gen/types.go":          {true, true},
+		"internal/gen/types.go": {true, true},
+		"docs/index.md":         {},
+	}
+	for path, want := range tests {
+		generated, ok := a.Generated(path)
+		if got := (result{generated, ok}); got != want {
+			t.Errorf("Generated(%q) = %+v, want %+v", path, got, want)
+		}
+	}
+
+	var nilAttributes *Attributes
+	if _, ok := nilAttributes.Generated("api/client.ts"); ok {
+		t.Error("got an attribute from nil attributes")
+	}
+}
@@ -0,0 +1,93 @@
+// Package linguist detects generated files like GitHub Linguist does: with
+// heuristics on their paths and contents, and with the linguist-generated
+// attribute of .gitattributes.
+package linguist
+
+import (
+	"bytes"
+	"path"
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/internal/lazyregexp"
+)
+
+// generatedPathPatterns match the paths of files that are usually generated.
+var generatedPathPatterns = []string{
+	// Minified files and source maps
+	`[.-]min\.(js|css)$`,
+	`\.(js|css)\.map$`,
+
+	// Protocol buffers
+	`\.pb\.go$`,
+	`\.pb\.gw\.go$`,
+	`\.pb\.(cc|h)$`,
+	`_pb2(_grpc)?\.py$`,
+	`_pb\.(js|rb)$`,
+
+	// Other code generators
+	`\.designer\.(cs|vb)$`,
+	`\.(g|freezed)\.dart$`,
+	`\.gen\.go$`,
+	`[._]generated\.[^/]+$`,
+	`bindata\.go$`,
+
+	// Lock files of package managers
+	`(^|/)(package-lock\.json|npm-shrinkwrap\.json|yarn\.lock|pnpm-lock\.yaml|composer\.lock|Cargo\.lock|Gopkg\.lock|glide\.lock|Pipfile\.lock|poetry\.lock|Gemfile\.lock|go\.sum)$`,
+}
+
+// GeneratedPathPattern is a regexp matching the paths of files that are
+// usually generated.
+var GeneratedPathPattern = "(" + strings.Join(generatedPathPatterns, ")|(") + ")"
+
+// GeneratedMarkerPattern is a regexp matching the lines that mark a file as
+// generated, such as the "Code generated ... DO NOT EDIT." comment of Go (see
+// https://golang.org/s/generatedcode) and the "@generated" annotation.
+const GeneratedMarkerPattern = `(?m)^\W*(Code generated .* DO NOT EDIT\.|@generated\b)`
+
+var (
+	generatedPathRegexp   = lazyregexp.New(GeneratedPathPattern)
+	generatedMarkerRegexp = lazyregexp.New(GeneratedMarkerPattern)
+)
+
+// minifiedLineLength is the average line length above which a JavaScript or
+// CSS file is considered minified. It is the same as Linguist's.
+const minifiedLineLength = 110
+
+// IsGeneratedPath reports whether the file at path is usually generated,
+// based on its path alone.
+func IsGeneratedPath(path string) bool {
+	return generatedPathRegexp.MatchString(path)
+}
+
+// IsGenerated reports whether the file at path with the given content looks
+// generated: its path is that of a usually generated file, it contains a line
+// matching GeneratedMarkerPattern, or it is minified JavaScript or CSS.
+//
+// IsGenerated does not consider .gitattributes. See ParseAttributes.
+func IsGenerated(path string, content []byte) bool {
+	if IsGeneratedPath(path) {
+		return true
+	}
+	// Most files have no marker, so check for the literals before running
+	// the regexp.
+	if (bytes.Contains(content, []byte("DO NOT EDIT")) || bytes.Contains(content, []byte("@generated"))) && generatedMarkerRegexp.Match(content) {
+		return true
+	}
+	return isMinified(path, content)
+}
+
+// isMinified reports whether the file at path is JavaScript or CSS with long
+// lines on average.
+func isMinified(name string, content []byte) bool {
+	switch path.Ext(name) {
+	case ".js", ".mjs", ".css":
+	default:
+		return false
+	}
+	content = bytes.TrimRight(content, "\n")
+	if len(content) == 0 {
+		return false
+	}
+	lines := bytes.Count(content, []byte{'\n'}) + 1
+	return len(content)/lines > minifiedLineLength
+}
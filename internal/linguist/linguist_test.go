@@ -0,0 +1,85 @@
+package linguist
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIsGeneratedPath(t *testing.T) {
+	tests := map[string]bool{
+		"main.go":                 false,
+		"web/app.js":              false,
+		"dist/app.min.js":         true,
+		"dist/app-min.css":        true,
+		"dist/app.js.map":         true,
+		"schema/schema.pb.go":     true,
+		"api/api_pb2_grpc.py":     true,
+		"api/client.generated.ts": true,
+		"internal/db/bindata.go":  true,
+		"yarn.lock":               true,
+		"web/package-lock.json":   true,
+		"cmd/generated/main.go":   false,
+		"package-lock.json.go":    false,
+	}
+	for path, want := range tests {
+		if got := IsGeneratedPath(path); got != want {
+			t.Errorf("IsGeneratedPath(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestIsGenerated(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		content string
+		want    bool
+	}{
+		{
+			name:    "plain",
+			path:    "main.go",
+			content: "package main\n\nfunc main() {}\n",
+		},
+		{
+			name:    "path",
+			path:    "schema.pb.go",
+			content: "package schema\n",
+			want:    true,
+		},
+		{
+			name:    "go marker",
+			path:    "schema.go",
+			content: "// Code generated by go-bindata. DO NOT EDIT.\n\npackage schema\n",
+			want:    true,
+		},
+		{
+			name:    "generated annotation",
+			path:    "Relay.js",
+			content: "/**\n * @generated SignedSource<<abc>>\n */\n",
+			want:    true,
+		},
+		{
+			name:    "marker in prose",
+			path:    "README.md",
+			content: "Files with @generated comments are skipped.\n",
+		},
+		{
+			name:    "minified",
+			path:    "bundle.js",
+			content: strings.Repeat("var a=1;", 20) + "\n" + strings.Repeat("var b=2;", 20),
+			want:    true,
+		},
+		{
+			name:    "long lines in other languages",
+			path:    "data.txt",
+			content: strings.Repeat("x", 200),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := IsGenerated(test.path, []byte(test.content)); got != test.want {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -2,12 +2,41 @@ package backend
 
 import (
 	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
 	"sort"
+	"strings"
 
 	"github.com/google/zoekt"
+	"github.com/inconshreveable/log15"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
 	"github.com/sourcegraph/sourcegraph/schema"
 )
 
+func init() {
+	conf.ContributeValidator(func(c conf.Unified) (problems conf.Problems) {
+		if c.ExperimentalFeatures == nil {
+			return nil
+		}
+		for _, rule := range c.ExperimentalFeatures.SearchIndexRevisions {
+			if _, err := regexp.Compile(rule.RepoPattern); err != nil {
+				problems = append(problems, conf.NewSiteProblem(fmt.Sprintf("search.index.revisions: not a valid regexp: %s. See the valid syntax: https://golang.org/pkg/regexp/", rule.RepoPattern)))
+			}
+			for _, glob := range []string{rule.RefGlob, rule.LatestTags} {
+				if _, err := path.Match(glob, ""); err != nil {
+					problems = append(problems, conf.NewSiteProblem(fmt.Sprintf("search.index.revisions: not a valid glob: %s", glob)))
+				}
+			}
+		}
+		return problems
+	})
+}
+
+// maxBranches is the maximum number of branches zoekt can index for a
+// repository.
+const maxBranches = 64
+
 // zoektIndexOptions are options which change what we index for a
 // repository. Everytime a repository is indexed by zoekt this structure is
 // fetched. See getIndexOptions in the zoekt codebase.
@@ -26,13 +55,33 @@ type zoektIndexOptions struct {
 	Branches []zoekt.RepositoryBranch `json:",omitempty"`
 }
 
+// RepoRefs are the branches and tags of a repository, which the
+// search.index.revisions rules are resolved against.
+type RepoRefs struct {
+	// Branches are the branches of the repository, by short name such as
+	// "release/1.0", ordered from the most recently committed.
+	Branches []zoekt.RepositoryBranch
+
+	// Tags are the tags of the repository, by short name such as "v1.0",
+	// ordered from the most recently created.
+	Tags []zoekt.RepositoryBranch
+}
+
 // GetIndexOptions returns a json blob for consumption by
 // sourcegraph-zoekt-indexserver. It is for repoName based on site settings c.
 //
 // getVersion is used to resolve revisions for repoName. If it fails, the
 // error is returned. If the revision is missing, an empty string should be
 // returned rather than an error.
-func GetIndexOptions(c *schema.SiteConfiguration, repoName string, getVersion func(branch string) (string, error)) ([]byte, error) {
+//
+// listRefs is used to list the branches and tags of repoName, and is only
+// called if a search.index.revisions rule applies to repoName. If it fails,
+// the error is returned.
+//
+// At most maxBranches branches are indexed: HEAD, then the configured
+// revisions, then the revisions resolved by rules. The revisions that don't
+// fit are logged.
+func GetIndexOptions(c *schema.SiteConfiguration, repoName string, getVersion func(branch string) (string, error), listRefs func() (*RepoRefs, error)) ([]byte, error) {
 	o := &zoektIndexOptions{
 		LargeFiles: c.SearchLargeFiles,
 		Symbols:    getBoolPtr(c.SearchIndexSymbolsEnabled, true),
@@ -43,27 +92,60 @@ func GetIndexOptions(c *schema.SiteConfiguration, repoName string, getVersion fu
 		return json.Marshal(o)
 	}
 
-	// Set of branch names. Always index HEAD
-	branches := map[string]struct{}{"HEAD": {}}
+	// The branches to index by priority, with their version if already
+	// known. Always index HEAD. Zoekt can only index maxBranches branches, so
+	// the explicitly configured revisions are preferred over the revisions
+	// resolved by search.index.revisions rules.
+	var (
+		branches []zoekt.RepositoryBranch
+		seen     = map[string]bool{}
+	)
+	add := func(name, version string) {
+		if !seen[name] {
+			seen[name] = true
+			branches = append(branches, zoekt.RepositoryBranch{Name: name, Version: version})
+		}
+	}
+	add("HEAD", "")
 
 	if c.ExperimentalFeatures != nil {
 		for _, vc := range c.ExperimentalFeatures.VersionContexts {
 			for _, rev := range vc.Revisions {
 				if rev.Repo == repoName && rev.Rev != "" {
-					branches[rev.Rev] = struct{}{}
+					add(rev.Rev, "")
 				}
 			}
 		}
 
 		for _, rev := range c.ExperimentalFeatures.SearchIndexBranches[repoName] {
-			branches[rev] = struct{}{}
+			add(rev, "")
+		}
+
+		if rules := revisionsRules(c.ExperimentalFeatures.SearchIndexRevisions, repoName); len(rules) > 0 {
+			refs, err := listRefs()
+			if err != nil {
+				return nil, err
+			}
+			for _, b := range resolveRevisionsRules(rules, refs) {
+				add(b.Name, b.Version)
+			}
 		}
 	}
 
-	for branch := range branches {
-		v, err := getVersion(branch)
-		if err != nil {
-			return nil, err
+	var dropped []string
+	for _, b := range branches {
+		if len(o.Branches) == maxBranches {
+			dropped = append(dropped, b.Name)
+			continue
+		}
+
+		v := b.Version
+		if v == "" {
+			var err error
+			v, err = getVersion(b.Name)
+			if err != nil {
+				return nil, err
+			}
 		}
 
 		// If we failed to resolve a branch, skip it
@@ -72,10 +154,13 @@ func GetIndexOptions(c *schema.SiteConfiguration, repoName string, getVersion fu
 		}
 
 		o.Branches = append(o.Branches, zoekt.RepositoryBranch{
-			Name:    branch,
+			Name:    b.Name,
 			Version: v,
 		})
 	}
+	if len(dropped) > 0 {
+		log15.Warn("Too many revisions to index, not indexing some of them.", "repo", repoName, "limit", maxBranches, "dropped", dropped)
+	}
 
 	sort.Slice(o.Branches, func(i, j int) bool {
 		a, b := o.Branches[i].Name, o.Branches[j].Name
@@ -92,12 +177,63 @@ func GetIndexOptions(c *schema.SiteConfiguration, repoName string, getVersion fu
 		o.Branches = nil
 	}
 
-	// Zoekt has a limit of 64 branches
-	if len(o.Branches) > 64 {
-		o.Branches = o.Branches[:64]
+	return json.Marshal(o)
+}
+
+// revisionsRules returns the rules which apply to repoName.
+func revisionsRules(rules []*schema.SearchIndexRevisionsRule, repoName string) []*schema.SearchIndexRevisionsRule {
+	var matching []*schema.SearchIndexRevisionsRule
+	for _, rule := range rules {
+		re, err := regexp.Compile(rule.RepoPattern)
+		if err != nil {
+			// Skip if there's an error. A user-visible validation error will appear due to the ContributeValidator call above.
+			log15.Error("Site config: unable to compile search.index.revisions regexp", "regexp", rule.RepoPattern)
+			continue
+		}
+		if re.MatchString(repoName) {
+			matching = append(matching, rule)
+		}
 	}
+	return matching
+}
 
-	return json.Marshal(o)
+// resolveRevisionsRules returns the branches and tags in refs which are
+// matched by rules, in the order of the rules. The refs matched by a rule are
+// in the order of refs, so the most recent refs come first.
+func resolveRevisionsRules(rules []*schema.SearchIndexRevisionsRule, refs *RepoRefs) []zoekt.RepositoryBranch {
+	var resolved []zoekt.RepositoryBranch
+	for _, rule := range rules {
+		if rule.RefGlob != "" {
+			glob, candidates := rule.RefGlob, refs.Branches
+			if strings.HasPrefix(glob, "refs/tags/") {
+				glob, candidates = strings.TrimPrefix(glob, "refs/tags/"), refs.Tags
+			} else {
+				glob = strings.TrimPrefix(glob, "refs/heads/")
+			}
+			for _, ref := range candidates {
+				if ok, _ := path.Match(glob, ref.Name); ok {
+					resolved = append(resolved, ref)
+				}
+			}
+		}
+
+		if rule.LatestTags != "" {
+			count := rule.LatestTagsCount
+			if count <= 0 {
+				count = 1
+			}
+			for _, tag := range refs.Tags {
+				if count == 0 {
+					break
+				}
+				if ok, _ := path.Match(rule.LatestTags, tag.Name); ok {
+					resolved = append(resolved, tag)
+					count--
+				}
+			}
+		}
+	}
+	return resolved
 }
 
 func getBoolPtr(b *bool, default_ bool) bool {
@@ -28,6 +28,13 @@ func TestGetIndexOptions(t *testing.T) {
 		c.ExperimentalFeatures.SearchIndexBranches = b
 		return c
 	}
+	withRules := func(c schema.SiteConfiguration, rules ...*schema.SearchIndexRevisionsRule) schema.SiteConfiguration {
+		if c.ExperimentalFeatures == nil {
+			c.ExperimentalFeatures = &schema.ExperimentalFeatures{}
+		}
+		c.ExperimentalFeatures.SearchIndexRevisions = rules
+		return c
+	}
 
 	type caseT struct {
 		name string
@@ -142,6 +149,77 @@ func TestGetIndexOptions(t *testing.T) {
 				{Name: "c", Version: "!c"},
 			},
 		},
+	}, {
+		name: "rules ref glob",
+		conf: withRules(schema.SiteConfiguration{}, &schema.SearchIndexRevisionsRule{RepoPattern: "^re", RefGlob: "release/*"}),
+		repo: "repo",
+		want: zoektIndexOptions{
+			Symbols: true,
+			Branches: []zoekt.RepositoryBranch{
+				{Name: "HEAD", Version: "!HEAD"},
+				{Name: "release/1", Version: "#release/1"},
+				{Name: "release/2", Version: "#release/2"},
+			},
+		},
+	}, {
+		name: "rules tag glob",
+		conf: withRules(schema.SiteConfiguration{}, &schema.SearchIndexRevisionsRule{RepoPattern: "repo", RefGlob: "refs/tags/v[12]"}),
+		repo: "repo",
+		want: zoektIndexOptions{
+			Symbols: true,
+			Branches: []zoekt.RepositoryBranch{
+				{Name: "HEAD", Version: "!HEAD"},
+				{Name: "v1", Version: "#v1"},
+				{Name: "v2", Version: "#v2"},
+			},
+		},
+	}, {
+		name: "rules latest tags",
+		conf: withRules(schema.SiteConfiguration{}, &schema.SearchIndexRevisionsRule{RepoPattern: "repo", LatestTags: "v*", LatestTagsCount: 2}),
+		repo: "repo",
+		want: zoektIndexOptions{
+			Symbols: true,
+			Branches: []zoekt.RepositoryBranch{
+				{Name: "HEAD", Version: "!HEAD"},
+				{Name: "v2", Version: "#v2"},
+				{Name: "v3", Version: "#v3"},
+			},
+		},
+	}, {
+		name: "rules latest tag default count",
+		conf: withRules(schema.SiteConfiguration{}, &schema.SearchIndexRevisionsRule{RepoPattern: "repo", LatestTags: "v*"}),
+		repo: "repo",
+		want: zoektIndexOptions{
+			Symbols: true,
+			Branches: []zoekt.RepositoryBranch{
+				{Name: "HEAD", Version: "!HEAD"},
+				{Name: "v3", Version: "#v3"},
+			},
+		},
+	}, {
+		name: "rules other repo",
+		conf: withRules(schema.SiteConfiguration{}, &schema.SearchIndexRevisionsRule{RepoPattern: "^other$", RefGlob: "*"}),
+		repo: "repo",
+		want: zoektIndexOptions{
+			Symbols: true,
+			Branches: []zoekt.RepositoryBranch{
+				{Name: "HEAD", Version: "!HEAD"},
+			},
+		},
+	}, {
+		name: "rules and conf index branches",
+		conf: withRules(
+			withBranches(schema.SiteConfiguration{}, map[string][]string{"repo": {"release/1"}}),
+			&schema.SearchIndexRevisionsRule{RepoPattern: "repo", RefGlob: "release/*"}),
+		repo: "repo",
+		want: zoektIndexOptions{
+			Symbols: true,
+			Branches: []zoekt.RepositoryBranch{
+				{Name: "HEAD", Version: "!HEAD"},
+				{Name: "release/1", Version: "!release/1"},
+				{Name: "release/2", Version: "#release/2"},
+			},
+		},
 	}}
 
 	{
@@ -168,11 +246,53 @@ func TestGetIndexOptions(t *testing.T) {
 		})
 	}
 
+	{
+		// Generate case for configured branches taking precedence over rules
+		// when there are more than 64 branches
+		var branches []string
+		want := []zoekt.RepositoryBranch{{Name: "HEAD", Version: "!HEAD"}}
+		for i := 0; i < 62; i++ {
+			branches = append(branches, fmt.Sprintf("%.2d", i))
+			want = append(want, zoekt.RepositoryBranch{
+				Name:    fmt.Sprintf("%.2d", i),
+				Version: fmt.Sprintf("!%.2d", i),
+			})
+		}
+		want = append(want, zoekt.RepositoryBranch{Name: "release/1", Version: "#release/1"})
+		cases = append(cases, caseT{
+			name: "limit branches with rules",
+			conf: withRules(
+				withBranches(schema.SiteConfiguration{}, map[string][]string{"repo": branches}),
+				&schema.SearchIndexRevisionsRule{RepoPattern: "repo", RefGlob: "release/*"}),
+			repo: "repo",
+			want: zoektIndexOptions{
+				Symbols:  true,
+				Branches: want,
+			},
+		})
+	}
+
+	listRefs := func() (*RepoRefs, error) {
+		return &RepoRefs{
+			Branches: []zoekt.RepositoryBranch{
+				{Name: "main", Version: "#main"},
+				{Name: "release/1", Version: "#release/1"},
+				{Name: "release/2", Version: "#release/2"},
+			},
+			Tags: []zoekt.RepositoryBranch{
+				{Name: "v3", Version: "#v3"},
+				{Name: "nightly", Version: "#nightly"},
+				{Name: "v2", Version: "#v2"},
+				{Name: "v1", Version: "#v1"},
+			},
+		}, nil
+	}
+
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
 			b, err := GetIndexOptions(&tc.conf, tc.repo, func(branch string) (string, error) {
 				return "!" + branch, nil
-			})
+			}, listRefs)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -247,7 +367,7 @@ func TestGetIndexOptions_getVersion(t *testing.T) {
 
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
-			b, err := GetIndexOptions(&conf, "repo", tc.f)
+			b, err := GetIndexOptions(&conf, "repo", tc.f, nil)
 			if err != tc.wantErr {
 				t.Fatalf("expected error %v, got body %s and error %v", tc.wantErr, b, err)
 			}
@@ -10,6 +10,7 @@ var allFields = map[string]struct{}{
 	"g":                     empty,
 	FieldFile:               empty,
 	"f":                     empty,
+	FieldFileSize:           empty,
 	FieldFork:               empty,
 	FieldArchived:           empty,
 	FieldLang:               empty,
@@ -18,6 +19,10 @@ var allFields = map[string]struct{}{
 	FieldType:               empty,
 	FieldPatternType:        empty,
 	FieldContent:            empty,
+	FieldRepoDescription:    empty,
+	FieldRepoTopic:          empty,
+	FieldRepoLanguage:       empty,
+	FieldRepoSize:           empty,
 	FieldVisibility:         empty,
 	FieldRepoHasFile:        empty,
 	FieldRepoHasCommitAfter: empty,
@@ -33,6 +38,7 @@ var allFields = map[string]struct{}{
 	FieldIndex:              empty,
 	FieldCount:              empty,
 	FieldStable:             empty,
+	FieldRank:               empty,
 	FieldMax:                empty,
 	FieldTimeout:            empty,
 	FieldReplace:            empty,
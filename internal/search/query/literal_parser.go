@@ -242,11 +242,11 @@ loop:
 			// Caller advances.
 			break loop
 		case p.matchUnaryKeyword(NOT):
-			parameter, err := p.parseNegatedLeafNode()
+			negated, err := p.parseNegated()
 			if err != nil {
 				return nil, err
 			}
-			nodes = append(nodes, parameter)
+			nodes = append(nodes, negated...)
 		default:
 			parameter, ok, err := p.ParseParameter()
 			if err != nil {
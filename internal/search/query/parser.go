@@ -300,6 +300,85 @@ func (p *parser) parseNegatedLeafNode() (Node, error) {
 	return parameter, nil
 }
 
+// parseNegated parses `NOT leaf` or `NOT (expression)`. A negated group is
+// rewritten so that only leaf nodes are negated, see negate.
+func (p *parser) parseNegated() ([]Node, error) {
+	start := p.pos
+	_ = p.expect(NOT)
+	if err := p.skipSpaces(); err != nil {
+		return nil, err
+	}
+
+	if !p.match(LPAREN) || isSet(p.heuristics, allowDanglingParens) {
+		p.pos = start
+		node, err := p.parseNegatedLeafNode()
+		if err != nil {
+			return nil, err
+		}
+		return []Node{node}, nil
+	}
+
+	_ = p.expect(LPAREN) // Guaranteed to succeed.
+	p.balanced++
+	p.heuristics |= disambiguated
+	result, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	return negate(string(p.buf[start:p.pos]), result)
+}
+
+// negate pushes a negation through nodes using De Morgan's laws, so that the
+// resulting expression only contains negated leaf nodes. For example,
+//
+// NOT (file:a or (lang:go and foo)) => -file:a and (-lang:go or NOT foo)
+//
+// group is the input of the negated group, which errors about the parameters
+// that can't be negated refer to.
+// Nodes are implicitly and-ed together, so their negations are or-ed.
+func negate(group string, nodes []Node) ([]Node, error) {
+	var result []Node
+	for _, node := range nodes {
+		switch v := node.(type) {
+		case Parameter:
+			if v.Value == "" {
+				// Empty group.
+				continue
+			}
+			if !isNegatable(v.Field) {
+				return nil, &UnsupportedError{Msg: fmt.Sprintf("field %q does not support negation in the group %s", v.Field, group)}
+			}
+			v.Negated = !v.Negated
+			result = append(result, v)
+		case Pattern:
+			v.Negated = !v.Negated
+			result = append(result, v)
+		case Operator:
+			switch v.Kind {
+			case And:
+				operands, err := negate(group, v.Operands)
+				if err != nil {
+					return nil, err
+				}
+				result = append(result, operands...)
+			case Or:
+				var operands []Node
+				for _, operand := range v.Operands {
+					negated, err := negate(group, []Node{operand})
+					if err != nil {
+						return nil, err
+					}
+					operands = append(operands, negated...)
+				}
+				result = append(result, newOperator(operands, And)...)
+			case Concat:
+				return nil, &UnsupportedError{Msg: fmt.Sprintf("cannot negate the sequence of search patterns %s, try quoting the patterns", v.String())}
+			}
+		}
+	}
+	return newOperator(result, Or), nil
+}
+
 // ScanDelimited takes a delimited (e.g., quoted) value for some arbitrary
 // delimiter, returning the undelimited value, and the end position of the
 // original delimited value (i.e., including quotes). `\` is treated as an
@@ -364,9 +443,9 @@ loop:
 }
 
 // ScanField scans an optional '-' at the beginning of a string, and then scans
-// one or more alphabetic characters until it encounters a ':', in which case it
-// returns the value before the colon and its length. In all other cases it
-// returns the empty string and zero length.
+// one or more alphabetic characters or dots until it encounters a ':', in
+// which case it returns the value before the colon and its length. In all
+// other cases it returns the empty string and zero length.
 func ScanField(buf []byte) (string, int) {
 	var count int
 	var r rune
@@ -389,7 +468,7 @@ func ScanField(buf []byte) (string, int) {
 	success := false
 	for len(buf) > 0 {
 		r = next()
-		if strings.ContainsRune(allowed, r) {
+		if strings.ContainsRune(allowed, r) || (r == '.' && result[len(result)-1] != '-') {
 			result = append(result, r)
 			continue
 		}
@@ -570,7 +649,7 @@ func (p *parser) ParsePattern() Pattern {
 
 // ParseParameter returns a leaf node corresponding to the syntax
 // (-?)field:<string> where : matches the first encountered colon, and field
-// must match ^[a-zA-Z]+ and be allowed by allFields. Field may optionally
+// must match ^[a-zA-Z][a-zA-Z.]* and be allowed by allFields. Field may optionally
 // be preceded by '-' which means the parameter is negated.
 func (p *parser) ParseParameter() (Parameter, bool, error) {
 	start := p.pos
@@ -736,11 +815,11 @@ loop:
 			// Caller advances.
 			break loop
 		case p.matchUnaryKeyword(NOT):
-			parameter, err := p.parseNegatedLeafNode()
+			negated, err := p.parseNegated()
 			if err != nil {
 				return nil, err
 			}
-			nodes = append(nodes, parameter)
+			nodes = append(nodes, negated...)
 		default:
 			parameter, ok, err := p.ParseParameter()
 			if err != nil {
@@ -196,6 +196,27 @@ func TestScanField(t *testing.T) {
 				Advance: 6,
 			},
 		},
+		{
+			Input: "-repo.topic:legacy",
+			Want: value{
+				Field:   "-repo.topic",
+				Advance: 12,
+			},
+		},
+		{
+			Input: ".repo:",
+			Want: value{
+				Field:   "",
+				Advance: 0,
+			},
+		},
+		{
+			Input: "-.repo:",
+			Want: value{
+				Field:   "",
+				Advance: 0,
+			},
+		},
 		{
 			Input: "-repo:",
 			Want: value{
@@ -745,6 +766,46 @@ func TestParse(t *testing.T) {
 			WantGrammar:   `(and "file:(a)" "file:(b)")`,
 			WantHeuristic: Same,
 		},
+		{
+			Input:         `not (file:a or file:b)`,
+			WantGrammar:   `(and "-file:a" "-file:b")`,
+			WantHeuristic: Same,
+		},
+		{
+			Input:         `(repo:foo or repo:bar) and not (file:test or lang:markdown)`,
+			WantGrammar:   `(and (or "repo:foo" "repo:bar") "-file:test" "-lang:markdown")`,
+			WantHeuristic: Same,
+		},
+		{
+			Input:         `not (file:a or (lang:go and foo))`,
+			WantGrammar:   `(and "-file:a" (or "-lang:go" "NOT foo"))`,
+			WantHeuristic: Same,
+		},
+		{
+			Input:         `not (foo and not bar)`,
+			WantGrammar:   `(or "NOT foo" "bar")`,
+			WantHeuristic: Same,
+		},
+		{
+			Input:         `not (a b)`,
+			WantGrammar:   Spec(`cannot negate the sequence of search patterns (concat "a" "b"), try quoting the patterns`),
+			WantHeuristic: Same,
+		},
+		{
+			Input:         `not (count:10 foo)`,
+			WantGrammar:   Spec(`field "count" does not support negation in the group not (count:10 foo)`),
+			WantHeuristic: Same,
+		},
+		{
+			Input:         `repo:foo not (file:a or (TYPE:diff and foo))`,
+			WantGrammar:   Spec(`field "TYPE" does not support negation in the group not (file:a or (TYPE:diff and foo))`),
+			WantHeuristic: Same,
+		},
+		{
+			Input:         `not (author:a or r:b)`,
+			WantGrammar:   `(and "-author:a" "-r:b")`,
+			WantHeuristic: Same,
+		},
 		// Fringe tests cases at the boundary of heuristics and invalid syntax.
 		{
 			Input:         `(0(F)(:())(:())(<0)0()`,
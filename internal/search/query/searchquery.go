@@ -17,6 +17,7 @@ const (
 	FieldRepo               = "repo"
 	FieldRepoGroup          = "repogroup"
 	FieldFile               = "file"
+	FieldFileSize           = "file.size"
 	FieldFork               = "fork"
 	FieldArchived           = "archived"
 	FieldLang               = "lang"
@@ -27,6 +28,12 @@ const (
 	FieldContent            = "content"
 	FieldVisibility         = "visibility"
 
+	// Fields of the metadata of repositories on their code host:
+	FieldRepoDescription = "repo.description"
+	FieldRepoTopic       = "repo.topic"
+	FieldRepoLanguage    = "repo.language"
+	FieldRepoSize        = "repo.size"
+
 	// For diff and commit search only:
 	FieldBefore    = "before"
 	FieldAfter     = "after"
@@ -38,6 +45,7 @@ const (
 	FieldIndex     = "index"
 	FieldCount     = "count"  // Searches that specify `count:` will fetch at least that number of results, or the full result set
 	FieldStable    = "stable" // Forces search to return a stable result ordering (currently limited to file content matches).
+	FieldRank      = "rank"   // Orders results by ranking signals such as recency, repository priority and stars instead of by name.
 	FieldMax       = "max"    // Deprecated alias for count
 	FieldTimeout   = "timeout"
 	FieldReplace   = "replace"
@@ -55,6 +63,7 @@ var (
 			FieldRepo:        regexpNegatableFieldType,
 			FieldRepoGroup:   {Literal: types.StringType, Quoted: types.StringType, Singular: true},
 			FieldFile:        regexpNegatableFieldType,
+			FieldFileSize:    stringFieldType,
 			FieldFork:        {Literal: types.StringType, Quoted: types.StringType, Singular: true},
 			FieldArchived:    {Literal: types.StringType, Quoted: types.StringType, Singular: true},
 			FieldLang:        {Literal: types.StringType, Quoted: types.StringType, Negatable: true},
@@ -66,6 +75,11 @@ var (
 			FieldRepoHasFile:        regexpNegatableFieldType,
 			FieldRepoHasCommitAfter: {Literal: types.StringType, Quoted: types.StringType, Singular: true},
 
+			FieldRepoDescription: regexpNegatableFieldType,
+			FieldRepoTopic:       {Literal: types.StringType, Quoted: types.StringType, Negatable: true},
+			FieldRepoLanguage:    {Literal: types.StringType, Quoted: types.StringType, Negatable: true},
+			FieldRepoSize:        stringFieldType,
+
 			FieldBefore:    stringFieldType,
 			FieldAfter:     stringFieldType,
 			FieldAuthor:    regexpNegatableFieldType,
@@ -76,6 +90,7 @@ var (
 			FieldIndex:     {Literal: types.StringType, Quoted: types.StringType, Singular: true},
 			FieldCount:     {Literal: types.StringType, Quoted: types.StringType, Singular: true},
 			FieldStable:    {Literal: types.BoolType, Quoted: types.BoolType, Singular: true},
+			FieldRank:      {Literal: types.BoolType, Quoted: types.BoolType, Singular: true},
 			FieldMax:       {Literal: types.StringType, Quoted: types.StringType, Singular: true},
 			FieldTimeout:   {Literal: types.StringType, Quoted: types.StringType, Singular: true},
 			FieldReplace:   {Literal: types.StringType, Quoted: types.StringType, Singular: true},
@@ -0,0 +1,57 @@
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/internal/lazyregexp"
+)
+
+// SizeFilter is a comparison of a size in bytes, such as the value >10MB of
+// repo.size:>10MB.
+type SizeFilter struct {
+	Op    string // one of "<", "<=", ">" or ">="
+	Bytes int64
+}
+
+var sizeFilterPattern = lazyregexp.New(`^(<=|>=|<|>)\s*([0-9]+(?:\.[0-9]+)?)\s*([kmgt]?b)?$`)
+
+var sizeUnits = map[string]int64{
+	"":   1,
+	"b":  1,
+	"kb": 1 << 10,
+	"mb": 1 << 20,
+	"gb": 1 << 30,
+	"tb": 1 << 40,
+}
+
+// ParseSizeFilter parses a size comparison such as ">10MB" or "<=512KB". The
+// units B, KB, MB, GB and TB are powers of 1024, and a size without a unit is
+// in bytes.
+func ParseSizeFilter(s string) (SizeFilter, error) {
+	m := sizeFilterPattern.FindStringSubmatch(strings.ToLower(strings.TrimSpace(s)))
+	if m == nil {
+		return SizeFilter{}, fmt.Errorf("invalid size %q, expected a comparison such as >10MB or <=512KB", s)
+	}
+	n, err := strconv.ParseFloat(m[2], 64)
+	if err != nil {
+		return SizeFilter{}, fmt.Errorf("invalid size %q: %s", s, err)
+	}
+	return SizeFilter{Op: m[1], Bytes: int64(n * float64(sizeUnits[m[3]]))}, nil
+}
+
+// Bounds returns the inclusive range of sizes in bytes that match f. A
+// negative bound means that the range is unbounded on that side.
+func (f SizeFilter) Bounds() (min, max int64) {
+	switch f.Op {
+	case "<":
+		return -1, f.Bytes - 1
+	case "<=":
+		return -1, f.Bytes
+	case ">":
+		return f.Bytes + 1, -1
+	default:
+		return f.Bytes, -1
+	}
+}
@@ -0,0 +1,34 @@
+package query
+
+import "testing"
+
+func TestParseSizeFilter(t *testing.T) {
+	tests := []struct {
+		input    string
+		want     SizeFilter
+		min, max int64
+	}{
+		{input: ">10MB", want: SizeFilter{Op: ">", Bytes: 10 << 20}, min: 10<<20 + 1, max: -1},
+		{input: ">= 1.5kb", want: SizeFilter{Op: ">=", Bytes: 1536}, min: 1536, max: -1},
+		{input: "<100", want: SizeFilter{Op: "<", Bytes: 100}, min: -1, max: 99},
+		{input: "<=2GB", want: SizeFilter{Op: "<=", Bytes: 2 << 30}, min: -1, max: 2 << 30},
+	}
+	for _, test := range tests {
+		got, err := ParseSizeFilter(test.input)
+		if err != nil {
+			t.Fatalf("ParseSizeFilter(%q): %s", test.input, err)
+		}
+		if got != test.want {
+			t.Errorf("ParseSizeFilter(%q) = %+v, want %+v", test.input, got, test.want)
+		}
+		if min, max := got.Bounds(); min != test.min || max != test.max {
+			t.Errorf("%q: got bounds [%d, %d], want [%d, %d]", test.input, min, max, test.min, test.max)
+		}
+	}
+
+	for _, input := range []string{"", "10MB", ">", ">10PB", "=10MB", ">-1"} {
+		if _, err := ParseSizeFilter(input); err == nil {
+			t.Errorf("ParseSizeFilter(%q): got no error", input)
+		}
+	}
+}
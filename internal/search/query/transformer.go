@@ -417,6 +417,51 @@ func dnf(query []Node) [][]Node {
 	return distribute([][]Node{}, query)
 }
 
+// distributeScope is like distribute, but treats pure search pattern
+// expressions as atoms. See Disjuncts for context.
+func distributeScope(prefixes [][]Node, nodes []Node) [][]Node {
+	for _, node := range nodes {
+		switch v := node.(type) {
+		case Operator:
+			if isPatternExpression([]Node{v}) {
+				prefixes = product(prefixes, []Node{v})
+				continue
+			}
+			switch v.Kind {
+			case Or:
+				result := [][]Node{}
+				for _, o := range v.Operands {
+					var newPrefixes [][]Node
+					newPrefixes = distributeScope(newPrefixes, []Node{o})
+					for _, newPrefix := range newPrefixes {
+						result = append(result, product(prefixes, newPrefix)...)
+					}
+				}
+				prefixes = result
+			case And, Concat:
+				prefixes = distributeScope(prefixes, v.Operands)
+			}
+		case Parameter, Pattern:
+			prefixes = product(prefixes, []Node{v})
+		}
+	}
+	return prefixes
+}
+
+// Disjuncts returns the Disjunctive Normal Form of a query with respect to
+// its scope parameters. Unlike dnf, search pattern expressions like (a or b)
+// are preserved as-is. For example, the query:
+//
+// (repo:a or repo:b) -file:c (d or e)
+// becomes the disjuncts:
+// (repo:a -file:c (d or e)) OR (repo:b -file:c (d or e))
+//
+// Every disjunct contains only flat parameters and pattern expressions, so it
+// can be partitioned with PartitionSearchPattern and evaluated separately.
+func Disjuncts(query []Node) [][]Node {
+	return distributeScope([][]Node{}, query)
+}
+
 func substituteOrForRegexp(nodes []Node) []Node {
 	isPattern := func(node Node) bool {
 		if pattern, ok := node.(Pattern); ok && !pattern.Negated {
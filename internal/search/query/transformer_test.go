@@ -342,6 +342,47 @@ func TestExpandOr(t *testing.T) {
 	}
 }
 
+func TestDisjuncts(t *testing.T) {
+	cases := []struct {
+		input string
+		want  string
+	}{
+		{
+			input: `repo:a b`,
+			want:  `("repo:a" "b")`,
+		},
+		{
+			input: `repo:a or repo:b`,
+			want:  `("repo:a") OR ("repo:b")`,
+		},
+		{
+			input: `(repo:a or repo:b) -file:c (d or e)`,
+			want:  `("repo:a" "-file:c" (or "d" "e")) OR ("repo:b" "-file:c" (or "d" "e"))`,
+		},
+		{
+			input: `(repo:a or repo:b) and not (file:test or lang:markdown)`,
+			want:  `("repo:a" "-file:test" "-lang:markdown") OR ("repo:b" "-file:test" "-lang:markdown")`,
+		},
+		{
+			input: `(repo:a (file:b or file:c) x y)`,
+			want:  `("repo:a" "file:b" (concat "x" "y")) OR ("repo:a" "file:c" (concat "x" "y"))`,
+		},
+	}
+	for _, c := range cases {
+		t.Run("disjuncts", func(t *testing.T) {
+			query, _ := ParseAndOr(c.input, SearchTypeRegex)
+			var queriesStr []string
+			for _, q := range Disjuncts(query) {
+				queriesStr = append(queriesStr, prettyPrint(q))
+			}
+			got := "(" + strings.Join(queriesStr, ") OR (") + ")"
+			if diff := cmp.Diff(c.want, got); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
 func TestMap(t *testing.T) {
 	cases := []struct {
 		input string
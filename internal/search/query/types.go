@@ -231,7 +231,9 @@ func (q AndOrQuery) valueToTypedValue(field, value string, label labels) []*type
 		FieldContent:
 		return []*types.Value{{String: &value}}
 
-	case FieldRepoHasFile:
+	case
+		FieldRepoHasFile,
+		FieldRepoDescription:
 		return []*types.Value{{Regexp: parseRegexpOrPanic(field, value)}}
 
 	case
@@ -6,6 +6,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/sourcegraph/sourcegraph/internal/lazyregexp"
 	"github.com/src-d/enry/v2"
 )
 
@@ -83,6 +84,32 @@ func ContainsAndOrKeyword(input string) bool {
 	return strings.Contains(lower, " and ") || strings.Contains(lower, " or ")
 }
 
+// ContainsNegatedGroup returns true if this query negates a group, as in
+// "not (file:foo or file:bar)". Like ContainsAndOrKeyword, it is a signal to
+// process the query with the and/or parser.
+func ContainsNegatedGroup(input string) bool {
+	return negatedGroupRx.MatchString(input)
+}
+
+// negatedGroupRx matches the keyword "not" followed by an opening
+// parenthesis. Like the and/or parser (see matchUnaryKeyword), it expects the
+// keyword to be preceded and followed by whitespace.
+var negatedGroupRx = lazyregexp.New(`(?i)(^|\s)not\s+\(`)
+
+// ContainsDottedField returns true if this query contains a field with a dot
+// in its name, as in "repo.topic:legacy". Like ContainsAndOrKeyword, it is a
+// signal to process the query with the and/or parser, because the ordinary
+// parser doesn't scan dots in field names.
+func ContainsDottedField(input string) bool {
+	lower := strings.ToLower(input)
+	for field := range allFields {
+		if strings.Contains(field, ".") && strings.Contains(lower, field+":") {
+			return true
+		}
+	}
+	return false
+}
+
 // ContainsRegexpMetasyntax returns true if a string is a valid regular
 // expression and contains regex metasyntax (i.e., it is not a literal).
 func ContainsRegexpMetasyntax(input string) bool {
@@ -193,6 +220,42 @@ func parseBool(s string) (bool, error) {
 	}
 }
 
+// notNegatable are the fields, and their aliases, that validateField rejects
+// when negated.
+var notNegatable = map[string]struct{}{
+	FieldCase:               {},
+	FieldRepoGroup:          {},
+	"g":                     {},
+	FieldFork:               {},
+	FieldArchived:           {},
+	FieldType:               {},
+	FieldPatternType:        {},
+	FieldContent:            {},
+	FieldVisibility:         {},
+	FieldFileSize:           {},
+	FieldRepoSize:           {},
+	FieldRepoHasCommitAfter: {},
+	FieldBefore:             {},
+	"until":                 {},
+	FieldAfter:              {},
+	"since":                 {},
+	FieldIndex:              {},
+	FieldCount:              {},
+	FieldStable:             {},
+	FieldRank:               {},
+	FieldMax:                {},
+	FieldTimeout:            {},
+	FieldReplace:            {},
+	FieldCombyRule:          {},
+}
+
+// isNegatable returns whether field may be negated. Unrecognized fields are
+// reported by validateField.
+func isNegatable(field string) bool {
+	_, ok := notNegatable[strings.ToLower(field)]
+	return !ok
+}
+
 func validateField(field, value string, negated bool, seen map[string]struct{}) error {
 	isNotNegated := func() error {
 		if negated {
@@ -244,6 +307,11 @@ func validateField(field, value string, negated bool, seen map[string]struct{})
 		return nil
 	}
 
+	isSize := func() error {
+		_, err := ParseSizeFilter(value)
+		return err
+	}
+
 	isUnrecognizedField := func() error {
 		return fmt.Errorf("unrecognized field %q", field)
 	}
@@ -288,6 +356,19 @@ func validateField(field, value string, negated bool, seen map[string]struct{})
 		FieldContent,
 		FieldVisibility:
 		return satisfies(isSingular, isNotNegated)
+	case
+		FieldRepoDescription:
+		return satisfies(isValidRegexp)
+	case
+		FieldRepoTopic:
+		// Any topic name is valid.
+	case
+		FieldRepoLanguage:
+		return satisfies(isLanguage)
+	case
+		FieldFileSize,
+		FieldRepoSize:
+		return satisfies(isSize, isNotNegated)
 	case
 		FieldRepoHasFile:
 		return satisfies(isValidRegexp)
@@ -310,7 +391,8 @@ func validateField(field, value string, negated bool, seen map[string]struct{})
 		FieldCount:
 		return satisfies(isSingular, isNumber, isNotNegated)
 	case
-		FieldStable:
+		FieldStable,
+		FieldRank:
 		return satisfies(isSingular, isBoolean, isNotNegated)
 	case
 		FieldMax,
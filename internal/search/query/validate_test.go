@@ -30,6 +30,22 @@ func TestAndOrQuery_Validation(t *testing.T) {
 			input: "lang:c lang:go lang:stephenhas9cats",
 			want:  `unknown language: "stephenhas9cats"`,
 		},
+		{
+			input: "repo.language:stephenhas9cats",
+			want:  `unknown language: "stephenhas9cats"`,
+		},
+		{
+			input: "repo.size:10MB",
+			want:  `invalid size "10MB", expected a comparison such as >10MB or <=512KB`,
+		},
+		{
+			input: "-repo.size:>10MB",
+			want:  `field "repo.size" does not support negation`,
+		},
+		{
+			input: "-file.size:>10MB",
+			want:  `field "file.size" does not support negation`,
+		},
 		{
 			input: "stable:???",
 			want:  `invalid boolean "???"`,
@@ -273,6 +289,39 @@ func TestContainsAndOrKeyword(t *testing.T) {
 	}
 }
 
+func TestContainsNegatedGroup(t *testing.T) {
+	if !ContainsNegatedGroup("NOT (file:foo or file:bar)") {
+		t.Errorf("Expected query to contain negated group")
+	}
+	if !ContainsNegatedGroup("repo:foo not (bar)") {
+		t.Errorf("Expected query to contain negated group")
+	}
+	if !ContainsNegatedGroup("repo:foo\tnot  (bar)") {
+		t.Errorf("Expected query to contain negated group")
+	}
+	if ContainsNegatedGroup("repo:foo not bar") {
+		t.Errorf("Did not expect query to contain negated group")
+	}
+	if ContainsNegatedGroup("cannot (bar)") {
+		t.Errorf("Did not expect query to contain negated group")
+	}
+	if ContainsNegatedGroup("repo:foo not(bar)") {
+		t.Errorf("Did not expect query to contain negated group")
+	}
+}
+
+func TestContainsDottedField(t *testing.T) {
+	if !ContainsDottedField("repo.topic:legacy foo") {
+		t.Errorf("Expected query to contain dotted field")
+	}
+	if !ContainsDottedField("foo -REPO.LANGUAGE:java") {
+		t.Errorf("Expected query to contain dotted field")
+	}
+	if ContainsDottedField("repo:foo topic.name:bar") {
+		t.Errorf("Did not expect query to contain dotted field")
+	}
+}
+
 func TestForAll(t *testing.T) {
 	nodes := []Node{
 		Parameter{Field: "repo", Value: "foo"},
@@ -36,6 +36,37 @@ func (r1 RevisionSpecifier) String() string {
 	return r1.RevSpec
 }
 
+// RevisionRange returns the revisions of a revision range of the form
+// "base..head", which refers to the commits that are reachable from head but
+// not from base. ok is false if r is not such a range. Symmetric differences
+// ("base...head") are not revision ranges.
+func (r1 RevisionSpecifier) RevisionRange() (base, head string, ok bool) {
+	i := strings.Index(r1.RevSpec, "..")
+	if i <= 0 || strings.Contains(r1.RevSpec, "...") {
+		return "", "", false
+	}
+	base, head = r1.RevSpec[:i], r1.RevSpec[i+2:]
+	if head == "" || strings.Contains(head, "..") {
+		return "", "", false
+	}
+	return base, head, true
+}
+
+// DiffRange returns the revisions of a diff range of the form "base...head",
+// which refers to the changes made on head since it diverged from base, like
+// `git diff base...head`. ok is false if r is not such a range.
+func (r1 RevisionSpecifier) DiffRange() (base, head string, ok bool) {
+	i := strings.Index(r1.RevSpec, "...")
+	if i <= 0 {
+		return "", "", false
+	}
+	base, head = r1.RevSpec[:i], r1.RevSpec[i+3:]
+	if head == "" || strings.HasPrefix(head, ".") || strings.Contains(base, "..") || strings.Contains(head, "..") {
+		return "", "", false
+	}
+	return base, head, true
+}
+
 // Less compares two revspecOrRefGlob entities, suitable for use
 // with sort.Slice()
 //
@@ -86,6 +117,10 @@ func (r *RepositoryRevisions) Equal(other *RepositoryRevisions) bool {
 // - 'foo@*bar' refers to the 'foo' repo and all refs matching the glob 'bar/*',
 //   because git interprets the ref glob 'bar' as being 'bar/*' (see `man git-log`
 //   section on the --glob flag)
+// - 'foo@v1.0..v2.0' refers to the 'foo' repo and every version of its files
+//   between the tags 'v1.0' and 'v2.0' (see RevisionSpecifier.RevisionRange)
+// - 'foo@main...feature' refers to the 'foo' repo and the changes made on
+//   'feature' since it diverged from 'main' (see RevisionSpecifier.DiffRange)
 func ParseRepositoryRevisions(repoAndOptionalRev string) (string, []RevisionSpecifier) {
 	i := strings.Index(repoAndOptionalRev, "@")
 	if i == -1 {
@@ -16,6 +16,7 @@ func TestParseRepositoryRevisions(t *testing.T) {
 		"repo@rev1:rev2": {repo: "repo", revs: []RevisionSpecifier{{RevSpec: "rev1"}, {RevSpec: "rev2"}}},
 		"repo@:rev1:":    {repo: "repo", revs: []RevisionSpecifier{{RevSpec: "rev1"}}},
 		"repo@*glob":     {repo: "repo", revs: []RevisionSpecifier{{RefGlob: "glob"}}},
+		"repo@v1..v2":    {repo: "repo", revs: []RevisionSpecifier{{RevSpec: "v1..v2"}}},
 		"repo@rev1:*glob1:^rev2": {
 			repo: "repo",
 			revs: []RevisionSpecifier{{RevSpec: "rev1"}, {RefGlob: "glob1"}, {RevSpec: "^rev2"}},
@@ -43,3 +44,52 @@ func TestParseRepositoryRevisions(t *testing.T) {
 		})
 	}
 }
+
+func TestRevisionSpecifier_RevisionRange(t *testing.T) {
+	tests := []struct {
+		revSpec    string
+		base, head string
+		ok         bool
+	}{
+		{revSpec: "v1.0..v2.0", base: "v1.0", head: "v2.0", ok: true},
+		{revSpec: "main..feature/x", base: "main", head: "feature/x", ok: true},
+		{revSpec: "v1.0"},
+		{revSpec: ""},
+		{revSpec: "main...feature"},
+		{revSpec: "..v2.0"},
+		{revSpec: "v1.0.."},
+		{revSpec: "a..b..c"},
+	}
+	for _, test := range tests {
+		base, head, ok := RevisionSpecifier{RevSpec: test.revSpec}.RevisionRange()
+		if base != test.base || head != test.head || ok != test.ok {
+			t.Errorf("%q: got (%q, %q, %v), want (%q, %q, %v)", test.revSpec, base, head, ok, test.base, test.head, test.ok)
+		}
+	}
+}
+
+func TestRevisionSpecifier_DiffRange(t *testing.T) {
+	tests := []struct {
+		revSpec    string
+		base, head string
+		ok         bool
+	}{
+		{revSpec: "main...feature-x", base: "main", head: "feature-x", ok: true},
+		{revSpec: "v1.0...refs/pull/1/head", base: "v1.0", head: "refs/pull/1/head", ok: true},
+		{revSpec: "main"},
+		{revSpec: ""},
+		{revSpec: "main..feature"},
+		{revSpec: "...feature"},
+		{revSpec: "main..."},
+		{revSpec: "a...b...c"},
+		{revSpec: "a...b..c"},
+		{revSpec: "a..b...c"},
+		{revSpec: "a....b"},
+	}
+	for _, test := range tests {
+		base, head, ok := RevisionSpecifier{RevSpec: test.revSpec}.DiffRange()
+		if base != test.base || head != test.head || ok != test.ok {
+			t.Errorf("%q: got (%q, %q, %v), want (%q, %q, %v)", test.revSpec, base, head, ok, test.base, test.head, test.ok)
+		}
+	}
+}
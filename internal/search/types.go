@@ -66,6 +66,10 @@ type SymbolsParameters struct {
 
 	// First indicates that only the first n symbols should be returned.
 	First int
+
+	// Refs if true returns the occurrences of the symbols whose names match
+	// Query instead of the symbols themselves.
+	Refs bool
 }
 
 // TextParameters are the parameters passed to a search backend. It contains the Pattern
@@ -129,6 +133,15 @@ type TextPatternInfo struct {
 	PatternMatchesPath    bool
 
 	Languages []string
+
+	// MinFileSize and MaxFileSize are the inclusive bounds in bytes of the
+	// sizes of files to search, from file.size: filters. A nil bound means
+	// that sizes are unbounded on that side.
+	MinFileSize, MaxFileSize *int64
+
+	// ExcludeGenerated is whether to exclude generated files (-file:generated).
+	// See package linguist for how they are detected.
+	ExcludeGenerated bool
 }
 
 func (p *TextPatternInfo) String() string {
@@ -161,6 +174,15 @@ func (p *TextPatternInfo) String() string {
 	for _, lang := range p.Languages {
 		args = append(args, fmt.Sprintf("lang:%s", lang))
 	}
+	if p.MinFileSize != nil {
+		args = append(args, fmt.Sprintf("minsize:%d", *p.MinFileSize))
+	}
+	if p.MaxFileSize != nil {
+		args = append(args, fmt.Sprintf("maxsize:%d", *p.MaxFileSize))
+	}
+	if p.ExcludeGenerated {
+		args = append(args, "nogenerated")
+	}
 
 	for _, inc := range p.FilePatternsReposMustInclude {
 		args = append(args, fmt.Sprintf("repositoryPathPattern:%s", inc))
@@ -12,6 +12,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -34,6 +35,10 @@ import (
 // than this are searched.
 const maxFileSize = 1 << 20 // 1MB; match https://sourcegraph.com/search?q=repo:%5Egithub%5C.com/sourcegraph/zoekt%24+%22-file_limit%22
 
+// archiveFormatVersion is part of the keys of the cached archives. Increment
+// it when the contents of the archives change.
+const archiveFormatVersion = 2
+
 // Store manages the fetching and storing of git archives. Its main purpose is
 // keeping a local disk cache of the fetched archives to help speed up future
 // requests for the same archive. As a performance optimization, it is also
@@ -136,7 +141,7 @@ func (s *Store) PrepareZip(ctx context.Context, repo gitserver.Repo, commit api.
 	largeFilePatterns := conf.Get().SearchLargeFiles
 
 	// key is a sha256 hash since we want to use it for the disk name
-	h := sha256.Sum256([]byte(fmt.Sprintf("%q %q %q", repo.Name, commit, largeFilePatterns)))
+	h := sha256.Sum256([]byte(fmt.Sprintf("%q %q %q %d", repo.Name, commit, largeFilePatterns, archiveFormatVersion)))
 	key := hex.EncodeToString(h[:])
 	span.LogKV("key", key)
 
@@ -279,21 +284,9 @@ func copySearchable(tr *tar.Reader, zw *zip.Writer, largeFilePatterns []string)
 			continue
 		}
 
-		// We are happy with the file, so we can write it to zw.
-		w, err := zw.CreateHeader(&zip.FileHeader{
-			Name:   hdr.Name,
-			Method: zip.Store,
-		})
-		if err != nil {
-			return err
-		}
-
 		n, err := tr.Read(buf)
 		switch err {
 		case io.EOF:
-			if n == 0 {
-				continue
-			}
 		case nil:
 		default:
 			return err
@@ -301,13 +294,26 @@ func copySearchable(tr *tar.Reader, zw *zip.Writer, largeFilePatterns []string)
 
 		// We do not search the content of large files unless they are
 		// allowed.
-		if hdr.Size > maxFileSize && !ignoreSizeMax(hdr.Name, largeFilePatterns) {
-			continue
-		}
-
+		//
 		// Heuristic: Assume file is binary if first 256 bytes contain a
 		// 0x00. Best effort, so ignore err. We only search names of binary files.
-		if n > 0 && bytes.IndexByte(buf[:n], 0x00) >= 0 {
+		omitContent := (hdr.Size > maxFileSize && !ignoreSizeMax(hdr.Name, largeFilePatterns)) || (n > 0 && bytes.IndexByte(buf[:n], 0x00) >= 0)
+
+		// We are happy with the file, so we can write it to zw. The size of
+		// a file whose content is omitted is kept in its comment, so that
+		// files can still be filtered by size.
+		fh := &zip.FileHeader{
+			Name:   hdr.Name,
+			Method: zip.Store,
+		}
+		if omitContent {
+			fh.Comment = strconv.FormatInt(hdr.Size, 10)
+		}
+		w, err := zw.CreateHeader(fh)
+		if err != nil {
+			return err
+		}
+		if omitContent || n == 0 {
 			continue
 		}
 
@@ -2,6 +2,7 @@ package store
 
 import (
 	"archive/tar"
+	"archive/zip"
 	"bytes"
 	"context"
 	"io"
@@ -117,6 +118,56 @@ func TestPrepareZip_errHeader(t *testing.T) {
 	}
 }
 
+func TestCopySearchable_sizes(t *testing.T) {
+	files := map[string][]byte{
+		"text":   []byte("hello\n"),
+		"binary": []byte("a\x00b"),
+		"large":  bytes.Repeat([]byte("a"), maxFileSize+1),
+		"empty":  nil,
+	}
+	buf := new(bytes.Buffer)
+	tw := tar.NewWriter(buf)
+	for name, data := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: int64(len(data)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	zipBuf := new(bytes.Buffer)
+	zw := zip.NewWriter(zipBuf)
+	if err := copySearchable(tar.NewReader(buf), zw, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	zf, err := MockZipFile(zipBuf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The contents of binary and large files are omitted, but their sizes
+	// are kept.
+	wantLen := map[string]int32{"text": 6, "binary": 0, "large": 0, "empty": 0}
+	for _, f := range zf.Files {
+		if f.Len != wantLen[f.Name] {
+			t.Errorf("%s: got Len %d, want %d", f.Name, f.Len, wantLen[f.Name])
+		}
+		if want := uint32(len(files[f.Name])); f.Size != want {
+			t.Errorf("%s: got Size %d, want %d", f.Name, f.Size, want)
+		}
+	}
+	if len(zf.Files) != len(files) {
+		t.Errorf("got %d files, want %d", len(zf.Files), len(files))
+	}
+}
+
 func TestIngoreSizeMax(t *testing.T) {
 	patterns := []string{
 		"foo",
@@ -7,8 +7,10 @@ import (
 	"hash/fnv"
 	"io"
 	"log"
+	"math"
 	"os"
 	"sort"
+	"strconv"
 	"sync"
 	"syscall"
 
@@ -152,7 +154,17 @@ func (f *ZipFile) PopulateFiles(r *zip.Reader) error {
 		if uint64(size) != file.UncompressedSize64 {
 			return errors.Errorf("file %s has size > 2gb: %v", file.Name, size)
 		}
-		f.Files[i] = SrcFile{Name: file.Name, Off: off, Len: int32(size)}
+		f.Files[i] = SrcFile{Name: file.Name, Off: off, Len: int32(size), Size: uint32(size)}
+		if file.Comment != "" {
+			// The content of the file is omitted, and the comment is its
+			// size.
+			if omitted, err := strconv.ParseUint(file.Comment, 10, 64); err == nil {
+				if omitted > math.MaxUint32 {
+					omitted = math.MaxUint32
+				}
+				f.Files[i].Size = uint32(omitted)
+			}
+		}
 		if size > f.MaxLen {
 			f.MaxLen = size
 		}
@@ -202,6 +214,11 @@ type SrcFile struct {
 	Name string
 	Off  int64
 	Len  int32
+
+	// Size is the size of the file in the repository. It is larger than Len
+	// if the content of the file is omitted from the archive, as for binary
+	// files and files over the size limit. Sizes over 4GB are capped.
+	Size uint32
 }
 
 // Data returns the contents of s, which is a SrcFile in f.
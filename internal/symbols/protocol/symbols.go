@@ -35,11 +35,16 @@ type SearchArgs struct {
 
 	// First indicates that only the first n symbols should be returned.
 	First int
+
+	// Refs if true returns the occurrences of the symbols whose names match
+	// Query instead of the symbols themselves.
+	Refs bool
 }
 
 // SearchResult is the result of a search on the symbols service.
 type SearchResult struct {
-	Symbols []Symbol // code symbols
+	Symbols []Symbol    // code symbols
+	Refs    []SymbolRef // occurrences of code symbols, if SearchArgs.Refs
 }
 
 // Symbol is a code symbol.
@@ -56,3 +61,15 @@ type Symbol struct {
 
 	FileLimited bool
 }
+
+// SymbolRef is an occurrence of the name of a code symbol. Occurrences are
+// found by tokenizing files into identifiers, so they are approximate: any
+// identifier with the name of a symbol in the repository is an occurrence of
+// it, including its definition.
+type SymbolRef struct {
+	Name      string
+	Path      string
+	Line      int    // 1-based, like Symbol.Line
+	Character int    // 0-based byte offset of the name in the line
+	Preview   string // the content of the line
+}
@@ -0,0 +1,74 @@
+package git
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver/protocol"
+	"github.com/sourcegraph/sourcegraph/internal/trace/ot"
+)
+
+// BatchCommit is replaced by the commit ID that the revision resolved to in
+// the arguments of the commands passed to ExecBatch, as in
+// "{commit}:README.md".
+const BatchCommit = protocol.BatchExecCommit
+
+// BatchResult is the output of a command run by ExecBatch.
+type BatchResult struct {
+	Stdout, Stderr []byte
+	ExitCode       int
+}
+
+// ExecBatch runs several git commands against the commit that rev resolves
+// to, in a single request to gitserver instead of one per command. The
+// commands run in order, and BatchCommit in their arguments is replaced by
+// the commit ID. Only read-only commands (such as rev-parse, ls-tree,
+// cat-file, log and show) are allowed.
+//
+// Like ExecSafe, a command that exits with a nonzero exit code is not an
+// error. If rev does not exist, the error is a
+// *gitserver.RevisionNotFoundError.
+func ExecBatch(ctx context.Context, repo gitserver.Repo, rev string, commands [][]string) (api.CommitID, []*BatchResult, error) {
+	if Mocks.ExecBatch != nil {
+		return Mocks.ExecBatch(rev, commands)
+	}
+
+	span, ctx := ot.StartSpanFromContext(ctx, "Git: ExecBatch")
+	span.SetTag("rev", rev)
+	span.SetTag("commands", len(commands))
+	defer span.Finish()
+
+	if len(commands) == 0 {
+		return "", nil, errors.New("at least one command required")
+	}
+	if err := checkSpecArgSafety(rev); err != nil {
+		return "", nil, err
+	}
+	if rev == "" {
+		rev = "HEAD"
+	}
+
+	cmd := gitserver.DefaultClient.BatchCommand(rev)
+	cmd.Repo = repo
+	cmd.EnsureRevision = rev
+	cmd.Commands = commands
+	commit, results, err := cmd.Run(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+
+	batchResults := make([]*BatchResult, len(results))
+	for i, result := range results {
+		if result.Error != "" && result.ExitStatus == 0 {
+			return "", nil, errors.Errorf("git command %q failed: %s", commands[i], result.Error)
+		}
+		batchResults[i] = &BatchResult{
+			Stdout:   result.Stdout,
+			Stderr:   []byte(result.Stderr),
+			ExitCode: result.ExitStatus,
+		}
+	}
+	return commit, batchResults, nil
+}
@@ -0,0 +1,50 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+)
+
+func TestExecBatch(t *testing.T) {
+	t.Parallel()
+
+	repo := MakeGitRepository(t,
+		"echo hello > a",
+		"git add a",
+		"GIT_COMMITTER_NAME=a GIT_COMMITTER_EMAIL=a@a.com GIT_COMMITTER_DATE=2006-01-02T15:04:05Z git commit -m foo --author='a <a@a.com>' --date 2006-01-02T15:04:05Z",
+	)
+
+	commit, results, err := ExecBatch(ctx, repo, "HEAD", [][]string{
+		{"ls-tree", "--name-only", BatchCommit},
+		{"show", BatchCommit + ":a"},
+		{"show", BatchCommit + ":missing"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, err := ResolveRevision(ctx, repo, nil, "HEAD", ResolveRevisionOptions{}); err != nil {
+		t.Fatal(err)
+	} else if commit != want {
+		t.Errorf("got commit %q, want %q", commit, want)
+	}
+	if len(results) != 3 {
+		t.Fatalf("got %d results, want 3", len(results))
+	}
+	if got := string(results[0].Stdout); got != "a\n" {
+		t.Errorf("got ls-tree stdout %q, want %q", got, "a\n")
+	}
+	if got := string(results[1].Stdout); got != "hello\n" {
+		t.Errorf("got show stdout %q, want %q", got, "hello\n")
+	}
+	if results[2].ExitCode == 0 || len(results[2].Stderr) == 0 {
+		t.Errorf("got %+v for missing file, want a non-zero exit code and stderr", results[2])
+	}
+
+	if _, _, err := ExecBatch(ctx, repo, "doesnotexist", [][]string{{"rev-parse", BatchCommit}}); !gitserver.IsRevisionNotFound(err) {
+		t.Errorf("got error %v, want revision not found", err)
+	}
+	if _, _, err := ExecBatch(ctx, repo, "HEAD", [][]string{{"update-ref", "refs/heads/x", "HEAD"}}); err == nil {
+		t.Error("got no error for a command that is not allowed")
+	}
+}
@@ -19,6 +19,22 @@ func compilePathMatcher(options PathOptions) (pathmatch.PathMatcher, error) {
 	)
 }
 
+// compileTextSearchQuery compiles the query options into a regexp, or returns
+// nil if the query has no pattern.
+func compileTextSearchQuery(options TextSearchOptions) (*regexp.Regexp, error) {
+	if options.Pattern == "" {
+		return nil, nil
+	}
+	pattern := options.Pattern
+	if !options.IsRegExp {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if !options.IsCaseSensitive {
+		pattern = "(?i:" + pattern + ")"
+	}
+	return regexp.Compile(pattern)
+}
+
 // filterAndHighlightDiff returns the raw diff with query matches highlighted
 // and only hunks that satisfy the query (if onlyMatchingHunks) and path matcher.
 func filterAndHighlightDiff(rawDiff []byte, query *regexp.Regexp, onlyMatchingHunks bool, pathMatcher pathmatch.PathMatcher) ([]byte, []Highlight, error) {
@@ -16,15 +16,21 @@ var Mocks, emptyMocks struct {
 	GetCommit        func(api.CommitID) (*Commit, error)
 	ExecSafe         func(params []string) (stdout, stderr []byte, exitCode int, err error)
 	ExecReader       func(args []string) (reader io.ReadCloser, err error)
+	ExecBatch        func(rev string, commands [][]string) (api.CommitID, []*BatchResult, error)
 	RawLogDiffSearch func(opt RawLogDiffSearchOptions) ([]*LogCommitSearchResult, bool, error)
 	NewFileReader    func(commit api.CommitID, name string) (io.ReadCloser, error)
 	ReadFile         func(commit api.CommitID, name string) ([]byte, error)
 	ReadDir          func(commit api.CommitID, name string, recurse bool) ([]os.FileInfo, error)
 	ResolveRevision  func(spec string, opt ResolveRevisionOptions) (api.CommitID, error)
 	Stat             func(commit api.CommitID, name string) (os.FileInfo, error)
+	FileSizes        func(commit api.CommitID, paths []string) (map[string]int64, error)
 	GetObject        func(objectName string) (OID, ObjectType, error)
 	Commits          func(repo gitserver.Repo, opt CommitsOptions) ([]*Commit, error)
 	MergeBase        func(repo gitserver.Repo, a, b api.CommitID) (api.CommitID, error)
+	RangeChanges     func(base, head api.CommitID) ([]*CommitChanges, error)
+	RangeDiffSearch  func(base, head api.CommitID, opt RangeDiffSearchOptions) ([]*FileDiffSearchResult, bool, error)
+	ListTags         func(repo gitserver.Repo) ([]*Tag, error)
+	ListRefs         func(repo gitserver.Repo) ([]Ref, error)
 }
 
 // ResetMocks clears the mock functions set on Mocks (so that subsequent tests don't inadvertently
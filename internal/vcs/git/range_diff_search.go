@@ -0,0 +1,115 @@
+package git
+
+import (
+	"context"
+	"io"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/go-diff/diff"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/trace/ot"
+)
+
+// RangeDiffSearchOptions specifies options to RangeDiffSearch.
+type RangeDiffSearchOptions struct {
+	// Query specifies the search query to find in added and removed lines.
+	Query TextSearchOptions
+
+	// Paths specifies the paths to include/exclude.
+	Paths PathOptions
+
+	// MaxFiles is the maximum number of matching file diffs to return.
+	MaxFiles int
+}
+
+// FileDiffSearchResult describes a matching file diff from RangeDiffSearch.
+type FileDiffSearchResult struct {
+	Path           string      // the path of the file after the change, or before it if the file was deleted
+	Diff           *RawDiff    // the file diff, with non-matching hunks deleted
+	DiffHighlights []Highlight // highlighted query matches in the diff
+}
+
+// RangeDiffSearch searches the combined diff between the commits base and
+// head, computed once, for the file diffs whose added or removed lines match
+// the query. Unlike RawLogDiffSearch, it does not look at the diffs of the
+// individual commits between base and head.
+//
+// If more than opt.MaxFiles file diffs match, the first opt.MaxFiles are
+// returned and limitHit is true.
+func RangeDiffSearch(ctx context.Context, repo gitserver.Repo, base, head api.CommitID, opt RangeDiffSearchOptions) (results []*FileDiffSearchResult, limitHit bool, err error) {
+	if Mocks.RangeDiffSearch != nil {
+		return Mocks.RangeDiffSearch(base, head, opt)
+	}
+
+	span, ctx := ot.StartSpanFromContext(ctx, "Git: RangeDiffSearch")
+	span.SetTag("Base", base)
+	span.SetTag("Head", head)
+	defer span.Finish()
+
+	if err := ensureAbsoluteCommit(base); err != nil {
+		return nil, false, err
+	}
+	if err := ensureAbsoluteCommit(head); err != nil {
+		return nil, false, err
+	}
+
+	query, err := compileTextSearchQuery(opt.Query)
+	if err != nil {
+		return nil, false, err
+	}
+	pathMatcher, err := compilePathMatcher(opt.Paths)
+	if err != nil {
+		return nil, false, err
+	}
+
+	rdr, err := ExecReader(ctx, repo, []string{
+		"diff",
+		"--find-renames",
+		"--no-prefix",
+		"--no-color",
+		"--unified=0",
+		string(base) + ".." + string(head),
+		"--",
+	})
+	if err != nil {
+		return nil, false, errors.Wrap(err, "executing git diff")
+	}
+	defer rdr.Close()
+
+	dr := diff.NewMultiFileDiffReader(rdr)
+	for {
+		fileDiff, err := dr.ReadFile()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, false, err
+		}
+
+		rawDiff, err := diff.PrintFileDiff(fileDiff)
+		if err != nil {
+			return nil, false, err
+		}
+		rawDiff, highlights, err := filterAndHighlightDiff(rawDiff, query, true, pathMatcher)
+		if err != nil {
+			return nil, false, err
+		}
+		if rawDiff == nil {
+			continue
+		}
+		if len(results) == opt.MaxFiles {
+			return results, true, nil
+		}
+
+		path := fileDiff.NewName
+		if path == "/dev/null" {
+			path = fileDiff.OrigName
+		}
+		results = append(results, &FileDiffSearchResult{
+			Path:           path,
+			Diff:           &RawDiff{Raw: string(rawDiff)},
+			DiffHighlights: highlights,
+		})
+	}
+	return results, false, nil
+}
@@ -0,0 +1,105 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+)
+
+func TestRangeDiffSearch(t *testing.T) {
+	t.Parallel()
+
+	commit := "GIT_COMMITTER_NAME=a GIT_COMMITTER_EMAIL=a@a.com GIT_COMMITTER_DATE=2006-01-02T15:04:05Z git commit -m foo --author='a <a@a.com>' --date 2006-01-02T15:04:05Z"
+	repo := MakeGitRepository(t,
+		"printf 'a\\nb\\n' > f",
+		"echo Call > g",
+		"git add f g",
+		commit,
+		"git checkout -b feature",
+		"printf 'a\\nCall\\nb\\n' > f",
+		"git add f",
+		commit,
+		"git rm g",
+		"echo 'x = Call()' > h",
+		"git add h",
+		commit,
+	)
+
+	resolve := func(spec string) api.CommitID {
+		t.Helper()
+		id, err := ResolveRevision(ctx, repo, nil, spec, ResolveRevisionOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+	base, head := resolve("master"), resolve("feature")
+
+	tests := []struct {
+		name         string
+		opt          RangeDiffSearchOptions
+		want         []*FileDiffSearchResult
+		wantLimitHit bool
+	}{{
+		name: "query",
+		opt:  RangeDiffSearchOptions{Query: TextSearchOptions{Pattern: "call"}, MaxFiles: 10},
+		want: []*FileDiffSearchResult{{
+			Path:           "f",
+			Diff:           &RawDiff{Raw: "diff --git f f\nindex 422c2b7..93e027e 100644\n--- f\n+++ f\n@@ -1,0 +2,1 @@ a\n+Call\n"},
+			DiffHighlights: []Highlight{{Line: 6, Character: 1, Length: 4}},
+		}, {
+			Path:           "g",
+			Diff:           &RawDiff{Raw: "diff --git g g\ndeleted file mode 100644\nindex 2d973ab..0000000\n--- g\n+++ /dev/null\n@@ -1,1 +0,0 @@\n-Call\n"},
+			DiffHighlights: []Highlight{{Line: 7, Character: 1, Length: 4}},
+		}, {
+			Path:           "h",
+			Diff:           &RawDiff{Raw: "diff --git h h\nnew file mode 100644\nindex 0000000..6efef21\n--- /dev/null\n+++ h\n@@ -0,0 +1,1 @@\n+x = Call()\n"},
+			DiffHighlights: []Highlight{{Line: 7, Character: 5, Length: 4}},
+		}},
+	}, {
+		name: "paths",
+		opt: RangeDiffSearchOptions{
+			Query:    TextSearchOptions{Pattern: "Call", IsCaseSensitive: true},
+			Paths:    PathOptions{IncludePatterns: []string{"^h$"}, IsRegExp: true},
+			MaxFiles: 10,
+		},
+		want: []*FileDiffSearchResult{{
+			Path:           "h",
+			Diff:           &RawDiff{Raw: "diff --git h h\nnew file mode 100644\nindex 0000000..6efef21\n--- /dev/null\n+++ h\n@@ -0,0 +1,1 @@\n+x = Call()\n"},
+			DiffHighlights: []Highlight{{Line: 7, Character: 5, Length: 4}},
+		}},
+	}, {
+		name: "case sensitive",
+		opt:  RangeDiffSearchOptions{Query: TextSearchOptions{Pattern: "call", IsCaseSensitive: true}, MaxFiles: 10},
+	}, {
+		name:         "limit",
+		opt:          RangeDiffSearchOptions{Query: TextSearchOptions{Pattern: "call"}, MaxFiles: 1},
+		want:         []*FileDiffSearchResult{{Path: "f"}},
+		wantLimitHit: true,
+	}}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			results, limitHit, err := RangeDiffSearch(ctx, repo, base, head, test.opt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if limitHit != test.wantLimitHit {
+				t.Errorf("got limitHit %v, want %v", limitHit, test.wantLimitHit)
+			}
+			if test.wantLimitHit {
+				// Only compare paths.
+				for _, r := range results {
+					r.Diff, r.DiffHighlights = nil, nil
+				}
+			}
+			if diff := cmp.Diff(test.want, results); diff != "" {
+				t.Errorf("results mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	if _, _, err := RangeDiffSearch(ctx, repo, "master", head, RangeDiffSearchOptions{}); err == nil {
+		t.Error("got no error for a non-absolute commit")
+	}
+}
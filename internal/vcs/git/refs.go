@@ -86,6 +86,9 @@ type BranchesOptions struct {
 	// ContainsCommit filters the list of branches to only those that
 	// contain a specific commit ID (if set).
 	ContainsCommit string `json:"ContainsCommit,omitempty" url:",omitempty"`
+	// OrderByCommitterDate orders the branches from the one whose head
+	// commit was most recently committed, instead of by name.
+	OrderByCommitterDate bool `json:"OrderByCommitterDate,omitempty" url:",omitempty"`
 }
 
 // A Tag is a VCS tag.
@@ -166,6 +169,18 @@ func ListBranches(ctx context.Context, repo gitserver.Repo, opt BranchesOptions)
 		f.add(b)
 	}
 
+	var order map[string]int
+	if opt.OrderByCommitterDate {
+		b, err := branches(ctx, repo, "--sort=-committerdate")
+		if err != nil {
+			return nil, err
+		}
+		order = make(map[string]int, len(b))
+		for i, name := range b {
+			order[name] = i
+		}
+	}
+
 	refs, err := showRef(ctx, repo, "--heads")
 	if err != nil {
 		return nil, err
@@ -193,6 +208,18 @@ func ListBranches(ctx context.Context, repo gitserver.Repo, opt BranchesOptions)
 		}
 		branches = append(branches, branch)
 	}
+
+	if opt.OrderByCommitterDate {
+		// Branches created since show-ref ran are missing from order, and
+		// sort last.
+		rank := func(name string) int {
+			if i, ok := order[name]; ok {
+				return i
+			}
+			return len(order)
+		}
+		sort.SliceStable(branches, func(i, j int) bool { return rank(branches[i].Name) < rank(branches[j].Name) })
+	}
 	return branches, nil
 }
 
@@ -247,6 +274,9 @@ func GetBehindAhead(ctx context.Context, repo gitserver.Repo, left, right string
 
 // ListTags returns a list of all tags in the repository.
 func ListTags(ctx context.Context, repo gitserver.Repo) ([]*Tag, error) {
+	if Mocks.ListTags != nil {
+		return Mocks.ListTags(repo)
+	}
 	span, ctx := ot.StartSpanFromContext(ctx, "Git: Tags")
 	defer span.Finish()
 
@@ -295,6 +325,9 @@ func (p byteSlices) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
 
 // ListRefs returns a list of all refs in the repository.
 func ListRefs(ctx context.Context, repo gitserver.Repo) ([]Ref, error) {
+	if Mocks.ListRefs != nil {
+		return Mocks.ListRefs(repo)
+	}
 	span, ctx := ot.StartSpanFromContext(ctx, "Git: ListRefs")
 	defer span.Finish()
 	return showRef(ctx, repo)
@@ -68,6 +68,31 @@ func TestRepository_ListBranches(t *testing.T) {
 	}
 }
 
+func TestRepository_ListBranches_OrderByCommitterDate(t *testing.T) {
+	t.Parallel()
+
+	repo := MakeGitRepository(t,
+		"GIT_COMMITTER_NAME=a GIT_COMMITTER_EMAIL=a@a.com GIT_COMMITTER_DATE=2006-01-02T15:04:05Z git commit --allow-empty -m foo --author='a <a@a.com>' --date 2006-01-02T15:04:05Z",
+		"git checkout -b a",
+		"GIT_COMMITTER_NAME=a GIT_COMMITTER_EMAIL=a@a.com GIT_COMMITTER_DATE=2008-01-02T15:04:05Z git commit --allow-empty -m a --author='a <a@a.com>' --date 2008-01-02T15:04:05Z",
+		"git checkout master",
+		"git checkout -b b",
+		"GIT_COMMITTER_NAME=a GIT_COMMITTER_EMAIL=a@a.com GIT_COMMITTER_DATE=2007-01-02T15:04:05Z git commit --allow-empty -m b --author='a <a@a.com>' --date 2007-01-02T15:04:05Z",
+	)
+
+	branches, err := ListBranches(ctx, repo, BranchesOptions{OrderByCommitterDate: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, b := range branches {
+		got = append(got, b.Name)
+	}
+	if want := []string{"a", "b", "master"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got branches %v, want %v", got, want)
+	}
+}
+
 func TestRepository_Branches_MergedInto(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,154 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/internal/api"
+	"github.com/sourcegraph/sourcegraph/internal/gitserver"
+	"github.com/sourcegraph/sourcegraph/internal/trace/ot"
+)
+
+// FileChange is a change to a file in a commit.
+type FileChange struct {
+	Path   string
+	OldOID OID // the blob before the change, or zero if the file was added
+	NewOID OID // the blob after the change, or zero if the file was deleted
+}
+
+// CommitChanges is a commit and the files it changed relative to its first
+// parent.
+type CommitChanges struct {
+	Commit  api.CommitID
+	Changes []FileChange
+}
+
+// RevisionRangeTooLargeError is returned by RangeChanges when a range contains
+// more than the allowed number of commits.
+type RevisionRangeTooLargeError struct {
+	Range      string
+	MaxCommits int
+}
+
+func (e *RevisionRangeTooLargeError) Error() string {
+	return fmt.Sprintf("revision range %s contains more than %d commits", e.Range, e.MaxCommits)
+}
+
+// RangeChanges returns the commits on the first-parent history of the range
+// base..head, oldest first, and the files that each of them changed. Changes
+// that were merged from other branches are attributed to the merge commit.
+//
+// If the range contains more than maxCommits commits, a
+// *RevisionRangeTooLargeError is returned.
+func RangeChanges(ctx context.Context, repo gitserver.Repo, base, head api.CommitID, maxCommits int) ([]*CommitChanges, error) {
+	if Mocks.RangeChanges != nil {
+		return Mocks.RangeChanges(base, head)
+	}
+
+	span, ctx := ot.StartSpanFromContext(ctx, "Git: RangeChanges")
+	defer span.Finish()
+
+	if err := ensureAbsoluteCommit(base); err != nil {
+		return nil, err
+	}
+	if err := ensureAbsoluteCommit(head); err != nil {
+		return nil, err
+	}
+
+	revRange := string(base) + ".." + string(head)
+	cmd := gitserver.DefaultClient.Command("git", "log",
+		"--first-parent", "-m", "--raw", "--no-abbrev", "--no-renames", "-z",
+		"--format=format:%x1e%H",
+		"-n", strconv.Itoa(maxCommits+1),
+		revRange,
+	)
+	cmd.Repo = repo
+	out, stderr, err := cmd.DividedOutput(ctx)
+	if err != nil {
+		return nil, errors.WithMessage(err, fmt.Sprintf("git command %v failed (output: %q)", cmd.Args, stderr))
+	}
+
+	commits, err := parseRangeChanges(out)
+	if err != nil {
+		return nil, err
+	}
+	if len(commits) > maxCommits {
+		return nil, &RevisionRangeTooLargeError{Range: revRange, MaxCommits: maxCommits}
+	}
+
+	// git log lists the newest commit first.
+	for i, j := 0, len(commits)-1; i < j; i, j = i+1, j-1 {
+		commits[i], commits[j] = commits[j], commits[i]
+	}
+	return commits, nil
+}
+
+// parseRangeChanges parses the output of the git log command run by
+// RangeChanges. Each commit starts with a record separator (0x1E) followed by
+// its ID, and is followed by NUL-separated pairs of raw diff entries and
+// paths.
+func parseRangeChanges(data []byte) ([]*CommitChanges, error) {
+	var commits []*CommitChanges
+	for _, record := range bytes.Split(data, []byte{'\x1e'}) {
+		if len(bytes.TrimSpace(record)) == 0 {
+			continue
+		}
+		var raw []byte
+		if i := bytes.IndexByte(record, '\n'); i >= 0 {
+			record, raw = record[:i], record[i+1:]
+		}
+		commit := &CommitChanges{Commit: api.CommitID(bytes.TrimRight(record, "\x00"))}
+		if err := ensureAbsoluteCommit(commit.Commit); err != nil {
+			return nil, err
+		}
+
+		fields := bytes.Split(raw, []byte{'\x00'})
+		for i := 0; i < len(fields); i++ {
+			if len(fields[i]) == 0 {
+				continue
+			}
+			if i+1 >= len(fields) {
+				return nil, errors.Errorf("invalid raw diff entry %q: no path", fields[i])
+			}
+			change, isFile, err := parseRawDiffEntry(fields[i], fields[i+1])
+			if err != nil {
+				return nil, err
+			}
+			if isFile {
+				commit.Changes = append(commit.Changes, change)
+			}
+			i++
+		}
+		commits = append(commits, commit)
+	}
+	return commits, nil
+}
+
+// parseRawDiffEntry parses an entry of git diff --raw, such as
+// ":100644 100644 bcd1234... 0123456... M". isFile is false for changes to
+// submodules.
+func parseRawDiffEntry(entry, path []byte) (change FileChange, isFile bool, err error) {
+	parts := bytes.Fields(bytes.TrimPrefix(entry, []byte{':'}))
+	if len(parts) != 5 {
+		return FileChange{}, false, errors.Errorf("invalid raw diff entry %q", entry)
+	}
+	const gitlinkMode = "160000"
+	if string(parts[0]) == gitlinkMode || string(parts[1]) == gitlinkMode {
+		return FileChange{}, false, nil
+	}
+	change = FileChange{Path: string(path)}
+	for _, p := range []struct {
+		hex []byte
+		oid *OID
+	}{{parts[2], &change.OldOID}, {parts[3], &change.NewOID}} {
+		oid, err := decodeOID(string(p.hex))
+		if err != nil {
+			return FileChange{}, false, err
+		}
+		*p.oid = oid
+	}
+	return change, true, nil
+}
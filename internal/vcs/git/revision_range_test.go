@@ -0,0 +1,76 @@
+package git
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/internal/api"
+)
+
+func TestRangeChanges(t *testing.T) {
+	t.Parallel()
+
+	commit := "GIT_COMMITTER_NAME=a GIT_COMMITTER_EMAIL=a@a.com GIT_COMMITTER_DATE=2006-01-02T15:04:05Z git commit -m foo --author='a <a@a.com>' --date 2006-01-02T15:04:05Z"
+	repo := MakeGitRepository(t,
+		"echo a > f",
+		"git add f",
+		commit,
+		"git tag v1",
+		"echo b > f",
+		"echo x > 'g h'",
+		"git add f 'g h'",
+		commit,
+		"git checkout -b side",
+		"echo y > y",
+		"git add y",
+		commit,
+		"git checkout master",
+		"git rm f",
+		commit,
+		"GIT_COMMITTER_NAME=a GIT_COMMITTER_EMAIL=a@a.com GIT_AUTHOR_NAME=a GIT_AUTHOR_EMAIL=a@a.com git merge --no-ff -m merge side",
+		"git tag v2",
+	)
+
+	resolve := func(spec string) api.CommitID {
+		t.Helper()
+		id, err := ResolveRevision(ctx, repo, nil, spec, ResolveRevisionOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+	oid := func(spec string) OID {
+		t.Helper()
+		oid, _, err := GetObject(ctx, repo, spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return oid
+	}
+
+	got, err := RangeChanges(ctx, repo, resolve("v1"), resolve("v2"), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*CommitChanges{
+		{Commit: resolve("v2~2"), Changes: []FileChange{
+			{Path: "f", OldOID: oid("v1:f"), NewOID: oid("v2~2:f")},
+			{Path: "g h", NewOID: oid("v2~2:g h")},
+		}},
+		{Commit: resolve("v2~1"), Changes: []FileChange{
+			{Path: "f", OldOID: oid("v2~2:f")},
+		}},
+		// The change on the side branch is attributed to the merge commit.
+		{Commit: resolve("v2"), Changes: []FileChange{
+			{Path: "y", NewOID: oid("v2:y")},
+		}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	_, err = RangeChanges(ctx, repo, resolve("v1"), resolve("v2"), 2)
+	if _, ok := err.(*RevisionRangeTooLargeError); !ok {
+		t.Errorf("got error %v, want *RevisionRangeTooLargeError", err)
+	}
+}
@@ -97,6 +97,58 @@ func Stat(ctx context.Context, repo gitserver.Repo, commit api.CommitID, path st
 	return fi, nil
 }
 
+// FileSizes returns the sizes in bytes of the files at paths at commit. Paths
+// that are not files at commit are left out.
+func FileSizes(ctx context.Context, repo gitserver.Repo, commit api.CommitID, paths []string) (map[string]int64, error) {
+	if Mocks.FileSizes != nil {
+		return Mocks.FileSizes(commit, paths)
+	}
+
+	span, ctx := ot.StartSpanFromContext(ctx, "Git: FileSizes")
+	span.SetTag("Commit", commit)
+	span.SetTag("Paths", len(paths))
+	defer span.Finish()
+
+	if err := ensureAbsoluteCommit(commit); err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		if err := checkSpecArgSafety(path); err != nil {
+			return nil, err
+		}
+	}
+
+	args := append([]string{"ls-tree", "--long", "--full-name", "-z", string(commit), "--"}, paths...)
+	cmd := gitserver.DefaultClient.Command("git", args...)
+	cmd.Repo = repo
+	out, err := cmd.CombinedOutput(ctx)
+	if err != nil {
+		return nil, errors.WithMessage(err, fmt.Sprintf("git command %v failed (output: %q)", cmd.Args, out))
+	}
+
+	sizes := make(map[string]int64, len(paths))
+	for _, line := range strings.Split(string(out), "\x00") {
+		tabPos := strings.IndexByte(line, '\t')
+		if tabPos == -1 {
+			// The last entry is empty.
+			continue
+		}
+		info := strings.Fields(line[:tabPos])
+		if len(info) != 4 {
+			return nil, fmt.Errorf("invalid `git ls-tree` output: %q", out)
+		}
+		if info[1] != "blob" {
+			continue
+		}
+		size, err := strconv.ParseInt(info[3], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid `git ls-tree` size output: %q (error: %s)", info[3], err)
+		}
+		sizes[line[tabPos+1:]] = size
+	}
+	return sizes, nil
+}
+
 // ReadDir reads the contents of the named directory at commit.
 func ReadDir(ctx context.Context, repo gitserver.Repo, commit api.CommitID, path string, recurse bool) ([]os.FileInfo, error) {
 	if Mocks.ReadDir != nil {
@@ -364,6 +364,30 @@ func TestRepository_FileSystem_quoteChars(t *testing.T) {
 	}
 }
 
+func TestFileSizes(t *testing.T) {
+	t.Parallel()
+
+	repo := MakeGitRepository(t,
+		"mkdir dir",
+		"printf abc > a.txt",
+		"printf 0123456789 > dir/b.txt",
+		"git add a.txt dir/b.txt",
+		"GIT_COMMITTER_NAME=a GIT_COMMITTER_EMAIL=a@a.com GIT_COMMITTER_DATE=2006-01-02T15:04:05Z git commit -m commit1 --author='a <a@a.com>' --date 2006-01-02T15:04:05Z",
+	)
+	commitID, err := ResolveRevision(ctx, repo, nil, "master", ResolveRevisionOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sizes, err := FileSizes(ctx, repo, commitID, []string{"a.txt", "dir/b.txt", "dir", "missing.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]int64{"a.txt": 3, "dir/b.txt": 10}; !reflect.DeepEqual(sizes, want) {
+		t.Errorf("got sizes %v, want %v", sizes, want)
+	}
+}
+
 func TestRepository_FileSystem_gitSubmodules(t *testing.T) {
 	t.Parallel()
 
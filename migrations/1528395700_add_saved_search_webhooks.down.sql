@@ -0,0 +1,9 @@
+BEGIN;
+
+DROP TABLE IF EXISTS saved_search_webhook_deliveries;
+
+ALTER TABLE saved_searches DROP COLUMN IF EXISTS notify_webhook;
+ALTER TABLE saved_searches DROP COLUMN IF EXISTS webhook_url;
+ALTER TABLE saved_searches DROP COLUMN IF EXISTS webhook_secret;
+
+COMMIT;
@@ -0,0 +1,20 @@
+BEGIN;
+
+ALTER TABLE saved_searches ADD COLUMN IF NOT EXISTS notify_webhook boolean NOT NULL DEFAULT false;
+ALTER TABLE saved_searches ADD COLUMN IF NOT EXISTS webhook_url text;
+ALTER TABLE saved_searches ADD COLUMN IF NOT EXISTS webhook_secret text;
+
+CREATE TABLE IF NOT EXISTS saved_search_webhook_deliveries (
+    id bigserial PRIMARY KEY,
+    saved_search_id integer NOT NULL REFERENCES saved_searches(id) ON DELETE CASCADE,
+    url text NOT NULL,
+    attempts integer NOT NULL,
+    status_code integer,
+    error text,
+    created_at timestamp with time zone NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS saved_search_webhook_deliveries_saved_search_id_created_at ON saved_search_webhook_deliveries(saved_search_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS saved_search_webhook_deliveries_failed_created_at ON saved_search_webhook_deliveries(created_at DESC) WHERE error IS NOT NULL;
+
+COMMIT;
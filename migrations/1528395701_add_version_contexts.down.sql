@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE IF EXISTS version_context_revisions;
+DROP TABLE IF EXISTS version_contexts;
+
+COMMIT;
@@ -0,0 +1,29 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS version_contexts (
+    id serial PRIMARY KEY,
+    name citext NOT NULL,
+    description text NOT NULL DEFAULT '',
+    user_id integer REFERENCES users(id) ON DELETE CASCADE,
+    org_id integer REFERENCES orgs(id) ON DELETE CASCADE,
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    updated_at timestamp with time zone NOT NULL DEFAULT now(),
+    CONSTRAINT version_contexts_has_one_namespace CHECK ((user_id IS NULL) <> (org_id IS NULL))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS version_contexts_user_id_name_unique ON version_contexts(user_id, name) WHERE user_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS version_contexts_org_id_name_unique ON version_contexts(org_id, name) WHERE org_id IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS version_context_revisions (
+    id bigserial PRIMARY KEY,
+    version_context_id integer NOT NULL REFERENCES version_contexts(id) ON DELETE CASCADE,
+    repo_id integer NOT NULL REFERENCES repo(id) ON DELETE CASCADE,
+    rev text NOT NULL DEFAULT '',
+    ref_glob text NOT NULL DEFAULT '',
+    latest_tag text NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS version_context_revisions_version_context_id ON version_context_revisions(version_context_id);
+CREATE INDEX IF NOT EXISTS version_context_revisions_repo_id ON version_context_revisions(repo_id);
+
+COMMIT;
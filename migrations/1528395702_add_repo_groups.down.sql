@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE IF EXISTS repo_group_repos;
+DROP TABLE IF EXISTS repo_groups;
+
+COMMIT;
@@ -0,0 +1,27 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS repo_groups (
+    id serial PRIMARY KEY,
+    name citext NOT NULL,
+    description text NOT NULL DEFAULT '',
+    user_id integer REFERENCES users(id) ON DELETE CASCADE,
+    org_id integer REFERENCES orgs(id) ON DELETE CASCADE,
+    pattern text NOT NULL DEFAULT '',
+    external_service_id bigint REFERENCES external_services(id) ON DELETE SET NULL,
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    updated_at timestamp with time zone NOT NULL DEFAULT now(),
+    CONSTRAINT repo_groups_has_one_namespace CHECK ((user_id IS NULL) <> (org_id IS NULL))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS repo_groups_user_id_name_unique ON repo_groups(user_id, name) WHERE user_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS repo_groups_org_id_name_unique ON repo_groups(org_id, name) WHERE org_id IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS repo_group_repos (
+    repo_group_id integer NOT NULL REFERENCES repo_groups(id) ON DELETE CASCADE,
+    repo_id integer NOT NULL REFERENCES repo(id) ON DELETE CASCADE,
+    PRIMARY KEY (repo_group_id, repo_id)
+);
+
+CREATE INDEX IF NOT EXISTS repo_group_repos_repo_id ON repo_group_repos(repo_id);
+
+COMMIT;
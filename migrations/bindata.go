@@ -98,7 +98,15 @@
 // 1528395697_add_changeset_state_machine.up.sql (2.213kB)
 // 1528395698_add_sync_time_and_user_id_to_external_services.down.sql (335B)
 // 1528395698_add_sync_time_and_user_id_to_external_services.up.sql (425B)
-
+// 1528395699_add_query_runner_state_result_fingerprints.down.sql (91B)
+// 1528395699_add_query_runner_state_result_fingerprints.up.sql (101B)
+// 1528395700_add_saved_search_webhooks.down.sql (264B)
+// 1528395700_add_saved_search_webhooks.up.sql (909B)
+
+// 1528395701_add_version_contexts.down.sql (104B)
+// 1528395701_add_version_contexts.up.sql (1.351kB)
+// 1528395702_add_repo_groups.down.sql (90B)
+// 1528395702_add_repo_groups.up.sql (1.182kB)
 package migrations
 
 import (
@@ -2126,6 +2134,166 @@ func _1528395698_add_sync_time_and_user_id_to_external_servicesUpSql() (*asset,
 	return a, nil
 }
 
+var __1528395699_add_query_runner_state_result_fingerprintsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x5b\x00\xa4\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x71\x75\x65\x72\x79\x5f\x72\x75\x6e\x6e\x65\x72\x5f\x73\x74\x61\x74\x65\x20\x44\x52\x4f\x50\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x72\x65\x73\x75\x6c\x74\x5f\x66\x69\x6e\x67\x65\x72\x70\x72\x69\x6e\x74\x73\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x3f\xc1\x05\x0b\x5b\x00\x00\x00")
+
+func _1528395699_add_query_runner_state_result_fingerprintsDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395699_add_query_runner_state_result_fingerprintsDownSql,
+		"1528395699_add_query_runner_state_result_fingerprints.down.sql",
+	)
+}
+
+func _1528395699_add_query_runner_state_result_fingerprintsDownSql() (*asset, error) {
+	bytes, err := _1528395699_add_query_runner_state_result_fingerprintsDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395699_add_query_runner_state_result_fingerprints.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x70, 0x42, 0x71, 0x6d, 0xf2, 0xd1, 0x4, 0x22, 0xfa, 0x99, 0x80, 0xbd, 0x20, 0xc2, 0xcc, 0x67, 0x9a, 0xcc, 0x49, 0xfb, 0x73, 0x75, 0x8a, 0x8a, 0x90, 0xda, 0xc5, 0xd, 0xb5, 0xf, 0x84, 0xc}}
+	return a, nil
+}
+
+var __1528395699_add_query_runner_state_result_fingerprintsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x65\x00\x9a\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x71\x75\x65\x72\x79\x5f\x72\x75\x6e\x6e\x65\x72\x5f\x73\x74\x61\x74\x65\x20\x41\x44\x44\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x49\x46\x20\x4e\x4f\x54\x20\x45\x58\x49\x53\x54\x53\x20\x72\x65\x73\x75\x6c\x74\x5f\x66\x69\x6e\x67\x65\x72\x70\x72\x69\x6e\x74\x73\x20\x74\x65\x78\x74\x5b\x5d\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x5e\x70\x0d\xd1\x65\x00\x00\x00")
+
+func _1528395699_add_query_runner_state_result_fingerprintsUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395699_add_query_runner_state_result_fingerprintsUpSql,
+		"1528395699_add_query_runner_state_result_fingerprints.up.sql",
+	)
+}
+
+func _1528395699_add_query_runner_state_result_fingerprintsUpSql() (*asset, error) {
+	bytes, err := _1528395699_add_query_runner_state_result_fingerprintsUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395699_add_query_runner_state_result_fingerprints.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xac, 0xc4, 0x2b, 0x5f, 0xe7, 0x17, 0x12, 0x99, 0x6e, 0x4c, 0x70, 0x6d, 0x9c, 0xc5, 0x1a, 0x38, 0x1c, 0x81, 0x9d, 0xd6, 0x14, 0x16, 0x38, 0xb2, 0x33, 0x43, 0x3f, 0xdd, 0x56, 0xa2, 0x37, 0xfe}}
+	return a, nil
+}
+
+var __1528395700_add_saved_search_webhooksDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\xe2\x72\x09\xf2\x0f\x50\x08\x71\x74\xf2\x71\x55\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\x28\x4e\x2c\x4b\x4d\x89\x2f\x4e\x4d\x2c\x4a\xce\x88\x2f\x4f\x4d\xca\xc8\xcf\xcf\x8e\x4f\x49\xcd\xc9\x2c\x4b\x2d\xca\x4c\x2d\xb6\xe6\xe2\x72\xf4\x09\x71\x0d\x82\xea\x43\x56\x9d\x5a\xac\x00\x36\xd1\xd9\xdf\x27\xd4\xd7\x0f\xc9\xc8\xbc\xfc\x92\xcc\xb4\x4a\x98\x61\xd6\xa4\x1b\x00\x73\x46\x69\x51\x0e\x05\xba\x8b\x53\x93\x8b\x52\x4b\xac\xb9\xb8\x9c\xfd\x7d\x7d\x3d\x43\xac\xb9\x00\x03\x00\xc9\x29\xf8\xd3\x08\x01\x00\x00")
+
+func _1528395700_add_saved_search_webhooksDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395700_add_saved_search_webhooksDownSql,
+		"1528395700_add_saved_search_webhooks.down.sql",
+	)
+}
+
+func _1528395700_add_saved_search_webhooksDownSql() (*asset, error) {
+	bytes, err := _1528395700_add_saved_search_webhooksDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395700_add_saved_search_webhooks.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xe7, 0x30, 0x20, 0xe0, 0xb3, 0xa8, 0x5b, 0x59, 0xb6, 0x6b, 0x97, 0xf8, 0xb3, 0x8e, 0xc4, 0x6d, 0x94, 0xd, 0xf4, 0x5, 0xe6, 0x61, 0x86, 0xe2, 0xd2, 0x33, 0xac, 0x44, 0xa1, 0x59, 0x66, 0xcd}}
+	return a, nil
+}
+
+var __1528395700_add_saved_search_webhooksUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xa4\x91\xc1\x72\x9b\x30\x10\x86\xef\x7a\x8a\x3d\x9a\x99\xbc\x01\x27\x05\xd6\x2d\x53\x10\x1d\x90\xa7\xc9\x49\x23\xa3\x75\xac\x29\x46\x19\x49\x89\xdb\x3e\x7d\x67\x00\x13\xe3\x1e\x32\x4d\x8e\xd2\x4a\xdf\xfe\xfb\xed\x3d\x7e\x29\x44\xca\x18\x2f\x25\x36\x20\xf9\x7d\x89\x10\xf4\x2b\x19\x15\x48\xfb\xee\x48\x01\x78\x9e\x43\x56\x97\xbb\x4a\x40\xb1\x05\x51\x4b\xc0\x87\xa2\x95\x2d\x0c\x2e\xda\xc3\x6f\x75\xa6\xfd\xd1\xb9\x9f\xb0\x77\xae\x27\x3d\x8c\x2f\xc4\xae\x2c\x21\xc7\x2d\xdf\x95\x12\x0e\xba\x0f\x94\x7e\xa8\xc5\xcc\x56\x2f\xbe\x87\x48\xbf\xe2\xe7\x28\x81\x3a\x4f\x71\x06\xb1\xac\x41\x2e\x71\x46\xad\x3f\x5c\x83\x2f\xf3\x29\x43\xbd\x7d\x25\x6f\x29\xc0\x86\x01\x00\x58\x03\x7b\xfb\x14\xc8\x5b\xdd\xc3\xf7\xa6\xa8\x78\xf3\x08\xdf\xf0\xf1\x6e\xac\xae\x18\xd6\x80\x1d\x22\x3d\x91\x7f\xd3\xd3\xe0\x16\x1b\x14\x19\xae\xfb\x51\xd8\x58\x93\x40\x2d\x20\xc7\x12\x25\x42\xc6\xdb\x8c\xe7\x38\x51\x2f\x22\x16\xcc\x74\xad\x63\xa4\xd3\x73\x0c\xff\x74\x99\xb3\x44\x1d\x5f\x82\xea\x9c\xa1\xcb\x8b\xa9\x40\xde\x3b\x3f\x0a\x99\xce\x9d\x27\x1d\xc9\x28\x1d\x21\xda\x13\x85\xa8\x4f\xcf\x70\xb6\xf1\x38\x1e\xe1\x8f\x1b\x68\x41\x2f\xfb\x1d\xdc\x79\x93\xb0\xe4\x4d\x69\x21\x72\x7c\xb8\xd9\xc1\x3b\x4a\xd5\xaa\x6e\x8d\xba\x4a\x52\x8b\xf7\x16\xb2\xb9\xf9\x7d\x77\x3d\x48\x8e\x6d\x96\xa4\x9f\xc9\x76\xd0\xb6\xa7\xff\x8d\x74\x9b\x00\x7e\x7c\xc5\x06\x67\xe3\x45\xbb\x68\x4c\x19\xcb\xea\xaa\x2a\x64\xca\xfe\x0e\x00\x46\x85\x95\x65\x8d\x03\x00\x00")
+
+func _1528395700_add_saved_search_webhooksUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395700_add_saved_search_webhooksUpSql,
+		"1528395700_add_saved_search_webhooks.up.sql",
+	)
+}
+
+func _1528395700_add_saved_search_webhooksUpSql() (*asset, error) {
+	bytes, err := _1528395700_add_saved_search_webhooksUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395700_add_saved_search_webhooks.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xd3, 0xd0, 0x6d, 0xec, 0x5d, 0x3d, 0x8c, 0xac, 0xd2, 0xf3, 0xf2, 0x7, 0x1, 0xc6, 0xc2, 0x3e, 0xa9, 0xae, 0x90, 0x22, 0x31, 0xe9, 0xff, 0xf2, 0x2b, 0x87, 0x6c, 0x9d, 0xd7, 0x1c, 0x50, 0x88}}
+	return a, nil
+}
+
+var __1528395701_add_version_contextsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x68\x00\x97\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x76\x65\x72\x73\x69\x6f\x6e\x5f\x63\x6f\x6e\x74\x65\x78\x74\x5f\x72\x65\x76\x69\x73\x69\x6f\x6e\x73\x3b\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x76\x65\x72\x73\x69\x6f\x6e\x5f\x63\x6f\x6e\x74\x65\x78\x74\x73\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x21\xea\x44\x20\x68\x00\x00\x00")
+
+func _1528395701_add_version_contextsDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395701_add_version_contextsDownSql,
+		"1528395701_add_version_contexts.down.sql",
+	)
+}
+
+func _1528395701_add_version_contextsDownSql() (*asset, error) {
+	bytes, err := _1528395701_add_version_contextsDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395701_add_version_contexts.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xea, 0x2b, 0xec, 0xac, 0x12, 0x56, 0xf3, 0x8b, 0xf8, 0xf7, 0x99, 0xa7, 0x82, 0x7d, 0x3, 0x77, 0xce, 0x22, 0x76, 0x1b, 0x84, 0xdf, 0x41, 0x9c, 0xba, 0xe9, 0x50, 0x9, 0xba, 0x71, 0x2a, 0x79}}
+	return a, nil
+}
+
+var __1528395701_add_version_contextsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xa4\x93\xdd\x6e\x9b\x40\x10\x85\xef\x79\x8a\xb9\x0b\x48\x79\x03\x57\x95\x08\x1e\x37\xab\x60\xdc\xc2\x5a\x4d\xae\x56\x1b\x98\x92\x95\xec\x5d\xba\xbb\x76\xaa\x3e\x7d\x05\xf8\x87\xd8\xb5\x69\x93\x4b\x7b\xbe\x99\x3d\x73\xe6\x70\x87\x5f\x58\x36\x09\x82\x24\xc7\x98\x23\xf0\xf8\x2e\x45\x60\x33\xc8\x16\x1c\xf0\x91\x15\xbc\x80\x2d\x59\xa7\x8c\x16\xa5\xd1\x9e\x7e\x79\x07\x61\x00\x00\xa0\x2a\x70\x64\x95\x5c\xc1\xd7\x9c\xcd\xe3\xfc\x09\x1e\xf0\xe9\xb6\x2b\x69\xb9\x26\x28\x55\x4b\x77\x83\xb2\x65\x9a\xf6\x95\x8a\x5c\x69\x55\xe3\x95\xd1\xf0\xa6\x0c\x53\x9c\xc5\xcb\x94\xc3\xcd\x4d\x4f\x6e\x1c\x59\xa1\x2a\x50\xda\x53\x4d\x16\x72\x9c\x61\x8e\x59\x82\x45\x57\x72\xa1\xaa\x22\x58\x64\x30\xc5\x14\x39\x42\x12\x17\x49\x3c\xc5\xbe\xd7\xd8\xfa\x42\xab\xb1\xf5\xd5\xce\xd2\x92\xf4\x54\x09\xe9\xc1\xab\x35\x39\x2f\xd7\x0d\xbc\x2a\xff\xd2\xfd\x84\xdf\x46\xd3\xb9\x64\x6d\x5e\xc3\x68\xa7\xba\xa9\x3e\xd4\x9f\x2c\xb2\x82\xe7\x31\xcb\xf8\x99\xed\xe2\x45\x3a\x61\x34\x89\xd6\x5d\xd7\xc8\x92\x20\xb9\xc7\xe4\x01\xc2\x70\xef\x15\x2b\xba\xc1\x11\x7c\xfa\x0c\xe1\xce\x84\xfd\x7f\x51\x10\x1d\xcf\xbc\xcc\xd8\xb7\x25\x02\xcb\xa6\xf8\x38\x72\x6d\xb1\x1b\xde\x3d\x2b\x36\x5a\xfd\xdc\x50\xeb\xfb\x29\xb7\x17\x71\x0b\x2d\x18\xc1\xf7\x7b\xcc\xf1\x70\x45\x56\x1c\xd6\x9e\xbc\x47\x44\xbf\xcc\xa8\x86\x1e\x7b\x2b\x61\xe0\xc3\x41\xc1\x7f\xc4\x5d\x58\xda\xaa\x56\xcc\x20\xf7\xcf\xaa\xbe\x14\xfd\xd3\xee\x41\x0c\x0f\x77\x1f\xe4\xf1\x04\xbf\x9a\x4d\x4b\x8d\x19\x9b\xd7\x32\xd7\x67\x6c\x47\xbe\x3b\x4b\x3f\x44\xbd\x32\xcf\x23\xd8\x4a\x7a\x72\x5e\x78\x59\x5f\x04\x87\x81\xfb\x87\x23\x1f\x8d\x16\xa7\x15\x55\xfd\xe5\xda\x47\x3e\x3c\xe7\xa3\xc9\xfb\x5e\xde\x7b\x7c\xf5\xb9\x1d\xd4\xad\xb7\x98\xcf\x19\x9f\x04\x7f\x06\x00\xd2\x05\x33\xb4\x47\x05\x00\x00")
+
+func _1528395701_add_version_contextsUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395701_add_version_contextsUpSql,
+		"1528395701_add_version_contexts.up.sql",
+	)
+}
+
+func _1528395701_add_version_contextsUpSql() (*asset, error) {
+	bytes, err := _1528395701_add_version_contextsUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395701_add_version_contexts.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xaa, 0x89, 0xd7, 0x6d, 0x1b, 0x91, 0xf8, 0xc1, 0x31, 0xc0, 0x2f, 0x40, 0x8c, 0x41, 0xb3, 0x49, 0x4f, 0x35, 0xfb, 0xab, 0x4c, 0xf9, 0x34, 0xe8, 0xd8, 0xb0, 0x37, 0xad, 0xb, 0x62, 0xf1, 0xd6}}
+	return a, nil
+}
+
+var __1528395702_add_repo_groupsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x5a\x00\xa5\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x72\x65\x70\x6f\x5f\x67\x72\x6f\x75\x70\x5f\x72\x65\x70\x6f\x73\x3b\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x72\x65\x70\x6f\x5f\x67\x72\x6f\x75\x70\x73\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x5a\xb3\x60\xc4\x5a\x00\x00\x00")
+
+func _1528395702_add_repo_groupsDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395702_add_repo_groupsDownSql,
+		"1528395702_add_repo_groups.down.sql",
+	)
+}
+
+func _1528395702_add_repo_groupsDownSql() (*asset, error) {
+	bytes, err := _1528395702_add_repo_groupsDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395702_add_repo_groups.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x81, 0xb4, 0xd4, 0x99, 0x95, 0x90, 0x42, 0x7f, 0xbe, 0xb9, 0xf, 0x6a, 0x5a, 0x5b, 0xbb, 0x13, 0x83, 0x16, 0xc2, 0xe9, 0xb9, 0x8b, 0x1, 0xc0, 0x3, 0xc0, 0x6e, 0x27, 0x94, 0xe5, 0xc5, 0xa4}}
+	return a, nil
+}
+
+var __1528395702_add_repo_groupsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xa4\x53\xd1\x6e\x9c\x30\x10\x7c\xe7\x2b\xf6\x2d\x20\xdd\x1f\x5c\x55\x89\x70\x7b\x8d\x15\xce\xd7\x82\x4f\x4d\x9e\x2c\x17\x56\xc4\x52\x0e\xa8\xed\x6b\xa2\x7e\x7d\x85\x39\x2e\x26\x55\x68\xaa\xbc\x81\x77\x76\x66\xbd\x33\xbe\xc6\x2f\x8c\xaf\xa3\x28\x2b\x30\x15\x08\x22\xbd\xce\x11\xd8\x16\xf8\x5e\x00\xde\xb1\x52\x94\x60\xa8\xef\x64\x63\xba\x53\x6f\x21\x8e\x00\x00\x74\x0d\x96\x8c\x56\x8f\xf0\xb5\x60\xbb\xb4\xb8\x87\x5b\xbc\x5f\xf9\x52\xab\x8e\x04\x95\x76\xf4\xec\x3c\x07\x3f\xe4\xf9\x58\xa9\xc9\x56\x46\xf7\x4e\x77\x2d\xcc\xca\xb0\xc1\x6d\x7a\xc8\x05\x5c\x5d\x8d\xc8\x93\x25\x23\x75\x0d\xba\x75\xd4\x90\x81\x02\xb7\x58\x20\xcf\xb0\xf4\x25\x1b\xeb\x3a\x81\x3d\x87\x0d\xe6\x28\x10\xb2\xb4\xcc\xd2\x0d\x8e\xbd\x9d\x69\xde\x68\xed\x4c\xb3\xd8\xd9\x2b\xe7\xc8\xfc\x6b\x36\x7a\x1e\x40\xea\x51\x5a\x32\xbf\x74\x45\x83\xd8\x0f\xdd\xe8\xd6\x85\x5a\xaf\x51\xaf\x85\x4b\x0c\x37\x53\x19\x52\x8e\x6a\xa9\x1c\x38\x7d\x24\xeb\xd4\xb1\x87\x27\xed\x1e\xfc\x2f\xfc\xee\x5a\xfa\x7b\xa0\xb6\x7b\x8a\x93\xf3\xbe\xfa\xfa\x43\xfd\xd9\x9e\x97\xa2\x48\x19\x17\xa1\xd7\xf2\x41\x59\xd9\xb5\x24\x07\x4b\x6d\xaf\x2a\x82\xec\x06\xb3\x5b\x88\xe3\xc9\x20\x56\x7a\xce\x04\x3e\x7d\x86\xf8\xbc\xf9\xe9\x2c\x89\x92\x97\x58\x1d\x38\xfb\x76\x40\x60\x7c\x83\x77\x6f\xa7\x4b\x9e\x79\xbd\xa2\x3c\xb5\xfa\xe7\x89\x06\x9f\x03\xc8\x24\xbd\x82\x01\x93\xc0\xf7\x1b\x2c\xf0\x12\x18\x56\x5e\xee\xb9\xfe\x4f\xe9\x71\xfa\x25\xe5\x11\x31\x17\x0e\xee\x7c\xd1\x7d\xdf\x53\x92\xc3\xe7\xf4\x9e\x82\xe3\x20\xbb\x17\xcb\x82\x60\xbd\x20\x17\xb3\xec\x61\xef\xa0\x5a\xe2\x08\x9e\x36\xc4\xb3\x09\x57\x13\xff\xcc\xe3\xe5\x0d\x8f\xf7\x95\xd3\x60\xb3\xdd\xfa\x53\x1b\x4f\xa4\xeb\x28\xca\xf6\xbb\x1d\x13\xeb\xe8\xcf\x00\xdc\x70\x03\xe7\x9e\x04\x00\x00")
+
+func _1528395702_add_repo_groupsUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395702_add_repo_groupsUpSql,
+		"1528395702_add_repo_groups.up.sql",
+	)
+}
+
+func _1528395702_add_repo_groupsUpSql() (*asset, error) {
+	bytes, err := _1528395702_add_repo_groupsUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395702_add_repo_groups.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x95, 0x95, 0xcb, 0xc1, 0x18, 0x10, 0x22, 0x20, 0x9a, 0x44, 0x13, 0x5a, 0xc4, 0x15, 0x1f, 0xc4, 0x51, 0xca, 0xf2, 0xc, 0x46, 0x72, 0xf, 0xf7, 0x7a, 0x2, 0x6, 0xe7, 0x9d, 0x44, 0x9a, 0xc7}}
+	return a, nil
+}
+
 // Asset loads and returns the asset for the given name.
 // It returns an error if the asset could not be found or
 // could not be loaded.
@@ -2315,6 +2483,14 @@ var _bindata = map[string]func() (*asset, error){
 	"1528395697_add_changeset_state_machine.up.sql":                           _1528395697_add_changeset_state_machineUpSql,
 	"1528395698_add_sync_time_and_user_id_to_external_services.down.sql":      _1528395698_add_sync_time_and_user_id_to_external_servicesDownSql,
 	"1528395698_add_sync_time_and_user_id_to_external_services.up.sql":        _1528395698_add_sync_time_and_user_id_to_external_servicesUpSql,
+	"1528395699_add_query_runner_state_result_fingerprints.down.sql":          _1528395699_add_query_runner_state_result_fingerprintsDownSql,
+	"1528395699_add_query_runner_state_result_fingerprints.up.sql":            _1528395699_add_query_runner_state_result_fingerprintsUpSql,
+	"1528395700_add_saved_search_webhooks.down.sql":                           _1528395700_add_saved_search_webhooksDownSql,
+	"1528395700_add_saved_search_webhooks.up.sql":                             _1528395700_add_saved_search_webhooksUpSql,
+	"1528395701_add_version_contexts.down.sql":                                _1528395701_add_version_contextsDownSql,
+	"1528395701_add_version_contexts.up.sql":                                  _1528395701_add_version_contextsUpSql,
+	"1528395702_add_repo_groups.down.sql":                                     _1528395702_add_repo_groupsDownSql,
+	"1528395702_add_repo_groups.up.sql":                                       _1528395702_add_repo_groupsUpSql,
 }
 
 // AssetDebug is true if the assets were built with the debug flag enabled.
@@ -2459,6 +2635,14 @@ var _bintree = &bintree{nil, map[string]*bintree{
 	"1528395697_add_changeset_state_machine.up.sql":                           {_1528395697_add_changeset_state_machineUpSql, map[string]*bintree{}},
 	"1528395698_add_sync_time_and_user_id_to_external_services.down.sql":      {_1528395698_add_sync_time_and_user_id_to_external_servicesDownSql, map[string]*bintree{}},
 	"1528395698_add_sync_time_and_user_id_to_external_services.up.sql":        {_1528395698_add_sync_time_and_user_id_to_external_servicesUpSql, map[string]*bintree{}},
+	"1528395699_add_query_runner_state_result_fingerprints.down.sql":          {_1528395699_add_query_runner_state_result_fingerprintsDownSql, map[string]*bintree{}},
+	"1528395699_add_query_runner_state_result_fingerprints.up.sql":            {_1528395699_add_query_runner_state_result_fingerprintsUpSql, map[string]*bintree{}},
+	"1528395700_add_saved_search_webhooks.down.sql":                           {_1528395700_add_saved_search_webhooksDownSql, map[string]*bintree{}},
+	"1528395700_add_saved_search_webhooks.up.sql":                             {_1528395700_add_saved_search_webhooksUpSql, map[string]*bintree{}},
+	"1528395701_add_version_contexts.down.sql":                                {_1528395701_add_version_contextsDownSql, map[string]*bintree{}},
+	"1528395701_add_version_contexts.up.sql":                                  {_1528395701_add_version_contextsUpSql, map[string]*bintree{}},
+	"1528395702_add_repo_groups.down.sql":                                     {_1528395702_add_repo_groupsDownSql, map[string]*bintree{}},
+	"1528395702_add_repo_groups.up.sql":                                       {_1528395702_add_repo_groupsUpSql, map[string]*bintree{}},
 }}
 
 // RestoreAsset restores an asset under the given directory.
@@ -433,8 +433,12 @@ type ExperimentalFeatures struct {
 	DebugLog *DebugLog `json:"debug.log,omitempty"`
 	// EventLogging description: Enables user event logging inside of the Sourcegraph instance. This will allow admins to have greater visibility of user activity, such as frequently viewed pages, frequent searches, and more. These event logs (and any specific user actions) are only stored locally, and never leave this Sourcegraph instance.
 	EventLogging string `json:"eventLogging,omitempty"`
+	// GitCloneOptions description: Rules to clone the repositories whose names match a pattern without all their objects, for very large repositories. The first matching rule applies. The objects left out by a filter are fetched from the code host when they are needed, such as for archives and file contents. The rules do not apply to repositories fetched with customGitFetch. Removing a rule does not fetch the missing objects of the repositories already cloned with it.
+	GitCloneOptions []*GitCloneOptionsRule `json:"gitCloneOptions,omitempty"`
 	// SearchIndexBranches description: A map from repository name to a list of extra revs (branch, ref, tag, commit sha, etc) to index for a repository. We always index the default branch ("HEAD") and revisions in version contexts. This allows specifying additional revisions. Sourcegraph can index up to 64 branches per repository.
 	SearchIndexBranches map[string][]string `json:"search.index.branches,omitempty"`
+	// SearchIndexRevisions description: Rules for extra revisions to index for the repositories whose names match a pattern. The rules are resolved against the branches and tags of a repository each time it is indexed, in addition to the default branch ("HEAD"), the revisions in version contexts and search.index.branches. Sourcegraph can index up to 64 branches per repository.
+	SearchIndexRevisions []*SearchIndexRevisionsRule `json:"search.index.revisions,omitempty"`
 	// SearchMultipleRevisionsPerRepository description: DEPRECATED. Always on. Will be removed in 3.19.
 	SearchMultipleRevisionsPerRepository *bool `json:"searchMultipleRevisionsPerRepository,omitempty"`
 	// StructuralSearch description: Enables structural search.
@@ -466,6 +470,16 @@ type ExternalIdentity struct {
 	Type           string `json:"type"`
 }
 
+// GitCloneOptionsRule description: Options to clone and fetch the repositories whose names match repoPattern with.
+type GitCloneOptionsRule struct {
+	// Depth description: The number of commits of history to clone and fetch from the tip of each ref. Commits older than that are not available.
+	Depth int `json:"depth,omitempty"`
+	// Filter description: The partial clone filter, such as "blob:none" to leave out all file contents (blobs), or "blob:limit=1m" to leave out the blobs larger than 1 MiB. The code host must support partial clones.
+	Filter string `json:"filter,omitempty"`
+	// RepoPattern description: Regular expression which matches the names of the repositories the rule applies to.
+	RepoPattern string `json:"repoPattern"`
+}
+
 // GitCommitDescription description: The Git commit to create with the changes.
 type GitCommitDescription struct {
 	// Diff description: The commit diff (in unified diff format).
@@ -1009,6 +1023,24 @@ type SMTPServerConfig struct {
 	// Username description: The username to use when communicating with the SMTP server.
 	Username string `json:"username,omitempty"`
 }
+
+// SearchIndexRevisionsRule description: A rule of revisions to index for the repositories whose names match repoPattern.
+type SearchIndexRevisionsRule struct {
+	// LatestTags description: Glob of tag names, of which the most recently created matching tags are indexed, such as "v*".
+	LatestTags string `json:"latestTags,omitempty"`
+	// LatestTagsCount description: The number of tags matching latestTags to index.
+	LatestTagsCount int `json:"latestTagsCount,omitempty"`
+	// RefGlob description: Glob of branch names to index, such as "release/*". Prefix the glob with "refs/tags/" to match tag names instead.
+	RefGlob string `json:"refGlob,omitempty"`
+	// RepoPattern description: Regular expression which matches the names of the repositories the rule applies to.
+	RepoPattern string `json:"repoPattern"`
+}
+type SearchRepoPriority struct {
+	// Priority description: The priority of the repositories. Negative priorities rank their results lower.
+	Priority float64 `json:"priority"`
+	// RepoPattern description: Regular expression which matches the names of the repositories the priority applies to.
+	RepoPattern string `json:"repoPattern"`
+}
 type SearchSavedQueries struct {
 	// Description description: Description of this saved query
 	Description string `json:"description"`
@@ -1176,6 +1208,10 @@ type SiteConfiguration struct {
 	GitCloneURLToRepositoryName []*CloneURLToRepositoryName `json:"git.cloneURLToRepositoryName,omitempty"`
 	// GitMaxConcurrentClones description: Maximum number of git clone processes that will be run concurrently per gitserver to update repositories. Note: the global git update scheduler respects gitMaxConcurrentClones. However, we allow each gitserver to run upto gitMaxConcurrentClones to allow for urgent fetches. Urgent fetches are used when a user is browsing a PR and we do not have the commit yet.
 	GitMaxConcurrentClones int `json:"gitMaxConcurrentClones,omitempty"`
+	// GitServerHashing description: How repositories are assigned to gitservers. "modulo" (the default) uses the hash of the repository name modulo the number of gitservers, so adding or removing a gitserver moves nearly all repositories. "rendezvous" uses rendezvous hashing, so adding or removing a gitserver only moves about 1/n of the repositories. Changing it moves nearly all repositories: copy them to their new gitservers first by setting SRC_REBALANCE_GIT_SERVER_HASHING on gitserver (see the gitserver rebalancing documentation).
+	GitServerHashing string `json:"gitServerHashing,omitempty"`
+	// GitServerReplicationFactor description: The number of gitservers that each repository is cloned on. Repository updates are fetched on every replica, and requests fail over to another replica when a gitserver is unreachable or is still cloning the repository. Increasing it clones the repositories on their new replicas from the code hosts, and multiplies the disk space used by gitservers.
+	GitServerReplicationFactor int `json:"gitServerReplicationFactor,omitempty"`
 	// GithubClientID description: Client ID for GitHub. (DEPRECATED)
 	GithubClientID string `json:"githubClientID,omitempty"`
 	// GithubClientSecret description: Client secret for GitHub. (DEPRECATED)
@@ -1220,6 +1256,10 @@ type SiteConfiguration struct {
 	SearchIndexSymbolsEnabled *bool `json:"search.index.symbols.enabled,omitempty"`
 	// SearchLargeFiles description: A list of file glob patterns where matching files will be indexed and searched regardless of their size. The glob pattern syntax can be found here: https://golang.org/pkg/path/filepath/#Match.
 	SearchLargeFiles []string `json:"search.largeFiles,omitempty"`
+	// SearchMaxEstimatedCost description: The maximum estimated cost of a search query, as reported by the `explain` field of a search in the GraphQL API. Queries with a higher estimated cost are rejected with an alert before they run. Any value less than or equal to zero means unlimited.
+	SearchMaxEstimatedCost int `json:"search.maxEstimatedCost,omitempty"`
+	// SearchRankingRepoPriorities description: Priorities of the repositories whose names match a pattern, used to rank search results when a search has `rank:yes`. The priority of the first matching rule is added to the score of each result from a repository. Most other ranking signals add up to a few points, so a priority of 10 ranks the results of a repository above those of repositories without a priority.
+	SearchRankingRepoPriorities []*SearchRepoPriority `json:"search.ranking.repoPriorities,omitempty"`
 	// UpdateChannel description: The channel on which to automatically check for Sourcegraph updates.
 	UpdateChannel string `json:"update.channel,omitempty"`
 	// UseJaeger description: DEPRECATED. Use `"observability.tracing": { "sampling": "all" }`, instead. Enables Jaeger tracing.
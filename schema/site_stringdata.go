@@ -123,6 +123,40 @@ const SiteSchemaJSON = `{
             }
           }
         },
+        "gitCloneOptions": {
+          "description": "Rules to clone the repositories whose names match a pattern without all their objects, for very large repositories. The first matching rule applies. The objects left out by a filter are fetched from the code host when they are needed, such as for archives and file contents. The rules do not apply to repositories fetched with customGitFetch. Removing a rule does not fetch the missing objects of the repositories already cloned with it.",
+          "type": "array",
+          "items": {
+            "title": "GitCloneOptionsRule",
+            "description": "Options to clone and fetch the repositories whose names match repoPattern with.",
+            "type": "object",
+            "additionalProperties": false,
+            "required": ["repoPattern"],
+            "properties": {
+              "repoPattern": {
+                "description": "Regular expression which matches the names of the repositories the rule applies to.",
+                "type": "string",
+                "minLength": 1
+              },
+              "filter": {
+                "description": "The partial clone filter, such as \"blob:none\" to leave out all file contents (blobs), or \"blob:limit=1m\" to leave out the blobs larger than 1 MiB. The code host must support partial clones.",
+                "type": "string",
+                "pattern": "^(blob:none|blob:limit=[0-9]+[kmg]?)$"
+              },
+              "depth": {
+                "description": "The number of commits of history to clone and fetch from the tip of each ref. Commits older than that are not available.",
+                "type": "integer",
+                "minimum": 1
+              }
+            }
+          },
+          "examples": [
+            [
+              { "repoPattern": "^github\\.com/example/monorepo$", "filter": "blob:none" },
+              { "repoPattern": "^gitlab\\.example\\.com/assets/", "filter": "blob:limit=1m", "depth": 1000 }
+            ]
+          ]
+        },
         "customGitFetch": {
           "description": "JSON array of configuration that maps from Git clone URL domain/path to custom git fetch command.",
           "type": "array",
@@ -172,6 +206,44 @@ const SiteSchemaJSON = `{
             }
           ]
         },
+        "search.index.revisions": {
+          "description": "Rules for extra revisions to index for the repositories whose names match a pattern. The rules are resolved against the branches and tags of a repository each time it is indexed, in addition to the default branch (\"HEAD\"), the revisions in version contexts and search.index.branches. Sourcegraph can index up to 64 branches per repository.",
+          "type": "array",
+          "items": {
+            "title": "SearchIndexRevisionsRule",
+            "description": "A rule of revisions to index for the repositories whose names match repoPattern.",
+            "type": "object",
+            "additionalProperties": false,
+            "required": ["repoPattern"],
+            "properties": {
+              "repoPattern": {
+                "description": "Regular expression which matches the names of the repositories the rule applies to.",
+                "type": "string",
+                "minLength": 1
+              },
+              "refGlob": {
+                "description": "Glob of branch names to index, such as \"release/*\". Prefix the glob with \"refs/tags/\" to match tag names instead.",
+                "type": "string"
+              },
+              "latestTags": {
+                "description": "Glob of tag names, of which the most recently created matching tags are indexed, such as \"v*\".",
+                "type": "string"
+              },
+              "latestTagsCount": {
+                "description": "The number of tags matching latestTags to index.",
+                "type": "integer",
+                "minimum": 1,
+                "default": 1
+              }
+            }
+          },
+          "examples": [
+            [
+              { "repoPattern": "^services/", "refGlob": "release/*" },
+              { "repoPattern": "^github\\.com/sourcegraph/", "latestTags": "v*", "latestTagsCount": 3 }
+            ]
+          ]
+        },
         "versionContexts": {
           "description": "JSON array of version context configuration",
           "type": "array",
@@ -334,6 +406,20 @@ const SiteSchemaJSON = `{
       "default": 5,
       "group": "External services"
     },
+    "gitServerReplicationFactor": {
+      "description": "The number of gitservers that each repository is cloned on. Repository updates are fetched on every replica, and requests fail over to another replica when a gitserver is unreachable or is still cloning the repository. Increasing it clones the repositories on their new replicas from the code hosts, and multiplies the disk space used by gitservers.",
+      "type": "integer",
+      "minimum": 1,
+      "default": 1,
+      "group": "External services"
+    },
+    "gitServerHashing": {
+      "description": "How repositories are assigned to gitservers. \"modulo\" (the default) uses the hash of the repository name modulo the number of gitservers, so adding or removing a gitserver moves nearly all repositories. \"rendezvous\" uses rendezvous hashing, so adding or removing a gitserver only moves about 1/n of the repositories. Changing it moves nearly all repositories: copy them to their new gitservers first by setting SRC_REBALANCE_GIT_SERVER_HASHING on gitserver (see the gitserver rebalancing documentation).",
+      "type": "string",
+      "enum": ["modulo", "rendezvous"],
+      "default": "modulo",
+      "group": "External services"
+    },
     "repoListUpdateInterval": {
       "description": "Interval (in minutes) for checking code hosts (such as GitHub, Gitolite, etc.) for new repositories.",
       "type": "integer",
@@ -346,6 +432,36 @@ const SiteSchemaJSON = `{
       "default": -1,
       "group": "Search"
     },
+    "search.maxEstimatedCost": {
+      "description": "The maximum estimated cost of a search query, as reported by the ` + "`" + `explain` + "`" + ` field of a search in the GraphQL API. Queries with a higher estimated cost are rejected with an alert before they run. Any value less than or equal to zero means unlimited.",
+      "type": "integer",
+      "default": -1,
+      "group": "Search",
+      "examples": [10000]
+    },
+    "search.ranking.repoPriorities": {
+      "description": "Priorities of the repositories whose names match a pattern, used to rank search results when a search has ` + "`" + `rank:yes` + "`" + `. The priority of the first matching rule is added to the score of each result from a repository. Most other ranking signals add up to a few points, so a priority of 10 ranks the results of a repository above those of repositories without a priority.",
+      "type": "array",
+      "items": {
+        "title": "SearchRepoPriority",
+        "type": "object",
+        "additionalProperties": false,
+        "required": ["repoPattern", "priority"],
+        "properties": {
+          "repoPattern": {
+            "description": "Regular expression which matches the names of the repositories the priority applies to.",
+            "type": "string",
+            "minLength": 1
+          },
+          "priority": {
+            "description": "The priority of the repositories. Negative priorities rank their results lower.",
+            "type": "number"
+          }
+        }
+      },
+      "group": "Search",
+      "examples": [[{ "repoPattern": "^github\\.com/sourcegraph/sourcegraph$", "priority": 10 }]]
+    },
     "parentSourcegraph": {
       "description": "URL to fetch unreachable repository details from. Defaults to \"https://sourcegraph.com\"",
       "type": "object",